@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -18,7 +19,13 @@ import (
 	"github.com/tacheraSasi/go-api-starter/internals/repositories"
 	"github.com/tacheraSasi/go-api-starter/internals/services"
 	"github.com/tacheraSasi/go-api-starter/pkg/database"
+	"github.com/tacheraSasi/go-api-starter/pkg/dunning"
 	"github.com/tacheraSasi/go-api-starter/pkg/logger"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"github.com/tacheraSasi/go-api-starter/pkg/numbering"
+	"github.com/tacheraSasi/go-api-starter/pkg/pdf"
+	"github.com/tacheraSasi/go-api-starter/pkg/schedule"
+	"github.com/tacheraSasi/go-api-starter/pkg/signing"
 	"github.com/tacheraSasi/go-api-starter/ui/pages"
 )
 
@@ -68,8 +75,36 @@ func main() {
 		&models.UserRole{},
 		&models.RolePermission{},
 		&models.Customer{},
+		&models.Tag{},
+		&models.CustomFieldDefinition{},
+		&models.CustomFieldValue{},
+		&models.CustomerContact{},
+		&models.CustomerAddress{},
+		&models.CustomerMerge{},
 		&models.Invoice{},
+		&models.InvoiceSequence{},
 		&models.InvoiceItem{},
+		&models.InvoiceCharge{},
+		&models.TaxRate{},
+		&models.Product{},
+		&models.InvoiceTaxLine{},
+		&models.InvoiceStatusChange{},
+		&models.Payment{},
+		&models.PaymentAllocation{},
+		&models.ExchangeRate{},
+		&models.InvoiceShareLink{},
+		&models.RecurringInvoice{},
+		&models.RecurringInvoiceItem{},
+		&models.InvoiceReminder{},
+		&models.CreditNote{},
+		&models.CreditNoteItem{},
+		&models.CreditNoteAllocation{},
+		&models.CreditNoteRefund{},
+		&models.Quote{},
+		&models.QuoteItem{},
+		&models.QuoteTaxLine{},
+		&models.QuoteCharge{},
+		&models.Attachment{},
 		&models.BlacklistedToken{},
 		&models.PasswordResetToken{},
 	)
@@ -82,17 +117,121 @@ func main() {
 	roleRepo := repositories.NewRoleRepository(database.GetDB())
 	permissionRepo := repositories.NewPermissionRepository(database.GetDB())
 	customerRepo := repositories.NewCustomerRepository(database.GetDB())
+	customerContactRepo := repositories.NewCustomerContactRepository(database.GetDB())
+	customerAddressRepo := repositories.NewCustomerAddressRepository(database.GetDB())
+	customerMergeRepo := repositories.NewCustomerMergeRepository(database.GetDB())
 	invoiceRepo := repositories.NewInvoiceRepository(database.GetDB())
+	paymentRepo := repositories.NewPaymentRepository(database.GetDB())
+	invoiceSequenceRepo := repositories.NewInvoiceSequenceRepository(database.GetDB())
 	tokenRepo := repositories.NewTokenRepository(database.GetDB())
+	customFieldRepo := repositories.NewCustomFieldRepository(database.GetDB())
+	exchangeRateRepo := repositories.NewExchangeRateRepository(database.GetDB())
+	taxRateRepo := repositories.NewTaxRateRepository(database.GetDB())
+	productRepo := repositories.NewProductRepository(database.GetDB())
+	invoiceShareLinkRepo := repositories.NewInvoiceShareLinkRepository(database.GetDB())
+	recurringInvoiceRepo := repositories.NewRecurringInvoiceRepository(database.GetDB())
+	invoiceReminderRepo := repositories.NewInvoiceReminderRepository(database.GetDB())
+	creditNoteRepo := repositories.NewCreditNoteRepository(database.GetDB())
+	quoteRepo := repositories.NewQuoteRepository(database.GetDB())
+	attachmentRepo := repositories.NewAttachmentRepository(database.GetDB())
+	transactor := repositories.NewTransactor(database.GetDB())
 
 	// services
-	permissionService := services.NewPermissionService(permissionRepo)
+	permissionService := services.NewPermissionService(permissionRepo, roleRepo)
 	roleService := services.NewRoleService(roleRepo, permissionRepo)
 	userService := services.NewUserService(userRepo, roleRepo)
 	tokenService := services.NewTokenService(tokenRepo)
-	authService := services.NewAuthService(userRepo, tokenService)
-	customerService := services.NewCustomerService(customerRepo)
-	invoiceService := services.NewInvoiceService(invoiceRepo)
+	authService := services.NewAuthService(userRepo, roleRepo, transactor, tokenService)
+	customFieldService := services.NewCustomFieldService(customFieldRepo)
+	customerService := services.NewCustomerService(customerRepo, transactor, customFieldService)
+	customerContactService := services.NewCustomerContactService(customerContactRepo, customerRepo)
+	customerAddressService := services.NewCustomerAddressService(customerAddressRepo, customerRepo, transactor)
+	// Money, numbering, PDF, e-invoice, share link, quote, scheduler, email, dunning and attachment settings were checked by cfg.Validate
+	defaultCurrency, _ := money.ParseCurrency(cfg.DefaultCurrency)
+	baseCurrency, _ := money.ParseCurrency(cfg.BaseCurrency)
+	roundingMode, _ := money.ParseRoundingMode(cfg.RoundingMode)
+	taxRounding, _ := services.ParseTaxRounding(cfg.TaxRounding)
+	invoiceNumbering, _ := numbering.Parse(cfg.InvoiceNumberFormat, numbering.Reset(cfg.InvoiceNumberReset))
+	creditNoteNumbering, _ := numbering.Parse(cfg.CreditNoteNumberFormat, numbering.Reset(cfg.CreditNoteNumberReset))
+	quoteNumbering, _ := numbering.Parse(cfg.QuoteNumberFormat, numbering.Reset(cfg.QuoteNumberReset))
+	quoteValidityDays, _ := strconv.Atoi(cfg.QuoteValidityDays)
+	pdfPageSize, _ := pdf.ParseSize(cfg.PDFPageSize)
+	pdfBrandColor, _ := pdf.ParseColor(cfg.PDFBrandColor)
+	var pdfLogo *pdf.Image
+	if cfg.PDFLogoPath != "" {
+		pdfLogo, _ = pdf.LoadImage(cfg.PDFLogoPath)
+	}
+	companyEndpoint, _ := cfg.CompanyEndpoint()
+	shareLinkTTLDays, _ := strconv.Atoi(cfg.ShareLinkTTLDays)
+	schedulerInterval, _ := time.ParseDuration(cfg.SchedulerInterval)
+	mail, _ := cfg.Mailer()
+	dunningSchedule, _ := dunning.ParseSchedule(cfg.DunningSchedule)
+	dunningTemplates := dunning.DefaultTemplates()
+	if cfg.DunningTemplateDir != "" {
+		dunningTemplates, _ = dunning.LoadTemplates(cfg.DunningTemplateDir)
+	}
+	fileStorage, _ := cfg.Storage()
+	attachmentMaxSizeMB, _ := strconv.Atoi(cfg.AttachmentMaxSizeMB)
+	avatarMaxSizeMB, _ := strconv.Atoi(cfg.AvatarMaxSizeMB)
+	exchangeRateService := services.NewExchangeRateService(exchangeRateRepo, roundingMode)
+	taxRateService := services.NewTaxRateService(taxRateRepo)
+	productService := services.NewProductService(productRepo, taxRateService, defaultCurrency)
+	invoiceService := services.NewInvoiceService(transactor, invoiceRepo, invoiceSequenceRepo, customerRepo, customerAddressRepo, customFieldService, exchangeRateService, taxRateService, productService, services.InvoiceSettings{
+		DefaultCurrency: defaultCurrency,
+		BaseCurrency:    baseCurrency,
+		Rounding:        roundingMode,
+		TaxRounding:     taxRounding,
+		Numbering:       invoiceNumbering,
+	})
+	paymentService := services.NewPaymentService(transactor, paymentRepo, invoiceRepo, customerRepo, defaultCurrency)
+	issuer := services.InvoiceIssuer{
+		Name:    cfg.CompanyName,
+		Address: cfg.CompanyAddress,
+		Email:   cfg.CompanyEmail,
+		Phone:   cfg.CompanyPhone,
+		TaxID:   cfg.CompanyTaxID,
+	}
+	invoicePDFService := services.NewInvoicePDFService(invoiceRepo, services.InvoicePDFSettings{
+		Issuer:              issuer,
+		PaymentInstructions: cfg.PaymentInstructions,
+		PageSize:            pdfPageSize,
+		BrandColor:          pdfBrandColor,
+		Logo:                pdfLogo,
+	})
+	ublService := services.NewUBLService(invoiceRepo, customerRepo, customerAddressRepo, productRepo, invoiceService, taxRateService, customFieldService, services.UBLSettings{
+		Issuer:              issuer,
+		City:                cfg.CompanyCity,
+		PostalCode:          cfg.CompanyPostalCode,
+		Country:             cfg.CompanyCountry,
+		Endpoint:            companyEndpoint,
+		PaymentInstructions: cfg.PaymentInstructions,
+		BuyerReferenceField: cfg.UBLBuyerReferenceField,
+	})
+	invoiceShareService := services.NewInvoiceShareService(invoiceShareLinkRepo, invoiceRepo, invoicePDFService, signing.NewSigner(cfg.ShareLinkSecret, services.ShareLinkPurpose), time.Duration(shareLinkTTLDays)*24*time.Hour)
+	recurringInvoiceService := services.NewRecurringInvoiceService(recurringInvoiceRepo, invoiceRepo, customerRepo, invoiceService, taxRateService, customFieldService)
+	dunningService := services.NewDunningService(invoiceReminderRepo, invoiceRepo, mail, services.DunningSettings{
+		Schedule:            dunningSchedule,
+		Templates:           dunningTemplates,
+		CompanyName:         cfg.CompanyName,
+		CompanyEmail:        cfg.CompanyEmail,
+		PaymentInstructions: cfg.PaymentInstructions,
+	})
+	creditNoteService := services.NewCreditNoteService(transactor, creditNoteRepo, invoiceRepo, invoiceSequenceRepo, services.CreditNoteSettings{
+		Numbering: creditNoteNumbering,
+		Rounding:  roundingMode,
+	})
+	quoteService := services.NewQuoteService(transactor, quoteRepo, invoiceRepo, invoiceSequenceRepo, invoiceService, signing.NewSigner(cfg.ShareLinkSecret, services.QuoteLinkPurpose), services.QuoteSettings{
+		Numbering:    quoteNumbering,
+		ValidityDays: quoteValidityDays,
+	})
+	statementService := services.NewStatementService(customerRepo, invoiceRepo, paymentRepo, creditNoteRepo, defaultCurrency)
+	reportService := services.NewReportService(invoiceRepo, creditNoteRepo, baseCurrency)
+	customerMergeService := services.NewCustomerMergeService(transactor, customerRepo, invoiceRepo, paymentRepo, creditNoteRepo, quoteRepo, recurringInvoiceRepo, attachmentRepo, customerContactRepo, customerAddressRepo, customerMergeRepo)
+	attachmentService := services.NewAttachmentService(attachmentRepo, fileStorage, userRepo, invoiceRepo, customerRepo, services.AttachmentSettings{
+		MaxSize:       int64(attachmentMaxSizeMB) << 20,
+		AllowedTypes:  cfg.AttachmentAllowedTypes,
+		AvatarMaxSize: int64(avatarMaxSizeMB) << 20,
+	})
 
 	// Initialize default roles and permissions
 	if err := permissionService.InitializeDefaultPermissions(); err != nil {
@@ -101,6 +240,31 @@ func main() {
 	if err := roleService.InitializeDefaultRoles(); err != nil {
 		log.Printf("Warning: Failed to initialize default roles: %v", err)
 	}
+	if migrated, err := userService.MigrateLegacyRoles(); err != nil {
+		log.Printf("Warning: Failed to migrate legacy user roles: %v", err)
+	} else if migrated > 0 {
+		log.Printf("Migrated legacy roles of %d user(s)", migrated)
+	}
+	if migrated, err := invoiceService.MigrateLegacyStatuses(); err != nil {
+		log.Printf("Warning: Failed to migrate legacy invoice statuses: %v", err)
+	} else if migrated > 0 {
+		log.Printf("Migrated legacy statuses of %d invoice(s)", migrated)
+	}
+	if created, err := taxRateService.InitializeDefaultTaxRates(); err != nil {
+		log.Printf("Warning: Failed to initialize default tax rates: %v", err)
+	} else if created {
+		log.Printf("Created the default 10%% tax rate; manage tax rates under /api/v1/admin/tax-rates")
+	}
+	if converted, pending, err := invoiceService.ConvertToBaseCurrency(); err != nil {
+		log.Printf("Warning: Failed to convert invoices to %s: %v", baseCurrency, err)
+	} else if converted > 0 || pending > 0 {
+		log.Printf("Converted %d invoice(s) to %s; %d still need an exchange rate", converted, baseCurrency, pending)
+	}
+	if migrated, err := paymentService.MigrateLegacyInvoices(); err != nil {
+		log.Printf("Warning: Failed to migrate invoices to payment tracking: %v", err)
+	} else if migrated > 0 {
+		log.Printf("Migrated %d invoice(s) to payment tracking", migrated)
+	}
 
 	// handlers
 	healthHandler := handlers.NewHealthHandler()
@@ -109,7 +273,24 @@ func main() {
 	roleHandler := handlers.NewRoleHandler(roleService)
 	permissionHandler := handlers.NewPermissionHandler(permissionService)
 	customerHandler := handlers.NewCustomerHandler(customerService)
-	invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
+	customerContactHandler := handlers.NewCustomerContactHandler(customerContactService)
+	customerAddressHandler := handlers.NewCustomerAddressHandler(customerAddressService)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService, paymentService, invoicePDFService)
+	ublHandler := handlers.NewUBLHandler(ublService)
+	invoiceShareHandler := handlers.NewInvoiceShareHandler(invoiceShareService, cfg.PublicURL, cfg.CompanyName)
+	recurringInvoiceHandler := handlers.NewRecurringInvoiceHandler(recurringInvoiceService)
+	dunningHandler := handlers.NewDunningHandler(dunningService)
+	statementHandler := handlers.NewStatementHandler(statementService)
+	customerMergeHandler := handlers.NewCustomerMergeHandler(customerMergeService)
+	customFieldHandler := handlers.NewCustomFieldHandler(customFieldService)
+	exchangeRateHandler := handlers.NewExchangeRateHandler(exchangeRateService)
+	reportHandler := handlers.NewReportHandler(reportService)
+	taxRateHandler := handlers.NewTaxRateHandler(taxRateService)
+	productHandler := handlers.NewProductHandler(productService)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService, int64(max(attachmentMaxSizeMB, avatarMaxSizeMB))<<20)
+	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	creditNoteHandler := handlers.NewCreditNoteHandler(creditNoteService)
+	quoteHandler := handlers.NewQuoteHandler(quoteService, cfg.PublicURL, cfg.CompanyName)
 
 	// Setup router
 	r := gin.New()
@@ -123,6 +304,8 @@ func main() {
 	r.Use(middlewares.CORSMiddleware(cfg.CORSOrigins...))
 	r.Static("/assets", "./assets")
 	authHandler.RegisterWebRoutes(r)
+	invoiceShareHandler.RegisterWebRoutes(r)
+	quoteHandler.RegisterWebRoutes(r)
 
 	r.GET("/health", healthHandler.HealthCheck)
 	r.GET("/health/ready", healthHandler.ReadinessCheck)
@@ -153,31 +336,144 @@ func main() {
 		protected.PUT("/users/:id/password", userHandler.UpdateUserPassword)
 		protected.GET("/users/:id/roles", userHandler.GetUserRoles)
 		protected.GET("/users/:id/permissions/:resource/:action", userHandler.CheckUserPermission)
+		protected.GET("/users/:id/avatar", attachmentHandler.GetAvatar)
+		protected.PUT("/users/:id/avatar", attachmentHandler.UploadAvatar)
+		protected.DELETE("/users/:id/avatar", attachmentHandler.DeleteAvatar)
 
 		// Customer routes
 		protected.GET("/customers", customerHandler.ListCustomers)
+		protected.GET("/customers/export", customerHandler.ExportCustomers)
+		protected.POST("/customers/import", customerHandler.ImportCustomers)
 		protected.GET("/customers/:id", customerHandler.GetCustomer)
 		protected.POST("/customers", customerHandler.CreateCustomer)
 		protected.PUT("/customers/:id", customerHandler.UpdateCustomer)
 		protected.DELETE("/customers/:id", customerHandler.DeleteCustomer)
+		protected.GET("/customers/:id/statement", statementHandler.GetCustomerStatement)
+		protected.GET("/customers/:id/credit", paymentHandler.GetCustomerCredit)
+		protected.GET("/customers/:id/contacts", customerContactHandler.ListContacts)
+		protected.POST("/customers/:id/contacts", customerContactHandler.CreateContact)
+		protected.GET("/customers/:id/contacts/:contactId", customerContactHandler.GetContact)
+		protected.PUT("/customers/:id/contacts/:contactId", customerContactHandler.UpdateContact)
+		protected.DELETE("/customers/:id/contacts/:contactId", customerContactHandler.DeleteContact)
+		protected.GET("/customers/:id/addresses", customerAddressHandler.ListAddresses)
+		protected.POST("/customers/:id/addresses", customerAddressHandler.CreateAddress)
+		protected.GET("/customers/:id/addresses/:addressId", customerAddressHandler.GetAddress)
+		protected.PUT("/customers/:id/addresses/:addressId", customerAddressHandler.UpdateAddress)
+		protected.DELETE("/customers/:id/addresses/:addressId", customerAddressHandler.DeleteAddress)
+		protected.GET("/customers/:id/attachments", attachmentHandler.ListCustomerAttachments)
+		protected.POST("/customers/:id/attachments", attachmentHandler.UploadCustomerAttachment)
+		protected.GET("/customers/:id/attachments/:attachmentId", attachmentHandler.DownloadCustomerAttachment)
+		protected.DELETE("/customers/:id/attachments/:attachmentId", attachmentHandler.DeleteCustomerAttachment)
 
 		// Invoice routes
 		protected.GET("/invoices", invoiceHandler.ListInvoices)
+		protected.POST("/invoices/import", ublHandler.ImportInvoice)
 		protected.GET("/invoices/:id", invoiceHandler.GetInvoice)
+		protected.GET("/invoices/:id/pdf", invoiceHandler.GetInvoicePDF)
+		protected.GET("/invoices/:id/ubl", ublHandler.ExportInvoice)
 		protected.POST("/invoices", invoiceHandler.CreateInvoice)
 		protected.PUT("/invoices/:id", invoiceHandler.UpdateInvoice)
 		protected.DELETE("/invoices/:id", invoiceHandler.DeleteInvoice)
+		protected.POST("/invoices/:id/send", invoiceHandler.SendInvoice)
+		protected.POST("/invoices/:id/void", invoiceHandler.VoidInvoice)
+		protected.POST("/invoices/:id/mark-paid", invoiceHandler.MarkInvoicePaid)
+		protected.GET("/invoices/:id/share-links", invoiceShareHandler.ListShareLinks)
+		protected.POST("/invoices/:id/share-links", invoiceShareHandler.CreateShareLink)
+		protected.DELETE("/invoices/:id/share-links/:linkId", invoiceShareHandler.RevokeShareLink)
+		protected.GET("/invoices/:id/reminders", dunningHandler.ListInvoiceReminders)
+		protected.GET("/invoices/:id/attachments", attachmentHandler.ListInvoiceAttachments)
+		protected.POST("/invoices/:id/attachments", attachmentHandler.UploadInvoiceAttachment)
+		protected.GET("/invoices/:id/attachments/:attachmentId", attachmentHandler.DownloadInvoiceAttachment)
+		protected.DELETE("/invoices/:id/attachments/:attachmentId", attachmentHandler.DeleteInvoiceAttachment)
+
+		// Recurring invoice routes
+		protected.GET("/recurring-invoices", recurringInvoiceHandler.ListRecurringInvoices)
+		protected.POST("/recurring-invoices", recurringInvoiceHandler.CreateRecurringInvoice)
+		protected.GET("/recurring-invoices/:id", recurringInvoiceHandler.GetRecurringInvoice)
+		protected.PUT("/recurring-invoices/:id", recurringInvoiceHandler.UpdateRecurringInvoice)
+		protected.DELETE("/recurring-invoices/:id", recurringInvoiceHandler.DeleteRecurringInvoice)
+		protected.GET("/recurring-invoices/:id/preview", recurringInvoiceHandler.PreviewRecurringInvoice)
+
+		// Quote routes
+		protected.GET("/quotes", quoteHandler.ListQuotes)
+		protected.POST("/quotes", quoteHandler.CreateQuote)
+		protected.GET("/quotes/:id", quoteHandler.GetQuote)
+		protected.PUT("/quotes/:id", quoteHandler.UpdateQuote)
+		protected.DELETE("/quotes/:id", quoteHandler.DeleteQuote)
+		protected.POST("/quotes/:id/send", quoteHandler.SendQuote)
+		protected.GET("/quotes/:id/link", quoteHandler.GetQuoteLink)
+		protected.POST("/quotes/:id/accept", quoteHandler.AcceptQuote)
+		protected.POST("/quotes/:id/decline", quoteHandler.DeclineQuote)
+		protected.POST("/quotes/:id/convert", quoteHandler.ConvertQuote)
+
+		// Payment routes
+		protected.GET("/payments", paymentHandler.ListPayments)
+		protected.GET("/payments/:id", paymentHandler.GetPayment)
+		protected.POST("/payments", paymentHandler.CreatePayment)
+		protected.POST("/payments/:id/apply", paymentHandler.ApplyPayment)
+		protected.POST("/payments/:id/reverse", paymentHandler.ReversePayment)
+
+		// Credit note routes
+		protected.GET("/credit-notes", creditNoteHandler.ListCreditNotes)
+		protected.GET("/credit-notes/:id", creditNoteHandler.GetCreditNote)
+		protected.POST("/credit-notes", creditNoteHandler.CreateCreditNote)
+		protected.POST("/credit-notes/:id/apply", creditNoteHandler.ApplyCreditNote)
+		protected.POST("/credit-notes/:id/refund", creditNoteHandler.RefundCreditNote)
+
+		// Exchange rate routes
+		protected.GET("/exchange-rates", exchangeRateHandler.ListExchangeRates)
+		protected.GET("/exchange-rates/convert", exchangeRateHandler.ConvertCurrency)
+
+		// Tax rate routes
+		protected.GET("/tax-rates", taxRateHandler.ListTaxRates)
+		protected.GET("/tax-rates/:id", taxRateHandler.GetTaxRate)
+
+		// Product catalog routes
+		protected.GET("/products", productHandler.ListProducts)
+		protected.GET("/products/:id", productHandler.GetProduct)
+
+		// Report routes
+		protected.GET("/reports/revenue", reportHandler.GetRevenueReport)
 	}
 
 	// Admin routes
 	admin := r.Group("/api/v1/admin")
-	admin.Use(middlewares.AuthMiddleware(tokenService, []byte(cfg.JWTSecret)), middlewares.AdminMiddleware())
+	admin.Use(middlewares.AuthMiddleware(tokenService, []byte(cfg.JWTSecret)), middlewares.AdminMiddleware(userRepo))
 	{
 		// User management
 		admin.GET("/users", userHandler.ListUsers)
 		admin.DELETE("/users/:id", userHandler.DeleteUser)
 		admin.POST("/users/:id/roles/:roleId", userHandler.AddRoleToUser)
 		admin.DELETE("/users/:id/roles/:roleId", userHandler.RemoveRoleFromUser)
+		admin.GET("/users/:id/permissions", userHandler.ExplainUserPermissions)
+		admin.POST("/users/:id/permissions/check", userHandler.CheckUserPermissions)
+
+		// Customer deduplication
+		admin.GET("/customers/duplicates", customerMergeHandler.FindDuplicates)
+		admin.POST("/customers/merge", customerMergeHandler.MergeCustomers)
+		admin.GET("/customers/merges", customerMergeHandler.ListMerges)
+
+		// Custom field definitions
+		admin.GET("/custom-fields", customFieldHandler.ListCustomFields)
+		admin.POST("/custom-fields", customFieldHandler.CreateCustomField)
+		admin.GET("/custom-fields/:id", customFieldHandler.GetCustomField)
+		admin.PUT("/custom-fields/:id", customFieldHandler.UpdateCustomField)
+		admin.DELETE("/custom-fields/:id", customFieldHandler.DeleteCustomField)
+
+		// Exchange rates
+		admin.POST("/exchange-rates", exchangeRateHandler.CreateExchangeRate)
+		admin.POST("/exchange-rates/import", exchangeRateHandler.ImportExchangeRates)
+		admin.DELETE("/exchange-rates/:id", exchangeRateHandler.DeleteExchangeRate)
+
+		// Tax rates
+		admin.POST("/tax-rates", taxRateHandler.CreateTaxRate)
+		admin.PUT("/tax-rates/:id", taxRateHandler.UpdateTaxRate)
+		admin.DELETE("/tax-rates/:id", taxRateHandler.DeleteTaxRate)
+
+		// Product catalog
+		admin.POST("/products", productHandler.CreateProduct)
+		admin.PUT("/products/:id", productHandler.UpdateProduct)
+		admin.DELETE("/products/:id", productHandler.DeleteProduct)
 
 		// Role management
 		admin.POST("/roles", roleHandler.CreateRole)
@@ -208,6 +504,39 @@ func main() {
 		IdleTimeout:       60 * time.Second,
 	}
 
+	// Background jobs run until shutdown; missed work is caught up on start
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	jobs := schedule.NewRunner(schedulerInterval)
+	jobs.Add("recurring invoices", func(now time.Time) error {
+		generated, err := recurringInvoiceService.GenerateDueInvoices(now)
+		if generated > 0 {
+			log.Printf("Generated %d recurring invoice(s)", generated)
+		}
+		return err
+	})
+	jobs.Add("overdue invoices", func(now time.Time) error {
+		marked, err := invoiceService.MarkOverdueInvoices(now)
+		if marked > 0 {
+			log.Printf("Marked %d invoice(s) overdue", marked)
+		}
+		return err
+	})
+	jobs.Add("expired quotes", func(now time.Time) error {
+		marked, err := quoteService.MarkExpiredQuotes(now)
+		if marked > 0 {
+			log.Printf("Marked %d quote(s) expired", marked)
+		}
+		return err
+	})
+	jobs.Add("payment reminders", func(now time.Time) error {
+		sent, err := dunningService.SendReminders(now)
+		if sent > 0 {
+			log.Printf("Sent %d payment reminder(s)", sent)
+		}
+		return err
+	})
+	go jobs.Run(jobsCtx)
+
 	go func() {
 		log.Printf("Server starting on :%s", cfg.ServerPort)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -218,6 +547,7 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
+	stopJobs()
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
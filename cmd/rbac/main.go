@@ -0,0 +1,183 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/tacheraSasi/go-api-starter/internals/config"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/policy"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/pkg/database"
+)
+
+const usage = `Manage RBAC roles and permissions as code.
+
+Usage:
+  rbac export [-o file] [-format yaml|json]   Write the current database policy
+  rbac diff -f file [-prune]                  Show changes needed to match a policy file
+  rbac apply -f file [-prune] [-dry-run]      Apply a policy file in a single transaction
+
+diff exits with status 2 when the database differs from the policy file.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(1)
+	}
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "export":
+		runExport(args)
+	case "diff":
+		runDiff(args)
+	case "apply":
+		runApply(args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(1)
+	}
+}
+
+func runExport(args []string) {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	output := flags.String("o", "", "output file (defaults to stdout)")
+	format := flags.String("format", "", "output format: yaml or json (defaults to the output file extension)")
+	_ = flags.Parse(args)
+
+	if *format == "" {
+		*format = policy.FormatFromPath(*output)
+	}
+
+	current, err := newPolicyService().Export()
+	if err != nil {
+		log.Fatal("Export failed:", err)
+	}
+	data, err := current.Marshal(*format)
+	if err != nil {
+		log.Fatal("Export failed:", err)
+	}
+
+	if *output == "" {
+		fmt.Print(string(data))
+		return
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		log.Fatal("Failed to write policy file:", err)
+	}
+	fmt.Printf("Policy exported to %s\n", *output)
+}
+
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	file := flags.String("f", "", "policy file (yaml or json)")
+	prune := flags.Bool("prune", false, "also report roles and permissions missing from the file")
+	_ = flags.Parse(args)
+
+	desired := loadPolicy(*file)
+	changes, err := newPolicyService().Diff(desired, policy.DiffOptions{Prune: *prune})
+	if err != nil {
+		log.Fatal("Diff failed:", err)
+	}
+
+	printChanges(changes)
+	if len(changes) > 0 {
+		os.Exit(2)
+	}
+}
+
+func runApply(args []string) {
+	flags := flag.NewFlagSet("apply", flag.ExitOnError)
+	file := flags.String("f", "", "policy file (yaml or json)")
+	prune := flags.Bool("prune", false, "delete roles and permissions missing from the file")
+	dryRun := flags.Bool("dry-run", false, "only show the changes that would be applied")
+	_ = flags.Parse(args)
+
+	desired := loadPolicy(*file)
+	service := newPolicyService()
+	opts := policy.DiffOptions{Prune: *prune}
+
+	if *dryRun {
+		changes, err := service.Diff(desired, opts)
+		if err != nil {
+			log.Fatal("Diff failed:", err)
+		}
+		printChanges(changes)
+		return
+	}
+
+	changes, err := service.Apply(desired, opts)
+	if err != nil {
+		log.Fatal("Apply failed, no changes were made:", err)
+	}
+	printChanges(changes)
+	fmt.Printf("Applied %d change(s)\n", len(changes))
+}
+
+func loadPolicy(path string) *policy.Policy {
+	if path == "" {
+		log.Fatal("A policy file is required (-f)")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal("Failed to read policy file:", err)
+	}
+	p, err := policy.Parse(data, policy.FormatFromPath(path))
+	if err != nil {
+		log.Fatal("Invalid policy file:", err)
+	}
+	return p
+}
+
+func printChanges(changes []policy.Change) {
+	if len(changes) == 0 {
+		fmt.Println("No changes. Database matches the policy.")
+		return
+	}
+	for _, change := range changes {
+		fmt.Println(change.String())
+	}
+}
+
+func newPolicyService() *services.PolicyService {
+	cfg := config.LoadConfig()
+
+	err := database.Connect(database.DBConfig{
+		Type:     cfg.DBType,
+		Host:     cfg.DBHost,
+		Port:     cfg.DBPort,
+		User:     cfg.DBUser,
+		Password: cfg.DBPassword,
+		DBName:   cfg.DBName,
+		SSLMode:  "disable",
+		FilePath: cfg.DBPath,
+	})
+	if err != nil {
+		log.Fatal("Database connection failed:", err)
+	}
+
+	err = database.AutoMigrate(
+		&models.User{},
+		&models.Role{},
+		&models.Permission{},
+		&models.UserRole{},
+		&models.RolePermission{},
+	)
+	if err != nil {
+		log.Fatal("Auto migration failed:", err)
+	}
+
+	db := database.GetDB()
+	return services.NewPolicyService(
+		repositories.NewTransactor(db),
+		repositories.NewRoleRepository(db),
+		repositories.NewPermissionRepository(db),
+	)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/tacheraSasi/go-api-starter/internals/config"
 	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/policy"
 	"github.com/tacheraSasi/go-api-starter/internals/repositories"
 	"github.com/tacheraSasi/go-api-starter/internals/services"
 	"github.com/tacheraSasi/go-api-starter/pkg/database"
@@ -34,8 +35,36 @@ func main() {
 		&models.UserRole{},
 		&models.RolePermission{},
 		&models.Customer{},
+		&models.Tag{},
+		&models.CustomFieldDefinition{},
+		&models.CustomFieldValue{},
+		&models.CustomerContact{},
+		&models.CustomerAddress{},
+		&models.CustomerMerge{},
 		&models.Invoice{},
+		&models.InvoiceSequence{},
 		&models.InvoiceItem{},
+		&models.InvoiceCharge{},
+		&models.TaxRate{},
+		&models.Product{},
+		&models.InvoiceTaxLine{},
+		&models.InvoiceStatusChange{},
+		&models.Payment{},
+		&models.PaymentAllocation{},
+		&models.ExchangeRate{},
+		&models.InvoiceShareLink{},
+		&models.RecurringInvoice{},
+		&models.RecurringInvoiceItem{},
+		&models.InvoiceReminder{},
+		&models.CreditNote{},
+		&models.CreditNoteItem{},
+		&models.CreditNoteAllocation{},
+		&models.CreditNoteRefund{},
+		&models.Quote{},
+		&models.QuoteItem{},
+		&models.QuoteTaxLine{},
+		&models.QuoteCharge{},
+		&models.Attachment{},
 		&models.BlacklistedToken{},
 		&models.PasswordResetToken{},
 	)
@@ -48,9 +77,10 @@ func main() {
 	roleRepo := repositories.NewRoleRepository(database.GetDB())
 	userRepo := repositories.NewUserRepository(database.GetDB())
 
-	permissionService := services.NewPermissionService(permissionRepo)
+	permissionService := services.NewPermissionService(permissionRepo, roleRepo)
 	roleService := services.NewRoleService(roleRepo, permissionRepo)
 	userService := services.NewUserService(userRepo, roleRepo)
+	policyService := services.NewPolicyService(repositories.NewTransactor(database.GetDB()), roleRepo, permissionRepo)
 
 	// Seed permissions
 	fmt.Println("📋 Creating default permissions...")
@@ -68,49 +98,21 @@ func main() {
 		fmt.Println("✅ Default roles created")
 	}
 
-	// Assign permissions to admin role
-	fmt.Println("🔐 Assigning permissions to admin role...")
-	adminRole, err := roleService.GetRoleByName(models.RoleAdmin)
+	// Create whatever the default policy is missing; grants changed by administrators are
+	// kept, a full sync is left to the rbac apply command
+	fmt.Println("🔐 Applying missing default role permissions...")
+	defaults, err := policy.Default()
 	if err != nil {
-		log.Printf("Warning: Could not find admin role: %v", err)
+		log.Printf("Warning: Could not load default policy: %v", err)
+	} else if _, err := policyService.Apply(defaults, policy.DiffOptions{CreateOnly: true}); err != nil {
+		log.Printf("Warning: Could not apply default policy: %v", err)
 	} else {
-		// Get all permissions
-		permissions, err := permissionService.ListPermissions(1000, 0, "")
-		if err != nil {
-			log.Printf("Warning: Could not get permissions: %v", err)
-		} else {
-			for _, permission := range permissions {
-				_ = roleService.AddPermissionToRole(adminRole.ID, permission.ID)
-			}
-			fmt.Println("✅ Admin permissions assigned")
-		}
+		fmt.Println("✅ Default role permissions assigned")
 	}
 
-	// Assign basic permissions to user role
-	fmt.Println("📖 Assigning basic permissions to user role...")
-	userRole, err := roleService.GetRoleByName(models.RoleUser)
+	adminRole, err := roleService.GetRoleByName(models.RoleAdmin)
 	if err != nil {
-		log.Printf("Warning: Could not find user role: %v", err)
-	} else {
-		// Basic read permissions
-		basicPermissions := []struct {
-			resource string
-			action   string
-		}{
-			{models.ResourceCustomer, models.ActionRead},
-			{models.ResourceCustomer, models.ActionList},
-			{models.ResourceInvoice, models.ActionRead},
-			{models.ResourceInvoice, models.ActionList},
-			{models.ResourceUser, models.ActionRead}, // Own profile
-		}
-
-		for _, perm := range basicPermissions {
-			permission, err := permissionRepo.GetByResourceAndAction(perm.resource, perm.action)
-			if err == nil {
-				_ = roleService.AddPermissionToRole(userRole.ID, permission.ID)
-			}
-		}
-		fmt.Println("✅ User permissions assigned")
+		log.Printf("Warning: Could not find admin role: %v", err)
 	}
 
 	// Create admin user
@@ -2,14 +2,32 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/gabriel-vasile/mimetype"
 	"github.com/joho/godotenv"
+	"github.com/tacheraSasi/go-api-starter/pkg/dunning"
+	"github.com/tacheraSasi/go-api-starter/pkg/mailer"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"github.com/tacheraSasi/go-api-starter/pkg/numbering"
+	"github.com/tacheraSasi/go-api-starter/pkg/pdf"
+	"github.com/tacheraSasi/go-api-starter/pkg/storage"
+	"github.com/tacheraSasi/go-api-starter/pkg/ubl"
 )
 
 type ConfigKey string
 
+// defaultAttachmentTypes are the file types accepted as attachments unless
+// ATTACHMENT_ALLOWED_TYPES says otherwise: PDFs, images, text and office documents
+const defaultAttachmentTypes = "application/pdf,image/png,image/jpeg,image/gif,image/webp,text/plain,text/csv," +
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document," +
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet," +
+	"application/vnd.oasis.opendocument.text,application/vnd.oasis.opendocument.spreadsheet"
+
 const (
 	DBHostKey       ConfigKey = "DB_HOST"
 	DBPortKey       ConfigKey = "DB_PORT"
@@ -35,6 +53,64 @@ type Config struct {
 	LogFilePath  string
 	GINMode      string
 	CORSOrigins  []string
+	// Money settings
+	DefaultCurrency string // ISO 4217 code used when an invoice does not specify one
+	BaseCurrency    string // reporting currency invoice totals are converted into
+	RoundingMode    string // half_up, half_even, half_down, down or up
+	TaxRounding     string // line or invoice
+	// Invoice numbering
+	InvoiceNumberFormat string // template such as INV-{YYYY}-{SEQ:5}
+	InvoiceNumberReset  string // never, yearly or monthly
+	// Credit note numbering
+	CreditNoteNumberFormat string // template such as CN-{YYYY}-{SEQ:5}
+	CreditNoteNumberReset  string // never, yearly or monthly
+	// Quotes
+	QuoteNumberFormat string // template such as QT-{YYYY}-{SEQ:5}
+	QuoteNumberReset  string // never, yearly or monthly
+	QuoteValidityDays string // days after the issue date a quote expires unless it sets an expiry date
+	// Invoice documents
+	CompanyName         string
+	CompanyAddress      []string // address lines, separated by | in COMPANY_ADDRESS
+	CompanyCity         string   // city of the issuer on e-invoices
+	CompanyPostalCode   string   // postal code of the issuer on e-invoices
+	CompanyEmail        string
+	CompanyPhone        string
+	CompanyTaxID        string
+	CompanyCountry      string // ISO 3166-1 alpha-2 code, required by e-invoices
+	CompanyEndpointID   string // Peppol electronic address as scheme:identifier; defaults to EM:COMPANY_EMAIL
+	PaymentInstructions string // lines separated by |
+	PDFPageSize         string // a4 or letter
+	PDFBrandColor       string // #rrggbb
+	PDFLogoPath         string // optional JPEG or PNG file
+	// E-invoices
+	UBLBuyerReferenceField string // key of the invoice custom field holding the buyer reference
+	// Invoice share links
+	ShareLinkSecret  string // signs share link and quote link tokens; defaults to JWT_SECRET
+	ShareLinkTTLDays string // days a new share link stays valid unless the request says otherwise
+	PublicURL        string // scheme and host share link URLs start with; the request host when empty
+	// Background jobs
+	SchedulerInterval string // how often background jobs such as recurring invoices run, e.g. 1m
+	// Email
+	SMTPHost     string // email is written to the log instead of sent when empty
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string // sender address; defaults to COMPANY_EMAIL
+	// Payment reminders
+	DunningSchedule    string // reminder days relative to the due date, e.g. -3,0,7,14, or off
+	DunningTemplateDir string // optional directory of templates replacing the built-in ones
+	// File attachments
+	StorageDriver          string // local or s3
+	StorageLocalDir        string // directory of the local driver
+	S3Endpoint             string
+	S3Region               string
+	S3Bucket               string
+	S3AccessKeyID          string
+	S3SecretAccessKey      string
+	S3ForcePathStyle       string // true to address the bucket as endpoint/bucket, as MinIO expects
+	AttachmentMaxSizeMB    string
+	AttachmentAllowedTypes []string // MIME types, separated by commas in ATTACHMENT_ALLOWED_TYPES
+	AvatarMaxSizeMB        string
 }
 
 func LoadConfig() *Config {
@@ -45,6 +121,7 @@ func LoadConfig() *Config {
 	logFilePath := getEnvAny("logs/app.log", "LOG_FILE_PATH")
 	ginMode := getEnvAny("release", "GIN_MODE")
 	corsAllowedOrigins := getEnvAny("*", "CORS_ALLOWED_ORIGINS")
+	defaultCurrency := getEnvAny("USD", "DEFAULT_CURRENCY")
 
 	origins := splitAndTrim(corsAllowedOrigins)
 	if len(origins) == 0 {
@@ -65,6 +142,64 @@ func LoadConfig() *Config {
 		LogFilePath:  logFilePath,
 		GINMode:      ginMode,
 		CORSOrigins:  origins,
+
+		DefaultCurrency: defaultCurrency,
+		BaseCurrency:    getEnvAny(defaultCurrency, "BASE_CURRENCY"),
+		RoundingMode:    getEnvAny("half_up", "ROUNDING_MODE"),
+		TaxRounding:     getEnvAny("invoice", "TAX_ROUNDING"),
+
+		InvoiceNumberFormat: getEnvAny("INV-{YYYY}-{SEQ:5}", "INVOICE_NUMBER_FORMAT"),
+		InvoiceNumberReset:  getEnvAny("yearly", "INVOICE_NUMBER_RESET"),
+
+		CreditNoteNumberFormat: getEnvAny("CN-{YYYY}-{SEQ:5}", "CREDIT_NOTE_NUMBER_FORMAT"),
+		CreditNoteNumberReset:  getEnvAny("yearly", "CREDIT_NOTE_NUMBER_RESET"),
+
+		QuoteNumberFormat: getEnvAny("QT-{YYYY}-{SEQ:5}", "QUOTE_NUMBER_FORMAT"),
+		QuoteNumberReset:  getEnvAny("yearly", "QUOTE_NUMBER_RESET"),
+		QuoteValidityDays: getEnvAny("30", "QUOTE_VALIDITY_DAYS"),
+
+		CompanyName:         getEnvAny("", "COMPANY_NAME"),
+		CompanyAddress:      splitLines(getEnvAny("", "COMPANY_ADDRESS")),
+		CompanyCity:         getEnvAny("", "COMPANY_CITY"),
+		CompanyPostalCode:   getEnvAny("", "COMPANY_POSTAL_CODE"),
+		CompanyEmail:        getEnvAny("", "COMPANY_EMAIL"),
+		CompanyPhone:        getEnvAny("", "COMPANY_PHONE"),
+		CompanyTaxID:        getEnvAny("", "COMPANY_TAX_ID"),
+		CompanyCountry:      strings.ToUpper(getEnvAny("", "COMPANY_COUNTRY")),
+		CompanyEndpointID:   getEnvAny("", "COMPANY_ENDPOINT_ID"),
+		PaymentInstructions: strings.Join(splitLines(getEnvAny("", "PAYMENT_INSTRUCTIONS")), "\n"),
+		PDFPageSize:         getEnvAny("a4", "PDF_PAGE_SIZE"),
+		PDFBrandColor:       getEnvAny("#1d4ed8", "PDF_BRAND_COLOR"),
+		PDFLogoPath:         getEnvAny("", "PDF_LOGO_PATH"),
+
+		UBLBuyerReferenceField: getEnvAny("buyer_reference", "UBL_BUYER_REFERENCE_FIELD"),
+
+		ShareLinkSecret:  getEnvAny(getEnv("JWT_SECRET", "secret"), "SHARE_LINK_SECRET"),
+		ShareLinkTTLDays: getEnvAny("30", "SHARE_LINK_TTL_DAYS"),
+		PublicURL:        strings.TrimRight(getEnvAny("", "PUBLIC_URL"), "/"),
+
+		SchedulerInterval: getEnvAny("1m", "SCHEDULER_INTERVAL"),
+
+		SMTPHost:     getEnvAny("", "SMTP_HOST"),
+		SMTPPort:     getEnvAny("587", "SMTP_PORT"),
+		SMTPUsername: getEnvAny("", "SMTP_USERNAME"),
+		SMTPPassword: getEnvAny("", "SMTP_PASSWORD"),
+		MailFrom:     getEnvAny(getEnvAny("billing@localhost", "COMPANY_EMAIL"), "MAIL_FROM"),
+
+		DunningSchedule:    getEnvAny("-3,0,7,14", "DUNNING_SCHEDULE"),
+		DunningTemplateDir: getEnvAny("", "DUNNING_TEMPLATE_DIR"),
+
+		StorageDriver:          getEnvAny("local", "STORAGE_DRIVER"),
+		StorageLocalDir:        getEnvAny("uploads", "STORAGE_LOCAL_DIR"),
+		S3Endpoint:             getEnvAny("https://s3.amazonaws.com", "S3_ENDPOINT"),
+		S3Region:               getEnvAny("us-east-1", "S3_REGION"),
+		S3Bucket:               getEnvAny("", "S3_BUCKET"),
+		S3AccessKeyID:          getEnvAny("", "S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey:      getEnvAny("", "S3_SECRET_ACCESS_KEY"),
+		S3ForcePathStyle:       getEnvAny("true", "S3_FORCE_PATH_STYLE"),
+		AttachmentMaxSizeMB:    getEnvAny("10", "ATTACHMENT_MAX_SIZE_MB"),
+		AttachmentAllowedTypes: splitAndTrim(getEnvAny(defaultAttachmentTypes, "ATTACHMENT_ALLOWED_TYPES")),
+		AvatarMaxSizeMB:        getEnvAny("2", "AVATAR_MAX_SIZE_MB"),
 	}
 }
 
@@ -75,9 +210,143 @@ func (c *Config) Validate() error {
 	if strings.TrimSpace(c.ServerPort) == "" {
 		return fmt.Errorf("SERVER_PORT must not be empty")
 	}
+	if _, err := money.ParseCurrency(c.DefaultCurrency); err != nil {
+		return fmt.Errorf("DEFAULT_CURRENCY: %w", err)
+	}
+	if _, err := money.ParseCurrency(c.BaseCurrency); err != nil {
+		return fmt.Errorf("BASE_CURRENCY: %w", err)
+	}
+	if _, err := money.ParseRoundingMode(c.RoundingMode); err != nil {
+		return fmt.Errorf("ROUNDING_MODE: %w", err)
+	}
+	if c.TaxRounding != "line" && c.TaxRounding != "invoice" {
+		return fmt.Errorf("TAX_ROUNDING must be line or invoice, got %q", c.TaxRounding)
+	}
+	if _, err := numbering.Parse(c.InvoiceNumberFormat, numbering.Reset(c.InvoiceNumberReset)); err != nil {
+		return fmt.Errorf("INVOICE_NUMBER_FORMAT/INVOICE_NUMBER_RESET: %w", err)
+	}
+	if _, err := numbering.Parse(c.CreditNoteNumberFormat, numbering.Reset(c.CreditNoteNumberReset)); err != nil {
+		return fmt.Errorf("CREDIT_NOTE_NUMBER_FORMAT/CREDIT_NOTE_NUMBER_RESET: %w", err)
+	}
+	if _, err := numbering.Parse(c.QuoteNumberFormat, numbering.Reset(c.QuoteNumberReset)); err != nil {
+		return fmt.Errorf("QUOTE_NUMBER_FORMAT/QUOTE_NUMBER_RESET: %w", err)
+	}
+	if days, err := strconv.Atoi(c.QuoteValidityDays); err != nil || days < 1 || days > 365 {
+		return fmt.Errorf("QUOTE_VALIDITY_DAYS must be a number of days between 1 and 365, got %q", c.QuoteValidityDays)
+	}
+	if _, err := pdf.ParseSize(c.PDFPageSize); err != nil {
+		return fmt.Errorf("PDF_PAGE_SIZE: %w", err)
+	}
+	if _, err := pdf.ParseColor(c.PDFBrandColor); err != nil {
+		return fmt.Errorf("PDF_BRAND_COLOR: %w", err)
+	}
+	if c.PDFLogoPath != "" {
+		if _, err := pdf.LoadImage(c.PDFLogoPath); err != nil {
+			return fmt.Errorf("PDF_LOGO_PATH: %w", err)
+		}
+	}
+	if c.CompanyCountry != "" && !isCountryCode(c.CompanyCountry) {
+		return fmt.Errorf("COMPANY_COUNTRY must be an ISO 3166-1 alpha-2 code such as DE, got %q", c.CompanyCountry)
+	}
+	if _, err := c.CompanyEndpoint(); err != nil {
+		return fmt.Errorf("COMPANY_ENDPOINT_ID: %w", err)
+	}
+	if strings.TrimSpace(c.UBLBuyerReferenceField) == "" {
+		return fmt.Errorf("UBL_BUYER_REFERENCE_FIELD must not be empty")
+	}
+	if strings.TrimSpace(c.ShareLinkSecret) == "" {
+		return fmt.Errorf("SHARE_LINK_SECRET must not be empty")
+	}
+	if days, err := strconv.Atoi(c.ShareLinkTTLDays); err != nil || days < 1 || days > 365 {
+		return fmt.Errorf("SHARE_LINK_TTL_DAYS must be a number of days between 1 and 365, got %q", c.ShareLinkTTLDays)
+	}
+	if c.PublicURL != "" {
+		u, err := url.Parse(c.PublicURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("PUBLIC_URL must be an absolute http or https URL, got %q", c.PublicURL)
+		}
+	}
+	if interval, err := time.ParseDuration(c.SchedulerInterval); err != nil || interval < time.Second {
+		return fmt.Errorf("SCHEDULER_INTERVAL must be a duration of at least 1s such as 30s or 5m, got %q", c.SchedulerInterval)
+	}
+	if _, err := c.Mailer(); err != nil {
+		return fmt.Errorf("SMTP_PORT/MAIL_FROM: %w", err)
+	}
+	if _, err := dunning.ParseSchedule(c.DunningSchedule); err != nil {
+		return fmt.Errorf("DUNNING_SCHEDULE: %w", err)
+	}
+	if c.DunningTemplateDir != "" {
+		if _, err := dunning.LoadTemplates(c.DunningTemplateDir); err != nil {
+			return fmt.Errorf("DUNNING_TEMPLATE_DIR: %w", err)
+		}
+	}
+	if _, err := c.Storage(); err != nil {
+		return fmt.Errorf("STORAGE_DRIVER/S3 settings: %w", err)
+	}
+	if mb, err := strconv.Atoi(c.AttachmentMaxSizeMB); err != nil || mb < 1 || mb > 1024 {
+		return fmt.Errorf("ATTACHMENT_MAX_SIZE_MB must be a number of megabytes between 1 and 1024, got %q", c.AttachmentMaxSizeMB)
+	}
+	if mb, err := strconv.Atoi(c.AvatarMaxSizeMB); err != nil || mb < 1 || mb > 1024 {
+		return fmt.Errorf("AVATAR_MAX_SIZE_MB must be a number of megabytes between 1 and 1024, got %q", c.AvatarMaxSizeMB)
+	}
+	if len(c.AttachmentAllowedTypes) == 0 {
+		return fmt.Errorf("ATTACHMENT_ALLOWED_TYPES must list at least one MIME type")
+	}
+	for _, mimeType := range c.AttachmentAllowedTypes {
+		if mimetype.Lookup(mimeType) == nil {
+			return fmt.Errorf("ATTACHMENT_ALLOWED_TYPES: unsupported MIME type %q", mimeType)
+		}
+	}
 	return nil
 }
 
+// CompanyEndpoint returns the Peppol electronic address of the company: COMPANY_ENDPOINT_ID,
+// or its email address when that is not set. It is nil when neither is configured.
+func (c *Config) CompanyEndpoint() (*ubl.Identifier, error) {
+	if c.CompanyEndpointID != "" {
+		return ubl.ParseEndpoint(c.CompanyEndpointID)
+	}
+	if c.CompanyEmail != "" {
+		return &ubl.Identifier{Value: c.CompanyEmail, SchemeID: ubl.EndpointSchemeEmail}, nil
+	}
+	return nil, nil
+}
+
+// Storage returns the file storage configured by the STORAGE_ and S3_ settings
+func (c *Config) Storage() (storage.Storage, error) {
+	forcePathStyle, err := strconv.ParseBool(c.S3ForcePathStyle)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3_FORCE_PATH_STYLE %q", c.S3ForcePathStyle)
+	}
+	return storage.New(storage.Config{
+		Driver:   c.StorageDriver,
+		LocalDir: c.StorageLocalDir,
+		S3: storage.S3Config{
+			Endpoint:        c.S3Endpoint,
+			Region:          c.S3Region,
+			Bucket:          c.S3Bucket,
+			AccessKeyID:     c.S3AccessKeyID,
+			SecretAccessKey: c.S3SecretAccessKey,
+			ForcePathStyle:  forcePathStyle,
+		},
+	})
+}
+
+// Mailer returns the mailer configured by the SMTP settings
+func (c *Config) Mailer() (mailer.Mailer, error) {
+	port, err := strconv.Atoi(c.SMTPPort)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP port %q", c.SMTPPort)
+	}
+	return mailer.New(mailer.Config{
+		Host:     c.SMTPHost,
+		Port:     port,
+		Username: c.SMTPUsername,
+		Password: c.SMTPPassword,
+		From:     c.MailFrom,
+	})
+}
+
 func (c *Config) Get(key ConfigKey) string {
 	values := map[ConfigKey]string{
 		DBHostKey:       c.DBHost,
@@ -113,7 +382,16 @@ func getEnvAny(defaultValue string, keys ...string) string {
 }
 
 func splitAndTrim(input string) []string {
-	parts := strings.Split(input, ",")
+	return splitOn(input, ",")
+}
+
+// splitLines splits a multi-line setting written on one line with | between the lines
+func splitLines(input string) []string {
+	return splitOn(input, "|")
+}
+
+func splitOn(input, separator string) []string {
+	parts := strings.Split(input, separator)
 	result := make([]string, 0, len(parts))
 	for _, part := range parts {
 		trimmed := strings.TrimSpace(part)
@@ -123,3 +401,16 @@ func splitAndTrim(input string) []string {
 	}
 	return result
 }
+
+// isCountryCode reports whether s is written like an ISO 3166-1 alpha-2 code
+func isCountryCode(s string) bool {
+	if len(s) != 2 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package dtos
+
+import "github.com/tacheraSasi/go-api-starter/pkg/money"
+
+// Credit note DTOs
+type CreditNoteItemRequest struct {
+	InvoiceItemID uint `json:"invoice_item_id" binding:"required"`
+	// Quantity credits that many units of the line; Amount instead credits an amount
+	// including tax. Omitting both credits what is left of the line.
+	Quantity int           `json:"quantity" binding:"min=0"`
+	Amount   *money.Amount `json:"amount"`
+}
+
+type CreateCreditNoteRequest struct {
+	InvoiceID uint   `json:"invoice_id" binding:"required"`
+	IssueDate string `json:"issue_date" binding:"omitempty,datetime=2006-01-02"` // defaults to today
+	Reason    string `json:"reason" binding:"max=1000"`
+	// Items credit single invoice lines; when omitted, whatever is left of the invoice,
+	// including its charges, is credited
+	Items []CreditNoteItemRequest `json:"items" binding:"omitempty,dive"`
+}
+
+type CreditNoteAllocationRequest struct {
+	InvoiceID uint         `json:"invoice_id" binding:"required"`
+	Amount    money.Amount `json:"amount"`
+}
+
+type ApplyCreditNoteRequest struct {
+	// Allocations apply the remaining credit; omitted means the oldest open invoices
+	Allocations []CreditNoteAllocationRequest `json:"allocations" binding:"omitempty,dive"`
+}
+
+type RefundCreditNoteRequest struct {
+	Amount     *money.Amount `json:"amount"` // defaults to the remaining credit
+	RefundDate string        `json:"refund_date" binding:"omitempty,datetime=2006-01-02"`
+	Method     string        `json:"method"` // bank_transfer, card, cash, cheque or other (default)
+	Reference  string        `json:"reference" binding:"max=100"`
+	Notes      string        `json:"notes" binding:"max=1000"`
+}
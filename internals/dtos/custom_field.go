@@ -0,0 +1,27 @@
+package dtos
+
+// Custom field DTOs
+type CreateCustomFieldRequest struct {
+	EntityType string   `json:"entity_type" binding:"required,oneof=customers invoices"`
+	Key        string   `json:"key" binding:"required"`
+	Label      string   `json:"label" binding:"required"`
+	Type       string   `json:"type" binding:"required,oneof=text number boolean date select"`
+	Required   bool     `json:"required,omitempty"`
+	Options    []string `json:"options,omitempty"`
+	Pattern    string   `json:"pattern,omitempty"`
+	Min        *float64 `json:"min,omitempty"`
+	Max        *float64 `json:"max,omitempty"`
+	Position   int      `json:"position,omitempty"`
+}
+
+// UpdateCustomFieldRequest replaces a definition; its entity type and key cannot change
+type UpdateCustomFieldRequest struct {
+	Label    string   `json:"label" binding:"required"`
+	Type     string   `json:"type" binding:"required,oneof=text number boolean date select"`
+	Required bool     `json:"required,omitempty"`
+	Options  []string `json:"options,omitempty"`
+	Pattern  string   `json:"pattern,omitempty"`
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+	Position int      `json:"position,omitempty"`
+}
@@ -0,0 +1,73 @@
+package dtos
+
+import "time"
+
+// Customer import DTOs
+type CustomerImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Message string `json:"message"`
+}
+
+type CustomerImportRowResult struct {
+	Row        int    `json:"row"`
+	Email      string `json:"email"`
+	Action     string `json:"action"` // create, update, error
+	CustomerID uint   `json:"customer_id,omitempty"`
+}
+
+type CustomerImportReport struct {
+	DryRun    bool                      `json:"dry_run"`
+	Upsert    bool                      `json:"upsert"`
+	Mapping   map[string]string         `json:"mapping"`
+	TotalRows int                       `json:"total_rows"`
+	Created   int                       `json:"created"`
+	Updated   int                       `json:"updated"`
+	Failed    int                       `json:"failed"`
+	Rows      []CustomerImportRowResult `json:"rows"`
+	Errors    []CustomerImportRowError  `json:"errors"`
+}
+
+// Customer contact DTOs
+type CustomerContactRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email,omitempty" binding:"omitempty,email"`
+	Phone    string `json:"phone,omitempty"`
+	JobTitle string `json:"job_title,omitempty"`
+	Role     string `json:"role,omitempty" binding:"omitempty,oneof=primary billing technical other"`
+}
+
+// Customer address DTOs
+type CustomerAddressRequest struct {
+	Type       string `json:"type,omitempty" binding:"omitempty,oneof=billing shipping"`
+	Label      string `json:"label,omitempty"`
+	Line1      string `json:"line1" binding:"required"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city" binding:"required"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty" binding:"omitempty,max=20"`
+	Country    string `json:"country" binding:"required,iso3166_1_alpha2"`
+	IsDefault  bool   `json:"is_default,omitempty"`
+}
+
+// Customer duplicate and merge DTOs
+type DuplicateCustomer struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Phone     string    `json:"phone"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type DuplicateCandidate struct {
+	Customer  DuplicateCustomer `json:"customer"`  // the older customer, suggested as survivor
+	Duplicate DuplicateCustomer `json:"duplicate"` // the newer customer, suggested to merge away
+	Score     float64           `json:"score"`
+	Reasons   []string          `json:"reasons"` // email, phone, name
+}
+
+type MergeCustomersRequest struct {
+	SurvivorID  uint `json:"survivor_id" binding:"required"`
+	DuplicateID uint `json:"duplicate_id" binding:"required"`
+}
@@ -0,0 +1,44 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+// Exchange rate DTOs
+type CreateExchangeRateRequest struct {
+	Date          string     `json:"date" binding:"required,datetime=2006-01-02"`
+	BaseCurrency  string     `json:"base_currency" binding:"required,len=3,alpha"`
+	QuoteCurrency string     `json:"quote_currency" binding:"required,len=3,alpha"`
+	Rate          money.Rate `json:"rate"`
+}
+
+type ExchangeRateImportError struct {
+	Row      int    `json:"row,omitempty"`
+	Date     string `json:"date,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	Message  string `json:"message"`
+}
+
+type ExchangeRateImportReport struct {
+	Format   string                    `json:"format"` // csv, ecb
+	Imported int                       `json:"imported"`
+	Failed   int                       `json:"failed"`
+	From     *time.Time                `json:"from,omitempty"`
+	To       *time.Time                `json:"to,omitempty"`
+	Errors   []ExchangeRateImportError `json:"errors"`
+}
+
+// ExchangeRateQuote is the rate used to convert between two currencies on a date
+type ExchangeRateQuote struct {
+	From      money.Currency `json:"from"`
+	To        money.Currency `json:"to"`
+	Date      time.Time      `json:"date"`
+	Rate      money.Rate     `json:"rate"`
+	RateDate  time.Time      `json:"rate_date"` // date of the oldest stored rate used
+	Method    string         `json:"method"`    // identity, direct, inverse, cross
+	Via       money.Currency `json:"via,omitempty"`
+	Amount    *money.Amount  `json:"amount,omitempty"`
+	Converted *money.Amount  `json:"converted,omitempty"`
+}
@@ -0,0 +1,47 @@
+package dtos
+
+import (
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/pkg/ubl"
+)
+
+// Invoice DTOs
+type InvoiceTransitionRequest struct {
+	Note string `json:"note" binding:"max=500"`
+}
+
+// MarkInvoicePaidRequest records a payment of the amount due on an invoice
+type MarkInvoicePaidRequest struct {
+	PaidAt    string `json:"paid_at" binding:"omitempty,datetime=2006-01-02"` // defaults to today
+	Method    string `json:"method"`                                          // defaults to other
+	Reference string `json:"reference" binding:"max=100"`
+	Note      string `json:"note" binding:"max=500"`
+}
+
+// CreateShareLinkRequest creates a share link; the expiry defaults to SHARE_LINK_TTL_DAYS
+type CreateShareLinkRequest struct {
+	ExpiresInDays int `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+}
+
+// InvoiceShareLink is a share link with the token and URL that open it
+type InvoiceShareLink struct {
+	models.InvoiceShareLink
+	Active bool   `json:"active"`
+	Token  string `json:"token"`
+	URL    string `json:"url"`
+}
+
+// UBLValidationReport lists the EN 16931 and Peppol rules a UBL invoice breaks
+type UBLValidationReport struct {
+	Valid    bool        `json:"valid"` // no errors; warnings do not block sending or importing
+	Errors   []ubl.Issue `json:"errors"`
+	Warnings []ubl.Issue `json:"warnings"`
+}
+
+// UBLImportReport is the result of importing a UBL invoice as a draft invoice
+type UBLImportReport struct {
+	UBLValidationReport
+	DryRun            bool            `json:"dry_run"`
+	CustomerMatchedBy string          `json:"customer_matched_by,omitempty"` // peppol_id, tax_id or email
+	Invoice           *models.Invoice `json:"invoice,omitempty"`             // priced on a dry run, created otherwise
+}
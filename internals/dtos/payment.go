@@ -0,0 +1,41 @@
+package dtos
+
+import "github.com/tacheraSasi/go-api-starter/pkg/money"
+
+// Payment DTOs
+type PaymentAllocationRequest struct {
+	InvoiceID uint         `json:"invoice_id" binding:"required"`
+	Amount    money.Amount `json:"amount"`
+}
+
+type CreatePaymentRequest struct {
+	CustomerID  uint         `json:"customer_id" binding:"required"`
+	Amount      money.Amount `json:"amount"`
+	Currency    string       `json:"currency" binding:"omitempty,len=3,alpha"` // defaults to the customer's currency
+	PaymentDate string       `json:"payment_date" binding:"omitempty,datetime=2006-01-02"`
+	Method      string       `json:"method"` // bank_transfer, card, cash, cheque or other (default)
+	Reference   string       `json:"reference" binding:"max=100"`
+	Notes       string       `json:"notes" binding:"max=1000"`
+	// Allocations split the payment over invoices. When omitted, the payment is allocated
+	// to the oldest open invoices; an empty list keeps it all as customer credit.
+	Allocations []PaymentAllocationRequest `json:"allocations" binding:"omitempty,dive"`
+}
+
+type ApplyPaymentRequest struct {
+	// Allocations apply customer credit left on the payment; omitted means the oldest open invoices
+	Allocations []PaymentAllocationRequest `json:"allocations" binding:"omitempty,dive"`
+}
+
+type ReversePaymentRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+type CreditBalance struct {
+	Currency money.Currency `json:"currency"`
+	Amount   money.Amount   `json:"amount"`
+}
+
+type CustomerCredit struct {
+	CustomerID uint            `json:"customer_id"`
+	Balances   []CreditBalance `json:"balances"`
+}
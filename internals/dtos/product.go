@@ -0,0 +1,15 @@
+package dtos
+
+import "github.com/tacheraSasi/go-api-starter/pkg/money"
+
+// Product DTOs
+type ProductRequest struct {
+	SKU          string       `json:"sku" binding:"required,max=64"`
+	Name         string       `json:"name" binding:"required,max=200"`
+	Description  string       `json:"description" binding:"max=2000"`
+	Unit         string       `json:"unit" binding:"max=20"`
+	Currency     string       `json:"currency" binding:"omitempty,len=3,alpha"` // defaults to DEFAULT_CURRENCY
+	DefaultPrice money.Amount `json:"default_price"`
+	TaxRateID    *uint        `json:"tax_rate_id"` // omitted means the default tax rates
+	Active       *bool        `json:"active"`      // defaults to true on create and is kept on update
+}
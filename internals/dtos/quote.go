@@ -0,0 +1,56 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+// Quote DTOs
+type QuoteItemRequest struct {
+	ProductID     *uint        `json:"product_id"`                              // fills an empty description, unit price and tax rates
+	Description   string       `json:"description" binding:"omitempty,max=500"` // required without a product_id
+	Quantity      int          `json:"quantity" binding:"required,min=1"`
+	UnitPrice     money.Amount `json:"unit_price"`
+	DiscountType  string       `json:"discount_type" binding:"omitempty,oneof=percent fixed"`
+	DiscountValue money.Amount `json:"discount_value"`
+	TaxRateIDs    []uint       `json:"tax_rate_ids"` // omitted means the default tax rates
+}
+
+type QuoteChargeRequest struct {
+	Description string       `json:"description" binding:"required,max=200"`
+	Amount      money.Amount `json:"amount"`
+}
+
+type QuoteRequest struct {
+	CustomerID       uint                 `json:"customer_id" binding:"required"`
+	BillingAddressID *uint                `json:"billing_address_id"`                                  // defaults to the customer's default billing address
+	Currency         string               `json:"currency" binding:"omitempty,len=3,alpha"`            // defaults to the customer's currency
+	IssueDate        string               `json:"issue_date" binding:"omitempty,datetime=2006-01-02"`  // defaults to today
+	ExpiryDate       string               `json:"expiry_date" binding:"omitempty,datetime=2006-01-02"` // defaults to QUOTE_VALIDITY_DAYS after the issue date
+	Items            []QuoteItemRequest   `json:"items" binding:"required,min=1,dive"`
+	Charges          []QuoteChargeRequest `json:"charges" binding:"omitempty,dive"`
+	DiscountType     string               `json:"discount_type" binding:"omitempty,oneof=percent fixed"`
+	DiscountValue    money.Amount         `json:"discount_value"`
+	Notes            string               `json:"notes" binding:"max=2000"`
+	CustomFields     models.CustomFields  `json:"custom_fields"` // invoice custom fields
+}
+
+type DeclineQuoteRequest struct {
+	Reason string `json:"reason" binding:"max=1000"`
+}
+
+// ConvertQuoteRequest sets the dates of the invoice an accepted quote is converted into
+type ConvertQuoteRequest struct {
+	IssueDate    string              `json:"issue_date" binding:"omitempty,datetime=2006-01-02"` // defaults to today
+	DueDate      string              `json:"due_date" binding:"omitempty,datetime=2006-01-02"`   // defaults to 30 days after the issue date
+	CustomFields models.CustomFields `json:"custom_fields"`                                      // added to the custom fields of the quote
+}
+
+// QuoteLink is the public page where the customer accepts or declines a quote
+type QuoteLink struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
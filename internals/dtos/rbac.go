@@ -1,5 +1,7 @@
 package dtos
 
+import "github.com/tacheraSasi/go-api-starter/internals/models"
+
 // User DTOs
 type CreateUserRequest struct {
 	Name     string `json:"name" binding:"required"`
@@ -86,6 +88,25 @@ type PermissionCheckResponse struct {
 	Action        string `json:"action"`
 }
 
+type BatchPermissionCheckRequest struct {
+	Checks []PermissionCheckRequest `json:"checks" binding:"required,min=1,max=100,dive"`
+}
+
+type BatchPermissionCheckResponse struct {
+	UserID  uint                        `json:"user_id"`
+	Results []models.PermissionDecision `json:"results"`
+}
+
+// Permission Explain DTOs
+type PermissionExplainResponse struct {
+	UserID        uint                         `json:"user_id"`
+	UserActive    bool                         `json:"user_active"`
+	ActiveRoles   []string                     `json:"active_roles"`
+	InactiveRoles []string                     `json:"inactive_roles"`
+	Permissions   []models.EffectivePermission `json:"permissions"`
+	Decision      *models.PermissionDecision   `json:"decision,omitempty"`
+}
+
 // Role Assignment DTOs
 type AssignRoleRequest struct {
 	RoleID uint `json:"role_id" binding:"required"`
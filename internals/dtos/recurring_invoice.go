@@ -0,0 +1,45 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+// Recurring invoice DTOs
+type RecurringInvoiceItemRequest struct {
+	Description   string       `json:"description" binding:"required,max=500"`
+	Quantity      int          `json:"quantity" binding:"required,min=1"`
+	UnitPrice     money.Amount `json:"unit_price"`
+	DiscountType  string       `json:"discount_type" binding:"omitempty,oneof=percent fixed"`
+	DiscountValue money.Amount `json:"discount_value"`
+	TaxRateIDs    []uint       `json:"tax_rate_ids"` // omitted means the default tax rates
+}
+
+type RecurringInvoiceRequest struct {
+	Name          string                        `json:"name" binding:"required,max=100"`
+	CustomerID    uint                          `json:"customer_id" binding:"required"`
+	Items         []RecurringInvoiceItemRequest `json:"items" binding:"required,min=1,dive"`
+	Currency      string                        `json:"currency" binding:"omitempty,len=3,alpha"` // defaults to the customer's currency
+	DiscountType  string                        `json:"discount_type" binding:"omitempty,oneof=percent fixed"`
+	DiscountValue money.Amount                  `json:"discount_value"`
+	Notes         string                        `json:"notes" binding:"max=2000"`
+	CustomFields  models.CustomFields           `json:"custom_fields"`                 // invoice custom fields
+	Interval      string                        `json:"interval" binding:"required"`   // weekly, monthly, quarterly, yearly or cron
+	Cron          string                        `json:"cron"`                          // required when interval is cron
+	StartDate     string                        `json:"start_date" binding:"required"` // YYYY-MM-DD or RFC 3339
+	EndDate       string                        `json:"end_date"`                      // optional last day a period may start on
+	DueDays       int                           `json:"due_days" binding:"min=0,max=365"`
+	AutoSend      bool                          `json:"auto_send"`
+	Paused        bool                          `json:"paused"`
+}
+
+// RecurringInvoiceRun is one upcoming period of a recurring invoice
+type RecurringInvoiceRun struct {
+	Period    time.Time `json:"period"`
+	IssueDate time.Time `json:"issue_date"`
+	DueDate   time.Time `json:"due_date"`
+	// Overdue marks periods that have already started and are invoiced on the next scheduler run
+	Overdue bool `json:"overdue"`
+}
@@ -0,0 +1,46 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+// Revenue report groupings
+const (
+	ReportGroupByMonth    = "month"
+	ReportGroupByCustomer = "customer"
+	ReportGroupByCurrency = "currency"
+	ReportGroupByStatus   = "status"
+)
+
+// ReportGroupCreditNote is the status group of credit notes
+const ReportGroupCreditNote = "credit_note"
+
+// Report DTOs
+type RevenueReportGroup struct {
+	Key          string `json:"key"`
+	Label        string `json:"label"`
+	InvoiceCount int    `json:"invoice_count"`
+	// CreditNoteCount counts the credit notes subtracted from the amounts
+	CreditNoteCount int          `json:"credit_note_count"`
+	Subtotal        money.Amount `json:"subtotal"`
+	DiscountAmount  money.Amount `json:"discount_amount"`
+	TaxAmount       money.Amount `json:"tax_amount"`
+	ChargesTotal    money.Amount `json:"charges_total"`
+	Total           money.Amount `json:"total"`
+	// OriginalTotals sums the invoice totals per invoice currency, before conversion
+	OriginalTotals map[money.Currency]money.Amount `json:"original_totals"`
+}
+
+type RevenueReport struct {
+	BaseCurrency money.Currency       `json:"base_currency"`
+	From         *time.Time           `json:"from,omitempty"`
+	To           *time.Time           `json:"to,omitempty"`
+	GroupBy      string               `json:"group_by"`
+	Groups       []RevenueReportGroup `json:"groups"`
+	Totals       RevenueReportGroup   `json:"totals"`
+	// Unconverted counts invoices and credit notes without an exchange rate into the base
+	// currency; they are left out
+	Unconverted int `json:"unconverted"`
+}
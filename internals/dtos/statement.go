@@ -0,0 +1,53 @@
+package dtos
+
+import (
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+// Statement entry types
+const (
+	StatementEntryInvoice  = "invoice"
+	StatementEntryPayment  = "payment"
+	StatementEntryCredit   = "credit"
+	StatementEntryReversal = "reversal"
+	StatementEntryRefund   = "refund"
+)
+
+// Customer statement DTOs
+type StatementEntry struct {
+	Date        time.Time    `json:"date"`
+	Type        string       `json:"type"` // invoice, payment, credit, reversal, refund
+	Reference   string       `json:"reference"`
+	Description string       `json:"description"`
+	InvoiceID   *uint        `json:"invoice_id,omitempty"`
+	DueDate     *time.Time   `json:"due_date,omitempty"`
+	Debit       money.Amount `json:"debit"`
+	Credit      money.Amount `json:"credit"`
+	Balance     money.Amount `json:"balance"`
+}
+
+type AgingSummary struct {
+	AsOf       time.Time    `json:"as_of"`
+	Current    money.Amount `json:"current"`
+	Days1To30  money.Amount `json:"days_1_30"`
+	Days31To60 money.Amount `json:"days_31_60"`
+	Days61To90 money.Amount `json:"days_61_90"`
+	Over90     money.Amount `json:"days_over_90"`
+	Total      money.Amount `json:"total"`
+}
+
+type CustomerStatement struct {
+	Customer       models.Customer  `json:"customer"`
+	From           *time.Time       `json:"from,omitempty"`
+	To             time.Time        `json:"to"`
+	Currency       money.Currency   `json:"currency"`
+	OpeningBalance money.Amount     `json:"opening_balance"`
+	Entries        []StatementEntry `json:"entries"`
+	TotalDebits    money.Amount     `json:"total_debits"`
+	TotalCredits   money.Amount     `json:"total_credits"`
+	ClosingBalance money.Amount     `json:"closing_balance"`
+	Aging          AgingSummary     `json:"aging"`
+}
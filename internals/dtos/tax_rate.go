@@ -0,0 +1,12 @@
+package dtos
+
+import "github.com/tacheraSasi/go-api-starter/pkg/money"
+
+// Tax rate DTOs
+type TaxRateRequest struct {
+	Name      string        `json:"name" binding:"required,max=100"`
+	Rate      *money.Amount `json:"rate" binding:"required"` // percentage between 0 and 100
+	Compound  bool          `json:"compound,omitempty"`
+	Inclusive bool          `json:"inclusive,omitempty"`
+	IsDefault bool          `json:"is_default,omitempty"`
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+)
+
+// multipartOverhead is the room left for multipart headers and boundaries above the file size limit
+const multipartOverhead = 64 << 10
+
+type AttachmentHandler struct {
+	service     services.AttachmentService
+	maxBodySize int64
+}
+
+// NewAttachmentHandler creates a handler that rejects request bodies larger than
+// maxFileSize plus room for the multipart framing
+func NewAttachmentHandler(service services.AttachmentService, maxFileSize int64) *AttachmentHandler {
+	return &AttachmentHandler{service: service, maxBodySize: maxFileSize + multipartOverhead}
+}
+
+// ListInvoiceAttachments handles GET /invoices/:id/attachments
+func (h *AttachmentHandler) ListInvoiceAttachments(c *gin.Context) {
+	h.list(c, models.AttachmentParentInvoice)
+}
+
+// UploadInvoiceAttachment handles POST /invoices/:id/attachments (multipart field "file")
+func (h *AttachmentHandler) UploadInvoiceAttachment(c *gin.Context) {
+	h.upload(c, models.AttachmentParentInvoice)
+}
+
+// DownloadInvoiceAttachment handles GET /invoices/:id/attachments/:attachmentId
+func (h *AttachmentHandler) DownloadInvoiceAttachment(c *gin.Context) {
+	h.download(c, models.AttachmentParentInvoice)
+}
+
+// DeleteInvoiceAttachment handles DELETE /invoices/:id/attachments/:attachmentId
+func (h *AttachmentHandler) DeleteInvoiceAttachment(c *gin.Context) {
+	h.delete(c, models.AttachmentParentInvoice)
+}
+
+// ListCustomerAttachments handles GET /customers/:id/attachments
+func (h *AttachmentHandler) ListCustomerAttachments(c *gin.Context) {
+	h.list(c, models.AttachmentParentCustomer)
+}
+
+// UploadCustomerAttachment handles POST /customers/:id/attachments (multipart field "file")
+func (h *AttachmentHandler) UploadCustomerAttachment(c *gin.Context) {
+	h.upload(c, models.AttachmentParentCustomer)
+}
+
+// DownloadCustomerAttachment handles GET /customers/:id/attachments/:attachmentId
+func (h *AttachmentHandler) DownloadCustomerAttachment(c *gin.Context) {
+	h.download(c, models.AttachmentParentCustomer)
+}
+
+// DeleteCustomerAttachment handles DELETE /customers/:id/attachments/:attachmentId
+func (h *AttachmentHandler) DeleteCustomerAttachment(c *gin.Context) {
+	h.delete(c, models.AttachmentParentCustomer)
+}
+
+// UploadAvatar handles PUT /users/:id/avatar (multipart field "file"), replacing any previous avatar
+func (h *AttachmentHandler) UploadAvatar(c *gin.Context) {
+	h.upload(c, models.AttachmentParentUser)
+}
+
+// GetAvatar handles GET /users/:id/avatar
+func (h *AttachmentHandler) GetAvatar(c *gin.Context) {
+	actorID, userID, ok := h.params(c, models.AttachmentParentUser)
+	if !ok {
+		return
+	}
+
+	avatar, reader, err := h.service.OpenAvatar(c.Request.Context(), actorID, userID)
+	if err != nil {
+		utils.APIError(c, attachmentErrorStatus(err), err.Error())
+		return
+	}
+	defer reader.Close()
+
+	serveAttachment(c, avatar, reader, "inline")
+}
+
+// DeleteAvatar handles DELETE /users/:id/avatar
+func (h *AttachmentHandler) DeleteAvatar(c *gin.Context) {
+	actorID, userID, ok := h.params(c, models.AttachmentParentUser)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteAvatar(c.Request.Context(), actorID, userID); err != nil {
+		utils.APIError(c, attachmentErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{"message": "Avatar deleted successfully"})
+}
+
+func (h *AttachmentHandler) list(c *gin.Context, parentType string) {
+	actorID, parentID, ok := h.params(c, parentType)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.service.ListAttachments(actorID, parentType, parentID)
+	if err != nil {
+		utils.APIError(c, attachmentErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, attachments)
+}
+
+// upload streams the "file" part of a multipart body to the service without buffering
+// the whole request in memory or on disk first
+func (h *AttachmentHandler) upload(c *gin.Context, parentType string) {
+	actorID, parentID, ok := h.params(c, parentType)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBodySize)
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: expected a multipart/form-data body with a \"file\" field")
+		return
+	}
+	var part *multipart.Part
+	for {
+		part, err = reader.NextPart()
+		if err == io.EOF {
+			utils.APIError(c, http.StatusBadRequest, "Invalid input: the \"file\" field is required")
+			return
+		}
+		if err != nil {
+			utils.APIError(c, uploadReadStatus(err), "Invalid input: "+err.Error())
+			return
+		}
+		if part.FormName() == "file" && part.FileName() != "" {
+			break
+		}
+		part.Close()
+	}
+	defer part.Close()
+
+	attachment, err := h.service.UploadAttachment(c.Request.Context(), actorID, parentType, parentID, part.FileName(), part)
+	if err != nil {
+		status := attachmentErrorStatus(err)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		utils.APIError(c, status, err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusCreated, attachment)
+}
+
+func (h *AttachmentHandler) download(c *gin.Context, parentType string) {
+	actorID, parentID, ok := h.params(c, parentType)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "attachmentId", "attachment")
+	if !ok {
+		return
+	}
+
+	attachment, reader, err := h.service.OpenAttachment(c.Request.Context(), actorID, parentType, parentID, id)
+	if err != nil {
+		utils.APIError(c, attachmentErrorStatus(err), err.Error())
+		return
+	}
+	defer reader.Close()
+
+	serveAttachment(c, attachment, reader, "attachment")
+}
+
+func (h *AttachmentHandler) delete(c *gin.Context, parentType string) {
+	actorID, parentID, ok := h.params(c, parentType)
+	if !ok {
+		return
+	}
+	id, ok := parseIDParam(c, "attachmentId", "attachment")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteAttachment(c.Request.Context(), actorID, parentType, parentID, id); err != nil {
+		utils.APIError(c, attachmentErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{"message": "Attachment deleted successfully"})
+}
+
+// params reads the authenticated user and the parent ID, writing an error response when either is missing
+func (h *AttachmentHandler) params(c *gin.Context, parentType string) (uint, uint, bool) {
+	actorID := currentUserID(c)
+	if actorID == nil {
+		utils.APIError(c, http.StatusUnauthorized, "Unauthorized")
+		return 0, 0, false
+	}
+	parentID, ok := parseIDParam(c, "id", parentType)
+	if !ok {
+		return 0, 0, false
+	}
+	return *actorID, parentID, true
+}
+
+// serveAttachment streams a stored file with headers that stop browsers from sniffing
+// or running it
+func serveAttachment(c *gin.Context, attachment *models.Attachment, reader io.Reader, disposition string) {
+	etag := strconv.Quote(attachment.Checksum)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, reader, map[string]string{
+		"Content-Disposition":     mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": "sandbox; default-src 'none'",
+		"ETag":                    etag,
+		"Cache-Control":           "private, no-cache",
+	})
+}
+
+// uploadReadStatus reports 413 when an upload was cut off by the body size limit
+func uploadReadStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func attachmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrAttachmentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrAttachmentForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrAttachmentTooLarge):
+		return http.StatusRequestEntityTooLarge
+	}
+	return serviceErrorStatus(err, http.StatusInternalServerError)
+}
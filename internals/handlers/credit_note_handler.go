@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+)
+
+type CreditNoteHandler struct {
+	service services.CreditNoteService
+}
+
+func NewCreditNoteHandler(service services.CreditNoteService) *CreditNoteHandler {
+	return &CreditNoteHandler{service: service}
+}
+
+// ListCreditNotes handles GET /credit-notes?customer_id=&invoice_id=&page=&limit=
+func (h *CreditNoteHandler) ListCreditNotes(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	var ids [2]uint
+	for i, param := range []struct{ name, label string }{{"customer_id", "customer"}, {"invoice_id", "invoice"}} {
+		if raw := c.Query(param.name); raw != "" {
+			id, err := strconv.ParseUint(raw, 10, 32)
+			if err != nil {
+				utils.APIError(c, http.StatusBadRequest, "Invalid "+param.label+" ID")
+				return
+			}
+			ids[i] = uint(id)
+		}
+	}
+
+	notes, pagination, err := h.service.ListCreditNotes(ids[0], ids[1], page, limit)
+	if err != nil {
+		utils.APIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{
+		"credit_notes": notes,
+		"pagination":   pagination,
+	})
+}
+
+// GetCreditNote handles GET /credit-notes/:id
+func (h *CreditNoteHandler) GetCreditNote(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "credit note")
+	if !ok {
+		return
+	}
+
+	note, err := h.service.GetCreditNote(id)
+	if err != nil {
+		utils.APIError(c, creditNoteErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, note)
+}
+
+// CreateCreditNote handles POST /credit-notes
+func (h *CreditNoteHandler) CreateCreditNote(c *gin.Context) {
+	var req dtos.CreateCreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	note, err := h.service.CreateCreditNote(&req, currentUserID(c))
+	if err != nil {
+		utils.APIError(c, creditNoteErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusCreated, note)
+}
+
+// ApplyCreditNote handles POST /credit-notes/:id/apply
+func (h *CreditNoteHandler) ApplyCreditNote(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "credit note")
+	if !ok {
+		return
+	}
+
+	var req dtos.ApplyCreditNoteRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	note, err := h.service.ApplyCreditNote(id, req.Allocations, currentUserID(c))
+	if err != nil {
+		utils.APIError(c, creditNoteErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, note)
+}
+
+// RefundCreditNote handles POST /credit-notes/:id/refund
+func (h *CreditNoteHandler) RefundCreditNote(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "credit note")
+	if !ok {
+		return
+	}
+
+	var req dtos.RefundCreditNoteRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	note, err := h.service.RefundCreditNote(id, &req, currentUserID(c))
+	if err != nil {
+		utils.APIError(c, creditNoteErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, note)
+}
+
+func creditNoteErrorStatus(err error) int {
+	if errors.Is(err, services.ErrCreditNoteNotFound) {
+		return http.StatusNotFound
+	}
+	return serviceErrorStatus(err, http.StatusInternalServerError)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+)
+
+type CustomFieldHandler struct {
+	service services.CustomFieldService
+}
+
+func NewCustomFieldHandler(service services.CustomFieldService) *CustomFieldHandler {
+	return &CustomFieldHandler{service: service}
+}
+
+// ListCustomFields handles GET /admin/custom-fields?entity_type=
+func (h *CustomFieldHandler) ListCustomFields(c *gin.Context) {
+	definitions, err := h.service.ListDefinitions(c.Query("entity_type"))
+	if err != nil {
+		utils.APIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, definitions)
+}
+
+// CreateCustomField handles POST /admin/custom-fields
+func (h *CustomFieldHandler) CreateCustomField(c *gin.Context) {
+	var req dtos.CreateCustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	definition, err := h.service.CreateDefinition(&req)
+	if err != nil {
+		utils.APIError(c, serviceErrorStatus(err, http.StatusInternalServerError), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusCreated, definition)
+}
+
+// GetCustomField handles GET /admin/custom-fields/:id
+func (h *CustomFieldHandler) GetCustomField(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "custom field")
+	if !ok {
+		return
+	}
+
+	definition, err := h.service.GetDefinition(id)
+	if err != nil {
+		utils.APIError(c, customFieldErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, definition)
+}
+
+// UpdateCustomField handles PUT /admin/custom-fields/:id
+func (h *CustomFieldHandler) UpdateCustomField(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "custom field")
+	if !ok {
+		return
+	}
+
+	var req dtos.UpdateCustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	definition, err := h.service.UpdateDefinition(id, &req)
+	if err != nil {
+		utils.APIError(c, customFieldErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, definition)
+}
+
+// DeleteCustomField handles DELETE /admin/custom-fields/:id
+func (h *CustomFieldHandler) DeleteCustomField(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "custom field")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteDefinition(id); err != nil {
+		utils.APIError(c, customFieldErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{"message": "Custom field deleted successfully"})
+}
+
+func customFieldErrorStatus(err error) int {
+	if errors.Is(err, services.ErrCustomFieldNotFound) {
+		return http.StatusNotFound
+	}
+	return serviceErrorStatus(err, http.StatusInternalServerError)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+)
+
+type CustomerAddressHandler struct {
+	service services.CustomerAddressService
+}
+
+func NewCustomerAddressHandler(service services.CustomerAddressService) *CustomerAddressHandler {
+	return &CustomerAddressHandler{service: service}
+}
+
+// ListAddresses handles GET /customers/:id/addresses
+func (h *CustomerAddressHandler) ListAddresses(c *gin.Context) {
+	customerID, ok := parseIDParam(c, "id", "customer")
+	if !ok {
+		return
+	}
+
+	addresses, err := h.service.ListAddresses(customerID, c.Query("type"))
+	if err != nil {
+		utils.APIError(c, customerChildErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, addresses)
+}
+
+// CreateAddress handles POST /customers/:id/addresses
+func (h *CustomerAddressHandler) CreateAddress(c *gin.Context) {
+	customerID, ok := parseIDParam(c, "id", "customer")
+	if !ok {
+		return
+	}
+
+	var req dtos.CustomerAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	address, err := h.service.CreateAddress(customerID, &req)
+	if err != nil {
+		utils.APIError(c, customerChildErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusCreated, address)
+}
+
+// GetAddress handles GET /customers/:id/addresses/:addressId
+func (h *CustomerAddressHandler) GetAddress(c *gin.Context) {
+	customerID, ok := parseIDParam(c, "id", "customer")
+	if !ok {
+		return
+	}
+	addressID, ok := parseIDParam(c, "addressId", "address")
+	if !ok {
+		return
+	}
+
+	address, err := h.service.GetAddress(customerID, addressID)
+	if err != nil {
+		utils.APIError(c, customerChildErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, address)
+}
+
+// UpdateAddress handles PUT /customers/:id/addresses/:addressId
+func (h *CustomerAddressHandler) UpdateAddress(c *gin.Context) {
+	customerID, ok := parseIDParam(c, "id", "customer")
+	if !ok {
+		return
+	}
+	addressID, ok := parseIDParam(c, "addressId", "address")
+	if !ok {
+		return
+	}
+
+	var req dtos.CustomerAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	address, err := h.service.UpdateAddress(customerID, addressID, &req)
+	if err != nil {
+		utils.APIError(c, customerChildErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, address)
+}
+
+// DeleteAddress handles DELETE /customers/:id/addresses/:addressId
+func (h *CustomerAddressHandler) DeleteAddress(c *gin.Context) {
+	customerID, ok := parseIDParam(c, "id", "customer")
+	if !ok {
+		return
+	}
+	addressID, ok := parseIDParam(c, "addressId", "address")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteAddress(customerID, addressID); err != nil {
+		utils.APIError(c, customerChildErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{"message": "Address deleted successfully"})
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+)
+
+type CustomerContactHandler struct {
+	service services.CustomerContactService
+}
+
+func NewCustomerContactHandler(service services.CustomerContactService) *CustomerContactHandler {
+	return &CustomerContactHandler{service: service}
+}
+
+// ListContacts handles GET /customers/:id/contacts
+func (h *CustomerContactHandler) ListContacts(c *gin.Context) {
+	customerID, ok := parseIDParam(c, "id", "customer")
+	if !ok {
+		return
+	}
+
+	contacts, err := h.service.ListContacts(customerID)
+	if err != nil {
+		utils.APIError(c, customerChildErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, contacts)
+}
+
+// CreateContact handles POST /customers/:id/contacts
+func (h *CustomerContactHandler) CreateContact(c *gin.Context) {
+	customerID, ok := parseIDParam(c, "id", "customer")
+	if !ok {
+		return
+	}
+
+	var req dtos.CustomerContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	contact, err := h.service.CreateContact(customerID, &req)
+	if err != nil {
+		utils.APIError(c, customerChildErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusCreated, contact)
+}
+
+// GetContact handles GET /customers/:id/contacts/:contactId
+func (h *CustomerContactHandler) GetContact(c *gin.Context) {
+	customerID, ok := parseIDParam(c, "id", "customer")
+	if !ok {
+		return
+	}
+	contactID, ok := parseIDParam(c, "contactId", "contact")
+	if !ok {
+		return
+	}
+
+	contact, err := h.service.GetContact(customerID, contactID)
+	if err != nil {
+		utils.APIError(c, customerChildErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, contact)
+}
+
+// UpdateContact handles PUT /customers/:id/contacts/:contactId
+func (h *CustomerContactHandler) UpdateContact(c *gin.Context) {
+	customerID, ok := parseIDParam(c, "id", "customer")
+	if !ok {
+		return
+	}
+	contactID, ok := parseIDParam(c, "contactId", "contact")
+	if !ok {
+		return
+	}
+
+	var req dtos.CustomerContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	contact, err := h.service.UpdateContact(customerID, contactID, &req)
+	if err != nil {
+		utils.APIError(c, customerChildErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, contact)
+}
+
+// DeleteContact handles DELETE /customers/:id/contacts/:contactId
+func (h *CustomerContactHandler) DeleteContact(c *gin.Context) {
+	customerID, ok := parseIDParam(c, "id", "customer")
+	if !ok {
+		return
+	}
+	contactID, ok := parseIDParam(c, "contactId", "contact")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteContact(customerID, contactID); err != nil {
+		utils.APIError(c, customerChildErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{"message": "Contact deleted successfully"})
+}
+
+// customerChildErrorStatus maps errors of records nested under a customer to a status code
+func customerChildErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrCustomerNotFound),
+		errors.Is(err, services.ErrContactNotFound),
+		errors.Is(err, services.ErrAddressNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/tacheraSasi/go-api-starter/internals/models"
@@ -10,6 +14,9 @@ import (
 	"github.com/tacheraSasi/go-api-starter/internals/utils"
 )
 
+// maxCustomerImportSize limits the size of an uploaded customer CSV file
+const maxCustomerImportSize = 10 << 20
+
 type CustomerHandler struct {
 	service services.CustomerService
 }
@@ -26,7 +33,7 @@ func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
 	}
 
 	if err := h.service.CreateCustomer(&customer); err != nil {
-		utils.APIError(c, http.StatusInternalServerError, "Failed to create customer: "+err.Error())
+		utils.APIError(c, serviceErrorStatus(err, http.StatusInternalServerError), "Failed to create customer: "+err.Error())
 		return
 	}
 
@@ -50,11 +57,18 @@ func (h *CustomerHandler) GetCustomer(c *gin.Context) {
 }
 
 func (h *CustomerHandler) ListCustomers(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	filter, err := parseCustomerFilter(c)
+	if err != nil {
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	customers, pagination, err := h.service.GetAllCustomers(page, limit)
+	customers, pagination, err := h.service.GetAllCustomers(filter)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidCustomField) {
+			utils.APIError(c, http.StatusBadRequest, err.Error())
+			return
+		}
 		utils.APIError(c, http.StatusInternalServerError, "Failed to fetch customers")
 		return
 	}
@@ -81,7 +95,7 @@ func (h *CustomerHandler) UpdateCustomer(c *gin.Context) {
 	}
 
 	if err := h.service.UpdateCustomer(uint(id), &customer); err != nil {
-		utils.APIError(c, http.StatusInternalServerError, "Failed to update customer: "+err.Error())
+		utils.APIError(c, serviceErrorStatus(err, http.StatusInternalServerError), "Failed to update customer: "+err.Error())
 		return
 	}
 
@@ -102,3 +116,80 @@ func (h *CustomerHandler) DeleteCustomer(c *gin.Context) {
 
 	utils.APISuccess(c, http.StatusOK, gin.H{"message": "Customer deleted successfully"})
 }
+
+// ImportCustomers handles POST /customers/import
+func (h *CustomerHandler) ImportCustomers(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.APIError(c, http.StatusBadRequest, "A CSV file is required in the 'file' field")
+		return
+	}
+	if fileHeader.Size > maxCustomerImportSize {
+		utils.APIError(c, http.StatusRequestEntityTooLarge, "CSV file is too large")
+		return
+	}
+
+	opts := services.CustomerImportOptions{}
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts.Mapping); err != nil {
+			utils.APIError(c, http.StatusBadRequest, "Invalid mapping: expected a JSON object of CSV header to field")
+			return
+		}
+	}
+	for key, target := range map[string]*bool{"dry_run": &opts.DryRun, "upsert": &opts.Upsert} {
+		raw := c.DefaultPostForm(key, c.Query(key))
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			utils.APIError(c, http.StatusBadRequest, fmt.Sprintf("invalid %s %q", key, raw))
+			return
+		}
+		*target = value
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Failed to read uploaded file")
+		return
+	}
+	defer file.Close()
+
+	report, err := h.service.ImportCustomersCSV(file, opts)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCSV) {
+			utils.APIError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.APIError(c, http.StatusInternalServerError, "Failed to import customers: "+err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, report)
+}
+
+// ExportCustomers handles GET /customers/export
+func (h *CustomerHandler) ExportCustomers(c *gin.Context) {
+	filter, err := parseCustomerFilter(c)
+	if err != nil {
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("customers-%s.csv", time.Now().UTC().Format("20060102-150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	if err := h.service.ExportCustomersCSV(c.Writer, filter); err != nil {
+		if !c.Writer.Written() {
+			c.Header("Content-Type", "application/json; charset=utf-8")
+			c.Header("Content-Disposition", "")
+			utils.APIError(c, serviceErrorStatus(err, http.StatusInternalServerError), err.Error())
+			return
+		}
+		// Headers are already sent, so the truncated download is all we can signal
+		c.Error(err)
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+)
+
+// maxDuplicateResults limits the number of duplicate pairs returned at once
+const maxDuplicateResults = 500
+
+type CustomerMergeHandler struct {
+	service services.CustomerMergeService
+}
+
+func NewCustomerMergeHandler(service services.CustomerMergeService) *CustomerMergeHandler {
+	return &CustomerMergeHandler{service: service}
+}
+
+// FindDuplicates handles GET /admin/customers/duplicates?threshold=&limit=
+func (h *CustomerMergeHandler) FindDuplicates(c *gin.Context) {
+	threshold := services.DefaultDuplicateThreshold
+	if raw := c.Query("threshold"); raw != "" {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value < 0.5 || value > 1 {
+			utils.APIError(c, http.StatusBadRequest, "threshold must be a number between 0.5 and 1")
+			return
+		}
+		threshold = value
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		utils.APIError(c, http.StatusBadRequest, "limit must be a positive number")
+		return
+	}
+	if limit > maxDuplicateResults {
+		limit = maxDuplicateResults
+	}
+
+	candidates, err := h.service.FindDuplicates(threshold, limit)
+	if err != nil {
+		utils.APIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{
+		"threshold":  threshold,
+		"duplicates": candidates,
+	})
+}
+
+// MergeCustomers handles POST /admin/customers/merge
+func (h *CustomerMergeHandler) MergeCustomers(c *gin.Context) {
+	var req dtos.MergeCustomersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	merge, err := h.service.MergeCustomers(req.SurvivorID, req.DuplicateID, currentUserID(c))
+	if err != nil {
+		if errors.Is(err, services.ErrCustomerNotFound) {
+			utils.APIError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, merge)
+}
+
+// ListMerges handles GET /admin/customers/merges?customer_id=&page=&limit=
+func (h *CustomerMergeHandler) ListMerges(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	var customerID uint
+	if raw := c.Query("customer_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			utils.APIError(c, http.StatusBadRequest, "Invalid customer ID")
+			return
+		}
+		customerID = uint(id)
+	}
+
+	merges, pagination, err := h.service.ListMerges(customerID, page, limit)
+	if err != nil {
+		utils.APIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{
+		"merges":     merges,
+		"pagination": pagination,
+	})
+}
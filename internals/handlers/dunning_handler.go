@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+)
+
+type DunningHandler struct {
+	service services.DunningService
+}
+
+func NewDunningHandler(service services.DunningService) *DunningHandler {
+	return &DunningHandler{service: service}
+}
+
+// ListInvoiceReminders handles GET /invoices/:id/reminders
+func (h *DunningHandler) ListInvoiceReminders(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "invoice")
+	if !ok {
+		return
+	}
+
+	reminders, err := h.service.ListReminders(id)
+	if err != nil {
+		utils.APIError(c, invoiceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, reminders)
+}
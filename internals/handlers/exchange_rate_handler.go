@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+// maxExchangeRateImportSize limits the size of an uploaded rate file; the full ECB history is a few MB
+const maxExchangeRateImportSize = 32 << 20
+
+type ExchangeRateHandler struct {
+	service services.ExchangeRateService
+}
+
+func NewExchangeRateHandler(service services.ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{service: service}
+}
+
+// ListExchangeRates handles GET /exchange-rates?base=&quote=&from=&to=&page=&limit=&sort=
+func (h *ExchangeRateHandler) ListExchangeRates(c *gin.Context) {
+	listQuery, err := parseListQuery(c, repositories.ExchangeRateSortFields)
+	if err != nil {
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := repositories.ExchangeRateFilter{ListQuery: listQuery}
+	if filter.BaseCurrency, err = parseCurrencyParam(c, "base"); err != nil {
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.QuoteCurrency, err = parseCurrencyParam(c, "quote"); err != nil {
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Date, err = parseDateRange(c, "from", "to"); err != nil {
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rates, pagination, err := h.service.ListRates(filter)
+	if err != nil {
+		utils.APIError(c, http.StatusInternalServerError, "Failed to fetch exchange rates")
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{
+		"exchange_rates": rates,
+		"pagination":     pagination,
+	})
+}
+
+// ConvertCurrency handles GET /exchange-rates/convert?from=&to=&date=&amount=
+func (h *ExchangeRateHandler) ConvertCurrency(c *gin.Context) {
+	from, err := parseCurrencyParam(c, "from")
+	if err != nil {
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	to, err := parseCurrencyParam(c, "to")
+	if err != nil {
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if from == "" || to == "" {
+		utils.APIError(c, http.StatusBadRequest, "from and to currencies are required")
+		return
+	}
+
+	date := time.Now().UTC()
+	if raw := c.Query("date"); raw != "" {
+		if date, err = time.Parse("2006-01-02", raw); err != nil {
+			utils.APIError(c, http.StatusBadRequest, "invalid date \""+raw+"\", expected YYYY-MM-DD")
+			return
+		}
+	}
+	amount, err := parseAmountParam(c, "amount")
+	if err != nil {
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var quote *dtos.ExchangeRateQuote
+	if amount != nil {
+		quote, err = h.service.Convert(*amount, from, to, date)
+	} else {
+		quote, err = h.service.GetRate(from, to, date)
+	}
+	if err != nil {
+		if errors.Is(err, services.ErrExchangeRateNotFound) {
+			utils.APIError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.APIError(c, serviceErrorStatus(err, http.StatusInternalServerError), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, quote)
+}
+
+// CreateExchangeRate handles POST /admin/exchange-rates
+func (h *ExchangeRateHandler) CreateExchangeRate(c *gin.Context) {
+	var req dtos.CreateExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	date, _ := time.Parse("2006-01-02", req.Date) // checked by the binding
+	rate := models.ExchangeRate{
+		Date:          date,
+		BaseCurrency:  money.Currency(req.BaseCurrency),
+		QuoteCurrency: money.Currency(req.QuoteCurrency),
+		Rate:          req.Rate,
+	}
+	if err := h.service.SaveRate(&rate); err != nil {
+		utils.APIError(c, serviceErrorStatus(err, http.StatusInternalServerError), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusCreated, rate)
+}
+
+// ImportExchangeRates handles POST /admin/exchange-rates/import with a CSV or ECB XML
+// file in the "file" field, and optional "format" (csv, ecb) and "base" fields
+func (h *ExchangeRateHandler) ImportExchangeRates(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.APIError(c, http.StatusBadRequest, "A rate file is required in the 'file' field")
+		return
+	}
+	if fileHeader.Size > maxExchangeRateImportSize {
+		utils.APIError(c, http.StatusRequestEntityTooLarge, "Rate file is too large")
+		return
+	}
+
+	opts := services.ExchangeRateImportOptions{Format: c.DefaultPostForm("format", c.Query("format"))}
+	if raw := c.DefaultPostForm("base", c.Query("base")); raw != "" {
+		if opts.BaseCurrency, err = money.ParseCurrency(raw); err != nil {
+			utils.APIError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Failed to read uploaded file")
+		return
+	}
+	defer file.Close()
+
+	report, err := h.service.ImportRates(file, opts)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidExchangeRate) || errors.Is(err, services.ErrInvalidCSV) {
+			utils.APIError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.APIError(c, http.StatusInternalServerError, "Failed to import exchange rates: "+err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, report)
+}
+
+// DeleteExchangeRate handles DELETE /admin/exchange-rates/:id
+func (h *ExchangeRateHandler) DeleteExchangeRate(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "exchange rate")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRate(id); err != nil {
+		if errors.Is(err, services.ErrExchangeRateNotFound) {
+			utils.APIError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.APIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{"message": "Exchange rate deleted successfully"})
+}
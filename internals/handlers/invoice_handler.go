@@ -1,21 +1,27 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
 	"github.com/tacheraSasi/go-api-starter/internals/models"
 	"github.com/tacheraSasi/go-api-starter/internals/services"
 	"github.com/tacheraSasi/go-api-starter/internals/utils"
 )
 
 type InvoiceHandler struct {
-	service services.InvoiceService
+	service   services.InvoiceService
+	payments  services.PaymentService
+	documents services.InvoicePDFService
 }
 
-func NewInvoiceHandler(service services.InvoiceService) *InvoiceHandler {
-	return &InvoiceHandler{service: service}
+func NewInvoiceHandler(service services.InvoiceService, payments services.PaymentService, documents services.InvoicePDFService) *InvoiceHandler {
+	return &InvoiceHandler{service: service, payments: payments, documents: documents}
 }
 
 func (h *InvoiceHandler) CreateInvoice(c *gin.Context) {
@@ -24,9 +30,11 @@ func (h *InvoiceHandler) CreateInvoice(c *gin.Context) {
 		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
 		return
 	}
+	// Only the scheduler creates invoices of recurring invoice periods
+	invoice.RecurringInvoiceID, invoice.RecurringPeriod = nil, nil
 
-	if err := h.service.CreateInvoice(&invoice); err != nil {
-		utils.APIError(c, http.StatusInternalServerError, "Failed to create invoice: "+err.Error())
+	if err := h.service.CreateInvoice(&invoice, currentUserID(c)); err != nil {
+		utils.APIError(c, invoiceErrorStatus(err), "Failed to create invoice: "+err.Error())
 		return
 	}
 
@@ -42,19 +50,47 @@ func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
 
 	invoice, err := h.service.GetInvoiceByID(uint(id))
 	if err != nil {
-		utils.APIError(c, http.StatusNotFound, "Invoice not found")
+		utils.APIError(c, invoiceErrorStatus(err), err.Error())
 		return
 	}
 
 	utils.APISuccess(c, http.StatusOK, invoice)
 }
 
+// GetInvoicePDF handles GET /invoices/:id/pdf?download=true
+func (h *InvoiceHandler) GetInvoicePDF(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "invoice")
+	if !ok {
+		return
+	}
+
+	invoice, data, err := h.documents.RenderInvoice(id)
+	if err != nil {
+		utils.APIError(c, invoiceErrorStatus(err), err.Error())
+		return
+	}
+
+	disposition := "inline"
+	if c.Query("download") == "true" {
+		disposition = "attachment"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, invoice.InvoiceNumber+".pdf"))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
 func (h *InvoiceHandler) ListInvoices(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	filter, err := parseInvoiceFilter(c)
+	if err != nil {
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	invoices, pagination, err := h.service.GetAllInvoices(page, limit)
+	invoices, pagination, err := h.service.GetAllInvoices(filter)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidCustomField) {
+			utils.APIError(c, http.StatusBadRequest, err.Error())
+			return
+		}
 		utils.APIError(c, http.StatusInternalServerError, "Failed to fetch invoices")
 		return
 	}
@@ -81,7 +117,7 @@ func (h *InvoiceHandler) UpdateInvoice(c *gin.Context) {
 	}
 
 	if err := h.service.UpdateInvoice(uint(id), &invoice); err != nil {
-		utils.APIError(c, http.StatusInternalServerError, "Failed to update invoice: "+err.Error())
+		utils.APIError(c, invoiceErrorStatus(err), "Failed to update invoice: "+err.Error())
 		return
 	}
 
@@ -96,9 +132,95 @@ func (h *InvoiceHandler) DeleteInvoice(c *gin.Context) {
 	}
 
 	if err := h.service.DeleteInvoice(uint(id)); err != nil {
-		utils.APIError(c, http.StatusInternalServerError, "Failed to delete invoice: "+err.Error())
+		utils.APIError(c, invoiceErrorStatus(err), "Failed to delete invoice: "+err.Error())
 		return
 	}
 
 	utils.APISuccess(c, http.StatusOK, gin.H{"message": "Invoice deleted successfully"})
-}
\ No newline at end of file
+}
+
+// SendInvoice handles POST /invoices/:id/send
+func (h *InvoiceHandler) SendInvoice(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "invoice")
+	if !ok {
+		return
+	}
+
+	var req dtos.InvoiceTransitionRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	invoice, err := h.service.SendInvoice(id, currentUserID(c), req.Note)
+	if err != nil {
+		utils.APIError(c, invoiceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, invoice)
+}
+
+// VoidInvoice handles POST /invoices/:id/void
+func (h *InvoiceHandler) VoidInvoice(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "invoice")
+	if !ok {
+		return
+	}
+
+	var req dtos.InvoiceTransitionRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	invoice, err := h.service.VoidInvoice(id, currentUserID(c), req.Note)
+	if err != nil {
+		utils.APIError(c, invoiceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, invoice)
+}
+
+// MarkInvoicePaid handles POST /invoices/:id/mark-paid by recording a payment of the amount due
+func (h *InvoiceHandler) MarkInvoicePaid(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "invoice")
+	if !ok {
+		return
+	}
+
+	var req dtos.MarkInvoicePaidRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	invoice, err := h.payments.PayInvoice(id, &req, currentUserID(c))
+	if err != nil {
+		utils.APIError(c, invoiceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, invoice)
+}
+
+// bindOptionalJSON binds a request body that may be left out and writes a 400 response
+// when it is invalid
+func bindOptionalJSON(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil && !errors.Is(err, io.EOF) {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// invoiceErrorStatus maps invoice errors to a status code
+func invoiceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvoiceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidTransition), errors.Is(err, services.ErrInvoiceLocked),
+		errors.Is(err, services.ErrInvoiceNumberTaken):
+		return http.StatusConflict
+	default:
+		return serviceErrorStatus(err, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+	"github.com/tacheraSasi/go-api-starter/ui/pages"
+)
+
+type InvoiceShareHandler struct {
+	service    services.InvoiceShareService
+	publicURL  string // scheme and host share link URLs start with; the request host when empty
+	issuerName string
+}
+
+func NewInvoiceShareHandler(service services.InvoiceShareService, publicURL, issuerName string) *InvoiceShareHandler {
+	return &InvoiceShareHandler{service: service, publicURL: strings.TrimRight(publicURL, "/"), issuerName: issuerName}
+}
+
+// RegisterWebRoutes registers the public pages opened through share links
+func (h *InvoiceShareHandler) RegisterWebRoutes(router *gin.Engine) {
+	shareGroup := router.Group("/share/invoices")
+	{
+		shareGroup.GET("/:token", h.SharedInvoicePage)
+		shareGroup.GET("/:token/pdf", h.SharedInvoicePDF)
+	}
+}
+
+// CreateShareLink handles POST /invoices/:id/share-links
+func (h *InvoiceShareHandler) CreateShareLink(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "invoice")
+	if !ok {
+		return
+	}
+
+	var req dtos.CreateShareLinkRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	link, err := h.service.CreateShareLink(id, &req, currentUserID(c))
+	if err != nil {
+		utils.APIError(c, shareErrorStatus(err), err.Error())
+		return
+	}
+
+	h.addURL(c, link)
+	utils.APISuccess(c, http.StatusCreated, link)
+}
+
+// ListShareLinks handles GET /invoices/:id/share-links
+func (h *InvoiceShareHandler) ListShareLinks(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "invoice")
+	if !ok {
+		return
+	}
+
+	links, err := h.service.ListShareLinks(id)
+	if err != nil {
+		utils.APIError(c, shareErrorStatus(err), err.Error())
+		return
+	}
+
+	for i := range links {
+		h.addURL(c, &links[i])
+	}
+	utils.APISuccess(c, http.StatusOK, links)
+}
+
+// RevokeShareLink handles DELETE /invoices/:id/share-links/:linkId
+func (h *InvoiceShareHandler) RevokeShareLink(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "invoice")
+	if !ok {
+		return
+	}
+	linkID, ok := parseIDParam(c, "linkId", "share link")
+	if !ok {
+		return
+	}
+
+	link, err := h.service.RevokeShareLink(id, linkID, currentUserID(c))
+	if err != nil {
+		utils.APIError(c, shareErrorStatus(err), err.Error())
+		return
+	}
+
+	h.addURL(c, link)
+	utils.APISuccess(c, http.StatusOK, link)
+}
+
+// SharedInvoicePage handles GET /share/invoices/:token
+func (h *InvoiceShareHandler) SharedInvoicePage(c *gin.Context) {
+	token := c.Param("token")
+	invoice, err := h.service.OpenSharedInvoice(token)
+	if err != nil {
+		h.unavailable(c, err)
+		return
+	}
+
+	setSharedPageHeaders(c)
+	templ.Handler(pages.SharedInvoice(pages.SharedInvoiceProps{
+		AppName:    "GO-FullStack",
+		IssuerName: h.issuerName,
+		Invoice:    invoice,
+		PDFURL:     "/share/invoices/" + token + "/pdf",
+	})).ServeHTTP(c.Writer, c.Request)
+}
+
+// SharedInvoicePDF handles GET /share/invoices/:token/pdf
+func (h *InvoiceShareHandler) SharedInvoicePDF(c *gin.Context) {
+	invoice, data, err := h.service.RenderSharedInvoicePDF(c.Param("token"))
+	if err != nil {
+		h.unavailable(c, err)
+		return
+	}
+
+	setSharedPageHeaders(c)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", invoice.InvoiceNumber+".pdf"))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// unavailable renders the page shown for unknown, expired and revoked links
+func (h *InvoiceShareHandler) unavailable(c *gin.Context, err error) {
+	props := pages.ShareLinkUnavailableProps{
+		AppName: "GO-FullStack",
+		Title:   "Invoice unavailable",
+		Message: "Something went wrong while opening this invoice. Please try again later.",
+	}
+	status := shareErrorStatus(err)
+	switch status {
+	case http.StatusNotFound:
+		props.Message = "This link is not valid. Please check that you copied all of it."
+	case http.StatusGone:
+		props.Message = "This link has expired or was withdrawn. Please ask the sender for a new one."
+	}
+
+	setSharedPageHeaders(c)
+	templ.Handler(pages.ShareLinkUnavailable(props), templ.WithStatus(status)).ServeHTTP(c.Writer, c.Request)
+}
+
+// addURL completes a share link with the URL of its public page
+func (h *InvoiceShareHandler) addURL(c *gin.Context, link *dtos.InvoiceShareLink) {
+	link.URL = publicBaseURL(c, h.publicURL) + "/share/invoices/" + link.Token
+}
+
+// publicBaseURL returns the scheme and host public page URLs start with: the configured
+// public URL, or the host of the request when none is set
+func publicBaseURL(c *gin.Context, publicURL string) string {
+	if publicURL != "" {
+		return publicURL
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// setSharedPageHeaders keeps pages opened through share links, whose URL grants access,
+// out of shared caches, search engines and the Referer header of outgoing links
+func setSharedPageHeaders(c *gin.Context) {
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Header("Referrer-Policy", "no-referrer")
+}
+
+// shareErrorStatus maps share link errors to 404 and 410 and other errors like invoice errors
+func shareErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrShareLinkNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrShareLinkExpired):
+		return http.StatusGone
+	}
+	return invoiceErrorStatus(err)
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+// parseListQuery reads page, limit, q and sort query parameters
+func parseListQuery(c *gin.Context, sortFields map[string]string) (repositories.ListQuery, error) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	sort, err := repositories.ParseSort(c.Query("sort"), sortFields)
+	if err != nil {
+		return repositories.ListQuery{}, err
+	}
+
+	return repositories.ListQuery{
+		Page:   page,
+		Limit:  limit,
+		Search: strings.TrimSpace(c.Query("q")),
+		Sort:   sort,
+	}, nil
+}
+
+// parseCustomerFilter reads the customer list filters from the query string
+func parseCustomerFilter(c *gin.Context) (repositories.CustomerFilter, error) {
+	listQuery, err := parseListQuery(c, repositories.CustomerSortFields)
+	if err != nil {
+		return repositories.CustomerFilter{}, err
+	}
+
+	filter := repositories.CustomerFilter{ListQuery: listQuery}
+	if filter.Created, err = parseDateRange(c, "created_from", "created_to"); err != nil {
+		return filter, err
+	}
+	filter.Tags = splitQueryList(c.QueryArray("tag"))
+	if filter.HasOverdueInvoices, err = parseBoolParam(c, "has_overdue"); err != nil {
+		return filter, err
+	}
+	filter.CustomFields = parseCustomFieldParams(c)
+
+	return filter, nil
+}
+
+// parseInvoiceFilter reads the invoice list filters from the query string
+func parseInvoiceFilter(c *gin.Context) (repositories.InvoiceFilter, error) {
+	listQuery, err := parseListQuery(c, repositories.InvoiceSortFields)
+	if err != nil {
+		return repositories.InvoiceFilter{}, err
+	}
+
+	filter := repositories.InvoiceFilter{ListQuery: listQuery}
+	filter.Statuses = splitQueryList(c.QueryArray("status"))
+	if raw := c.Query("customer_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid customer_id %q", raw)
+		}
+		customerID := uint(id)
+		filter.CustomerID = &customerID
+	}
+	if raw := c.Query("recurring_invoice_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return filter, fmt.Errorf("invalid recurring_invoice_id %q", raw)
+		}
+		recurringInvoiceID := uint(id)
+		filter.RecurringInvoiceID = &recurringInvoiceID
+	}
+	if filter.IssueDate, err = parseDateRange(c, "issue_from", "issue_to"); err != nil {
+		return filter, err
+	}
+	if filter.DueDate, err = parseDateRange(c, "due_from", "due_to"); err != nil {
+		return filter, err
+	}
+	if filter.TotalMin, err = parseAmountParam(c, "total_min"); err != nil {
+		return filter, err
+	}
+	if filter.TotalMax, err = parseAmountParam(c, "total_max"); err != nil {
+		return filter, err
+	}
+	filter.Tags = splitQueryList(c.QueryArray("tag"))
+	filter.CustomFields = parseCustomFieldParams(c)
+
+	return filter, nil
+}
+
+// parseDateRange reads an inclusive from/to pair. Dates may be YYYY-MM-DD or
+// RFC 3339; a plain "to" date includes the whole day.
+func parseDateRange(c *gin.Context, fromKey, toKey string) (repositories.DateRange, error) {
+	var r repositories.DateRange
+
+	if raw := c.Query(fromKey); raw != "" {
+		from, _, err := parseDateParam(raw)
+		if err != nil {
+			return r, fmt.Errorf("invalid %s %q", fromKey, raw)
+		}
+		r.From = &from
+	}
+	if raw := c.Query(toKey); raw != "" {
+		to, dateOnly, err := parseDateParam(raw)
+		if err != nil {
+			return r, fmt.Errorf("invalid %s %q", toKey, raw)
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		} else {
+			to = to.Add(time.Nanosecond)
+		}
+		r.To = &to
+	}
+
+	return r, nil
+}
+
+func parseDateParam(raw string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	return t, false, err
+}
+
+func parseBoolParam(c *gin.Context, key string) (*bool, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", key, raw)
+	}
+	return &value, nil
+}
+
+func parseAmountParam(c *gin.Context, key string) (*money.Amount, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := money.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", key, raw)
+	}
+	return &value, nil
+}
+
+// parseCurrencyParam reads an optional ISO 4217 currency code
+func parseCurrencyParam(c *gin.Context, key string) (money.Currency, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return "", nil
+	}
+	currency, err := money.ParseCurrency(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q", key, raw)
+	}
+	return currency, nil
+}
+
+// parseCustomFieldParams collects cf.<key>=<value> parameters; values are validated by the service
+func parseCustomFieldParams(c *gin.Context) map[string]string {
+	var fields map[string]string
+	for name, values := range c.Request.URL.Query() {
+		key, ok := strings.CutPrefix(name, "cf.")
+		if !ok || key == "" || len(values) == 0 {
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+		fields[key] = values[len(values)-1]
+	}
+	return fields
+}
+
+// splitQueryList accepts both repeated parameters and comma separated values
+func splitQueryList(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
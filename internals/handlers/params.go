@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+)
+
+// parseIDParam reads a numeric path parameter and writes a 400 response when it is invalid
+func parseIDParam(c *gin.Context, name, label string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil || id == 0 {
+		utils.APIError(c, http.StatusBadRequest, "Invalid "+label+" ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// currentUserID returns the ID of the authenticated user, if any
+func currentUserID(c *gin.Context) *uint {
+	if userID, ok := c.Get("userID"); ok {
+		if id, ok := userID.(uint); ok {
+			return &id
+		}
+	}
+	return nil
+}
+
+// serviceErrorStatus maps validation errors returned by services to 400, totals that no
+// longer fit an amount to 422 and anything else to fallback
+func serviceErrorStatus(err error, fallback int) int {
+	if errors.Is(err, services.ErrAmountOutOfRange) {
+		return http.StatusUnprocessableEntity
+	}
+	if errors.Is(err, services.ErrInvalidCustomField) || errors.Is(err, services.ErrInvalidInvoice) ||
+		errors.Is(err, services.ErrInvalidCustomer) || errors.Is(err, services.ErrInvalidExchangeRate) ||
+		errors.Is(err, services.ErrInvalidTaxRate) || errors.Is(err, services.ErrInvalidPayment) ||
+		errors.Is(err, services.ErrInvalidRecurringInvoice) || errors.Is(err, services.ErrInvalidCreditNote) ||
+		errors.Is(err, services.ErrInvalidQuote) || errors.Is(err, services.ErrInvalidProduct) ||
+		errors.Is(err, services.ErrInvalidAttachment) {
+		return http.StatusBadRequest
+	}
+	return fallback
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+)
+
+type PaymentHandler struct {
+	service services.PaymentService
+}
+
+func NewPaymentHandler(service services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{service: service}
+}
+
+// ListPayments handles GET /payments?customer_id=&page=&limit=
+func (h *PaymentHandler) ListPayments(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	var customerID uint
+	if raw := c.Query("customer_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			utils.APIError(c, http.StatusBadRequest, "Invalid customer ID")
+			return
+		}
+		customerID = uint(id)
+	}
+
+	payments, pagination, err := h.service.ListPayments(customerID, page, limit)
+	if err != nil {
+		utils.APIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{
+		"payments":   payments,
+		"pagination": pagination,
+	})
+}
+
+// GetPayment handles GET /payments/:id
+func (h *PaymentHandler) GetPayment(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "payment")
+	if !ok {
+		return
+	}
+
+	payment, err := h.service.GetPayment(id)
+	if err != nil {
+		utils.APIError(c, paymentErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, payment)
+}
+
+// CreatePayment handles POST /payments
+func (h *PaymentHandler) CreatePayment(c *gin.Context) {
+	var req dtos.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	payment, err := h.service.RecordPayment(&req, currentUserID(c))
+	if err != nil {
+		utils.APIError(c, paymentErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusCreated, payment)
+}
+
+// ApplyPayment handles POST /payments/:id/apply
+func (h *PaymentHandler) ApplyPayment(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "payment")
+	if !ok {
+		return
+	}
+
+	var req dtos.ApplyPaymentRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	payment, err := h.service.ApplyCredit(id, req.Allocations, currentUserID(c))
+	if err != nil {
+		utils.APIError(c, paymentErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, payment)
+}
+
+// ReversePayment handles POST /payments/:id/reverse
+func (h *PaymentHandler) ReversePayment(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "payment")
+	if !ok {
+		return
+	}
+
+	var req dtos.ReversePaymentRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	payment, err := h.service.ReversePayment(id, req.Reason, currentUserID(c))
+	if err != nil {
+		utils.APIError(c, paymentErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, payment)
+}
+
+// GetCustomerCredit handles GET /customers/:id/credit
+func (h *PaymentHandler) GetCustomerCredit(c *gin.Context) {
+	customerID, ok := parseIDParam(c, "id", "customer")
+	if !ok {
+		return
+	}
+
+	credit, err := h.service.GetCustomerCredit(customerID)
+	if err != nil {
+		utils.APIError(c, paymentErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, credit)
+}
+
+func paymentErrorStatus(err error) int {
+	if errors.Is(err, services.ErrPaymentNotFound) || errors.Is(err, services.ErrCustomerNotFound) {
+		return http.StatusNotFound
+	}
+	return serviceErrorStatus(err, http.StatusInternalServerError)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+)
+
+type ProductHandler struct {
+	service services.ProductService
+}
+
+func NewProductHandler(service services.ProductService) *ProductHandler {
+	return &ProductHandler{service: service}
+}
+
+// ListProducts handles GET /products?q=&active=&currency=&sort=&page=&limit=
+func (h *ProductHandler) ListProducts(c *gin.Context) {
+	listQuery, err := parseListQuery(c, repositories.ProductSortFields)
+	if err != nil {
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := repositories.ProductFilter{ListQuery: listQuery}
+	if filter.Active, err = parseBoolParam(c, "active"); err != nil {
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Currency, err = parseCurrencyParam(c, "currency"); err != nil {
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	products, pagination, err := h.service.ListProducts(filter)
+	if err != nil {
+		utils.APIError(c, http.StatusInternalServerError, "Failed to fetch products")
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{
+		"products":   products,
+		"pagination": pagination,
+	})
+}
+
+// GetProduct handles GET /products/:id
+func (h *ProductHandler) GetProduct(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "product")
+	if !ok {
+		return
+	}
+
+	product, err := h.service.GetProduct(id)
+	if err != nil {
+		utils.APIError(c, productErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, product)
+}
+
+// CreateProduct handles POST /admin/products
+func (h *ProductHandler) CreateProduct(c *gin.Context) {
+	var req dtos.ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	product, err := h.service.CreateProduct(&req)
+	if err != nil {
+		utils.APIError(c, serviceErrorStatus(err, http.StatusInternalServerError), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusCreated, product)
+}
+
+// UpdateProduct handles PUT /admin/products/:id
+func (h *ProductHandler) UpdateProduct(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "product")
+	if !ok {
+		return
+	}
+
+	var req dtos.ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	product, err := h.service.UpdateProduct(id, &req)
+	if err != nil {
+		utils.APIError(c, productErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, product)
+}
+
+// DeleteProduct handles DELETE /admin/products/:id
+func (h *ProductHandler) DeleteProduct(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "product")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteProduct(id); err != nil {
+		utils.APIError(c, productErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{"message": "Product deleted successfully"})
+}
+
+func productErrorStatus(err error) int {
+	if errors.Is(err, services.ErrProductNotFound) {
+		return http.StatusNotFound
+	}
+	return serviceErrorStatus(err, http.StatusInternalServerError)
+}
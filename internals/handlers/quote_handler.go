@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+	"github.com/tacheraSasi/go-api-starter/ui/pages"
+)
+
+// maxDeclineReasonLength bounds the reason a customer gives on the quote page
+const maxDeclineReasonLength = 1000
+
+type QuoteHandler struct {
+	service    services.QuoteService
+	publicURL  string // scheme and host quote link URLs start with; the request host when empty
+	issuerName string
+}
+
+func NewQuoteHandler(service services.QuoteService, publicURL, issuerName string) *QuoteHandler {
+	return &QuoteHandler{service: service, publicURL: strings.TrimRight(publicURL, "/"), issuerName: issuerName}
+}
+
+// RegisterWebRoutes registers the public page where customers accept or decline quotes
+func (h *QuoteHandler) RegisterWebRoutes(router *gin.Engine) {
+	shareGroup := router.Group("/share/quotes")
+	{
+		shareGroup.GET("/:token", h.SharedQuotePage)
+		shareGroup.POST("/:token/accept", h.AcceptSharedQuote)
+		shareGroup.POST("/:token/decline", h.DeclineSharedQuote)
+	}
+}
+
+// ListQuotes handles GET /quotes?customer_id=&status=&page=&limit=
+func (h *QuoteHandler) ListQuotes(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	var customerID uint
+	if raw := c.Query("customer_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			utils.APIError(c, http.StatusBadRequest, "Invalid customer ID")
+			return
+		}
+		customerID = uint(id)
+	}
+
+	quotes, pagination, err := h.service.ListQuotes(customerID, c.Query("status"), page, limit)
+	if err != nil {
+		utils.APIError(c, quoteErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{
+		"quotes":     quotes,
+		"pagination": pagination,
+	})
+}
+
+// GetQuote handles GET /quotes/:id
+func (h *QuoteHandler) GetQuote(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "quote")
+	if !ok {
+		return
+	}
+
+	quote, err := h.service.GetQuote(id)
+	if err != nil {
+		utils.APIError(c, quoteErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, quote)
+}
+
+// CreateQuote handles POST /quotes
+func (h *QuoteHandler) CreateQuote(c *gin.Context) {
+	var req dtos.QuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	quote, err := h.service.CreateQuote(&req, currentUserID(c))
+	if err != nil {
+		utils.APIError(c, quoteErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusCreated, quote)
+}
+
+// UpdateQuote handles PUT /quotes/:id
+func (h *QuoteHandler) UpdateQuote(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "quote")
+	if !ok {
+		return
+	}
+
+	var req dtos.QuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	quote, err := h.service.UpdateQuote(id, &req)
+	if err != nil {
+		utils.APIError(c, quoteErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, quote)
+}
+
+// DeleteQuote handles DELETE /quotes/:id
+func (h *QuoteHandler) DeleteQuote(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "quote")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteQuote(id); err != nil {
+		utils.APIError(c, quoteErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{"message": "Quote deleted successfully"})
+}
+
+// SendQuote handles POST /quotes/:id/send
+func (h *QuoteHandler) SendQuote(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "quote")
+	if !ok {
+		return
+	}
+
+	quote, err := h.service.SendQuote(id)
+	if err != nil {
+		utils.APIError(c, quoteErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, quote)
+}
+
+// AcceptQuote handles POST /quotes/:id/accept
+func (h *QuoteHandler) AcceptQuote(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "quote")
+	if !ok {
+		return
+	}
+
+	quote, err := h.service.AcceptQuote(id, currentUserID(c))
+	if err != nil {
+		utils.APIError(c, quoteErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, quote)
+}
+
+// DeclineQuote handles POST /quotes/:id/decline
+func (h *QuoteHandler) DeclineQuote(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "quote")
+	if !ok {
+		return
+	}
+
+	var req dtos.DeclineQuoteRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	quote, err := h.service.DeclineQuote(id, req.Reason, currentUserID(c))
+	if err != nil {
+		utils.APIError(c, quoteErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, quote)
+}
+
+// ConvertQuote handles POST /quotes/:id/convert
+func (h *QuoteHandler) ConvertQuote(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "quote")
+	if !ok {
+		return
+	}
+
+	var req dtos.ConvertQuoteRequest
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	invoice, err := h.service.ConvertQuote(id, &req, currentUserID(c))
+	if err != nil {
+		utils.APIError(c, quoteErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusCreated, invoice)
+}
+
+// GetQuoteLink handles GET /quotes/:id/link
+func (h *QuoteHandler) GetQuoteLink(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "quote")
+	if !ok {
+		return
+	}
+
+	link, err := h.service.GetQuoteLink(id)
+	if err != nil {
+		utils.APIError(c, quoteErrorStatus(err), err.Error())
+		return
+	}
+
+	link.URL = publicBaseURL(c, h.publicURL) + "/share/quotes/" + link.Token
+	utils.APISuccess(c, http.StatusOK, link)
+}
+
+// SharedQuotePage handles GET /share/quotes/:token
+func (h *QuoteHandler) SharedQuotePage(c *gin.Context) {
+	token := c.Param("token")
+	quote, err := h.service.OpenSharedQuote(token)
+	if err != nil {
+		h.unavailable(c, err)
+		return
+	}
+
+	setSharedPageHeaders(c)
+	templ.Handler(pages.SharedQuote(pages.SharedQuoteProps{
+		AppName:    "GO-FullStack",
+		IssuerName: h.issuerName,
+		Quote:      quote,
+		AcceptURL:  "/share/quotes/" + token + "/accept",
+		DeclineURL: "/share/quotes/" + token + "/decline",
+	})).ServeHTTP(c.Writer, c.Request)
+}
+
+// AcceptSharedQuote handles POST /share/quotes/:token/accept
+func (h *QuoteHandler) AcceptSharedQuote(c *gin.Context) {
+	_, err := h.service.AcceptSharedQuote(c.Param("token"))
+	h.respondedOnPage(c, err)
+}
+
+// DeclineSharedQuote handles POST /share/quotes/:token/decline with an optional reason form field
+func (h *QuoteHandler) DeclineSharedQuote(c *gin.Context) {
+	reason := []rune(c.PostForm("reason"))
+	if len(reason) > maxDeclineReasonLength {
+		reason = reason[:maxDeclineReasonLength]
+	}
+	_, err := h.service.DeclineSharedQuote(c.Param("token"), string(reason))
+	h.respondedOnPage(c, err)
+}
+
+// respondedOnPage sends the customer back to the quote page, which shows the answer. A
+// quote that was answered or expired in the meantime shows its current status there.
+func (h *QuoteHandler) respondedOnPage(c *gin.Context, err error) {
+	if err != nil && !errors.Is(err, services.ErrInvalidQuoteTransition) {
+		h.unavailable(c, err)
+		return
+	}
+	setSharedPageHeaders(c)
+	c.Redirect(http.StatusSeeOther, "/share/quotes/"+c.Param("token"))
+}
+
+// unavailable renders the page shown for unknown and expired quote links
+func (h *QuoteHandler) unavailable(c *gin.Context, err error) {
+	props := pages.ShareLinkUnavailableProps{
+		AppName: "GO-FullStack",
+		Title:   "Quote unavailable",
+		Message: "Something went wrong while opening this quote. Please try again later.",
+	}
+	status := quoteErrorStatus(err)
+	switch {
+	case errors.Is(err, services.ErrShareLinkNotFound):
+		status = http.StatusNotFound
+		props.Message = "This link is not valid. Please check that you copied all of it."
+	case errors.Is(err, services.ErrShareLinkExpired):
+		status = http.StatusGone
+		props.Message = "This quote has expired. Please ask the sender for a new one."
+	}
+
+	setSharedPageHeaders(c)
+	templ.Handler(pages.ShareLinkUnavailable(props), templ.WithStatus(status)).ServeHTTP(c.Writer, c.Request)
+}
+
+// quoteErrorStatus maps quote errors to 404 and 409, and other errors like invoice errors,
+// since converting a quote creates an invoice
+func quoteErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrQuoteNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidQuoteTransition):
+		return http.StatusConflict
+	}
+	return invoiceErrorStatus(err)
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+)
+
+type RecurringInvoiceHandler struct {
+	service services.RecurringInvoiceService
+}
+
+func NewRecurringInvoiceHandler(service services.RecurringInvoiceService) *RecurringInvoiceHandler {
+	return &RecurringInvoiceHandler{service: service}
+}
+
+// ListRecurringInvoices handles GET /recurring-invoices?customer_id=&page=&limit=
+func (h *RecurringInvoiceHandler) ListRecurringInvoices(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	var customerID uint
+	if raw := c.Query("customer_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			utils.APIError(c, http.StatusBadRequest, "Invalid customer ID")
+			return
+		}
+		customerID = uint(id)
+	}
+
+	recurring, pagination, err := h.service.ListRecurringInvoices(customerID, page, limit)
+	if err != nil {
+		utils.APIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{
+		"recurring_invoices": recurring,
+		"pagination":         pagination,
+	})
+}
+
+// GetRecurringInvoice handles GET /recurring-invoices/:id
+func (h *RecurringInvoiceHandler) GetRecurringInvoice(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "recurring invoice")
+	if !ok {
+		return
+	}
+
+	recurring, err := h.service.GetRecurringInvoice(id)
+	if err != nil {
+		utils.APIError(c, recurringInvoiceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, recurring)
+}
+
+// CreateRecurringInvoice handles POST /recurring-invoices
+func (h *RecurringInvoiceHandler) CreateRecurringInvoice(c *gin.Context) {
+	var req dtos.RecurringInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	recurring, err := h.service.CreateRecurringInvoice(&req, currentUserID(c))
+	if err != nil {
+		utils.APIError(c, recurringInvoiceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusCreated, recurring)
+}
+
+// UpdateRecurringInvoice handles PUT /recurring-invoices/:id
+func (h *RecurringInvoiceHandler) UpdateRecurringInvoice(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "recurring invoice")
+	if !ok {
+		return
+	}
+
+	var req dtos.RecurringInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	recurring, err := h.service.UpdateRecurringInvoice(id, &req)
+	if err != nil {
+		utils.APIError(c, recurringInvoiceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, recurring)
+}
+
+// DeleteRecurringInvoice handles DELETE /recurring-invoices/:id
+func (h *RecurringInvoiceHandler) DeleteRecurringInvoice(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "recurring invoice")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRecurringInvoice(id); err != nil {
+		utils.APIError(c, recurringInvoiceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{"message": "Recurring invoice deleted successfully"})
+}
+
+// PreviewRecurringInvoice handles GET /recurring-invoices/:id/preview?count=
+func (h *RecurringInvoiceHandler) PreviewRecurringInvoice(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "recurring invoice")
+	if !ok {
+		return
+	}
+	count, err := strconv.Atoi(c.DefaultQuery("count", "0"))
+	if err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid count")
+		return
+	}
+
+	runs, err := h.service.PreviewRuns(id, count)
+	if err != nil {
+		utils.APIError(c, recurringInvoiceErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, runs)
+}
+
+func recurringInvoiceErrorStatus(err error) int {
+	if errors.Is(err, services.ErrRecurringInvoiceNotFound) {
+		return http.StatusNotFound
+	}
+	return serviceErrorStatus(err, http.StatusInternalServerError)
+}
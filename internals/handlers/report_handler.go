@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+)
+
+type ReportHandler struct {
+	service services.ReportService
+}
+
+func NewReportHandler(service services.ReportService) *ReportHandler {
+	return &ReportHandler{service: service}
+}
+
+// GetRevenueReport handles GET /reports/revenue?from=&to=&status=&customer_id=&group_by=
+func (h *ReportHandler) GetRevenueReport(c *gin.Context) {
+	var filter repositories.InvoiceReportFilter
+	var err error
+	if filter.IssueDate, err = parseDateRange(c, "from", "to"); err != nil {
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.Statuses = splitQueryList(c.QueryArray("status"))
+	if raw := c.Query("customer_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			utils.APIError(c, http.StatusBadRequest, fmt.Sprintf("invalid customer_id %q", raw))
+			return
+		}
+		customerID := uint(id)
+		filter.CustomerID = &customerID
+	}
+
+	report, err := h.service.GetRevenueReport(filter, c.Query("group_by"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidReportFilter) {
+			utils.APIError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrAmountOutOfRange) {
+			utils.APIError(c, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		utils.APIError(c, http.StatusInternalServerError, "Failed to build revenue report")
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, report)
+}
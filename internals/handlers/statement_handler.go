@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/a-h/templ"
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+	"github.com/tacheraSasi/go-api-starter/ui/pages"
+)
+
+type StatementHandler struct {
+	service services.StatementService
+}
+
+func NewStatementHandler(service services.StatementService) *StatementHandler {
+	return &StatementHandler{service: service}
+}
+
+// GetCustomerStatement handles GET /customers/:id/statement?from=&to=&currency=&format=json|html
+func (h *StatementHandler) GetCustomerStatement(c *gin.Context) {
+	customerID, ok := parseIDParam(c, "id", "customer")
+	if !ok {
+		return
+	}
+
+	period, err := parseDateRange(c, "from", "to")
+	if err != nil {
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	currency, err := parseCurrencyParam(c, "currency")
+	if err != nil {
+		utils.APIError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "html" {
+		utils.APIError(c, http.StatusBadRequest, "format must be json or html")
+		return
+	}
+
+	statement, err := h.service.GetCustomerStatement(customerID, currency, period)
+	if err != nil {
+		if errors.Is(err, services.ErrCustomerNotFound) {
+			utils.APIError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		utils.APIError(c, serviceErrorStatus(err, http.StatusBadRequest), err.Error())
+		return
+	}
+
+	if format == "html" {
+		templ.Handler(pages.CustomerStatement(pages.CustomerStatementProps{AppName: "GO-FullStack", Statement: statement})).ServeHTTP(c.Writer, c.Request)
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, statement)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+)
+
+type TaxRateHandler struct {
+	service services.TaxRateService
+}
+
+func NewTaxRateHandler(service services.TaxRateService) *TaxRateHandler {
+	return &TaxRateHandler{service: service}
+}
+
+// ListTaxRates handles GET /tax-rates
+func (h *TaxRateHandler) ListTaxRates(c *gin.Context) {
+	rates, err := h.service.ListTaxRates()
+	if err != nil {
+		utils.APIError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, rates)
+}
+
+// GetTaxRate handles GET /tax-rates/:id
+func (h *TaxRateHandler) GetTaxRate(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "tax rate")
+	if !ok {
+		return
+	}
+
+	rate, err := h.service.GetTaxRate(id)
+	if err != nil {
+		utils.APIError(c, taxRateErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, rate)
+}
+
+// CreateTaxRate handles POST /admin/tax-rates
+func (h *TaxRateHandler) CreateTaxRate(c *gin.Context) {
+	var req dtos.TaxRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	rate, err := h.service.CreateTaxRate(&req)
+	if err != nil {
+		utils.APIError(c, serviceErrorStatus(err, http.StatusInternalServerError), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusCreated, rate)
+}
+
+// UpdateTaxRate handles PUT /admin/tax-rates/:id
+func (h *TaxRateHandler) UpdateTaxRate(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "tax rate")
+	if !ok {
+		return
+	}
+
+	var req dtos.TaxRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid input: "+err.Error())
+		return
+	}
+
+	rate, err := h.service.UpdateTaxRate(id, &req)
+	if err != nil {
+		utils.APIError(c, taxRateErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, rate)
+}
+
+// DeleteTaxRate handles DELETE /admin/tax-rates/:id
+func (h *TaxRateHandler) DeleteTaxRate(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "tax rate")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteTaxRate(id); err != nil {
+		utils.APIError(c, taxRateErrorStatus(err), err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, gin.H{"message": "Tax rate deleted successfully"})
+}
+
+func taxRateErrorStatus(err error) int {
+	if errors.Is(err, services.ErrTaxRateNotFound) {
+		return http.StatusNotFound
+	}
+	return serviceErrorStatus(err, http.StatusInternalServerError)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/services"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+)
+
+// maxUBLImportSize limits the size of an uploaded UBL invoice
+const maxUBLImportSize = 5 << 20
+
+type UBLHandler struct {
+	service services.UBLService
+}
+
+func NewUBLHandler(service services.UBLService) *UBLHandler {
+	return &UBLHandler{service: service}
+}
+
+// ExportInvoice handles GET /invoices/:id/ubl?validate=true&force=true. The XML is only
+// returned when it passes validation, unless force is set; validate returns the
+// validation report instead of the document.
+func (h *UBLHandler) ExportInvoice(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "invoice")
+	if !ok {
+		return
+	}
+	validateOnly, force := c.Query("validate") == "true", c.Query("force") == "true"
+
+	invoice, data, report, err := h.service.ExportInvoice(id)
+	if err != nil {
+		utils.APIError(c, invoiceErrorStatus(err), err.Error())
+		return
+	}
+	if validateOnly {
+		utils.APISuccess(c, http.StatusOK, report)
+		return
+	}
+	if !report.Valid && !force {
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, utils.APIResponse{
+			Error: "The invoice is not a valid Peppol BIS Billing 3.0 document; see the validation report",
+			Data:  report,
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", invoice.InvoiceNumber+".xml"))
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", data)
+}
+
+// ImportInvoice handles POST /invoices/import?dry_run=true&allow_duplicate=true&cf.<key>=<value>.
+// The UBL invoice is the request body or the "file" field of a multipart form; cf parameters
+// set invoice custom fields the document does not carry.
+func (h *UBLHandler) ImportInvoice(c *gin.Context) {
+	opts := services.UBLImportOptions{CreatedByID: currentUserID(c)}
+	if raw := c.Query("dry_run"); raw != "" {
+		dryRun, err := strconv.ParseBool(raw)
+		if err != nil {
+			utils.APIError(c, http.StatusBadRequest, fmt.Sprintf("invalid dry_run %q", raw))
+			return
+		}
+		opts.DryRun = dryRun
+	}
+	if raw := c.Query("allow_duplicate"); raw != "" {
+		allow, err := strconv.ParseBool(raw)
+		if err != nil {
+			utils.APIError(c, http.StatusBadRequest, fmt.Sprintf("invalid allow_duplicate %q", raw))
+			return
+		}
+		opts.AllowDuplicate = allow
+	}
+	if fields := parseCustomFieldParams(c); len(fields) > 0 {
+		opts.CustomFields = make(models.CustomFields, len(fields))
+		for key, value := range fields {
+			opts.CustomFields[key] = value
+		}
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUBLImportSize+multipartOverhead)
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			utils.APIError(c, uploadReadStatus(err), "A UBL invoice is required in the 'file' field")
+			return
+		}
+		if fileHeader.Size > maxUBLImportSize {
+			utils.APIError(c, http.StatusRequestEntityTooLarge, "UBL invoice is too large")
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			utils.APIError(c, http.StatusBadRequest, "Failed to read uploaded file")
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		utils.APIError(c, uploadReadStatus(err), "Failed to read UBL invoice: "+err.Error())
+		return
+	}
+
+	report, err := h.service.ImportInvoice(bytes.NewReader(data), opts)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidUBL) {
+			utils.APIError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.APIError(c, http.StatusInternalServerError, "Failed to import invoice: "+err.Error())
+		return
+	}
+
+	switch {
+	case !report.Valid:
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, utils.APIResponse{
+			Error: "The invoice cannot be imported; see the validation report",
+			Data:  report,
+		})
+	case report.DryRun:
+		utils.APISuccess(c, http.StatusOK, report)
+	default:
+		utils.APISuccess(c, http.StatusCreated, report)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
 	"github.com/tacheraSasi/go-api-starter/internals/services"
 	"github.com/tacheraSasi/go-api-starter/internals/utils"
 )
@@ -186,3 +187,35 @@ func (h *UserHandler) CheckUserPermission(c *gin.Context) {
 		"action":         action,
 	})
 }
+
+// CheckUserPermissions handles POST /admin/users/:id/permissions/check
+func (h *UserHandler) CheckUserPermissions(c *gin.Context) {
+	userID := c.Param("id")
+
+	var req dtos.BatchPermissionCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.APIError(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	results, err := h.userService.CheckUserPermissions(userID, req.Checks)
+	if err != nil {
+		utils.APIError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, results)
+}
+
+// ExplainUserPermissions handles GET /admin/users/:id/permissions
+func (h *UserHandler) ExplainUserPermissions(c *gin.Context) {
+	userID := c.Param("id")
+
+	explanation, err := h.userService.ExplainUserPermissions(userID, c.Query("resource"), c.Query("action"))
+	if err != nil {
+		utils.APIError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.APISuccess(c, http.StatusOK, explanation)
+}
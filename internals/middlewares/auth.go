@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
 	"github.com/tacheraSasi/go-api-starter/internals/services"
 	"github.com/tacheraSasi/go-api-starter/internals/utils"
 	"github.com/tacheraSasi/go-api-starter/pkg/jwt"
@@ -47,20 +49,11 @@ func AuthMiddleware(tokenService services.TokenService, jwtSecret []byte) gin.Ha
 		}
 
 		c.Set("userID", claims.User.ID)
-		c.Set("userRole", claims.User.Role)
 		c.Next()
 	}
 }
 
-func AdminMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		role, exists := c.Get("userRole")
-		if !exists || role != "admin" {
-			utils.APIError(c, http.StatusForbidden, "Admin access required")
-			c.Abort()
-			return
-		}
-		c.Next()
-	}
+// AdminMiddleware gates admin routes on the system:manage permission
+func AdminMiddleware(userRepo repositories.UserRepository) gin.HandlerFunc {
+	return PermissionMiddleware(userRepo, models.ResourceSystem, models.ActionManage)
 }
-
@@ -13,15 +13,15 @@ import (
 // PermissionMiddleware checks if the user has the required permission
 func PermissionMiddleware(userRepo repositories.UserRepository, resource, action string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get user from context (set by AuthMiddleware)
-		userInterface, exists := c.Get("user")
+		// Get user ID from context (set by AuthMiddleware)
+		userID, exists := c.Get("userID")
 		if !exists {
 			utils.APIError(c, http.StatusUnauthorized, "User not found in context")
 			c.Abort()
 			return
 		}
 
-		user, ok := userInterface.(*models.User)
+		id, ok := userID.(uint)
 		if !ok {
 			utils.APIError(c, http.StatusUnauthorized, "Invalid user in context")
 			c.Abort()
@@ -29,7 +29,7 @@ func PermissionMiddleware(userRepo repositories.UserRepository, resource, action
 		}
 
 		// Get user with roles and permissions
-		userWithRoles, err := userRepo.GetUserByIDWithRoles(strconv.Itoa(int(user.ID)))
+		userWithRoles, err := userRepo.GetUserByIDWithRoles(strconv.FormatUint(uint64(id), 10))
 		if err != nil {
 			utils.APIError(c, http.StatusInternalServerError, "Failed to get user permissions")
 			c.Abort()
@@ -50,15 +50,15 @@ func PermissionMiddleware(userRepo repositories.UserRepository, resource, action
 // RequireRole middleware checks if the user has one of the required roles
 func RequireRole(userRepo repositories.UserRepository, requiredRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get user from context (set by AuthMiddleware)
-		userInterface, exists := c.Get("user")
+		// Get user ID from context (set by AuthMiddleware)
+		userID, exists := c.Get("userID")
 		if !exists {
 			utils.APIError(c, http.StatusUnauthorized, "User not found in context")
 			c.Abort()
 			return
 		}
 
-		user, ok := userInterface.(*models.User)
+		id, ok := userID.(uint)
 		if !ok {
 			utils.APIError(c, http.StatusUnauthorized, "Invalid user in context")
 			c.Abort()
@@ -66,7 +66,7 @@ func RequireRole(userRepo repositories.UserRepository, requiredRoles ...string)
 		}
 
 		// Get user with roles
-		userWithRoles, err := userRepo.GetUserByIDWithRoles(strconv.Itoa(int(user.ID)))
+		userWithRoles, err := userRepo.GetUserByIDWithRoles(strconv.FormatUint(uint64(id), 10))
 		if err != nil {
 			utils.APIError(c, http.StatusInternalServerError, "Failed to get user roles")
 			c.Abort()
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Attachment parent types
+const (
+	AttachmentParentInvoice  = "invoice"
+	AttachmentParentCustomer = "customer"
+	AttachmentParentUser     = "user" // the user's avatar
+)
+
+// Attachment is a file stored for an invoice, a customer or, as an avatar, a user.
+// ContentType is sniffed from the file itself rather than taken from the upload, and
+// Checksum is the hex SHA-256 of the stored bytes.
+type Attachment struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	ParentType   string    `gorm:"type:varchar(20);not null;index:idx_attachments_parent" json:"parent_type"`
+	ParentID     uint      `gorm:"not null;index:idx_attachments_parent" json:"parent_id"`
+	FileName     string    `gorm:"type:varchar(255);not null" json:"file_name"`
+	ContentType  string    `gorm:"type:varchar(255);not null" json:"content_type"`
+	Size         int64     `gorm:"not null" json:"size"`
+	Checksum     string    `gorm:"type:char(64);not null" json:"checksum_sha256"`
+	StorageKey   string    `gorm:"type:varchar(255);not null;uniqueIndex" json:"-"`
+	UploadedByID *uint     `json:"uploaded_by_id"`
+}
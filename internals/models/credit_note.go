@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+// Credit note statuses
+const (
+	CreditNoteStatusOpen   = "open"   // part of the credit is still to be applied or refunded
+	CreditNoteStatusClosed = "closed" // the credit was applied or refunded in full
+)
+
+// CreditNote reduces what a customer owes on an invoice, in full or for some of its lines,
+// without changing the invoice itself. Its credit is applied to open invoices of the
+// customer, starting with the credited invoice, or refunded. Amounts are positive; reports
+// and statements count them against revenue and the customer's balance.
+type CreditNote struct {
+	ID               uint                   `gorm:"primarykey" json:"id"`
+	CreatedAt        time.Time              `json:"created_at"`
+	UpdatedAt        time.Time              `json:"updated_at"`
+	CreditNoteNumber string                 `gorm:"uniqueIndex;not null" json:"credit_note_number"`
+	IssueDate        time.Time              `gorm:"not null;index" json:"issue_date"`
+	Status           string                 `gorm:"type:varchar(20);not null" json:"status"` // open or closed
+	InvoiceID        uint                   `gorm:"not null;index" json:"invoice_id"`
+	CustomerID       uint                   `gorm:"not null;index" json:"customer_id"`
+	Customer         *Customer              `json:"customer,omitempty"`
+	Currency         money.Currency         `gorm:"type:char(3);not null" json:"currency"`
+	Reason           string                 `gorm:"type:text" json:"reason"`
+	Items            []CreditNoteItem       `gorm:"foreignKey:CreditNoteID" json:"items"`
+	Subtotal         money.Amount           `gorm:"type:decimal(19,4);not null" json:"subtotal"` // credited lines without tax, after the invoice discount
+	TaxAmount        money.Amount           `gorm:"type:decimal(19,4);not null;default:0" json:"tax_amount"`
+	ChargesTotal     money.Amount           `gorm:"type:decimal(19,4);not null;default:0" json:"charges_total"` // credited with the rest of the invoice only
+	Total            money.Amount           `gorm:"type:decimal(19,4);not null" json:"total"`
+	AppliedAmount    money.Amount           `gorm:"type:decimal(19,4);not null;default:0" json:"applied_amount"`
+	RefundedAmount   money.Amount           `gorm:"type:decimal(19,4);not null;default:0" json:"refunded_amount"`
+	RemainingAmount  money.Amount           `gorm:"type:decimal(19,4);not null;default:0" json:"remaining_amount"`
+	BaseCurrency     money.Currency         `gorm:"type:char(3);index" json:"base_currency"` // converted at the exchange rate of the credited invoice
+	ExchangeRate     money.Rate             `gorm:"type:decimal(24,10)" json:"exchange_rate"`
+	BaseSubtotal     money.Amount           `gorm:"type:decimal(19,4)" json:"base_subtotal"`
+	BaseTaxAmount    money.Amount           `gorm:"type:decimal(19,4)" json:"base_tax_amount"`
+	BaseChargesTotal money.Amount           `gorm:"type:decimal(19,4)" json:"base_charges_total"`
+	BaseTotal        money.Amount           `gorm:"type:decimal(19,4)" json:"base_total"`
+	Allocations      []CreditNoteAllocation `gorm:"foreignKey:CreditNoteID" json:"allocations"`
+	Refunds          []CreditNoteRefund     `gorm:"foreignKey:CreditNoteID" json:"refunds"`
+	CreatedByID      *uint                  `json:"created_by_id"`
+}
+
+// CreditNoteItem credits part of one invoice line. Quantity is zero when the line was
+// credited by amount rather than by quantity.
+type CreditNoteItem struct {
+	ID            uint         `gorm:"primarykey" json:"id"`
+	CreditNoteID  uint         `gorm:"not null;index" json:"credit_note_id"`
+	InvoiceItemID uint         `gorm:"not null;index" json:"invoice_item_id"`
+	Description   string       `gorm:"not null" json:"description"`
+	Quantity      int          `gorm:"not null" json:"quantity"`
+	NetAmount     money.Amount `gorm:"type:decimal(19,4);not null" json:"net_amount"`
+	TaxAmount     money.Amount `gorm:"type:decimal(19,4);not null" json:"tax_amount"`
+	Total         money.Amount `gorm:"type:decimal(19,4);not null" json:"total"`
+}
+
+// CreditNoteAllocation is the part of a credit note applied to one invoice
+type CreditNoteAllocation struct {
+	ID           uint         `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time    `json:"created_at"`
+	CreditNoteID uint         `gorm:"not null;index" json:"credit_note_id"`
+	InvoiceID    uint         `gorm:"not null;index" json:"invoice_id"`
+	Amount       money.Amount `gorm:"type:decimal(19,4);not null" json:"amount"`
+	Date         time.Time    `gorm:"not null" json:"date"`
+	AppliedByID  *uint        `json:"applied_by_id"`
+}
+
+// CreditNoteRefund is credit paid back to the customer
+type CreditNoteRefund struct {
+	ID           uint         `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time    `json:"created_at"`
+	CreditNoteID uint         `gorm:"not null;index" json:"credit_note_id"`
+	Amount       money.Amount `gorm:"type:decimal(19,4);not null" json:"amount"`
+	RefundDate   time.Time    `gorm:"not null" json:"refund_date"`
+	Method       string       `gorm:"type:varchar(20);not null" json:"method"`
+	Reference    string       `gorm:"type:varchar(100)" json:"reference"`
+	Notes        string       `gorm:"type:text" json:"notes"`
+	RefundedByID *uint        `json:"refunded_by_id"`
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Custom field entity types, shared with the polymorphic tag types
+const (
+	CustomFieldEntityCustomer = TaggableCustomer
+	CustomFieldEntityInvoice  = TaggableInvoice
+)
+
+// Custom field types
+const (
+	CustomFieldTypeText    = "text"
+	CustomFieldTypeNumber  = "number"
+	CustomFieldTypeBoolean = "boolean"
+	CustomFieldTypeDate    = "date"
+	CustomFieldTypeSelect  = "select"
+)
+
+// CustomFieldDefinition is an admin-defined extra field on customers or invoices.
+// Min and Max bound numbers, or the length of text values.
+type CustomFieldDefinition struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	EntityType string     `gorm:"type:varchar(50);not null;uniqueIndex:idx_custom_field_key" json:"entity_type"`          // customers, invoices
+	Key        string     `gorm:"column:field_key;type:varchar(64);not null;uniqueIndex:idx_custom_field_key" json:"key"` // "key" is reserved in MySQL
+	Label      string     `gorm:"not null" json:"label"`
+	Type       string     `gorm:"type:varchar(20);not null" json:"type"` // text, number, boolean, date, select
+	Required   bool       `gorm:"default:false" json:"required"`
+	Options    StringList `gorm:"type:text" json:"options,omitempty"`
+	Pattern    string     `json:"pattern,omitempty"`
+	Min        *float64   `json:"min,omitempty"`
+	Max        *float64   `json:"max,omitempty"`
+	Position   int        `gorm:"default:0" json:"position"`
+}
+
+// CustomFieldValue stores the JSON encoded value of a custom field for one record
+type CustomFieldValue struct {
+	ID           uint                  `gorm:"primarykey" json:"-"`
+	UpdatedAt    time.Time             `json:"-"`
+	EntityID     uint                  `gorm:"not null;uniqueIndex:idx_custom_field_value" json:"-"`
+	EntityType   string                `gorm:"type:varchar(50);not null;uniqueIndex:idx_custom_field_value" json:"-"`
+	DefinitionID uint                  `gorm:"not null;uniqueIndex:idx_custom_field_value" json:"-"`
+	Definition   CustomFieldDefinition `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Value        string                `gorm:"type:text;not null" json:"-"`
+}
+
+// CustomFields maps custom field keys to their values in API requests and responses
+type CustomFields map[string]interface{}
+
+// customFieldMap decodes loaded values into a map keyed by definition key
+func customFieldMap(values []CustomFieldValue) CustomFields {
+	fields := make(CustomFields, len(values))
+	for _, value := range values {
+		if value.Definition.Key == "" {
+			continue
+		}
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(value.Value), &decoded); err == nil {
+			fields[value.Definition.Key] = decoded
+		}
+	}
+	return fields
+}
+
+// StringList is a list of strings stored as a JSON array
+type StringList []string
+
+// Value implements driver.Valuer
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]string(l))
+	return string(data), err
+}
+
+// Scan implements sql.Scanner
+func (l *StringList) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", src)
+	}
+	if len(data) == 0 {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+// Value implements driver.Valuer, for records that keep custom fields to copy onto others
+func (f CustomFields) Value() (driver.Value, error) {
+	if f == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(map[string]interface{}(f))
+	return string(data), err
+}
+
+// Scan implements sql.Scanner
+func (f *CustomFields) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*f = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into CustomFields", src)
+	}
+	if len(data) == 0 {
+		*f = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*map[string]interface{})(f))
+}
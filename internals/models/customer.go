@@ -3,16 +3,37 @@ package models
 import (
 	"time"
 
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
 	"gorm.io/gorm"
 )
 
+// TaggableCustomer is the polymorphic type stored on customer tags and custom field values
+const TaggableCustomer = "customers"
+
 type Customer struct {
-	ID        uint           `gorm:"primarykey" json:"id"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
-	Name      string         `gorm:"not null" json:"name"`
-	Email     string         `gorm:"not null;uniqueIndex" json:"email"`
-	Phone     string         `json:"phone"`
-	Address   string         `json:"address"`
+	ID                uint               `gorm:"primarykey" json:"id"`
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt     `gorm:"index" json:"-"`
+	Name              string             `gorm:"not null" json:"name"`
+	Email             string             `gorm:"not null;uniqueIndex" json:"email"`
+	Phone             string             `json:"phone"`
+	Address           string             `json:"address"`
+	Currency          money.Currency     `gorm:"type:char(3)" json:"currency"` // default invoice currency
+	TaxExempt         bool               `gorm:"not null;default:false" json:"tax_exempt"`
+	TaxExemptReason   string             `gorm:"type:varchar(255)" json:"tax_exempt_reason,omitempty"` // e.g. a certificate number or reverse charge
+	DunningOptOut     bool               `gorm:"not null;default:false" json:"dunning_opt_out"`        // no payment reminders are emailed to the customer
+	TaxID             string             `gorm:"type:varchar(50);index" json:"tax_id"`                 // VAT identifier with its country prefix, e.g. DE123456789
+	PeppolID          string             `gorm:"type:varchar(100);index" json:"peppol_id"`             // electronic address written as scheme:identifier, e.g. 0088:5790000435951
+	Tags              []Tag              `gorm:"polymorphic:Taggable;polymorphicValue:customers" json:"tags"`
+	Contacts          []CustomerContact  `gorm:"foreignKey:CustomerID" json:"contacts,omitempty"`
+	Addresses         []CustomerAddress  `gorm:"foreignKey:CustomerID" json:"addresses,omitempty"`
+	CustomFieldValues []CustomFieldValue `gorm:"polymorphic:Entity;polymorphicValue:customers" json:"-"`
+	CustomFields      CustomFields       `gorm:"-" json:"custom_fields"`
+}
+
+// AfterFind exposes preloaded custom field values as the custom_fields map
+func (c *Customer) AfterFind(tx *gorm.DB) error {
+	c.CustomFields = customFieldMap(c.CustomFieldValues)
+	return nil
 }
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Customer contact roles
+const (
+	ContactRolePrimary   = "primary"
+	ContactRoleBilling   = "billing"
+	ContactRoleTechnical = "technical"
+	ContactRoleOther     = "other"
+)
+
+// Customer address types
+const (
+	AddressTypeBilling  = "billing"
+	AddressTypeShipping = "shipping"
+)
+
+type CustomerContact struct {
+	ID         uint           `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+	CustomerID uint           `gorm:"not null;index" json:"customer_id"`
+	Name       string         `gorm:"not null" json:"name"`
+	Email      string         `json:"email"`
+	Phone      string         `json:"phone"`
+	JobTitle   string         `json:"job_title"`
+	Role       string         `gorm:"type:varchar(20);default:'other'" json:"role"` // primary, billing, technical, other
+}
+
+type CustomerAddress struct {
+	ID         uint           `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+	CustomerID uint           `gorm:"not null;index" json:"customer_id"`
+	Type       string         `gorm:"type:varchar(20);not null;default:'billing'" json:"type"` // billing, shipping
+	Label      string         `json:"label"`
+	Line1      string         `gorm:"not null" json:"line1"`
+	Line2      string         `json:"line2"`
+	City       string         `gorm:"not null" json:"city"`
+	Region     string         `json:"region"`
+	PostalCode string         `gorm:"type:varchar(20)" json:"postal_code"`
+	Country    string         `gorm:"type:char(2);not null" json:"country"` // ISO 3166-1 alpha-2
+	IsDefault  bool           `gorm:"default:false" json:"is_default"`
+}
+
+// Lines returns the address as printable lines, skipping empty parts
+func (a CustomerAddress) Lines() []string {
+	var lines []string
+	for _, line := range []string{
+		a.Line1,
+		a.Line2,
+		strings.TrimSpace(strings.Join(nonEmpty(a.PostalCode, a.City), " ")),
+		a.Region,
+		a.Country,
+	} {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// String returns the address on a single line
+func (a CustomerAddress) String() string {
+	return strings.Join(a.Lines(), ", ")
+}
+
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// CustomerMerge records a duplicate customer that was merged into a surviving customer
+type CustomerMerge struct {
+	ID                     uint      `gorm:"primarykey" json:"id"`
+	CreatedAt              time.Time `json:"created_at"`
+	SurvivorID             uint      `gorm:"not null;index" json:"survivor_id"`
+	MergedCustomerID       uint      `gorm:"not null;index" json:"merged_customer_id"`
+	MergedName             string    `json:"merged_name"`
+	MergedEmail            string    `json:"merged_email"`
+	MergedPhone            string    `json:"merged_phone"`
+	MergedByID             *uint     `json:"merged_by_id"`
+	InvoicesMoved          int64     `json:"invoices_moved"`
+	PaymentsMoved          int64     `json:"payments_moved"`
+	CreditNotesMoved       int64     `json:"credit_notes_moved"`
+	QuotesMoved            int64     `json:"quotes_moved"`
+	RecurringInvoicesMoved int64     `json:"recurring_invoices_moved"`
+	AttachmentsMoved       int64     `json:"attachments_moved"`
+	ContactsMoved          int64     `json:"contacts_moved"`
+	AddressesMoved         int64     `json:"addresses_moved"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+// Exchange rate sources
+const (
+	ExchangeRateSourceManual = "manual"
+	ExchangeRateSourceCSV    = "csv"
+	ExchangeRateSourceECB    = "ecb"
+)
+
+// ExchangeRate is the number of QuoteCurrency units one BaseCurrency unit buys on Date.
+// A rate stays in effect until a later rate for the same pair is recorded.
+type ExchangeRate struct {
+	ID            uint           `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	Date          time.Time      `gorm:"not null;uniqueIndex:idx_exchange_rate_pair_date,priority:3" json:"date"`
+	BaseCurrency  money.Currency `gorm:"type:char(3);not null;uniqueIndex:idx_exchange_rate_pair_date,priority:1" json:"base_currency"`
+	QuoteCurrency money.Currency `gorm:"type:char(3);not null;uniqueIndex:idx_exchange_rate_pair_date,priority:2" json:"quote_currency"`
+	Rate          money.Rate     `gorm:"type:decimal(24,10);not null" json:"rate"`
+	Source        string         `gorm:"type:varchar(20)" json:"source"` // manual, csv, ecb
+}
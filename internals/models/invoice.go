@@ -3,32 +3,174 @@ package models
 import (
 	"time"
 
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
 	"gorm.io/gorm"
 )
 
+// TaggableInvoice is the polymorphic type stored on invoice tags and custom field values
+const TaggableInvoice = "invoices"
+
+// Invoice statuses
+const (
+	InvoiceStatusDraft         = "draft"
+	InvoiceStatusSent          = "sent"
+	InvoiceStatusPartiallyPaid = "partially_paid"
+	InvoiceStatusOverdue       = "overdue"
+	InvoiceStatusPaid          = "paid"
+	InvoiceStatusCredited      = "credited"
+	InvoiceStatusVoid          = "void"
+)
+
+// Discount types of invoices and invoice items
+const (
+	DiscountTypePercent = "percent"
+	DiscountTypeFixed   = "fixed"
+)
+
+// InvoiceClosedStatuses are statuses of invoices that are not owed by the customer
+var InvoiceClosedStatuses = []string{InvoiceStatusDraft, InvoiceStatusPaid, InvoiceStatusCredited, InvoiceStatusVoid}
+
+// InvoicePayableStatuses are statuses of invoices that payments can be allocated to
+var InvoicePayableStatuses = []string{InvoiceStatusSent, InvoiceStatusPartiallyPaid, InvoiceStatusOverdue}
+
+// InvoiceTransitions lists the statuses an invoice can be moved to by hand from each status.
+// Sent invoices become partially paid and paid as payments are allocated to them, and move
+// back when those payments are reversed. Unpaid invoices become overdue once their due date
+// has passed. Invoices settled by credit notes alone become credited. Credited and void
+// invoices are final.
+var InvoiceTransitions = map[string][]string{
+	InvoiceStatusDraft:   {InvoiceStatusSent, InvoiceStatusVoid},
+	InvoiceStatusSent:    {InvoiceStatusVoid},
+	InvoiceStatusOverdue: {InvoiceStatusVoid},
+}
+
 type Invoice struct {
-	ID           uint           `gorm:"primarykey" json:"id"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
-	InvoiceNumber string        `gorm:"uniqueIndex;not null" json:"invoice_number"`
-	IssueDate    time.Time      `gorm:"not null" json:"issue_date"`
-	DueDate      time.Time      `gorm:"not null" json:"due_date"`
-	Status       string         `gorm:"type:varchar(20);default:'draft'" json:"status"` // draft, sent, paid, cancelled
-	CustomerID   uint           `gorm:"not null" json:"customer_id"`
-	Customer     Customer       `json:"customer"`
-	Items        []InvoiceItem  `gorm:"foreignKey:InvoiceID" json:"items"`
-	Subtotal     float64        `gorm:"type:decimal(10,2);not null" json:"subtotal"`
-	TaxAmount    float64        `gorm:"type:decimal(10,2);default:0" json:"tax_amount"`
-	Total        float64        `gorm:"type:decimal(10,2);not null" json:"total"`
-	Notes        string         `gorm:"type:text" json:"notes"`
+	ID                 uint                  `gorm:"primarykey" json:"id"`
+	CreatedAt          time.Time             `json:"created_at"`
+	UpdatedAt          time.Time             `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt        `gorm:"index" json:"-"`
+	InvoiceNumber      string                `gorm:"uniqueIndex;not null" json:"invoice_number"`
+	IssueDate          time.Time             `gorm:"not null" json:"issue_date"`
+	DueDate            time.Time             `gorm:"not null" json:"due_date"`
+	Status             string                `gorm:"type:varchar(20);default:'draft'" json:"status"` // draft, sent, partially_paid, overdue, paid, credited, void; changed by transitions, payments, credit notes and the overdue job only
+	CustomerID         uint                  `gorm:"not null" json:"customer_id"`
+	Customer           Customer              `json:"customer"`
+	BillingAddressID   *uint                 `json:"billing_address_id"`
+	BillingAddress     *CustomerAddress      `json:"billing_address,omitempty"`
+	Items              []InvoiceItem         `gorm:"foreignKey:InvoiceID" json:"items"`
+	Currency           money.Currency        `gorm:"type:char(3);not null;default:'USD'" json:"currency"`
+	Subtotal           money.Amount          `gorm:"type:decimal(19,4);not null" json:"subtotal"`
+	DiscountType       string                `gorm:"type:varchar(10)" json:"discount_type,omitempty"` // percent or fixed, spread over the lines before tax
+	DiscountValue      money.Amount          `gorm:"type:decimal(19,4);default:0" json:"discount_value"`
+	DiscountAmount     money.Amount          `gorm:"type:decimal(19,4);default:0" json:"discount_amount"`
+	TaxAmount          money.Amount          `gorm:"type:decimal(19,4);default:0" json:"tax_amount"`
+	TaxLines           []InvoiceTaxLine      `gorm:"foreignKey:InvoiceID" json:"tax_lines"`
+	TaxExempt          bool                  `gorm:"not null;default:false" json:"tax_exempt"` // copied from the customer when the invoice is priced
+	Charges            []InvoiceCharge       `gorm:"foreignKey:InvoiceID" json:"charges"`
+	ChargesTotal       money.Amount          `gorm:"type:decimal(19,4);default:0" json:"charges_total"`
+	Total              money.Amount          `gorm:"type:decimal(19,4);not null" json:"total"`
+	AmountPaid         money.Amount          `gorm:"type:decimal(19,4);not null;default:0" json:"amount_paid"`
+	AmountCredited     money.Amount          `gorm:"type:decimal(19,4);not null;default:0" json:"amount_credited"` // credit notes applied to the invoice
+	AmountDue          money.Amount          `gorm:"type:decimal(19,4);not null;default:0" json:"amount_due"`      // total less amount_paid and amount_credited; zero once void
+	Payments           []PaymentAllocation   `gorm:"foreignKey:InvoiceID" json:"payments,omitempty"`
+	BaseCurrency       money.Currency        `gorm:"type:char(3);index" json:"base_currency"` // reporting currency; base amounts use the rate of the issue date
+	ExchangeRate       money.Rate            `gorm:"type:decimal(24,10)" json:"exchange_rate"`
+	BaseSubtotal       money.Amount          `gorm:"type:decimal(19,4)" json:"base_subtotal"`
+	BaseDiscountAmount money.Amount          `gorm:"type:decimal(19,4);default:0" json:"base_discount_amount"`
+	BaseTaxAmount      money.Amount          `gorm:"type:decimal(19,4)" json:"base_tax_amount"`
+	BaseChargesTotal   money.Amount          `gorm:"type:decimal(19,4);default:0" json:"base_charges_total"`
+	BaseTotal          money.Amount          `gorm:"type:decimal(19,4)" json:"base_total"`
+	Notes              string                `gorm:"type:text" json:"notes"`
+	SentAt             *time.Time            `json:"sent_at"`
+	PaidAt             *time.Time            `json:"paid_at"`
+	VoidedAt           *time.Time            `json:"voided_at"`
+	FirstViewedAt      *time.Time            `json:"first_viewed_at"` // first time the invoice was opened through a share link
+	ViewCount          int                   `gorm:"not null;default:0" json:"view_count"`
+	RecurringInvoiceID *uint                 `gorm:"uniqueIndex:idx_invoices_recurring_period" json:"recurring_invoice_id,omitempty"` // set on invoices generated from a recurring invoice
+	RecurringPeriod    *time.Time            `gorm:"uniqueIndex:idx_invoices_recurring_period" json:"recurring_period,omitempty"`     // start of the period invoiced; each period is invoiced once
+	QuoteID            *uint                 `gorm:"index" json:"quote_id,omitempty"`                                                 // set on invoices converted from a quote
+	ImportedNumber     string                `gorm:"type:varchar(100);index" json:"imported_number,omitempty"`                        // number of the UBL document the invoice was imported from
+	StatusHistory      []InvoiceStatusChange `gorm:"foreignKey:InvoiceID" json:"status_history,omitempty"`
+	Tags               []Tag                 `gorm:"polymorphic:Taggable;polymorphicValue:invoices" json:"tags"`
+	CustomFieldValues  []CustomFieldValue    `gorm:"polymorphic:Entity;polymorphicValue:invoices" json:"-"`
+	CustomFields       CustomFields          `gorm:"-" json:"custom_fields"`
+}
+
+// AfterFind exposes preloaded custom field values as the custom_fields map
+func (i *Invoice) AfterFind(tx *gorm.DB) error {
+	i.CustomFields = customFieldMap(i.CustomFieldValues)
+	return nil
 }
 
+// InvoiceItem is one invoice line. Total is quantity times unit price less the line
+// discount, which includes tax when the line's tax rates are inclusive. NetAmount is the
+// total without tax; TaxAmount is the tax charged after the invoice discount.
 type InvoiceItem struct {
-	ID          uint    `gorm:"primarykey" json:"id"`
-	InvoiceID   uint    `gorm:"not null" json:"invoice_id"`
-	Description string  `gorm:"not null" json:"description"`
-	Quantity    int     `gorm:"not null" json:"quantity"`
-	UnitPrice   float64 `gorm:"type:decimal(10,2);not null" json:"unit_price"`
-	Total       float64 `gorm:"type:decimal(10,2);not null" json:"total"`
-}
\ No newline at end of file
+	ID             uint         `gorm:"primarykey" json:"id"`
+	InvoiceID      uint         `gorm:"not null" json:"invoice_id"`
+	ProductID      *uint        `gorm:"index" json:"product_id,omitempty"` // fills an empty description, unit price and tax rates
+	Description    string       `gorm:"not null" json:"description"`
+	Quantity       int          `gorm:"not null" json:"quantity"`
+	UnitPrice      money.Amount `gorm:"type:decimal(19,4);not null" json:"unit_price"`
+	DiscountType   string       `gorm:"type:varchar(10)" json:"discount_type,omitempty"` // percent or fixed
+	DiscountValue  money.Amount `gorm:"type:decimal(19,4)" json:"discount_value"`
+	DiscountAmount money.Amount `gorm:"type:decimal(19,4)" json:"discount_amount"`
+	TaxRateIDs     UintList     `gorm:"type:text" json:"tax_rate_ids"` // omitted means the default tax rates
+	NetAmount      money.Amount `gorm:"type:decimal(19,4)" json:"net_amount"`
+	TaxAmount      money.Amount `gorm:"type:decimal(19,4)" json:"tax_amount"`
+	Total          money.Amount `gorm:"type:decimal(19,4);not null" json:"total"`
+}
+
+// InvoiceCharge is an untaxed amount added after tax, such as shipping
+type InvoiceCharge struct {
+	ID          uint         `gorm:"primarykey" json:"-"`
+	InvoiceID   uint         `gorm:"not null;index" json:"-"`
+	Description string       `gorm:"not null" json:"description"`
+	Amount      money.Amount `gorm:"type:decimal(19,4);not null" json:"amount"`
+}
+
+// InvoiceStatusChange records one status transition of an invoice. FromStatus is empty
+// for the entry written when the invoice is created.
+type InvoiceStatusChange struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	InvoiceID   uint      `gorm:"not null;index" json:"-"`
+	FromStatus  string    `gorm:"type:varchar(20)" json:"from_status"`
+	ToStatus    string    `gorm:"type:varchar(20);not null" json:"to_status"`
+	ChangedByID *uint     `json:"changed_by_id"`
+	Note        string    `gorm:"type:text" json:"note,omitempty"`
+}
+
+// PastDue reports whether the due date of an invoice, taken as a whole UTC calendar day,
+// ended before now
+func (i *Invoice) PastDue(now time.Time) bool {
+	due := i.DueDate.UTC()
+	return !now.Before(time.Date(due.Year(), due.Month(), due.Day()+1, 0, 0, 0, 0, time.UTC))
+}
+
+// SettledStatus derives the status of a sent invoice from what was paid and credited on
+// it: paid once nothing is due, or credited when credit notes alone settled it; otherwise
+// overdue after the due date, partially paid once something was paid or credited, or sent
+func (i *Invoice) SettledStatus(now time.Time) string {
+	switch {
+	case !i.AmountDue.IsPositive() && i.AmountPaid.IsPositive():
+		return InvoiceStatusPaid
+	case !i.AmountDue.IsPositive():
+		return InvoiceStatusCredited
+	case i.PastDue(now):
+		return InvoiceStatusOverdue
+	case i.AmountPaid.IsPositive() || i.AmountCredited.IsPositive():
+		return InvoiceStatusPartiallyPaid
+	}
+	return InvoiceStatusSent
+}
+
+// CanTransition reports whether an invoice with status from can move to status to
+func CanTransition(from, to string) bool {
+	for _, allowed := range InvoiceTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// InvoiceReminder records one payment reminder of an invoice, identified by its step in the
+// dunning schedule. The row is written before the email is sent, so each step is emailed
+// at most once even with several instances; SentAt stays empty until the email is
+// accepted and Error holds why the last attempt failed.
+type InvoiceReminder struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	InvoiceID   uint       `gorm:"not null;uniqueIndex:idx_invoice_reminders_step" json:"invoice_id"`
+	DaysFromDue int        `gorm:"not null;uniqueIndex:idx_invoice_reminders_step" json:"days_from_due"` // negative before the due date
+	Level       string     `gorm:"type:varchar(20);not null" json:"level"`                               // upcoming, due, overdue or final
+	Recipients  StringList `gorm:"type:text" json:"recipients"`
+	Subject     string     `json:"subject"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	SentAt      *time.Time `json:"sent_at"`
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+}
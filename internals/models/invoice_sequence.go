@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// InvoiceSequence is the counter invoice numbers are taken from. Scope is "all", a year
+// or a year and month, depending on when the invoice number format starts over.
+type InvoiceSequence struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Scope      string    `gorm:"type:varchar(20);uniqueIndex;not null" json:"scope"`
+	LastNumber int64     `gorm:"not null;default:0" json:"last_number"`
+}
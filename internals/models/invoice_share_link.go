@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// InvoiceShareLink lets someone without an account view one invoice until the link
+// expires or is revoked. The link URL carries a token signed with the link ID and expiry,
+// so the token itself is never stored.
+type InvoiceShareLink struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	InvoiceID   uint       `gorm:"not null;index" json:"invoice_id"`
+	ExpiresAt   time.Time  `gorm:"not null" json:"expires_at"`
+	CreatedByID *uint      `json:"created_by_id"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	RevokedByID *uint      `json:"revoked_by_id,omitempty"`
+}
+
+// Active reports whether the link can still be opened at the given time
+func (l InvoiceShareLink) Active(now time.Time) bool {
+	return l.RevokedAt == nil && now.Before(l.ExpiresAt)
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+// Payment methods
+const (
+	PaymentMethodBankTransfer = "bank_transfer"
+	PaymentMethodCard         = "card"
+	PaymentMethodCash         = "cash"
+	PaymentMethodCheque       = "cheque"
+	PaymentMethodOther        = "other"
+)
+
+// PaymentMethods lists the accepted payment methods
+var PaymentMethods = []string{PaymentMethodBankTransfer, PaymentMethodCard, PaymentMethodCash, PaymentMethodCheque, PaymentMethodOther}
+
+// Payment is money received from a customer. It is allocated to one or more invoices of
+// the customer in the same currency; whatever is left unallocated is customer credit.
+// Reversed payments are kept for the record but no longer count towards invoices.
+type Payment struct {
+	ID                uint                `gorm:"primarykey" json:"id"`
+	CreatedAt         time.Time           `json:"created_at"`
+	UpdatedAt         time.Time           `json:"updated_at"`
+	CustomerID        uint                `gorm:"not null;index" json:"customer_id"`
+	Currency          money.Currency      `gorm:"type:char(3);not null" json:"currency"`
+	Amount            money.Amount        `gorm:"type:decimal(19,4);not null" json:"amount"`
+	AllocatedAmount   money.Amount        `gorm:"type:decimal(19,4);not null;default:0" json:"allocated_amount"`
+	UnallocatedAmount money.Amount        `gorm:"type:decimal(19,4);not null;default:0" json:"unallocated_amount"` // customer credit
+	PaymentDate       time.Time           `gorm:"not null;index" json:"payment_date"`
+	Method            string              `gorm:"type:varchar(20);not null" json:"method"`
+	Reference         string              `gorm:"type:varchar(100)" json:"reference"`
+	Notes             string              `gorm:"type:text" json:"notes"`
+	RecordedByID      *uint               `json:"recorded_by_id"`
+	Allocations       []PaymentAllocation `gorm:"foreignKey:PaymentID" json:"allocations"`
+	ReversedAt        *time.Time          `json:"reversed_at"`
+	ReversedByID      *uint               `json:"reversed_by_id,omitempty"`
+	ReversalReason    string              `gorm:"type:text" json:"reversal_reason,omitempty"`
+}
+
+// PaymentAllocation is the part of a payment applied to one invoice. Date is the payment
+// date, or the day customer credit was applied when that was later.
+type PaymentAllocation struct {
+	ID        uint         `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time    `json:"created_at"`
+	PaymentID uint         `gorm:"not null;index" json:"payment_id"`
+	InvoiceID uint         `gorm:"not null;index" json:"invoice_id"`
+	Amount    money.Amount `gorm:"type:decimal(19,4);not null" json:"amount"`
+	Date      time.Time    `gorm:"not null" json:"date"`
+}
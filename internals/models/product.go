@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"gorm.io/gorm"
+)
+
+// Product is a catalog entry invoice lines can be filled from. Lines copy its name,
+// price and tax rate when they are priced, so later changes to the product do not alter
+// existing invoices. Inactive products stay on the lines that use them but cannot fill
+// new ones.
+type Product struct {
+	ID           uint           `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	SKU          string         `gorm:"column:sku;type:varchar(64);uniqueIndex;not null" json:"sku"`
+	Name         string         `gorm:"type:varchar(200);not null;index" json:"name"`
+	Description  string         `gorm:"type:text" json:"description"`
+	Unit         string         `gorm:"type:varchar(20)" json:"unit"` // e.g. hour, day or piece
+	Currency     money.Currency `gorm:"type:char(3);not null" json:"currency"`
+	DefaultPrice money.Amount   `gorm:"type:decimal(19,4);not null" json:"default_price"`
+	TaxRateID    *uint          `json:"tax_rate_id"` // when empty, lines get the default tax rates
+	TaxRate      *TaxRate       `json:"tax_rate,omitempty"`
+	Active       bool           `gorm:"not null;index" json:"active"`
+}
+
+// LineDescription is the description a product gives the invoice lines it fills
+func (p *Product) LineDescription() string {
+	if p.Description != "" {
+		return p.Name + " - " + p.Description
+	}
+	return p.Name
+}
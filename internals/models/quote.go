@@ -0,0 +1,127 @@
+package models
+
+import (
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"gorm.io/gorm"
+)
+
+// Quote statuses
+const (
+	QuoteStatusDraft    = "draft"
+	QuoteStatusSent     = "sent"
+	QuoteStatusAccepted = "accepted"
+	QuoteStatusDeclined = "declined"
+	QuoteStatusExpired  = "expired"
+)
+
+// QuoteStatuses lists every quote status
+var QuoteStatuses = []string{QuoteStatusDraft, QuoteStatusSent, QuoteStatusAccepted, QuoteStatusDeclined, QuoteStatusExpired}
+
+// QuoteTransitions lists the statuses a quote can be moved to from each status. Sent
+// quotes are accepted or declined by the customer, or on their behalf, and become expired
+// once their expiry date has passed. Accepted, declined and expired quotes are final.
+var QuoteTransitions = map[string][]string{
+	QuoteStatusDraft: {QuoteStatusSent},
+	QuoteStatusSent:  {QuoteStatusAccepted, QuoteStatusDeclined, QuoteStatusExpired},
+}
+
+// Quote is an estimate sent to a customer before invoicing. It is priced exactly like an
+// invoice, and an accepted quote is converted into a draft invoice with the same lines.
+type Quote struct {
+	ID               uint             `gorm:"primarykey" json:"id"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt   `gorm:"index" json:"-"`
+	QuoteNumber      string           `gorm:"uniqueIndex;not null" json:"quote_number"`
+	IssueDate        time.Time        `gorm:"not null" json:"issue_date"`
+	ExpiryDate       time.Time        `gorm:"not null;index" json:"expiry_date"`                       // last day the quote can be accepted
+	Status           string           `gorm:"type:varchar(20);not null;default:'draft'" json:"status"` // draft, sent, accepted, declined or expired
+	CustomerID       uint             `gorm:"not null;index" json:"customer_id"`
+	Customer         Customer         `json:"customer"`
+	BillingAddressID *uint            `json:"billing_address_id"`
+	BillingAddress   *CustomerAddress `json:"billing_address,omitempty"`
+	Items            []QuoteItem      `gorm:"foreignKey:QuoteID" json:"items"`
+	Currency         money.Currency   `gorm:"type:char(3);not null" json:"currency"`
+	Subtotal         money.Amount     `gorm:"type:decimal(19,4);not null" json:"subtotal"`
+	DiscountType     string           `gorm:"type:varchar(10)" json:"discount_type,omitempty"`
+	DiscountValue    money.Amount     `gorm:"type:decimal(19,4);default:0" json:"discount_value"`
+	DiscountAmount   money.Amount     `gorm:"type:decimal(19,4);default:0" json:"discount_amount"`
+	TaxAmount        money.Amount     `gorm:"type:decimal(19,4);default:0" json:"tax_amount"`
+	TaxLines         []QuoteTaxLine   `gorm:"foreignKey:QuoteID" json:"tax_lines"`
+	TaxExempt        bool             `gorm:"not null;default:false" json:"tax_exempt"`
+	Charges          []QuoteCharge    `gorm:"foreignKey:QuoteID" json:"charges"`
+	ChargesTotal     money.Amount     `gorm:"type:decimal(19,4);default:0" json:"charges_total"`
+	Total            money.Amount     `gorm:"type:decimal(19,4);not null" json:"total"`
+	Notes            string           `gorm:"type:text" json:"notes"`
+	CustomFields     CustomFields     `gorm:"type:text" json:"custom_fields"` // invoice custom fields copied onto the converted invoice
+	SentAt           *time.Time       `json:"sent_at"`
+	AcceptedAt       *time.Time       `json:"accepted_at"`
+	DeclinedAt       *time.Time       `json:"declined_at"`
+	DeclineReason    string           `gorm:"type:text" json:"decline_reason,omitempty"`
+	ExpiredAt        *time.Time       `json:"expired_at"`
+	RespondedByID    *uint            `json:"responded_by_id"`         // user who recorded the answer; empty when the customer answered on the quote page
+	InvoiceID        *uint            `gorm:"index" json:"invoice_id"` // the invoice the quote was converted into
+	ConvertedAt      *time.Time       `json:"converted_at"`
+	CreatedByID      *uint            `json:"created_by_id"`
+}
+
+// QuoteItem is one quote line, priced like an invoice item
+type QuoteItem struct {
+	ID             uint         `gorm:"primarykey" json:"id"`
+	QuoteID        uint         `gorm:"not null;index" json:"quote_id"`
+	ProductID      *uint        `gorm:"index" json:"product_id,omitempty"`
+	Description    string       `gorm:"not null" json:"description"`
+	Quantity       int          `gorm:"not null" json:"quantity"`
+	UnitPrice      money.Amount `gorm:"type:decimal(19,4);not null" json:"unit_price"`
+	DiscountType   string       `gorm:"type:varchar(10)" json:"discount_type,omitempty"`
+	DiscountValue  money.Amount `gorm:"type:decimal(19,4)" json:"discount_value"`
+	DiscountAmount money.Amount `gorm:"type:decimal(19,4)" json:"discount_amount"`
+	TaxRateIDs     UintList     `gorm:"type:text" json:"tax_rate_ids"`
+	NetAmount      money.Amount `gorm:"type:decimal(19,4)" json:"net_amount"`
+	TaxAmount      money.Amount `gorm:"type:decimal(19,4)" json:"tax_amount"`
+	Total          money.Amount `gorm:"type:decimal(19,4);not null" json:"total"`
+}
+
+// QuoteTaxLine is the total of one tax rate on a quote
+type QuoteTaxLine struct {
+	ID            uint         `gorm:"primarykey" json:"-"`
+	QuoteID       uint         `gorm:"not null;index" json:"-"`
+	TaxRateID     uint         `gorm:"not null" json:"tax_rate_id"`
+	Name          string       `gorm:"type:varchar(100);not null" json:"name"`
+	Rate          money.Amount `gorm:"type:decimal(9,4);not null" json:"rate"`
+	Compound      bool         `gorm:"not null;default:false" json:"compound"`
+	Inclusive     bool         `gorm:"not null;default:false" json:"inclusive"`
+	TaxableAmount money.Amount `gorm:"type:decimal(19,4);not null" json:"taxable_amount"`
+	Amount        money.Amount `gorm:"type:decimal(19,4);not null" json:"amount"`
+}
+
+// QuoteCharge is an untaxed amount added after tax, such as shipping
+type QuoteCharge struct {
+	ID          uint         `gorm:"primarykey" json:"-"`
+	QuoteID     uint         `gorm:"not null;index" json:"-"`
+	Description string       `gorm:"not null" json:"description"`
+	Amount      money.Amount `gorm:"type:decimal(19,4);not null" json:"amount"`
+}
+
+// ExpiresAt is the end of the expiry date of a quote, taken as a whole UTC calendar day
+func (q *Quote) ExpiresAt() time.Time {
+	expiry := q.ExpiryDate.UTC()
+	return time.Date(expiry.Year(), expiry.Month(), expiry.Day()+1, 0, 0, 0, 0, time.UTC)
+}
+
+// Expired reports whether the expiry date of a quote ended before now
+func (q *Quote) Expired(now time.Time) bool {
+	return !now.Before(q.ExpiresAt())
+}
+
+// CanTransitionQuote reports whether a quote with status from can move to status to
+func CanTransitionQuote(from, to string) bool {
+	for _, allowed := range QuoteTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
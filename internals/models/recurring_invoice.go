@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"gorm.io/gorm"
+)
+
+// Recurring invoice intervals
+const (
+	RecurringIntervalWeekly    = "weekly"
+	RecurringIntervalMonthly   = "monthly"
+	RecurringIntervalQuarterly = "quarterly"
+	RecurringIntervalYearly    = "yearly"
+	RecurringIntervalCron      = "cron"
+)
+
+// RecurringIntervals lists the accepted intervals of recurring invoices
+var RecurringIntervals = []string{
+	RecurringIntervalWeekly,
+	RecurringIntervalMonthly,
+	RecurringIntervalQuarterly,
+	RecurringIntervalYearly,
+	RecurringIntervalCron,
+}
+
+// RecurringInvoice is a template that invoices a customer for the same items every period.
+// The first period starts at StartDate; later ones follow the interval, or the cron
+// expression when the interval is cron. NextRunAt is the start of the next period to
+// invoice and is cleared once EndDate has passed.
+type RecurringInvoice struct {
+	ID            uint                   `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt         `gorm:"index" json:"-"`
+	Name          string                 `gorm:"type:varchar(100);not null" json:"name"`
+	CustomerID    uint                   `gorm:"not null;index" json:"customer_id"`
+	Customer      Customer               `json:"customer"`
+	Items         []RecurringInvoiceItem `gorm:"foreignKey:RecurringInvoiceID" json:"items"`
+	Currency      money.Currency         `gorm:"type:char(3)" json:"currency,omitempty"` // empty means the customer's currency
+	DiscountType  string                 `gorm:"type:varchar(10)" json:"discount_type,omitempty"`
+	DiscountValue money.Amount           `gorm:"type:decimal(19,4)" json:"discount_value"`
+	Notes         string                 `gorm:"type:text" json:"notes"`
+	CustomFields  CustomFields           `gorm:"type:text" json:"custom_fields"`            // invoice custom fields copied onto every invoice
+	Interval      string                 `gorm:"type:varchar(20);not null" json:"interval"` // weekly, monthly, quarterly, yearly or cron
+	Cron          string                 `gorm:"type:varchar(100)" json:"cron,omitempty"`
+	StartDate     time.Time              `gorm:"not null" json:"start_date"`
+	EndDate       *time.Time             `json:"end_date"`
+	DueDays       int                    `gorm:"not null" json:"due_days"` // days from the issue date to the due date
+	AutoSend      bool                   `gorm:"not null;default:false" json:"auto_send"`
+	Paused        bool                   `gorm:"not null;default:false" json:"paused"`
+	NextRunAt     *time.Time             `gorm:"index" json:"next_run_at"`
+	LastRunAt     *time.Time             `json:"last_run_at"`
+	LastError     string                 `gorm:"type:text" json:"last_error,omitempty"` // why the last attempt to invoice a period failed
+	CreatedByID   *uint                  `json:"created_by_id"`
+}
+
+// RecurringInvoiceItem is one line copied onto every invoice of a recurring invoice
+type RecurringInvoiceItem struct {
+	ID                 uint         `gorm:"primarykey" json:"-"`
+	RecurringInvoiceID uint         `gorm:"not null;index" json:"-"`
+	Description        string       `gorm:"not null" json:"description"`
+	Quantity           int          `gorm:"not null" json:"quantity"`
+	UnitPrice          money.Amount `gorm:"type:decimal(19,4);not null" json:"unit_price"`
+	DiscountType       string       `gorm:"type:varchar(10)" json:"discount_type,omitempty"`
+	DiscountValue      money.Amount `gorm:"type:decimal(19,4)" json:"discount_value"`
+	TaxRateIDs         *UintList    `gorm:"type:text" json:"tax_rate_ids"` // null means the default tax rates when each invoice is created
+}
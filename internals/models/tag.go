@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tag is a free-form label attached to a record through a polymorphic association
+type Tag struct {
+	ID           uint      `gorm:"primarykey" json:"-"`
+	CreatedAt    time.Time `json:"-"`
+	TaggableID   uint      `gorm:"not null;index:idx_tags_taggable" json:"-"`
+	TaggableType string    `gorm:"type:varchar(50);not null;index:idx_tags_taggable" json:"-"`
+	Name         string    `gorm:"type:varchar(100);not null;index" json:"name"`
+}
+
+// MarshalJSON encodes a tag as its name
+func (t Tag) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Name)
+}
+
+// UnmarshalJSON accepts a tag name or an object with a name
+func (t *Tag) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		t.Name = name
+		return nil
+	}
+
+	var obj struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	t.Name = obj.Name
+	return nil
+}
+
+// NormalizeTagName trims and lowercases a tag name
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// NormalizeTags trims, lowercases, sorts and de-duplicates tags, dropping empty names
+func NormalizeTags(tags []Tag) []Tag {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]Tag, 0, len(tags))
+	for _, tag := range tags {
+		name := NormalizeTagName(tag.Name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		normalized = append(normalized, Tag{Name: name})
+	}
+	sort.Slice(normalized, func(i, j int) bool { return normalized[i].Name < normalized[j].Name })
+	return normalized
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"gorm.io/gorm"
+)
+
+// TaxRate is a named tax percentage that can be applied to invoice lines.
+//
+// Non-compound rates are charged on the net line amount; compound rates are charged on the
+// net amount plus the taxes applied before them. Inclusive rates are already part of the
+// unit price, so the tax is extracted from the line amount instead of added to it.
+type TaxRate struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	Name      string         `gorm:"type:varchar(100);not null" json:"name"`
+	Rate      money.Amount   `gorm:"type:decimal(9,4);not null" json:"rate"` // percentage, e.g. 20 for 20%
+	Compound  bool           `gorm:"not null;default:false" json:"compound"`
+	Inclusive bool           `gorm:"not null;default:false" json:"inclusive"`
+	IsDefault bool           `gorm:"not null;default:false" json:"is_default"` // applied to lines that do not list tax rates
+}
+
+// InvoiceTaxLine is the total of one tax rate on an invoice. Name, rate and flags are
+// copied from the tax rate so later changes to the rate do not alter issued invoices.
+type InvoiceTaxLine struct {
+	ID            uint         `gorm:"primarykey" json:"-"`
+	InvoiceID     uint         `gorm:"not null;index" json:"-"`
+	TaxRateID     uint         `gorm:"not null" json:"tax_rate_id"`
+	Name          string       `gorm:"type:varchar(100);not null" json:"name"`
+	Rate          money.Amount `gorm:"type:decimal(9,4);not null" json:"rate"`
+	Compound      bool         `gorm:"not null;default:false" json:"compound"`
+	Inclusive     bool         `gorm:"not null;default:false" json:"inclusive"`
+	TaxableAmount money.Amount `gorm:"type:decimal(19,4);not null" json:"taxable_amount"`
+	Amount        money.Amount `gorm:"type:decimal(19,4);not null" json:"amount"`
+}
+
+// UintList is a list of IDs stored as a JSON array
+type UintList []uint
+
+// Value implements driver.Valuer
+func (l UintList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal([]uint(l))
+	return string(data), err
+}
+
+// Scan implements sql.Scanner
+func (l *UintList) Scan(src interface{}) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into UintList", src)
+	}
+	if len(data) == 0 {
+		*l = nil
+		return nil
+	}
+	return json.Unmarshal(data, (*[]uint)(l))
+}
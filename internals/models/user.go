@@ -1,6 +1,7 @@
 package models
 
 import (
+	"sort"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -19,8 +20,9 @@ type User struct {
 	LastLogin *time.Time     `json:"last_login,omitempty"`
 	Roles     []Role         `gorm:"many2many:user_roles;" json:"roles,omitempty"`
 
-	// Legacy field for backward compatibility
-	Role string `gorm:"type:varchar(20);default:'user'" json:"role"`
+	// Legacy role name, converted into user_roles rows by UserService.MigrateLegacyRoles
+	// and cleared afterwards. Access checks use Roles only.
+	Role string `gorm:"type:varchar(20)" json:"-"`
 }
 
 func (u *User) CheckPassword(password string) error {
@@ -49,20 +51,98 @@ func (u *User) HasRole(roleName string) bool {
 	return false
 }
 
-// HasPermission checks if user has a specific permission
-func (u *User) HasPermission(resource, action string) bool {
+// Deny rules reported when a permission check fails
+const (
+	DenyRuleNoMatchingGrant = "no_matching_grant"
+	DenyRuleRoleInactive    = "granting_roles_inactive"
+	DenyRuleUserInactive    = "user_inactive"
+)
+
+// PermissionMatch is a grant that satisfies a permission check
+type PermissionMatch struct {
+	Role       string `json:"role"`
+	Permission string `json:"permission"`
+	ViaManage  bool   `json:"via_manage"`
+}
+
+// PermissionDecision explains the outcome of a permission check
+type PermissionDecision struct {
+	Resource     string            `json:"resource"`
+	Action       string            `json:"action"`
+	Allowed      bool              `json:"allowed"`
+	MatchedBy    []PermissionMatch `json:"matched_by"`
+	SkippedRoles []PermissionMatch `json:"skipped_roles"`
+	DenyRule     string            `json:"deny_rule,omitempty"`
+}
+
+// PermissionSource is a role a permission is granted through
+type PermissionSource struct {
+	Role       string `json:"role"`
+	RoleActive bool   `json:"role_active"`
+}
+
+// EffectivePermission is a permission together with every role that grants it.
+// Effective is false when all granting roles are inactive, or the user is.
+type EffectivePermission struct {
+	Permission Permission         `json:"permission"`
+	Effective  bool               `json:"effective"`
+	Sources    []PermissionSource `json:"sources"`
+}
+
+// permissionMatches reports whether a permission satisfies resource/action and whether it did so through manage
+func permissionMatches(permission Permission, resource, action string) (bool, bool) {
+	if permission.Resource != resource {
+		return false, false
+	}
+	if permission.Action == action {
+		return true, false
+	}
+	return permission.Action == ActionManage, true
+}
+
+// ExplainPermission evaluates a permission check and records which grants
+// matched, which inactive roles were skipped and which deny rule applied. Inactive
+// users are denied whatever their grants; the matching grants are still listed.
+func (u *User) ExplainPermission(resource, action string) PermissionDecision {
+	decision := PermissionDecision{
+		Resource:     resource,
+		Action:       action,
+		MatchedBy:    []PermissionMatch{},
+		SkippedRoles: []PermissionMatch{},
+	}
+
 	for _, role := range u.Roles {
-		if !role.IsActive {
-			continue
-		}
 		for _, permission := range role.Permissions {
-			if permission.Resource == resource &&
-				(permission.Action == action || permission.Action == ActionManage) {
-				return true
+			ok, viaManage := permissionMatches(permission, resource, action)
+			if !ok {
+				continue
+			}
+			match := PermissionMatch{Role: role.Name, Permission: permission.Name, ViaManage: viaManage}
+			if role.IsActive {
+				decision.MatchedBy = append(decision.MatchedBy, match)
+			} else {
+				decision.SkippedRoles = append(decision.SkippedRoles, match)
 			}
 		}
 	}
-	return false
+
+	decision.Allowed = u.IsActive && len(decision.MatchedBy) > 0
+	switch {
+	case decision.Allowed:
+	case !u.IsActive:
+		decision.DenyRule = DenyRuleUserInactive
+	case len(decision.SkippedRoles) > 0:
+		decision.DenyRule = DenyRuleRoleInactive
+	default:
+		decision.DenyRule = DenyRuleNoMatchingGrant
+	}
+
+	return decision
+}
+
+// HasPermission checks if user has a specific permission; inactive users have none
+func (u *User) HasPermission(resource, action string) bool {
+	return u.ExplainPermission(resource, action).Allowed
 }
 
 // GetPermissions returns all permissions for the user
@@ -86,6 +166,34 @@ func (u *User) GetPermissions() []Permission {
 	return permissions
 }
 
+// GetEffectivePermissions returns every permission granted to the user, including
+// those only granted through inactive roles, with the roles they come from. None is
+// effective while the user is inactive.
+func (u *User) GetEffectivePermissions() []EffectivePermission {
+	index := make(map[uint]int)
+	var effective []EffectivePermission
+
+	for _, role := range u.Roles {
+		for _, permission := range role.Permissions {
+			i, ok := index[permission.ID]
+			if !ok {
+				i = len(effective)
+				index[permission.ID] = i
+				effective = append(effective, EffectivePermission{Permission: permission})
+			}
+			effective[i].Sources = append(effective[i].Sources, PermissionSource{Role: role.Name, RoleActive: role.IsActive})
+			if role.IsActive && u.IsActive {
+				effective[i].Effective = true
+			}
+		}
+	}
+
+	sort.Slice(effective, func(i, j int) bool {
+		return effective[i].Permission.Name < effective[j].Permission.Name
+	})
+	return effective
+}
+
 // IsAdmin checks if user is an admin
 func (u *User) IsAdmin() bool {
 	return u.HasRole(RoleAdmin)
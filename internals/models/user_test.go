@@ -0,0 +1,27 @@
+package models
+
+import "testing"
+
+// An inactive user keeps its roles on record but none of their permissions apply
+func TestInactiveUserHasNoEffectivePermissions(t *testing.T) {
+	read := Permission{ID: 1, Name: "invoice:read", Resource: ResourceInvoice, Action: ActionRead}
+	user := User{
+		IsActive: true,
+		Roles:    []Role{{Name: "accountant", IsActive: true, Permissions: []Permission{read}}},
+	}
+	if !user.HasPermission(ResourceInvoice, ActionRead) || !user.GetEffectivePermissions()[0].Effective {
+		t.Fatal("an active user lacks the permission of an active role")
+	}
+
+	user.IsActive = false
+	if user.HasPermission(ResourceInvoice, ActionRead) {
+		t.Error("an inactive user has a permission")
+	}
+	effective := user.GetEffectivePermissions()
+	if len(effective) != 1 || effective[0].Effective {
+		t.Errorf("effective permissions of an inactive user = %+v; want the grant listed as not effective", effective)
+	}
+	if len(effective) == 1 && (len(effective[0].Sources) != 1 || !effective[0].Sources[0].RoleActive) {
+		t.Errorf("sources = %+v; want the active accountant role", effective[0].Sources)
+	}
+}
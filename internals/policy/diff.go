@@ -0,0 +1,172 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChangeType is the kind of change needed to reach the desired policy
+type ChangeType string
+
+const (
+	ChangeCreate ChangeType = "create"
+	ChangeUpdate ChangeType = "update"
+	ChangeDelete ChangeType = "delete"
+)
+
+// Objects a change can apply to
+const (
+	ObjectPermission = "permission"
+	ObjectRole       = "role"
+	ObjectGrant      = "grant"
+)
+
+// Change is a single difference between the current and desired policy
+type Change struct {
+	Type       ChangeType `json:"type"`
+	Object     string     `json:"object"`
+	Name       string     `json:"name"`
+	Permission string     `json:"permission,omitempty"`
+	Detail     string     `json:"detail,omitempty"`
+}
+
+// String renders the change as a single diff line
+func (c Change) String() string {
+	symbol := map[ChangeType]string{ChangeCreate: "+", ChangeUpdate: "~", ChangeDelete: "-"}[c.Type]
+	line := fmt.Sprintf("%s %s %s", symbol, c.Object, c.Name)
+	if c.Object == ObjectGrant {
+		line = fmt.Sprintf("%s %s %s -> %s", symbol, c.Object, c.Name, c.Permission)
+	}
+	if c.Detail != "" {
+		line += " (" + c.Detail + ")"
+	}
+	return line
+}
+
+// DiffOptions controls which changes Diff reports
+type DiffOptions struct {
+	// Prune reports deletions for permissions and roles missing from the desired policy
+	Prune bool
+	// CreateOnly only reports missing permissions and roles, the grants of roles being
+	// created and the grants of existing roles on permissions being created, so that a
+	// role granted "*" or "<resource>:*" also receives permissions added later
+	CreateOnly bool
+}
+
+// Diff compares the current state against the desired policy. The desired
+// policy is expected to be valid; its wildcard grants are expanded.
+func Diff(current, desired *Policy, opts DiffOptions) ([]Change, error) {
+	var changes []Change
+
+	for _, want := range desired.Permissions {
+		have := current.Permission(want.Name)
+		if have == nil {
+			changes = append(changes, Change{Type: ChangeCreate, Object: ObjectPermission, Name: want.Name})
+			continue
+		}
+		if opts.CreateOnly {
+			continue
+		}
+		if detail := permissionDiff(*have, want); detail != "" {
+			changes = append(changes, Change{Type: ChangeUpdate, Object: ObjectPermission, Name: want.Name, Detail: detail})
+		}
+	}
+
+	for _, want := range desired.Roles {
+		grants, err := desired.ResolveGrants(want)
+		if err != nil {
+			return nil, err
+		}
+
+		have := current.Role(want.Name)
+		if have == nil {
+			changes = append(changes, Change{Type: ChangeCreate, Object: ObjectRole, Name: want.Name})
+			for _, grant := range grants {
+				changes = append(changes, Change{Type: ChangeCreate, Object: ObjectGrant, Name: want.Name, Permission: grant})
+			}
+			continue
+		}
+		if opts.CreateOnly {
+			for _, grant := range grants {
+				if current.Permission(grant) == nil {
+					changes = append(changes, Change{Type: ChangeCreate, Object: ObjectGrant, Name: want.Name, Permission: grant})
+				}
+			}
+			continue
+		}
+		if detail := roleDiff(*have, want); detail != "" {
+			changes = append(changes, Change{Type: ChangeUpdate, Object: ObjectRole, Name: want.Name, Detail: detail})
+		}
+
+		existing := toSet(have.Permissions)
+		wanted := toSet(grants)
+		for _, grant := range grants {
+			if !existing[grant] {
+				changes = append(changes, Change{Type: ChangeCreate, Object: ObjectGrant, Name: want.Name, Permission: grant})
+			}
+		}
+		for _, grant := range sortedKeys(existing) {
+			if !wanted[grant] {
+				changes = append(changes, Change{Type: ChangeDelete, Object: ObjectGrant, Name: want.Name, Permission: grant})
+			}
+		}
+	}
+
+	if opts.Prune && !opts.CreateOnly {
+		for _, have := range current.Roles {
+			if desired.Role(have.Name) == nil {
+				changes = append(changes, Change{Type: ChangeDelete, Object: ObjectRole, Name: have.Name})
+			}
+		}
+		for _, have := range current.Permissions {
+			if desired.Permission(have.Name) == nil {
+				changes = append(changes, Change{Type: ChangeDelete, Object: ObjectPermission, Name: have.Name})
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func permissionDiff(have, want PermissionSpec) string {
+	var fields []string
+	if have.Resource != want.Resource {
+		fields = append(fields, fmt.Sprintf("resource %q -> %q", have.Resource, want.Resource))
+	}
+	if have.Action != want.Action {
+		fields = append(fields, fmt.Sprintf("action %q -> %q", have.Action, want.Action))
+	}
+	if have.Description != want.Description {
+		fields = append(fields, fmt.Sprintf("description %q -> %q", have.Description, want.Description))
+	}
+	return strings.Join(fields, ", ")
+}
+
+func roleDiff(have, want RoleSpec) string {
+	var fields []string
+	if have.Description != want.Description {
+		fields = append(fields, fmt.Sprintf("description %q -> %q", have.Description, want.Description))
+	}
+	if have.IsActive() != want.IsActive() {
+		fields = append(fields, fmt.Sprintf("active %t -> %t", have.IsActive(), want.IsActive()))
+	}
+	return strings.Join(fields, ", ")
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,55 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+)
+
+// In create-only mode new permissions are granted to existing roles whose grants cover
+// them, and grants missing from existing permissions are left alone
+func TestDiffCreateOnlyGrantsNewPermissions(t *testing.T) {
+	current := &Policy{
+		Version: CurrentVersion,
+		Permissions: []PermissionSpec{
+			{Name: "invoice:read", Resource: "invoice", Action: "read"},
+		},
+		Roles: []RoleSpec{
+			{Name: "admin", Permissions: []string{}},
+			{Name: "clerk", Permissions: []string{}},
+		},
+	}
+	desired := &Policy{
+		Version: CurrentVersion,
+		Permissions: []PermissionSpec{
+			{Name: "invoice:read", Resource: "invoice", Action: "read"},
+			{Name: "invoice:void", Resource: "invoice", Action: "void"},
+			{Name: "report:read", Resource: "report", Action: "read"},
+		},
+		Roles: []RoleSpec{
+			{Name: "admin", Permissions: []string{"*"}},
+			{Name: "clerk", Permissions: []string{"invoice:*"}},
+			{Name: "auditor", Permissions: []string{"report:read"}},
+		},
+	}
+
+	changes, err := Diff(current, desired, DiffOptions{CreateOnly: true})
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	var lines []string
+	for _, change := range changes {
+		lines = append(lines, change.String())
+	}
+	want := []string{
+		"+ permission invoice:void",
+		"+ permission report:read",
+		"+ grant admin -> invoice:void",
+		"+ grant admin -> report:read",
+		"+ grant clerk -> invoice:void",
+		"+ role auditor",
+		"+ grant auditor -> report:read",
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("changes\n%v\nwant\n%v", lines, want)
+	}
+}
@@ -0,0 +1,212 @@
+package policy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Supported policy file formats
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// CurrentVersion is the policy file version written by Export
+const CurrentVersion = 1
+
+//go:embed default_policy.yaml
+var defaultPolicy []byte
+
+// Policy describes permissions, roles and role→permission grants as code
+type Policy struct {
+	Version     int              `json:"version" yaml:"version"`
+	Permissions []PermissionSpec `json:"permissions" yaml:"permissions"`
+	Roles       []RoleSpec       `json:"roles" yaml:"roles"`
+}
+
+// PermissionSpec describes a single permission
+type PermissionSpec struct {
+	Name        string `json:"name" yaml:"name"`
+	Resource    string `json:"resource" yaml:"resource"`
+	Action      string `json:"action" yaml:"action"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// RoleSpec describes a role and the permission names granted to it.
+// Grants may use "*" for every permission or "<resource>:*" for every
+// permission on a resource.
+type RoleSpec struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Active      *bool    `json:"active,omitempty" yaml:"active,omitempty"`
+	Permissions []string `json:"permissions" yaml:"permissions"`
+}
+
+// IsActive reports whether the role should be active, defaulting to true
+func (r RoleSpec) IsActive() bool {
+	return r.Active == nil || *r.Active
+}
+
+// Default returns the embedded default policy
+func Default() (*Policy, error) {
+	return Parse(defaultPolicy, FormatYAML)
+}
+
+// FormatFromPath guesses the policy format from a file extension
+func FormatFromPath(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// Parse decodes and validates a policy document
+func Parse(data []byte, format string) (*Policy, error) {
+	var p Policy
+	switch format {
+	case FormatJSON:
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("failed to parse policy: %w", err)
+		}
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("failed to parse policy: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported policy format: %s", format)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Marshal encodes the policy in the given format
+func (p *Policy) Marshal(format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(p, "", "  ")
+	case FormatYAML:
+		return yaml.Marshal(p)
+	default:
+		return nil, fmt.Errorf("unsupported policy format: %s", format)
+	}
+}
+
+// Validate checks the policy for duplicates and dangling grants
+func (p *Policy) Validate() error {
+	if p.Version != 0 && p.Version != CurrentVersion {
+		return fmt.Errorf("unsupported policy version: %d", p.Version)
+	}
+
+	permissionNames := make(map[string]bool, len(p.Permissions))
+	resourceActions := make(map[string]bool, len(p.Permissions))
+	for _, perm := range p.Permissions {
+		if perm.Name == "" || perm.Resource == "" || perm.Action == "" {
+			return errors.New("permission name, resource and action are required")
+		}
+		if permissionNames[perm.Name] {
+			return fmt.Errorf("duplicate permission %s", perm.Name)
+		}
+		key := perm.Resource + ":" + perm.Action
+		if resourceActions[key] {
+			return fmt.Errorf("duplicate permission for resource %s and action %s", perm.Resource, perm.Action)
+		}
+		permissionNames[perm.Name] = true
+		resourceActions[key] = true
+	}
+
+	roleNames := make(map[string]bool, len(p.Roles))
+	for _, role := range p.Roles {
+		if role.Name == "" {
+			return errors.New("role name is required")
+		}
+		if roleNames[role.Name] {
+			return fmt.Errorf("duplicate role %s", role.Name)
+		}
+		roleNames[role.Name] = true
+
+		if _, err := p.ResolveGrants(role); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ResolveGrants expands wildcard grants of a role into sorted permission names
+func (p *Policy) ResolveGrants(role RoleSpec) ([]string, error) {
+	granted := make(map[string]bool)
+	for _, grant := range role.Permissions {
+		switch {
+		case grant == "*":
+			for _, perm := range p.Permissions {
+				granted[perm.Name] = true
+			}
+		case strings.HasSuffix(grant, ":*"):
+			resource := strings.TrimSuffix(grant, ":*")
+			matched := false
+			for _, perm := range p.Permissions {
+				if perm.Resource == resource {
+					granted[perm.Name] = true
+					matched = true
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("role %s grants unknown resource %s", role.Name, resource)
+			}
+		default:
+			if p.Permission(grant) == nil {
+				return nil, fmt.Errorf("role %s grants unknown permission %s", role.Name, grant)
+			}
+			granted[grant] = true
+		}
+	}
+
+	names := make([]string, 0, len(granted))
+	for name := range granted {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Permission returns the permission with the given name, or nil
+func (p *Policy) Permission(name string) *PermissionSpec {
+	for i := range p.Permissions {
+		if p.Permissions[i].Name == name {
+			return &p.Permissions[i]
+		}
+	}
+	return nil
+}
+
+// Role returns the role with the given name, or nil
+func (p *Policy) Role(name string) *RoleSpec {
+	for i := range p.Roles {
+		if p.Roles[i].Name == name {
+			return &p.Roles[i]
+		}
+	}
+	return nil
+}
+
+// Normalize sorts permissions, roles and grants so exports are stable
+func (p *Policy) Normalize() {
+	if p.Version == 0 {
+		p.Version = CurrentVersion
+	}
+	sort.Slice(p.Permissions, func(i, j int) bool { return p.Permissions[i].Name < p.Permissions[j].Name })
+	sort.Slice(p.Roles, func(i, j int) bool { return p.Roles[i].Name < p.Roles[j].Name })
+	for i := range p.Roles {
+		sort.Strings(p.Roles[i].Permissions)
+	}
+}
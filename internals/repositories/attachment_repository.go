@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"gorm.io/gorm"
+)
+
+type AttachmentRepository interface {
+	WithTx(tx *gorm.DB) AttachmentRepository
+	Create(attachment *models.Attachment) error
+	FindByID(parentType string, parentID, id uint) (*models.Attachment, error)
+	FindByParent(parentType string, parentID uint) ([]models.Attachment, error)
+	Delete(attachment *models.Attachment) error
+	ReassignParent(parentType string, fromParentID, toParentID uint) (int64, error)
+}
+
+type attachmentRepository struct {
+	db *gorm.DB
+}
+
+// NewAttachmentRepository creates a new AttachmentRepository instance
+func NewAttachmentRepository(db *gorm.DB) AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *attachmentRepository) WithTx(tx *gorm.DB) AttachmentRepository {
+	return &attachmentRepository{db: tx}
+}
+
+// Create inserts a new attachment
+func (r *attachmentRepository) Create(attachment *models.Attachment) error {
+	return r.db.Create(attachment).Error
+}
+
+// FindByID retrieves an attachment that belongs to the given parent
+func (r *attachmentRepository) FindByID(parentType string, parentID, id uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.db.Where("parent_type = ? AND parent_id = ?", parentType, parentID).First(&attachment, id).Error
+	return &attachment, err
+}
+
+// FindByParent returns the attachments of a parent, newest first
+func (r *attachmentRepository) FindByParent(parentType string, parentID uint) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.Where("parent_type = ? AND parent_id = ?", parentType, parentID).
+		Order("created_at DESC").
+		Order("id DESC").
+		Find(&attachments).Error
+	return attachments, err
+}
+
+// Delete removes an attachment; its file is removed from storage by the caller
+func (r *attachmentRepository) Delete(attachment *models.Attachment) error {
+	return r.db.Delete(attachment).Error
+}
+
+// ReassignParent moves every attachment of one parent to another of the same type
+func (r *attachmentRepository) ReassignParent(parentType string, fromParentID, toParentID uint) (int64, error) {
+	result := r.db.Model(&models.Attachment{}).
+		Where("parent_type = ? AND parent_id = ?", parentType, fromParentID).
+		Update("parent_id", toParentID)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,179 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CreditNoteRepository interface {
+	WithTx(tx *gorm.DB) CreditNoteRepository
+	Create(note *models.CreditNote) error
+	CreateAllocations(allocations []models.CreditNoteAllocation) error
+	CreateRefund(refund *models.CreditNoteRefund) error
+	FindByID(id uint) (*models.CreditNote, error)
+	FindByIDForUpdate(id uint) (*models.CreditNote, error)
+	FindAll(customerID, invoiceID uint, page, limit int) ([]models.CreditNote, int64, error)
+	Update(note *models.CreditNote) error
+	FindByInvoice(invoiceID uint) ([]models.CreditNote, error)
+	FindInvoiceAllocations(invoiceID uint) ([]models.CreditNoteAllocation, error)
+	FindLedgerCreditNotes(customerID uint, currency money.Currency, before time.Time) ([]models.CreditNote, error)
+	FindForReport(filter InvoiceReportFilter) ([]models.CreditNote, error)
+	ReassignCustomer(fromCustomerID, toCustomerID uint) (int64, error)
+}
+
+type creditNoteRepository struct {
+	db *gorm.DB
+}
+
+// NewCreditNoteRepository creates a new CreditNoteRepository instance
+func NewCreditNoteRepository(db *gorm.DB) CreditNoteRepository {
+	return &creditNoteRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *creditNoteRepository) WithTx(tx *gorm.DB) CreditNoteRepository {
+	return &creditNoteRepository{db: tx}
+}
+
+// Create inserts a credit note together with its items and allocations
+func (r *creditNoteRepository) Create(note *models.CreditNote) error {
+	return r.db.Create(note).Error
+}
+
+// CreateAllocations inserts allocations of an existing credit note
+func (r *creditNoteRepository) CreateAllocations(allocations []models.CreditNoteAllocation) error {
+	if len(allocations) == 0 {
+		return nil
+	}
+	return r.db.Create(&allocations).Error
+}
+
+// CreateRefund inserts a refund of an existing credit note
+func (r *creditNoteRepository) CreateRefund(refund *models.CreditNoteRefund) error {
+	return r.db.Create(refund).Error
+}
+
+// FindByID retrieves a credit note by its ID, including its items, allocations and refunds
+func (r *creditNoteRepository) FindByID(id uint) (*models.CreditNote, error) {
+	return findCreditNote(r.db, id)
+}
+
+// FindByIDForUpdate is FindByID that also locks the credit note row for the rest of the
+// transaction, so its remaining credit is spent one request at a time
+func (r *creditNoteRepository) FindByIDForUpdate(id uint) (*models.CreditNote, error) {
+	return findCreditNote(forUpdate(r.db), id)
+}
+
+func findCreditNote(db *gorm.DB, id uint) (*models.CreditNote, error) {
+	var note models.CreditNote
+	err := db.Preload("Items", orderedByID).
+		Preload("Allocations", orderedAllocations).
+		Preload("Refunds", orderedRefunds).
+		First(&note, id).Error
+	return &note, err
+}
+
+// FindAll returns a paginated list of credit notes, newest first, optionally for one
+// customer or one credited invoice
+func (r *creditNoteRepository) FindAll(customerID, invoiceID uint, page, limit int) ([]models.CreditNote, int64, error) {
+	var notes []models.CreditNote
+	var total int64
+
+	query := r.db.Model(&models.CreditNote{})
+	if customerID != 0 {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if invoiceID != 0 {
+		query = query.Where("invoice_id = ?", invoiceID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Items", orderedByID).
+		Preload("Allocations", orderedAllocations).
+		Preload("Refunds", orderedRefunds).
+		Order("issue_date DESC").
+		Order("id DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&notes).Error
+	return notes, total, err
+}
+
+// Update saves the amounts and status of a credit note, leaving its items, allocations and
+// refunds untouched
+func (r *creditNoteRepository) Update(note *models.CreditNote) error {
+	return r.db.Omit(clause.Associations).Save(note).Error
+}
+
+// FindByInvoice returns the credit notes of an invoice with their items, oldest first
+func (r *creditNoteRepository) FindByInvoice(invoiceID uint) ([]models.CreditNote, error) {
+	var notes []models.CreditNote
+	err := r.db.Preload("Items", orderedByID).
+		Where("invoice_id = ?", invoiceID).
+		Order("id ASC").
+		Find(&notes).Error
+	return notes, err
+}
+
+// FindInvoiceAllocations returns the credit note allocations to an invoice
+func (r *creditNoteRepository) FindInvoiceAllocations(invoiceID uint) ([]models.CreditNoteAllocation, error) {
+	var allocations []models.CreditNoteAllocation
+	err := orderedAllocations(r.db.Where("invoice_id = ?", invoiceID)).Find(&allocations).Error
+	return allocations, err
+}
+
+// FindLedgerCreditNotes returns the credit notes of a customer in one currency issued
+// before the given time, with their allocations and refunds, ordered by issue date
+func (r *creditNoteRepository) FindLedgerCreditNotes(customerID uint, currency money.Currency, before time.Time) ([]models.CreditNote, error) {
+	var notes []models.CreditNote
+	err := r.db.Preload("Allocations", orderedAllocations).
+		Preload("Refunds", orderedRefunds).
+		Where("customer_id = ?", customerID).
+		Where("currency = ?", currency).
+		Where("issue_date < ?", before).
+		Order("issue_date ASC").
+		Order("id ASC").
+		Find(&notes).Error
+	return notes, err
+}
+
+// FindForReport returns the credit notes issued in the period of a report filter with the
+// customer names, including customers that were deleted since. Statuses of the filter
+// are invoice statuses and do not apply.
+func (r *creditNoteRepository) FindForReport(filter InvoiceReportFilter) ([]models.CreditNote, error) {
+	var notes []models.CreditNote
+
+	query := applyDateRange(r.db, "issue_date", filter.IssueDate)
+	if filter.CustomerID != nil {
+		query = query.Where("customer_id = ?", *filter.CustomerID)
+	}
+
+	err := query.
+		Preload("Customer", func(db *gorm.DB) *gorm.DB { return db.Unscoped().Select("id", "name") }).
+		Order("issue_date ASC").
+		Order("id ASC").
+		Find(&notes).Error
+	return notes, err
+}
+
+// ReassignCustomer moves every credit note to another customer
+func (r *creditNoteRepository) ReassignCustomer(fromCustomerID, toCustomerID uint) (int64, error) {
+	result := r.db.Model(&models.CreditNote{}).
+		Where("customer_id = ?", fromCustomerID).
+		Update("customer_id", toCustomerID)
+	return result.RowsAffected, result.Error
+}
+
+func orderedByID(db *gorm.DB) *gorm.DB {
+	return db.Order("id ASC")
+}
+
+func orderedRefunds(db *gorm.DB) *gorm.DB {
+	return db.Order("refund_date ASC").Order("id ASC")
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"gorm.io/gorm"
+)
+
+type CustomFieldRepository interface {
+	Create(definition *models.CustomFieldDefinition) error
+	FindByID(id uint) (*models.CustomFieldDefinition, error)
+	FindByKey(entityType, key string) (*models.CustomFieldDefinition, error)
+	FindAll(entityType string) ([]models.CustomFieldDefinition, error)
+	Update(definition *models.CustomFieldDefinition) error
+	Delete(id uint) error
+	CountValues(definitionID uint) (int64, error)
+}
+
+type customFieldRepository struct {
+	db *gorm.DB
+}
+
+// NewCustomFieldRepository creates a new CustomFieldRepository instance
+func NewCustomFieldRepository(db *gorm.DB) CustomFieldRepository {
+	return &customFieldRepository{db: db}
+}
+
+// Create inserts a new custom field definition
+func (r *customFieldRepository) Create(definition *models.CustomFieldDefinition) error {
+	return r.db.Create(definition).Error
+}
+
+// FindByID retrieves a custom field definition by ID
+func (r *customFieldRepository) FindByID(id uint) (*models.CustomFieldDefinition, error) {
+	var definition models.CustomFieldDefinition
+	err := r.db.First(&definition, id).Error
+	return &definition, err
+}
+
+// FindByKey retrieves the custom field definition of an entity type by key
+func (r *customFieldRepository) FindByKey(entityType, key string) (*models.CustomFieldDefinition, error) {
+	var definition models.CustomFieldDefinition
+	err := r.db.Where("entity_type = ? AND field_key = ?", entityType, key).First(&definition).Error
+	return &definition, err
+}
+
+// FindAll returns the custom field definitions, optionally of one entity type, in display order
+func (r *customFieldRepository) FindAll(entityType string) ([]models.CustomFieldDefinition, error) {
+	var definitions []models.CustomFieldDefinition
+	query := r.db.Model(&models.CustomFieldDefinition{})
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	err := query.Order("entity_type ASC").Order("position ASC").Order("id ASC").Find(&definitions).Error
+	return definitions, err
+}
+
+// Update saves changes to an existing custom field definition
+func (r *customFieldRepository) Update(definition *models.CustomFieldDefinition) error {
+	return r.db.Save(definition).Error
+}
+
+// Delete permanently removes a custom field definition together with its values
+func (r *customFieldRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("definition_id = ?", id).Delete(&models.CustomFieldValue{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.CustomFieldDefinition{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// CountValues counts the records that have a value for the definition
+func (r *customFieldRepository) CountValues(definitionID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.CustomFieldValue{}).Where("definition_id = ?", definitionID).Count(&count).Error
+	return count, err
+}
+
+// replaceCustomFieldValues swaps the custom field values of a record for the given set.
+// A nil slice means the values were not loaded or changed and leaves them untouched.
+func replaceCustomFieldValues(tx *gorm.DB, entityType string, entityID uint, values []models.CustomFieldValue) error {
+	if values == nil {
+		return nil
+	}
+	if err := tx.Where("entity_type = ? AND entity_id = ?", entityType, entityID).Delete(&models.CustomFieldValue{}).Error; err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	rows := make([]models.CustomFieldValue, len(values))
+	for i, value := range values {
+		rows[i] = models.CustomFieldValue{EntityType: entityType, EntityID: entityID, DefinitionID: value.DefinitionID, Value: value.Value}
+	}
+	return tx.Omit("Definition").Create(&rows).Error
+}
+
+// applyCustomFieldFilter requires a record to have every given custom field value.
+// Values must already be encoded the way they are stored.
+func applyCustomFieldFilter(query *gorm.DB, table, entityType string, values map[string]string) *gorm.DB {
+	for key, value := range values {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM custom_field_values JOIN custom_field_definitions ON custom_field_definitions.id = custom_field_values.definition_id"+
+				" WHERE custom_field_values.entity_type = ? AND custom_field_values.entity_id = "+table+".id"+
+				" AND custom_field_definitions.field_key = ? AND custom_field_values.value = ?)",
+			entityType, key, value,
+		)
+	}
+	return query
+}
+
+// preloadCustomFields loads the custom field values of records with their definitions
+func preloadCustomFields(query *gorm.DB) *gorm.DB {
+	return query.Preload("CustomFieldValues.Definition")
+}
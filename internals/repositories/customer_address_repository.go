@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"gorm.io/gorm"
+)
+
+type CustomerAddressRepository interface {
+	WithTx(tx *gorm.DB) CustomerAddressRepository
+	Create(address *models.CustomerAddress) error
+	FindByID(customerID, id uint) (*models.CustomerAddress, error)
+	FindByCustomerID(customerID uint, addressType string) ([]models.CustomerAddress, error)
+	FindDefault(customerID uint, addressType string) (*models.CustomerAddress, error)
+	Update(address *models.CustomerAddress) error
+	Delete(customerID, id uint) error
+	ReassignCustomer(fromCustomerID, toCustomerID uint) (int64, error)
+	ClearDefault(customerID uint, addressType string, exceptID uint) error
+}
+
+type customerAddressRepository struct {
+	db *gorm.DB
+}
+
+// NewCustomerAddressRepository creates a new CustomerAddressRepository instance
+func NewCustomerAddressRepository(db *gorm.DB) CustomerAddressRepository {
+	return &customerAddressRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *customerAddressRepository) WithTx(tx *gorm.DB) CustomerAddressRepository {
+	return &customerAddressRepository{db: tx}
+}
+
+// Create inserts a new address for a customer
+func (r *customerAddressRepository) Create(address *models.CustomerAddress) error {
+	return r.db.Create(address).Error
+}
+
+// FindByID retrieves an address that belongs to the given customer
+func (r *customerAddressRepository) FindByID(customerID, id uint) (*models.CustomerAddress, error) {
+	var address models.CustomerAddress
+	err := r.db.Where("customer_id = ?", customerID).First(&address, id).Error
+	return &address, err
+}
+
+// FindByCustomerID returns the addresses of a customer, optionally limited to one type,
+// with default addresses first
+func (r *customerAddressRepository) FindByCustomerID(customerID uint, addressType string) ([]models.CustomerAddress, error) {
+	var addresses []models.CustomerAddress
+	query := r.db.Where("customer_id = ?", customerID)
+	if addressType != "" {
+		query = query.Where("type = ?", addressType)
+	}
+	err := query.Order("type ASC").Order("is_default DESC").Order("id ASC").Find(&addresses).Error
+	return addresses, err
+}
+
+// FindDefault retrieves the default address of the given type for a customer
+func (r *customerAddressRepository) FindDefault(customerID uint, addressType string) (*models.CustomerAddress, error) {
+	var address models.CustomerAddress
+	err := r.db.Where("customer_id = ? AND type = ? AND is_default = ?", customerID, addressType, true).First(&address).Error
+	return &address, err
+}
+
+// Update saves changes to an existing address
+func (r *customerAddressRepository) Update(address *models.CustomerAddress) error {
+	return r.db.Save(address).Error
+}
+
+// Delete removes an address that belongs to the given customer
+func (r *customerAddressRepository) Delete(customerID, id uint) error {
+	result := r.db.Where("customer_id = ?", customerID).Delete(&models.CustomerAddress{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ClearDefault unsets the default flag on every address of the type except exceptID
+func (r *customerAddressRepository) ClearDefault(customerID uint, addressType string, exceptID uint) error {
+	return r.db.Model(&models.CustomerAddress{}).
+		Where("customer_id = ? AND type = ? AND id <> ?", customerID, addressType, exceptID).
+		Update("is_default", false).Error
+}
+
+// ReassignCustomer moves every address of a customer to another customer
+func (r *customerAddressRepository) ReassignCustomer(fromCustomerID, toCustomerID uint) (int64, error) {
+	result := r.db.Model(&models.CustomerAddress{}).
+		Where("customer_id = ?", fromCustomerID).
+		Update("customer_id", toCustomerID)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"gorm.io/gorm"
+)
+
+type CustomerContactRepository interface {
+	WithTx(tx *gorm.DB) CustomerContactRepository
+	Create(contact *models.CustomerContact) error
+	FindByID(customerID, id uint) (*models.CustomerContact, error)
+	FindByCustomerID(customerID uint) ([]models.CustomerContact, error)
+	Update(contact *models.CustomerContact) error
+	Delete(customerID, id uint) error
+	ReassignCustomer(fromCustomerID, toCustomerID uint) (int64, error)
+}
+
+type customerContactRepository struct {
+	db *gorm.DB
+}
+
+// NewCustomerContactRepository creates a new CustomerContactRepository instance
+func NewCustomerContactRepository(db *gorm.DB) CustomerContactRepository {
+	return &customerContactRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *customerContactRepository) WithTx(tx *gorm.DB) CustomerContactRepository {
+	return &customerContactRepository{db: tx}
+}
+
+// Create inserts a new contact for a customer
+func (r *customerContactRepository) Create(contact *models.CustomerContact) error {
+	return r.db.Create(contact).Error
+}
+
+// FindByID retrieves a contact that belongs to the given customer
+func (r *customerContactRepository) FindByID(customerID, id uint) (*models.CustomerContact, error) {
+	var contact models.CustomerContact
+	err := r.db.Where("customer_id = ?", customerID).First(&contact, id).Error
+	return &contact, err
+}
+
+// FindByCustomerID returns all contacts of a customer
+func (r *customerContactRepository) FindByCustomerID(customerID uint) ([]models.CustomerContact, error) {
+	var contacts []models.CustomerContact
+	err := r.db.Where("customer_id = ?", customerID).Order("name ASC").Order("id ASC").Find(&contacts).Error
+	return contacts, err
+}
+
+// Update saves changes to an existing contact
+func (r *customerContactRepository) Update(contact *models.CustomerContact) error {
+	return r.db.Save(contact).Error
+}
+
+// Delete removes a contact that belongs to the given customer
+func (r *customerContactRepository) Delete(customerID, id uint) error {
+	result := r.db.Where("customer_id = ?", customerID).Delete(&models.CustomerContact{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ReassignCustomer moves every contact of a customer to another customer
+func (r *customerContactRepository) ReassignCustomer(fromCustomerID, toCustomerID uint) (int64, error) {
+	result := r.db.Model(&models.CustomerContact{}).
+		Where("customer_id = ?", fromCustomerID).
+		Update("customer_id", toCustomerID)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"gorm.io/gorm"
+)
+
+type CustomerMergeRepository interface {
+	WithTx(tx *gorm.DB) CustomerMergeRepository
+	Create(merge *models.CustomerMerge) error
+	FindAll(customerID uint, page, limit int) ([]models.CustomerMerge, int64, error)
+}
+
+type customerMergeRepository struct {
+	db *gorm.DB
+}
+
+// NewCustomerMergeRepository creates a new CustomerMergeRepository instance
+func NewCustomerMergeRepository(db *gorm.DB) CustomerMergeRepository {
+	return &customerMergeRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *customerMergeRepository) WithTx(tx *gorm.DB) CustomerMergeRepository {
+	return &customerMergeRepository{db: tx}
+}
+
+// Create records a merge
+func (r *customerMergeRepository) Create(merge *models.CustomerMerge) error {
+	return r.db.Create(merge).Error
+}
+
+// FindAll returns the merge history, newest first, optionally for one customer on either side
+func (r *customerMergeRepository) FindAll(customerID uint, page, limit int) ([]models.CustomerMerge, int64, error) {
+	var merges []models.CustomerMerge
+	var total int64
+
+	query := r.db.Model(&models.CustomerMerge{})
+	if customerID != 0 {
+		query = query.Where("survivor_id = ? OR merged_customer_id = ?", customerID, customerID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").Order("id DESC").Limit(limit).Offset((page - 1) * limit).Find(&merges).Error
+	return merges, total, err
+}
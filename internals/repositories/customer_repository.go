@@ -1,14 +1,23 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/tacheraSasi/go-api-starter/internals/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CustomerRepository interface {
+	WithTx(tx *gorm.DB) CustomerRepository
 	Create(customer *models.Customer) error
 	FindByID(id uint) (*models.Customer, error)
-	FindAll(page, limit int) ([]models.Customer, int64, error)
+	FindByEmail(email string) (*models.Customer, error)
+	FindByPeppolID(peppolID string) (*models.Customer, error)
+	FindByTaxID(taxID string) (*models.Customer, error)
+	FindAll(filter CustomerFilter) ([]models.Customer, int64, error)
+	FindInBatches(filter CustomerFilter, batchSize int, fn func(customers []models.Customer) error) error
+	FindAllForDuplicateScan() ([]models.Customer, error)
 	Update(customer *models.Customer) error
 	Delete(id uint) error
 }
@@ -21,29 +30,109 @@ func NewCustomerRepository(db *gorm.DB) CustomerRepository {
 	return &customerRepository{db: db}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *customerRepository) WithTx(tx *gorm.DB) CustomerRepository {
+	return &customerRepository{db: tx}
+}
+
 func (r *customerRepository) Create(customer *models.Customer) error {
 	return r.db.Create(customer).Error
 }
 
 func (r *customerRepository) FindByID(id uint) (*models.Customer, error) {
 	var customer models.Customer
-	err := r.db.First(&customer, id).Error
+	err := preloadCustomFields(r.db).Preload("Tags").Preload("Contacts").Preload("Addresses", func(db *gorm.DB) *gorm.DB {
+		return db.Order("type ASC").Order("is_default DESC").Order("id ASC")
+	}).First(&customer, id).Error
+	return &customer, err
+}
+
+// FindByEmail finds a customer by email, including soft-deleted customers
+// because they still hold the unique email index
+func (r *customerRepository) FindByEmail(email string) (*models.Customer, error) {
+	var customer models.Customer
+	err := r.db.Unscoped().Preload("Tags").Where("LOWER(email) = LOWER(?)", email).First(&customer).Error
 	return &customer, err
 }
 
-func (r *customerRepository) FindAll(page, limit int) ([]models.Customer, int64, error) {
+// FindByPeppolID finds the oldest customer with the given electronic address, ignoring case
+func (r *customerRepository) FindByPeppolID(peppolID string) (*models.Customer, error) {
+	var customer models.Customer
+	err := r.db.Where("LOWER(peppol_id) = LOWER(?)", peppolID).Order("id ASC").First(&customer).Error
+	return &customer, err
+}
+
+// FindByTaxID finds the oldest customer with the given VAT identifier, ignoring case
+func (r *customerRepository) FindByTaxID(taxID string) (*models.Customer, error) {
+	var customer models.Customer
+	err := r.db.Where("UPPER(tax_id) = UPPER(?)", taxID).Order("id ASC").First(&customer).Error
+	return &customer, err
+}
+
+func (r *customerRepository) FindAll(filter CustomerFilter) ([]models.Customer, int64, error) {
 	var customers []models.Customer
 	var total int64
 
-	offset := (page - 1) * limit
+	query := r.filtered(filter)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
 
-	err := r.db.Model(&models.Customer{}).Count(&total).Limit(limit).Offset(offset).Order("created_at DESC").Find(&customers).Error
+	query = applySort(query, filter.Sort, SortField{Column: "customers.created_at", Desc: true}, "customers.id")
+	err := preloadCustomFields(query).Preload("Tags").Limit(filter.Limit).Offset(filter.Offset()).Find(&customers).Error
 
 	return customers, total, err
 }
 
+// FindInBatches streams every customer matching the filter, ignoring pagination, in batches ordered by ID
+func (r *customerRepository) FindInBatches(filter CustomerFilter, batchSize int, fn func(customers []models.Customer) error) error {
+	var customers []models.Customer
+	return preloadCustomFields(r.filtered(filter)).Preload("Tags").FindInBatches(&customers, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(customers)
+	}).Error
+}
+
+// FindAllForDuplicateScan returns the identifying fields of every customer
+func (r *customerRepository) FindAllForDuplicateScan() ([]models.Customer, error) {
+	var customers []models.Customer
+	err := r.db.Select("id", "created_at", "name", "email", "phone").Order("id ASC").Find(&customers).Error
+	return customers, err
+}
+
+// filtered applies search and filters, but not sorting or pagination
+func (r *customerRepository) filtered(filter CustomerFilter) *gorm.DB {
+	query := r.db.Model(&models.Customer{})
+	query = applySearch(query, filter.Search, "customers.name", "customers.email", "customers.phone")
+	query = applyDateRange(query, "customers.created_at", filter.Created)
+	query = applyTagFilter(query, "customers", models.TaggableCustomer, filter.Tags)
+	query = applyCustomFieldFilter(query, "customers", models.TaggableCustomer, filter.CustomFields)
+
+	if filter.HasOverdueInvoices != nil {
+		overdue := r.db.Model(&models.Invoice{}).
+			Select("1").
+			Where("invoices.customer_id = customers.id").
+			Where("invoices.due_date < ?", time.Now()).
+			Where("invoices.status NOT IN ?", models.InvoiceClosedStatuses)
+		if *filter.HasOverdueInvoices {
+			query = query.Where("EXISTS (?)", overdue)
+		} else {
+			query = query.Where("NOT EXISTS (?)", overdue)
+		}
+	}
+
+	return query
+}
+
 func (r *customerRepository) Update(customer *models.Customer) error {
-	return r.db.Save(customer).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(customer).Error; err != nil {
+			return err
+		}
+		if err := replaceTags(tx, models.TaggableCustomer, customer.ID, customer.Tags); err != nil {
+			return err
+		}
+		return replaceCustomFieldValues(tx, models.TaggableCustomer, customer.ID, customer.CustomFieldValues)
+	})
 }
 
 func (r *customerRepository) Delete(id uint) error {
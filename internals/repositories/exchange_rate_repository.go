@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// exchangeRateBatchSize keeps bulk upserts below the bind variable limits of every database
+const exchangeRateBatchSize = 500
+
+type ExchangeRateRepository interface {
+	Upsert(rates []models.ExchangeRate) error
+	FindByID(id uint) (*models.ExchangeRate, error)
+	FindAll(filter ExchangeRateFilter) ([]models.ExchangeRate, int64, error)
+	FindLatest(base, quote money.Currency, on time.Time) (*models.ExchangeRate, error)
+	FindBaseCurrencies() ([]money.Currency, error)
+	Delete(id uint) error
+}
+
+type exchangeRateRepository struct {
+	db *gorm.DB
+}
+
+// NewExchangeRateRepository creates a new ExchangeRateRepository instance
+func NewExchangeRateRepository(db *gorm.DB) ExchangeRateRepository {
+	return &exchangeRateRepository{db: db}
+}
+
+// Upsert inserts the rates, replacing the rate of any pair already recorded for the same date
+func (r *exchangeRateRepository) Upsert(rates []models.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base_currency"}, {Name: "quote_currency"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "updated_at"}),
+	}).CreateInBatches(rates, exchangeRateBatchSize).Error
+}
+
+// FindByID retrieves an exchange rate by its unique ID
+func (r *exchangeRateRepository) FindByID(id uint) (*models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	err := r.db.First(&rate, id).Error
+	return &rate, err
+}
+
+// FindAll returns a filtered, sorted and paginated list of exchange rates and the total count
+func (r *exchangeRateRepository) FindAll(filter ExchangeRateFilter) ([]models.ExchangeRate, int64, error) {
+	var rates []models.ExchangeRate
+	var total int64
+
+	query := r.db.Model(&models.ExchangeRate{})
+	if filter.BaseCurrency != "" {
+		query = query.Where("exchange_rates.base_currency = ?", filter.BaseCurrency)
+	}
+	if filter.QuoteCurrency != "" {
+		query = query.Where("exchange_rates.quote_currency = ?", filter.QuoteCurrency)
+	}
+	query = applyDateRange(query, "exchange_rates.date", filter.Date)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = applySort(query, filter.Sort, SortField{Column: "exchange_rates.date", Desc: true}, "exchange_rates.id")
+	err := query.Limit(filter.Limit).Offset(filter.Offset()).Find(&rates).Error
+	return rates, total, err
+}
+
+// FindLatest returns the most recent rate of the pair dated on or before the given time
+func (r *exchangeRateRepository) FindLatest(base, quote money.Currency, on time.Time) (*models.ExchangeRate, error) {
+	var rate models.ExchangeRate
+	err := r.db.
+		Where("base_currency = ? AND quote_currency = ?", base, quote).
+		Where("date <= ?", on).
+		Order("date DESC").
+		First(&rate).Error
+	return &rate, err
+}
+
+// FindBaseCurrencies lists every currency that rates are quoted against, used for cross rates
+func (r *exchangeRateRepository) FindBaseCurrencies() ([]money.Currency, error) {
+	var currencies []money.Currency
+	err := r.db.Model(&models.ExchangeRate{}).Distinct().Order("base_currency").Pluck("base_currency", &currencies).Error
+	return currencies, err
+}
+
+// Delete removes an exchange rate by ID
+func (r *exchangeRateRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.ExchangeRate{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
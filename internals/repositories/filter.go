@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"gorm.io/gorm"
+)
+
+// MaxListLimit caps the page size of list queries
+const MaxListLimit = 100
+
+// SortField orders a list query by a whitelisted column
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// ListQuery is the filter spec shared by list endpoints: pagination,
+// free-text search and multi-field sorting
+type ListQuery struct {
+	Page   int
+	Limit  int
+	Search string
+	Sort   []SortField
+}
+
+// Normalize applies default and maximum pagination values
+func (q *ListQuery) Normalize() {
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.Limit < 1 {
+		q.Limit = 10
+	}
+	if q.Limit > MaxListLimit {
+		q.Limit = MaxListLimit
+	}
+}
+
+// Offset returns the number of rows to skip for the current page
+func (q ListQuery) Offset() int {
+	return (q.Page - 1) * q.Limit
+}
+
+// DateRange bounds a timestamp column; From is inclusive and To is exclusive
+type DateRange struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// CustomerFilter filters the customer list
+type CustomerFilter struct {
+	ListQuery
+	Created            DateRange
+	Tags               []string
+	HasOverdueInvoices *bool
+	// CustomFields maps custom field keys to required values
+	CustomFields map[string]string
+}
+
+// InvoiceFilter filters the invoice list
+type InvoiceFilter struct {
+	ListQuery
+	Statuses           []string
+	CustomerID         *uint
+	RecurringInvoiceID *uint
+	IssueDate          DateRange
+	DueDate            DateRange
+	TotalMin           *money.Amount
+	TotalMax           *money.Amount
+	Tags               []string
+	// CustomFields maps custom field keys to required values
+	CustomFields map[string]string
+}
+
+// InvoiceReportFilter selects the invoices aggregated by reports
+type InvoiceReportFilter struct {
+	IssueDate DateRange
+	// Statuses limits the report to these statuses; when empty, ExcludeStatuses are left out
+	Statuses        []string
+	ExcludeStatuses []string
+	CustomerID      *uint
+}
+
+// ExchangeRateFilter filters the exchange rate list
+type ExchangeRateFilter struct {
+	ListQuery
+	BaseCurrency  money.Currency
+	QuoteCurrency money.Currency
+	Date          DateRange
+}
+
+// ProductFilter filters the product catalog
+type ProductFilter struct {
+	ListQuery
+	Active   *bool
+	Currency money.Currency
+}
+
+// CustomerSortFields maps sortable customer fields to columns
+var CustomerSortFields = map[string]string{
+	"name":       "customers.name",
+	"email":      "customers.email",
+	"created_at": "customers.created_at",
+	"updated_at": "customers.updated_at",
+}
+
+// InvoiceSortFields maps sortable invoice fields to columns
+var InvoiceSortFields = map[string]string{
+	"invoice_number": "invoices.invoice_number",
+	"issue_date":     "invoices.issue_date",
+	"due_date":       "invoices.due_date",
+	"status":         "invoices.status",
+	"total":          "invoices.total",
+	"created_at":     "invoices.created_at",
+	"updated_at":     "invoices.updated_at",
+}
+
+// ExchangeRateSortFields maps sortable exchange rate fields to columns
+var ExchangeRateSortFields = map[string]string{
+	"date":           "exchange_rates.date",
+	"base_currency":  "exchange_rates.base_currency",
+	"quote_currency": "exchange_rates.quote_currency",
+}
+
+// ProductSortFields maps sortable product fields to columns
+var ProductSortFields = map[string]string{
+	"sku":           "products.sku",
+	"name":          "products.name",
+	"default_price": "products.default_price",
+	"created_at":    "products.created_at",
+	"updated_at":    "products.updated_at",
+}
+
+// ParseSort parses a comma separated sort expression such as "-created_at,name"
+// against a whitelist of fields. A leading "-" sorts descending.
+func ParseSort(raw string, allowed map[string]string) ([]SortField, error) {
+	var fields []SortField
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		desc := strings.HasPrefix(part, "-")
+		name := strings.TrimPrefix(strings.TrimPrefix(part, "-"), "+")
+		column, ok := allowed[name]
+		if !ok {
+			return nil, fmt.Errorf("cannot sort by %q", name)
+		}
+		fields = append(fields, SortField{Column: column, Desc: desc})
+	}
+	return fields, nil
+}
+
+// applySearch requires every whitespace separated term to match at least one of the columns
+func applySearch(query *gorm.DB, search string, columns ...string) *gorm.DB {
+	for _, term := range strings.Fields(strings.ToLower(search)) {
+		pattern := "%" + escapeLike(term) + "%"
+		conditions := make([]string, len(columns))
+		args := make([]any, len(columns))
+		for i, column := range columns {
+			conditions[i] = "LOWER(" + column + ") LIKE ? ESCAPE '!'"
+			args[i] = pattern
+		}
+		query = query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+	return query
+}
+
+// applyDateRange restricts a column to a DateRange
+func applyDateRange(query *gorm.DB, column string, r DateRange) *gorm.DB {
+	if r.From != nil {
+		query = query.Where(column+" >= ?", *r.From)
+	}
+	if r.To != nil {
+		query = query.Where(column+" < ?", *r.To)
+	}
+	return query
+}
+
+// applySort orders by the requested fields, falling back to a default order.
+// The primary key is always appended so pagination is stable.
+func applySort(query *gorm.DB, fields []SortField, fallback SortField, primaryKey string) *gorm.DB {
+	if len(fields) == 0 {
+		fields = []SortField{fallback}
+	}
+	for _, field := range fields {
+		direction := " ASC"
+		if field.Desc {
+			direction = " DESC"
+		}
+		query = query.Order(field.Column + direction)
+	}
+	return query.Order(primaryKey + " DESC")
+}
+
+// escapeLike escapes LIKE wildcards using "!", which needs no quoting on any supported database
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+	return replacer.Replace(value)
+}
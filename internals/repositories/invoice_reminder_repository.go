@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"gorm.io/gorm"
+)
+
+type InvoiceReminderRepository interface {
+	Create(reminder *models.InvoiceReminder) error
+	FindByStep(invoiceID uint, daysFromDue int) (*models.InvoiceReminder, error)
+	FindByInvoice(invoiceID uint) ([]models.InvoiceReminder, error)
+	ClaimRetry(reminder *models.InvoiceReminder) (bool, error)
+	Update(reminder *models.InvoiceReminder) error
+}
+
+type invoiceReminderRepository struct {
+	db *gorm.DB
+}
+
+// NewInvoiceReminderRepository creates a new InvoiceReminderRepository instance
+func NewInvoiceReminderRepository(db *gorm.DB) InvoiceReminderRepository {
+	return &invoiceReminderRepository{db: db}
+}
+
+// Create inserts a reminder. It fails when the invoice already has a reminder for the
+// same step.
+func (r *invoiceReminderRepository) Create(reminder *models.InvoiceReminder) error {
+	return r.db.Create(reminder).Error
+}
+
+// FindByStep retrieves the reminder of an invoice for one step of the schedule
+func (r *invoiceReminderRepository) FindByStep(invoiceID uint, daysFromDue int) (*models.InvoiceReminder, error) {
+	var reminder models.InvoiceReminder
+	err := r.db.Where("invoice_id = ? AND days_from_due = ?", invoiceID, daysFromDue).First(&reminder).Error
+	return &reminder, err
+}
+
+// FindByInvoice returns the reminders of an invoice, oldest first
+func (r *invoiceReminderRepository) FindByInvoice(invoiceID uint) ([]models.InvoiceReminder, error) {
+	var reminders []models.InvoiceReminder
+	err := r.db.Where("invoice_id = ?", invoiceID).Order("id ASC").Find(&reminders).Error
+	return reminders, err
+}
+
+// ClaimRetry counts another attempt at a failed reminder, unless another process claimed
+// it since it was read. It reports whether this caller may send the reminder.
+func (r *invoiceReminderRepository) ClaimRetry(reminder *models.InvoiceReminder) (bool, error) {
+	result := r.db.Model(&models.InvoiceReminder{}).
+		Where("id = ? AND attempts = ? AND sent_at IS NULL", reminder.ID, reminder.Attempts).
+		Updates(map[string]interface{}{"attempts": reminder.Attempts + 1, "error": ""})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	reminder.Attempts++
+	reminder.Error = ""
+	return true, nil
+}
+
+// Update saves a reminder
+func (r *invoiceReminderRepository) Update(reminder *models.InvoiceReminder) error {
+	return r.db.Save(reminder).Error
+}
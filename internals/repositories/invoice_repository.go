@@ -1,18 +1,39 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
 	"gorm.io/gorm"
 )
 
 type InvoiceRepository interface {
+	WithTx(tx *gorm.DB) InvoiceRepository
 	Create(invoice *models.Invoice) error
 	FindByID(id uint) (*models.Invoice, error)
-	FindAll(page, limit int) ([]models.Invoice, int64, error)
+	FindByIDForUpdate(id uint) (*models.Invoice, error)
+	FindAll(filter InvoiceFilter) ([]models.Invoice, int64, error)
 	Update(invoice *models.Invoice) error
-	Delete(id uint) error
+	UpdateDetails(invoice *models.Invoice) error
 	FindByCustomerID(customerID uint, page, limit int) ([]models.Invoice, int64, error)
 	FindByInvoiceNumber(invoiceNumber string) (*models.Invoice, error)
+	InvoiceNumberTaken(invoiceNumber string) (bool, error)
+	FindImportDuplicate(documentNumber string) (*models.Invoice, error)
+	FindLedgerInvoices(customerID uint, currency money.Currency, before time.Time) ([]models.Invoice, error)
+	ReassignCustomer(fromCustomerID, toCustomerID uint) (int64, error)
+	FindNotInBaseCurrency(base money.Currency) ([]models.Invoice, error)
+	UpdateBaseAmounts(invoice *models.Invoice) error
+	FindForReport(filter InvoiceReportFilter) ([]models.Invoice, error)
+	UpdateStatus(invoice *models.Invoice, change *models.InvoiceStatusChange) error
+	ReplaceStatus(from, to string) (int64, error)
+	FindPayable(customerID uint, currency money.Currency) ([]models.Invoice, error)
+	FindUnsettled() ([]models.Invoice, error)
+	RecordView(id uint, at time.Time) error
+	FindByRecurringPeriod(recurringInvoiceID uint, period time.Time) (*models.Invoice, error)
+	FindPastDue(before time.Time) ([]models.Invoice, error)
+	MarkOverdue(invoice *models.Invoice, change *models.InvoiceStatusChange) (bool, error)
+	FindForDunning(dueBefore time.Time, lastStepDays int) ([]models.Invoice, error)
 }
 
 type invoiceRepository struct {
@@ -24,6 +45,11 @@ func NewInvoiceRepository(db *gorm.DB) InvoiceRepository {
 	return &invoiceRepository{db: db}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *invoiceRepository) WithTx(tx *gorm.DB) InvoiceRepository {
+	return &invoiceRepository{db: tx}
+}
+
 // Create inserts a new invoice record into the database
 func (r *invoiceRepository) Create(invoice *models.Invoice) error {
 	return r.db.Create(invoice).Error
@@ -31,37 +57,133 @@ func (r *invoiceRepository) Create(invoice *models.Invoice) error {
 
 // FindByID retrieves an invoice by its unique ID, including related customer and items
 func (r *invoiceRepository) FindByID(id uint) (*models.Invoice, error) {
+	return findInvoice(r.db, id)
+}
+
+// FindByIDForUpdate is FindByID that also locks the invoice row for the rest of the
+// transaction, so payments and credit notes read its amount due one at a time
+func (r *invoiceRepository) FindByIDForUpdate(id uint) (*models.Invoice, error) {
+	return findInvoice(forUpdate(r.db), id)
+}
+
+func findInvoice(db *gorm.DB, id uint) (*models.Invoice, error) {
 	var invoice models.Invoice
-	err := r.db.Preload("Customer").Preload("Items").First(&invoice, id).Error
+	err := preloadCustomFields(db).Preload("Customer").Preload("Items").Preload("TaxLines").Preload("Charges").Preload("Tags").Preload("BillingAddress", unscoped).
+		Preload("StatusHistory", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		Preload("Payments", activeAllocations).
+		First(&invoice, id).Error
 	return &invoice, err
 }
 
-// FindAll returns a paginated list of invoices and the total count
-func (r *invoiceRepository) FindAll(page, limit int) ([]models.Invoice, int64, error) {
+// FindAll returns a filtered, sorted and paginated list of invoices and the total count
+func (r *invoiceRepository) FindAll(filter InvoiceFilter) ([]models.Invoice, int64, error) {
 	var invoices []models.Invoice
 	var total int64
 
-	offset := (page - 1) * limit
+	query := r.db.Model(&models.Invoice{})
+	if search := filter.Search; search != "" {
+		customers := applySearch(r.db.Model(&models.Customer{}).Select("customers.id"), search, "customers.name", "customers.email")
+		query = query.Where(
+			applySearch(r.db, search, "invoices.invoice_number", "invoices.notes").Or("invoices.customer_id IN (?)", customers),
+		)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("invoices.status IN ?", filter.Statuses)
+	}
+	if filter.CustomerID != nil {
+		query = query.Where("invoices.customer_id = ?", *filter.CustomerID)
+	}
+	if filter.RecurringInvoiceID != nil {
+		query = query.Where("invoices.recurring_invoice_id = ?", *filter.RecurringInvoiceID)
+	}
+	query = applyDateRange(query, "invoices.issue_date", filter.IssueDate)
+	query = applyDateRange(query, "invoices.due_date", filter.DueDate)
+	if filter.TotalMin != nil {
+		query = query.Where("invoices.total >= ?", *filter.TotalMin)
+	}
+	if filter.TotalMax != nil {
+		query = query.Where("invoices.total <= ?", *filter.TotalMax)
+	}
+	query = applyTagFilter(query, "invoices", models.TaggableInvoice, filter.Tags)
+	query = applyCustomFieldFilter(query, "invoices", models.TaggableInvoice, filter.CustomFields)
 
-	err := r.db.Preload("Customer").
-		Model(&models.Invoice{}).
-		Count(&total).
-		Limit(limit).
-		Offset(offset).
-		Order("created_at DESC").
-		Find(&invoices).Error
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = applySort(query, filter.Sort, SortField{Column: "invoices.created_at", Desc: true}, "invoices.id")
+	err := preloadCustomFields(query).Preload("Customer").Preload("Tags").Limit(filter.Limit).Offset(filter.Offset()).Find(&invoices).Error
 
 	return invoices, total, err
 }
 
-// Update saves changes to an existing invoice
+// Update saves changes to an existing invoice and replaces its items, tax lines, charges,
+// tags and custom field values. Preloaded belongs-to associations are omitted so they cannot
+// overwrite a changed customer or billing address ID, and the status history and payments
+// are left to UpdateStatus and the payment repository.
 func (r *invoiceRepository) Update(invoice *models.Invoice) error {
-	return r.db.Save(invoice).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Customer", "BillingAddress", "Items", "TaxLines", "Charges", "Tags", "CustomFieldValues", "StatusHistory", "Payments", "FirstViewedAt", "ViewCount").Save(invoice).Error; err != nil {
+			return err
+		}
+		if err := replaceInvoiceLines(tx, invoice); err != nil {
+			return err
+		}
+		if err := replaceTags(tx, models.TaggableInvoice, invoice.ID, invoice.Tags); err != nil {
+			return err
+		}
+		return replaceCustomFieldValues(tx, models.TaggableInvoice, invoice.ID, invoice.CustomFieldValues)
+	})
+}
+
+// UpdateDetails saves the notes of an invoice and replaces its tags and custom field
+// values, leaving its amounts and lines untouched
+func (r *invoiceRepository) UpdateDetails(invoice *models.Invoice) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Invoice{}).Where("id = ?", invoice.ID).Update("notes", invoice.Notes).Error; err != nil {
+			return err
+		}
+		if err := replaceTags(tx, models.TaggableInvoice, invoice.ID, invoice.Tags); err != nil {
+			return err
+		}
+		return replaceCustomFieldValues(tx, models.TaggableInvoice, invoice.ID, invoice.CustomFieldValues)
+	})
 }
 
-// Delete removes an invoice record from the database by ID
-func (r *invoiceRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Invoice{}, id).Error
+// replaceInvoiceLines swaps the stored items, tax lines and charges of an invoice for the
+// given ones. Items are recreated, so their IDs change on every update.
+func replaceInvoiceLines(tx *gorm.DB, invoice *models.Invoice) error {
+	for _, model := range []interface{}{&models.InvoiceItem{}, &models.InvoiceTaxLine{}, &models.InvoiceCharge{}} {
+		if err := tx.Where("invoice_id = ?", invoice.ID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	for i := range invoice.Items {
+		invoice.Items[i].ID = 0
+		invoice.Items[i].InvoiceID = invoice.ID
+	}
+	for i := range invoice.TaxLines {
+		invoice.TaxLines[i].ID = 0
+		invoice.TaxLines[i].InvoiceID = invoice.ID
+	}
+	for i := range invoice.Charges {
+		invoice.Charges[i].ID = 0
+		invoice.Charges[i].InvoiceID = invoice.ID
+	}
+	if len(invoice.Items) > 0 {
+		if err := tx.Create(&invoice.Items).Error; err != nil {
+			return err
+		}
+	}
+	if len(invoice.TaxLines) > 0 {
+		if err := tx.Create(&invoice.TaxLines).Error; err != nil {
+			return err
+		}
+	}
+	if len(invoice.Charges) > 0 {
+		return tx.Create(&invoice.Charges).Error
+	}
+	return nil
 }
 
 // FindByCustomerID returns a paginated list of invoices for a given customer
@@ -86,8 +208,240 @@ func (r *invoiceRepository) FindByCustomerID(customerID uint, page, limit int) (
 // FindByInvoiceNumber retrieves an invoice by its invoice number, including related customer and items
 func (r *invoiceRepository) FindByInvoiceNumber(invoiceNumber string) (*models.Invoice, error) {
 	var invoice models.Invoice
-	err := r.db.Preload("Customer").Preload("Items").
+	err := preloadCustomFields(r.db).Preload("Customer").Preload("Items").Preload("TaxLines").Preload("Charges").Preload("Tags").Preload("BillingAddress", unscoped).
 		Where("invoice_number = ?", invoiceNumber).
 		First(&invoice).Error
 	return &invoice, err
 }
+
+// InvoiceNumberTaken reports whether any invoice, including a deleted one, has the number
+func (r *invoiceRepository) InvoiceNumberTaken(invoiceNumber string) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Invoice{}).Where("invoice_number = ?", invoiceNumber).Count(&count).Error
+	return count > 0, err
+}
+
+// FindImportDuplicate returns the oldest invoice that is not void and has the document
+// number as its own number or as the number of the document it was imported from
+func (r *invoiceRepository) FindImportDuplicate(documentNumber string) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := r.db.
+		Where("invoice_number = ? OR imported_number = ?", documentNumber, documentNumber).
+		Where("status <> ?", models.InvoiceStatusVoid).
+		Order("id ASC").
+		First(&invoice).Error
+	return &invoice, err
+}
+
+// FindLedgerInvoices returns the invoices of a customer in one currency that affect its
+// balance, i.e. that are neither drafts nor void, issued before the given time and
+// ordered by issue date
+func (r *invoiceRepository) FindLedgerInvoices(customerID uint, currency money.Currency, before time.Time) ([]models.Invoice, error) {
+	var invoices []models.Invoice
+	err := r.db.
+		Where("customer_id = ?", customerID).
+		Where("currency = ?", currency).
+		Where("status NOT IN ?", []string{models.InvoiceStatusDraft, models.InvoiceStatusVoid}).
+		Where("issue_date < ?", before).
+		Order("issue_date ASC").
+		Order("id ASC").
+		Find(&invoices).Error
+	return invoices, err
+}
+
+// ReassignCustomer moves every invoice, including soft-deleted ones, to another customer
+func (r *invoiceRepository) ReassignCustomer(fromCustomerID, toCustomerID uint) (int64, error) {
+	result := r.db.Unscoped().Model(&models.Invoice{}).
+		Where("customer_id = ?", fromCustomerID).
+		Update("customer_id", toCustomerID)
+	return result.RowsAffected, result.Error
+}
+
+// FindNotInBaseCurrency returns the invoices whose base amounts are missing or in another currency
+func (r *invoiceRepository) FindNotInBaseCurrency(base money.Currency) ([]models.Invoice, error) {
+	var invoices []models.Invoice
+	err := r.db.
+		Where("base_currency IS NULL OR base_currency <> ?", base).
+		Order("id ASC").
+		Find(&invoices).Error
+	return invoices, err
+}
+
+// UpdateBaseAmounts stores the base currency amounts of an invoice without touching
+// updated_at, which dates the payment of paid invoices
+func (r *invoiceRepository) UpdateBaseAmounts(invoice *models.Invoice) error {
+	return r.db.Model(&models.Invoice{}).Where("id = ?", invoice.ID).UpdateColumns(map[string]interface{}{
+		"currency":             invoice.Currency,
+		"base_currency":        invoice.BaseCurrency,
+		"exchange_rate":        invoice.ExchangeRate,
+		"base_subtotal":        invoice.BaseSubtotal,
+		"base_discount_amount": invoice.BaseDiscountAmount,
+		"base_tax_amount":      invoice.BaseTaxAmount,
+		"base_charges_total":   invoice.BaseChargesTotal,
+		"base_total":           invoice.BaseTotal,
+	}).Error
+}
+
+// FindForReport returns the invoices aggregated by reports with only the columns reports
+// need and the customer names, including customers that were deleted since
+func (r *invoiceRepository) FindForReport(filter InvoiceReportFilter) ([]models.Invoice, error) {
+	var invoices []models.Invoice
+
+	query := r.db.Select(
+		"id", "customer_id", "issue_date", "status", "currency", "subtotal", "discount_amount", "tax_amount", "charges_total", "total",
+		"base_currency", "base_subtotal", "base_discount_amount", "base_tax_amount", "base_charges_total", "base_total",
+	)
+	query = applyDateRange(query, "issue_date", filter.IssueDate)
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	} else if len(filter.ExcludeStatuses) > 0 {
+		query = query.Where("status NOT IN ?", filter.ExcludeStatuses)
+	}
+	if filter.CustomerID != nil {
+		query = query.Where("customer_id = ?", *filter.CustomerID)
+	}
+
+	err := query.
+		Preload("Customer", func(db *gorm.DB) *gorm.DB { return db.Unscoped().Select("id", "name") }).
+		Order("issue_date ASC").
+		Order("id ASC").
+		Find(&invoices).Error
+	return invoices, err
+}
+
+// UpdateStatus stores the status, transition timestamps and settled amounts of an invoice and
+// appends the change, if any, to its status history
+func (r *invoiceRepository) UpdateStatus(invoice *models.Invoice, change *models.InvoiceStatusChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Invoice{}).Where("id = ?", invoice.ID).Updates(map[string]interface{}{
+			"status":          invoice.Status,
+			"sent_at":         invoice.SentAt,
+			"paid_at":         invoice.PaidAt,
+			"voided_at":       invoice.VoidedAt,
+			"amount_paid":     invoice.AmountPaid,
+			"amount_credited": invoice.AmountCredited,
+			"amount_due":      invoice.AmountDue,
+		}).Error; err != nil {
+			return err
+		}
+		if change == nil {
+			return nil
+		}
+		change.InvoiceID = invoice.ID
+		return tx.Create(change).Error
+	})
+}
+
+// ReplaceStatus moves every invoice, including soft-deleted ones, from one status to another
+// without touching updated_at
+func (r *invoiceRepository) ReplaceStatus(from, to string) (int64, error) {
+	result := r.db.Unscoped().Model(&models.Invoice{}).
+		Where("status = ? OR (? = '' AND status IS NULL)", from, from).
+		UpdateColumn("status", to)
+	return result.RowsAffected, result.Error
+}
+
+// FindPayable returns the invoices of a customer in one currency that payments can be
+// allocated to, oldest due date first, and locks them for the rest of the transaction
+func (r *invoiceRepository) FindPayable(customerID uint, currency money.Currency) ([]models.Invoice, error) {
+	var invoices []models.Invoice
+	err := forUpdate(r.db).
+		Where("customer_id = ?", customerID).
+		Where("currency = ?", currency).
+		Where("status IN ?", models.InvoicePayableStatuses).
+		Order("due_date ASC").
+		Order("id ASC").
+		Find(&invoices).Error
+	return invoices, err
+}
+
+// FindUnsettled returns the invoices stored before payments were tracked: they have a
+// total but neither an amount paid, credited nor due
+func (r *invoiceRepository) FindUnsettled() ([]models.Invoice, error) {
+	var invoices []models.Invoice
+	err := r.db.
+		Where("amount_paid = 0 AND amount_credited = 0 AND amount_due = 0 AND total > 0").
+		Where("status <> ?", models.InvoiceStatusVoid).
+		Order("id ASC").
+		Find(&invoices).Error
+	return invoices, err
+}
+
+// activeAllocations preloads the payment allocations of payments that were not reversed
+func activeAllocations(db *gorm.DB) *gorm.DB {
+	return db.Where("payment_id NOT IN (SELECT id FROM payments WHERE reversed_at IS NOT NULL)").Order("date ASC").Order("id ASC")
+}
+
+// unscoped preloads soft-deleted records, such as an address that was removed after it was billed
+func unscoped(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// RecordView counts one view of an invoice and sets its first view time if it has none
+func (r *invoiceRepository) RecordView(id uint, at time.Time) error {
+	return r.db.Model(&models.Invoice{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"view_count":      gorm.Expr("view_count + 1"),
+		"first_viewed_at": gorm.Expr("COALESCE(first_viewed_at, ?)", at),
+	}).Error
+}
+
+// FindByRecurringPeriod retrieves the invoice generated for one period of a recurring
+// invoice, including deleted invoices, which still count as generated
+func (r *invoiceRepository) FindByRecurringPeriod(recurringInvoiceID uint, period time.Time) (*models.Invoice, error) {
+	var invoice models.Invoice
+	err := r.db.Unscoped().Where("recurring_invoice_id = ? AND recurring_period = ?", recurringInvoiceID, period).First(&invoice).Error
+	return &invoice, err
+}
+
+// FindPastDue returns the sent and partially paid invoices with an amount due whose due
+// date is before the given time
+func (r *invoiceRepository) FindPastDue(before time.Time) ([]models.Invoice, error) {
+	var invoices []models.Invoice
+	err := r.db.
+		Where("status IN ?", []string{models.InvoiceStatusSent, models.InvoiceStatusPartiallyPaid}).
+		Where("amount_due > 0").
+		Where("due_date < ?", before).
+		Order("due_date ASC").
+		Order("id ASC").
+		Find(&invoices).Error
+	return invoices, err
+}
+
+// MarkOverdue moves an invoice to overdue and records the change, unless its status or
+// amount due changed since it was read, for example by a payment. It reports whether the
+// invoice was updated.
+func (r *invoiceRepository) MarkOverdue(invoice *models.Invoice, change *models.InvoiceStatusChange) (bool, error) {
+	updated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Invoice{}).
+			Where("id = ? AND status = ? AND amount_due > 0", invoice.ID, change.FromStatus).
+			Update("status", models.InvoiceStatusOverdue)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		updated = true
+		change.InvoiceID = invoice.ID
+		return tx.Create(change).Error
+	})
+	return updated, err
+}
+
+// FindForDunning returns the payable invoices with an amount due whose due date is before
+// dueBefore, of customers that did not opt out of reminders and that have no reminder yet
+// for the last step of the schedule, with their customer and its billing contacts
+func (r *invoiceRepository) FindForDunning(dueBefore time.Time, lastStepDays int) ([]models.Invoice, error) {
+	var invoices []models.Invoice
+	err := r.db.
+		Preload("Customer").
+		Preload("Customer.Contacts", "role = ?", models.ContactRoleBilling).
+		Where("status IN ?", models.InvoicePayableStatuses).
+		Where("amount_due > 0").
+		Where("due_date < ?", dueBefore).
+		Where("customer_id IN (?)", r.db.Model(&models.Customer{}).Select("id").Where("dunning_opt_out = ?", false)).
+		Where("NOT EXISTS (?)", r.db.Model(&models.InvoiceReminder{}).Select("1").
+			Where("invoice_reminders.invoice_id = invoices.id AND invoice_reminders.days_from_due = ?", lastStepDays)).
+		Order("due_date ASC").
+		Order("id ASC").
+		Find(&invoices).Error
+	return invoices, err
+}
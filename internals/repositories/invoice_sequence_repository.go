@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InvoiceSequenceRepository interface {
+	WithTx(tx *gorm.DB) InvoiceSequenceRepository
+	Next(scope string) (int64, error)
+}
+
+type invoiceSequenceRepository struct {
+	db *gorm.DB
+}
+
+// NewInvoiceSequenceRepository creates a new InvoiceSequenceRepository instance
+func NewInvoiceSequenceRepository(db *gorm.DB) InvoiceSequenceRepository {
+	return &invoiceSequenceRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *invoiceSequenceRepository) WithTx(tx *gorm.DB) InvoiceSequenceRepository {
+	return &invoiceSequenceRepository{db: tx}
+}
+
+// Next increments the counter of a scope, creating it on first use, and returns the new
+// value. The increment locks the counter until the surrounding transaction ends, so
+// concurrent callers are serialised and a rolled back transaction gives its number back.
+func (r *invoiceSequenceRepository) Next(scope string) (int64, error) {
+	increment := func() (int64, error) {
+		result := r.db.Model(&models.InvoiceSequence{}).
+			Where("scope = ?", scope).
+			UpdateColumn("last_number", gorm.Expr("last_number + 1"))
+		return result.RowsAffected, result.Error
+	}
+
+	updated, err := increment()
+	if err != nil {
+		return 0, err
+	}
+	if updated == 0 {
+		// Another transaction may create the same counter; the increment then waits for it
+		if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.InvoiceSequence{Scope: scope}).Error; err != nil {
+			return 0, err
+		}
+		if _, err := increment(); err != nil {
+			return 0, err
+		}
+	}
+
+	var sequence models.InvoiceSequence
+	if err := r.db.Where("scope = ?", scope).First(&sequence).Error; err != nil {
+		return 0, err
+	}
+	return sequence.LastNumber, nil
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"gorm.io/gorm"
+)
+
+type InvoiceShareLinkRepository interface {
+	Create(link *models.InvoiceShareLink) error
+	FindByID(id uint) (*models.InvoiceShareLink, error)
+	FindByInvoice(invoiceID uint) ([]models.InvoiceShareLink, error)
+	Update(link *models.InvoiceShareLink) error
+}
+
+type invoiceShareLinkRepository struct {
+	db *gorm.DB
+}
+
+// NewInvoiceShareLinkRepository creates a new InvoiceShareLinkRepository instance
+func NewInvoiceShareLinkRepository(db *gorm.DB) InvoiceShareLinkRepository {
+	return &invoiceShareLinkRepository{db: db}
+}
+
+// Create inserts a new share link
+func (r *invoiceShareLinkRepository) Create(link *models.InvoiceShareLink) error {
+	return r.db.Create(link).Error
+}
+
+// FindByID retrieves a share link by its ID
+func (r *invoiceShareLinkRepository) FindByID(id uint) (*models.InvoiceShareLink, error) {
+	var link models.InvoiceShareLink
+	err := r.db.First(&link, id).Error
+	return &link, err
+}
+
+// FindByInvoice returns the share links of an invoice, newest first
+func (r *invoiceShareLinkRepository) FindByInvoice(invoiceID uint) ([]models.InvoiceShareLink, error) {
+	var links []models.InvoiceShareLink
+	err := r.db.Where("invoice_id = ?", invoiceID).Order("id DESC").Find(&links).Error
+	return links, err
+}
+
+// Update saves a share link
+func (r *invoiceShareLinkRepository) Update(link *models.InvoiceShareLink) error {
+	return r.db.Save(link).Error
+}
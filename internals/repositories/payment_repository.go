@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentRepository interface {
+	WithTx(tx *gorm.DB) PaymentRepository
+	Create(payment *models.Payment) error
+	CreateAllocations(allocations []models.PaymentAllocation) error
+	FindByID(id uint) (*models.Payment, error)
+	FindByIDForUpdate(id uint) (*models.Payment, error)
+	FindAll(customerID uint, page, limit int) ([]models.Payment, int64, error)
+	Update(payment *models.Payment) error
+	FindInvoiceAllocations(invoiceID uint) ([]models.PaymentAllocation, error)
+	FindLedgerPayments(customerID uint, currency money.Currency, before time.Time) ([]models.Payment, error)
+	FindWithCredit(customerID uint) ([]models.Payment, error)
+	ReassignCustomer(fromCustomerID, toCustomerID uint) (int64, error)
+}
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+// NewPaymentRepository creates a new PaymentRepository instance
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *paymentRepository) WithTx(tx *gorm.DB) PaymentRepository {
+	return &paymentRepository{db: tx}
+}
+
+// Create inserts a payment together with its allocations
+func (r *paymentRepository) Create(payment *models.Payment) error {
+	return r.db.Create(payment).Error
+}
+
+// CreateAllocations inserts allocations of an existing payment
+func (r *paymentRepository) CreateAllocations(allocations []models.PaymentAllocation) error {
+	if len(allocations) == 0 {
+		return nil
+	}
+	return r.db.Create(&allocations).Error
+}
+
+// FindByID retrieves a payment by its ID, including its allocations
+func (r *paymentRepository) FindByID(id uint) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.Preload("Allocations", orderedAllocations).First(&payment, id).Error
+	return &payment, err
+}
+
+// FindByIDForUpdate is FindByID that also locks the payment row for the rest of the
+// transaction, so its unallocated amount is spent one request at a time
+func (r *paymentRepository) FindByIDForUpdate(id uint) (*models.Payment, error) {
+	var payment models.Payment
+	err := forUpdate(r.db).Preload("Allocations", orderedAllocations).First(&payment, id).Error
+	return &payment, err
+}
+
+// FindAll returns a paginated list of payments, newest first, optionally for one customer
+func (r *paymentRepository) FindAll(customerID uint, page, limit int) ([]models.Payment, int64, error) {
+	var payments []models.Payment
+	var total int64
+
+	query := r.db.Model(&models.Payment{})
+	if customerID != 0 {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Allocations", orderedAllocations).
+		Order("payment_date DESC").
+		Order("id DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&payments).Error
+	return payments, total, err
+}
+
+// Update saves the amounts and reversal of a payment, leaving its allocations untouched
+func (r *paymentRepository) Update(payment *models.Payment) error {
+	return r.db.Omit(clause.Associations).Save(payment).Error
+}
+
+// FindInvoiceAllocations returns the allocations to an invoice of payments that were not reversed
+func (r *paymentRepository) FindInvoiceAllocations(invoiceID uint) ([]models.PaymentAllocation, error) {
+	var allocations []models.PaymentAllocation
+	err := activeAllocations(r.db.Where("invoice_id = ?", invoiceID)).Find(&allocations).Error
+	return allocations, err
+}
+
+// FindLedgerPayments returns the payments of a customer in one currency received before
+// the given time, including reversed ones, ordered by payment date
+func (r *paymentRepository) FindLedgerPayments(customerID uint, currency money.Currency, before time.Time) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := r.db.Preload("Allocations", orderedAllocations).
+		Where("customer_id = ?", customerID).
+		Where("currency = ?", currency).
+		Where("payment_date < ?", before).
+		Order("payment_date ASC").
+		Order("id ASC").
+		Find(&payments).Error
+	return payments, err
+}
+
+// FindWithCredit returns the payments of a customer that were not reversed and still have
+// an unallocated amount, oldest first
+func (r *paymentRepository) FindWithCredit(customerID uint) ([]models.Payment, error) {
+	var payments []models.Payment
+	err := r.db.
+		Where("customer_id = ?", customerID).
+		Where("reversed_at IS NULL").
+		Where("unallocated_amount > 0").
+		Order("payment_date ASC").
+		Order("id ASC").
+		Find(&payments).Error
+	return payments, err
+}
+
+// ReassignCustomer moves every payment to another customer
+func (r *paymentRepository) ReassignCustomer(fromCustomerID, toCustomerID uint) (int64, error) {
+	result := r.db.Model(&models.Payment{}).
+		Where("customer_id = ?", fromCustomerID).
+		Update("customer_id", toCustomerID)
+	return result.RowsAffected, result.Error
+}
+
+func orderedAllocations(db *gorm.DB) *gorm.DB {
+	return db.Order("date ASC").Order("id ASC")
+}
@@ -13,6 +13,11 @@ func NewPermissionRepository(db *gorm.DB) *PermissionRepository {
 	return &PermissionRepository{db: db}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction
+func (p *PermissionRepository) WithTx(tx *gorm.DB) *PermissionRepository {
+	return &PermissionRepository{db: tx}
+}
+
 // Create creates a new permission
 func (p *PermissionRepository) Create(permission *models.Permission) error {
 	return p.db.Create(permission).Error
@@ -52,6 +57,13 @@ func (p *PermissionRepository) List(limit, offset int, resource string) ([]model
 	return permissions, err
 }
 
+// ListAll retrieves every permission ordered by name
+func (p *PermissionRepository) ListAll() ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := p.db.Order("name").Find(&permissions).Error
+	return permissions, err
+}
+
 // Update updates a permission
 func (p *PermissionRepository) Update(permission *models.Permission) error {
 	return p.db.Save(permission).Error
@@ -62,6 +74,11 @@ func (p *PermissionRepository) Delete(id uint) error {
 	return p.db.Delete(&models.Permission{}, id).Error
 }
 
+// ClearRoles removes a permission from every role it is granted to
+func (p *PermissionRepository) ClearRoles(permissionID uint) error {
+	return p.db.Model(&models.Permission{ID: permissionID}).Association("Roles").Clear()
+}
+
 // GetPermissionsByRole gets all permissions for a specific role
 func (p *PermissionRepository) GetPermissionsByRole(roleID uint) ([]models.Permission, error) {
 	var permissions []models.Permission
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"gorm.io/gorm"
+)
+
+type ProductRepository interface {
+	Create(product *models.Product) error
+	FindByID(id uint) (*models.Product, error)
+	FindByIDs(ids []uint) ([]models.Product, error)
+	FindBySKU(sku string) (*models.Product, error)
+	FindAll(filter ProductFilter) ([]models.Product, int64, error)
+	Update(product *models.Product) error
+	Delete(id uint) error
+}
+
+type productRepository struct {
+	db *gorm.DB
+}
+
+// NewProductRepository creates a new ProductRepository instance
+func NewProductRepository(db *gorm.DB) ProductRepository {
+	return &productRepository{db: db}
+}
+
+// Create inserts a new product
+func (r *productRepository) Create(product *models.Product) error {
+	return r.db.Omit("TaxRate").Create(product).Error
+}
+
+// FindByID retrieves a product by ID, including its tax rate
+func (r *productRepository) FindByID(id uint) (*models.Product, error) {
+	var product models.Product
+	err := r.db.Preload("TaxRate").First(&product, id).Error
+	return &product, err
+}
+
+// FindByIDs retrieves the products with the given IDs, including deleted ones, since
+// invoice lines keep referring to them; missing IDs are skipped
+func (r *productRepository) FindByIDs(ids []uint) ([]models.Product, error) {
+	var products []models.Product
+	if len(ids) == 0 {
+		return products, nil
+	}
+	err := r.db.Unscoped().Where("id IN ?", ids).Find(&products).Error
+	return products, err
+}
+
+// FindBySKU retrieves a product by SKU, ignoring case and including deleted products,
+// whose SKUs stay taken
+func (r *productRepository) FindBySKU(sku string) (*models.Product, error) {
+	var product models.Product
+	err := r.db.Unscoped().Where("LOWER(sku) = LOWER(?)", sku).First(&product).Error
+	return &product, err
+}
+
+// FindAll returns a filtered, sorted and paginated list of products and the total count
+func (r *productRepository) FindAll(filter ProductFilter) ([]models.Product, int64, error) {
+	var products []models.Product
+	var total int64
+
+	query := r.db.Model(&models.Product{})
+	query = applySearch(query, filter.Search, "products.sku", "products.name", "products.description")
+	if filter.Active != nil {
+		query = query.Where("products.active = ?", *filter.Active)
+	}
+	if filter.Currency != "" {
+		query = query.Where("products.currency = ?", filter.Currency)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = applySort(query, filter.Sort, SortField{Column: "products.name"}, "products.id")
+	err := query.Preload("TaxRate").Limit(filter.Limit).Offset(filter.Offset()).Find(&products).Error
+	return products, total, err
+}
+
+// Update saves changes to an existing product
+func (r *productRepository) Update(product *models.Product) error {
+	return r.db.Omit("TaxRate").Save(product).Error
+}
+
+// Delete soft-deletes a product; invoice lines keep their copy of its name and price
+func (r *productRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.Product{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"gorm.io/gorm"
+)
+
+type QuoteRepository interface {
+	WithTx(tx *gorm.DB) QuoteRepository
+	Create(quote *models.Quote) error
+	FindByID(id uint) (*models.Quote, error)
+	FindAll(customerID uint, status string, page, limit int) ([]models.Quote, int64, error)
+	Update(quote *models.Quote) error
+	UpdateStatus(quote *models.Quote, from string) (bool, error)
+	SetInvoice(quote *models.Quote, previousInvoiceID *uint) (bool, error)
+	Delete(id uint) error
+	FindExpired(before time.Time) ([]models.Quote, error)
+	ReassignCustomer(fromCustomerID, toCustomerID uint) (int64, error)
+}
+
+type quoteRepository struct {
+	db *gorm.DB
+}
+
+// NewQuoteRepository creates a new QuoteRepository instance
+func NewQuoteRepository(db *gorm.DB) QuoteRepository {
+	return &quoteRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *quoteRepository) WithTx(tx *gorm.DB) QuoteRepository {
+	return &quoteRepository{db: tx}
+}
+
+// Create inserts a quote together with its items, tax lines and charges
+func (r *quoteRepository) Create(quote *models.Quote) error {
+	return r.db.Omit("Customer", "BillingAddress").Create(quote).Error
+}
+
+// FindByID retrieves a quote by ID, including its customer, billing address and lines
+func (r *quoteRepository) FindByID(id uint) (*models.Quote, error) {
+	var quote models.Quote
+	err := r.db.Preload("Customer").Preload("BillingAddress", unscoped).
+		Preload("Items", orderedByID).Preload("TaxLines", orderedByID).Preload("Charges", orderedByID).
+		First(&quote, id).Error
+	return &quote, err
+}
+
+// FindAll returns a paginated list of quotes, newest first, optionally for one customer or
+// in one status
+func (r *quoteRepository) FindAll(customerID uint, status string, page, limit int) ([]models.Quote, int64, error) {
+	var quotes []models.Quote
+	var total int64
+
+	query := r.db.Model(&models.Quote{})
+	if customerID != 0 {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Customer").Preload("Items", orderedByID).
+		Order("issue_date DESC").
+		Order("id DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&quotes).Error
+	return quotes, total, err
+}
+
+// Update saves changes to a draft quote and replaces its items, tax lines and charges.
+// Preloaded belongs-to associations are omitted so they cannot overwrite a changed
+// customer or billing address ID.
+func (r *quoteRepository) Update(quote *models.Quote) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Customer", "BillingAddress", "Items", "TaxLines", "Charges").Save(quote).Error; err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.QuoteItem{}, &models.QuoteTaxLine{}, &models.QuoteCharge{}} {
+			if err := tx.Where("quote_id = ?", quote.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		for i := range quote.Items {
+			quote.Items[i].ID = 0
+			quote.Items[i].QuoteID = quote.ID
+		}
+		for i := range quote.TaxLines {
+			quote.TaxLines[i].ID = 0
+			quote.TaxLines[i].QuoteID = quote.ID
+		}
+		for i := range quote.Charges {
+			quote.Charges[i].ID = 0
+			quote.Charges[i].QuoteID = quote.ID
+		}
+		if len(quote.Items) > 0 {
+			if err := tx.Create(&quote.Items).Error; err != nil {
+				return err
+			}
+		}
+		if len(quote.TaxLines) > 0 {
+			if err := tx.Create(&quote.TaxLines).Error; err != nil {
+				return err
+			}
+		}
+		if len(quote.Charges) > 0 {
+			return tx.Create(&quote.Charges).Error
+		}
+		return nil
+	})
+}
+
+// UpdateStatus saves the status of a quote and the fields that record how it got there,
+// unless its status changed from the given one in the meantime. It reports whether the
+// quote was updated.
+func (r *quoteRepository) UpdateStatus(quote *models.Quote, from string) (bool, error) {
+	result := r.db.Model(&models.Quote{}).
+		Where("id = ? AND status = ?", quote.ID, from).
+		Select("status", "sent_at", "accepted_at", "declined_at", "decline_reason", "expired_at", "responded_by_id").
+		Updates(quote)
+	return result.RowsAffected > 0, result.Error
+}
+
+// SetInvoice links a quote to the invoice it was converted into, unless it was linked to
+// another invoice than previousInvoiceID in the meantime. It reports whether the quote was
+// updated.
+func (r *quoteRepository) SetInvoice(quote *models.Quote, previousInvoiceID *uint) (bool, error) {
+	query := r.db.Model(&models.Quote{}).Where("id = ?", quote.ID)
+	if previousInvoiceID == nil {
+		query = query.Where("invoice_id IS NULL")
+	} else {
+		query = query.Where("invoice_id = ?", *previousInvoiceID)
+	}
+	result := query.Select("invoice_id", "converted_at").Updates(quote)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Delete soft-deletes a quote
+func (r *quoteRepository) Delete(id uint) error {
+	return r.db.Delete(&models.Quote{}, id).Error
+}
+
+// FindExpired returns the sent quotes whose expiry date is before the given time
+func (r *quoteRepository) FindExpired(before time.Time) ([]models.Quote, error) {
+	var quotes []models.Quote
+	err := r.db.
+		Where("status = ?", models.QuoteStatusSent).
+		Where("expiry_date < ?", before).
+		Order("expiry_date ASC").
+		Order("id ASC").
+		Find(&quotes).Error
+	return quotes, err
+}
+
+// ReassignCustomer moves every quote to another customer
+func (r *quoteRepository) ReassignCustomer(fromCustomerID, toCustomerID uint) (int64, error) {
+	result := r.db.Model(&models.Quote{}).
+		Where("customer_id = ?", fromCustomerID).
+		Update("customer_id", toCustomerID)
+	return result.RowsAffected, result.Error
+}
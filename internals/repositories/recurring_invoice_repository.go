@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"gorm.io/gorm"
+)
+
+type RecurringInvoiceRepository interface {
+	WithTx(tx *gorm.DB) RecurringInvoiceRepository
+	Create(recurring *models.RecurringInvoice) error
+	FindByID(id uint) (*models.RecurringInvoice, error)
+	FindAll(customerID uint, page, limit int) ([]models.RecurringInvoice, int64, error)
+	FindDue(now time.Time) ([]models.RecurringInvoice, error)
+	Update(recurring *models.RecurringInvoice) error
+	UpdateRunState(recurring *models.RecurringInvoice) error
+	Delete(id uint) error
+	ReassignCustomer(fromCustomerID, toCustomerID uint) (int64, error)
+}
+
+type recurringInvoiceRepository struct {
+	db *gorm.DB
+}
+
+// NewRecurringInvoiceRepository creates a new RecurringInvoiceRepository instance
+func NewRecurringInvoiceRepository(db *gorm.DB) RecurringInvoiceRepository {
+	return &recurringInvoiceRepository{db: db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *recurringInvoiceRepository) WithTx(tx *gorm.DB) RecurringInvoiceRepository {
+	return &recurringInvoiceRepository{db: tx}
+}
+
+// Create inserts a recurring invoice together with its items
+func (r *recurringInvoiceRepository) Create(recurring *models.RecurringInvoice) error {
+	return r.db.Omit("Customer").Create(recurring).Error
+}
+
+// FindByID retrieves a recurring invoice by ID, including its customer and items
+func (r *recurringInvoiceRepository) FindByID(id uint) (*models.RecurringInvoice, error) {
+	var recurring models.RecurringInvoice
+	err := r.db.Preload("Customer").Preload("Items", orderedRecurringItems).First(&recurring, id).Error
+	return &recurring, err
+}
+
+// FindAll returns a paginated list of recurring invoices, newest first, optionally for one customer
+func (r *recurringInvoiceRepository) FindAll(customerID uint, page, limit int) ([]models.RecurringInvoice, int64, error) {
+	var recurring []models.RecurringInvoice
+	var total int64
+
+	query := r.db.Model(&models.RecurringInvoice{})
+	if customerID != 0 {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Customer").Preload("Items", orderedRecurringItems).
+		Order("id DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&recurring).Error
+	return recurring, total, err
+}
+
+// FindDue returns the recurring invoices that are not paused and have a period starting
+// at or before now, with their items
+func (r *recurringInvoiceRepository) FindDue(now time.Time) ([]models.RecurringInvoice, error) {
+	var recurring []models.RecurringInvoice
+	err := r.db.Preload("Items", orderedRecurringItems).
+		Where("paused = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", false, now).
+		Order("next_run_at ASC").
+		Order("id ASC").
+		Find(&recurring).Error
+	return recurring, err
+}
+
+// Update saves changes to a recurring invoice and replaces its items. The preloaded
+// customer is omitted so it cannot overwrite a changed customer ID.
+func (r *recurringInvoiceRepository) Update(recurring *models.RecurringInvoice) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Customer", "Items").Save(recurring).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("recurring_invoice_id = ?", recurring.ID).Delete(&models.RecurringInvoiceItem{}).Error; err != nil {
+			return err
+		}
+		for i := range recurring.Items {
+			recurring.Items[i].ID = 0
+			recurring.Items[i].RecurringInvoiceID = recurring.ID
+		}
+		if len(recurring.Items) > 0 {
+			return tx.Create(&recurring.Items).Error
+		}
+		return nil
+	})
+}
+
+// UpdateRunState saves the schedule progress of a recurring invoice, leaving the template
+// itself untouched
+func (r *recurringInvoiceRepository) UpdateRunState(recurring *models.RecurringInvoice) error {
+	return r.db.Model(&models.RecurringInvoice{}).Where("id = ?", recurring.ID).UpdateColumns(map[string]interface{}{
+		"next_run_at": recurring.NextRunAt,
+		"last_run_at": recurring.LastRunAt,
+		"last_error":  recurring.LastError,
+	}).Error
+}
+
+// Delete soft-deletes a recurring invoice; invoices it generated are kept
+func (r *recurringInvoiceRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.RecurringInvoice{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ReassignCustomer moves every recurring invoice to another customer
+func (r *recurringInvoiceRepository) ReassignCustomer(fromCustomerID, toCustomerID uint) (int64, error) {
+	result := r.db.Model(&models.RecurringInvoice{}).
+		Where("customer_id = ?", fromCustomerID).
+		Update("customer_id", toCustomerID)
+	return result.RowsAffected, result.Error
+}
+
+func orderedRecurringItems(db *gorm.DB) *gorm.DB {
+	return db.Order("id ASC")
+}
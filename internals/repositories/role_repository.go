@@ -13,6 +13,11 @@ func NewRoleRepository(db *gorm.DB) *RoleRepository {
 	return &RoleRepository{db: db}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *RoleRepository) WithTx(tx *gorm.DB) *RoleRepository {
+	return &RoleRepository{db: tx}
+}
+
 // Create creates a new role
 func (r *RoleRepository) Create(role *models.Role) error {
 	return r.db.Create(role).Error
@@ -45,6 +50,13 @@ func (r *RoleRepository) List(limit, offset int, activeOnly bool) ([]models.Role
 	return roles, err
 }
 
+// ListAll retrieves every role, active or not, with permissions preloaded
+func (r *RoleRepository) ListAll() ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.Preload("Permissions").Order("name").Find(&roles).Error
+	return roles, err
+}
+
 // Update updates a role
 func (r *RoleRepository) Update(role *models.Role) error {
 	return r.db.Save(role).Error
@@ -65,6 +77,11 @@ func (r *RoleRepository) RemovePermission(roleID, permissionID uint) error {
 	return r.db.Model(&models.Role{ID: roleID}).Association("Permissions").Delete(&models.Permission{ID: permissionID})
 }
 
+// ClearPermissions removes every permission grant from a role
+func (r *RoleRepository) ClearPermissions(roleID uint) error {
+	return r.db.Model(&models.Role{ID: roleID}).Association("Permissions").Clear()
+}
+
 // GetUsersWithRole gets all users with a specific role
 func (r *RoleRepository) GetUsersWithRole(roleID uint) ([]models.User, error) {
 	var role models.Role
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"gorm.io/gorm"
+)
+
+// replaceTags swaps the tags of a record for the given set
+func replaceTags(tx *gorm.DB, taggableType string, taggableID uint, tags []models.Tag) error {
+	if err := tx.Where("taggable_type = ? AND taggable_id = ?", taggableType, taggableID).Delete(&models.Tag{}).Error; err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	rows := make([]models.Tag, len(tags))
+	for i, tag := range tags {
+		rows[i] = models.Tag{TaggableType: taggableType, TaggableID: taggableID, Name: tag.Name}
+	}
+	return tx.Create(&rows).Error
+}
+
+// applyTagFilter requires a record to carry every one of the given tags
+func applyTagFilter(query *gorm.DB, table, taggableType string, tags []string) *gorm.DB {
+	for _, tag := range tags {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM tags WHERE tags.taggable_type = ? AND tags.taggable_id = "+table+".id AND tags.name = ?)",
+			taggableType, models.NormalizeTagName(tag),
+		)
+	}
+	return query
+}
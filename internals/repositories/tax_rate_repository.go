@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"gorm.io/gorm"
+)
+
+type TaxRateRepository interface {
+	Create(rate *models.TaxRate) error
+	FindByID(id uint) (*models.TaxRate, error)
+	FindByIDs(ids []uint) ([]models.TaxRate, error)
+	FindByName(name string) (*models.TaxRate, error)
+	FindAll() ([]models.TaxRate, error)
+	FindDefaults() ([]models.TaxRate, error)
+	Update(rate *models.TaxRate) error
+	Delete(id uint) error
+	CountAll() (int64, error)
+}
+
+type taxRateRepository struct {
+	db *gorm.DB
+}
+
+// NewTaxRateRepository creates a new TaxRateRepository instance
+func NewTaxRateRepository(db *gorm.DB) TaxRateRepository {
+	return &taxRateRepository{db: db}
+}
+
+// Create inserts a new tax rate
+func (r *taxRateRepository) Create(rate *models.TaxRate) error {
+	return r.db.Create(rate).Error
+}
+
+// FindByID retrieves a tax rate by ID
+func (r *taxRateRepository) FindByID(id uint) (*models.TaxRate, error) {
+	var rate models.TaxRate
+	err := r.db.First(&rate, id).Error
+	return &rate, err
+}
+
+// FindByIDs retrieves the tax rates with the given IDs; missing IDs are skipped
+func (r *taxRateRepository) FindByIDs(ids []uint) ([]models.TaxRate, error) {
+	var rates []models.TaxRate
+	if len(ids) == 0 {
+		return rates, nil
+	}
+	err := r.db.Where("id IN ?", ids).Order("id ASC").Find(&rates).Error
+	return rates, err
+}
+
+// FindByName retrieves a tax rate by name, ignoring case
+func (r *taxRateRepository) FindByName(name string) (*models.TaxRate, error) {
+	var rate models.TaxRate
+	err := r.db.Where("LOWER(name) = LOWER(?)", name).First(&rate).Error
+	return &rate, err
+}
+
+// FindAll returns every tax rate ordered by name
+func (r *taxRateRepository) FindAll() ([]models.TaxRate, error) {
+	var rates []models.TaxRate
+	err := r.db.Order("name ASC").Order("id ASC").Find(&rates).Error
+	return rates, err
+}
+
+// FindDefaults returns the tax rates applied to lines that do not list their own
+func (r *taxRateRepository) FindDefaults() ([]models.TaxRate, error) {
+	var rates []models.TaxRate
+	err := r.db.Where("is_default = ?", true).Order("id ASC").Find(&rates).Error
+	return rates, err
+}
+
+// Update saves changes to an existing tax rate
+func (r *taxRateRepository) Update(rate *models.TaxRate) error {
+	return r.db.Save(rate).Error
+}
+
+// Delete soft-deletes a tax rate; invoices keep their copy of its name and percentage
+func (r *taxRateRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.TaxRate{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CountAll counts the tax rates including deleted ones
+func (r *taxRateRepository) CountAll() (int64, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.TaxRate{}).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Transactor runs a unit of work inside a database transaction
+type Transactor interface {
+	Transaction(fn func(tx *gorm.DB) error) error
+}
+
+type transactor struct {
+	db *gorm.DB
+}
+
+// NewTransactor creates a new Transactor instance
+func NewTransactor(db *gorm.DB) Transactor {
+	return &transactor{db: db}
+}
+
+// Transaction commits when fn returns nil and rolls back otherwise
+func (t *transactor) Transaction(fn func(tx *gorm.DB) error) error {
+	return t.db.Transaction(fn)
+}
+
+// forUpdate makes a query lock the rows it reads (SELECT ... FOR UPDATE) until the
+// transaction ends, so concurrent transactions that change them take turns. SQLite has
+// no row locks and leaves the clause out; its write transactions already take turns.
+func forUpdate(db *gorm.DB) *gorm.DB {
+	return db.Clauses(clause.Locking{Strength: "UPDATE"})
+}
@@ -6,6 +6,7 @@ import (
 )
 
 type UserRepository interface {
+	WithTx(tx *gorm.DB) UserRepository
 	CreateUser(user *models.User) error
 	GetUserByID(id string) (*models.User, error)
 	GetUserByIDWithRoles(id string) (*models.User, error)
@@ -19,6 +20,8 @@ type UserRepository interface {
 	RemoveRoleFromUser(userID, roleID uint) error
 	GetUserRoles(userID uint) ([]models.Role, error)
 	UpdateLastLogin(userID uint) error
+	ListUsersWithLegacyRole() ([]models.User, error)
+	ClearLegacyRole(userID uint) error
 }
 
 type userRepository struct {
@@ -31,6 +34,11 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction
+func (r *userRepository) WithTx(tx *gorm.DB) UserRepository {
+	return &userRepository{db: tx}
+}
+
 // CreateUser inserts a new user record into the database
 func (r *userRepository) CreateUser(user *models.User) error {
 	return r.db.Create(user).Error
@@ -128,3 +136,15 @@ func (r *userRepository) GetUserRoles(userID uint) ([]models.Role, error) {
 func (r *userRepository) UpdateLastLogin(userID uint) error {
 	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("last_login", gorm.Expr("NOW()")).Error
 }
+
+// ListUsersWithLegacyRole lists users whose legacy role column is still set, with roles preloaded
+func (r *userRepository) ListUsersWithLegacyRole() ([]models.User, error) {
+	var users []models.User
+	err := r.db.Preload("Roles").Where("role IS NOT NULL AND role <> ''").Find(&users).Error
+	return users, err
+}
+
+// ClearLegacyRole empties the legacy role column once it has been migrated
+func (r *userRepository) ClearLegacyRole(userID uint) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("role", "").Error
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+// ErrAmountOutOfRange is returned when amounts that are already stored add up to more
+// than money.Amount can hold, for example in a statement or a report
+var ErrAmountOutOfRange = errors.New("totals out of range")
+
+// checkLimit rejects an amount beyond money.MaxAmount in either direction
+func checkLimit(amount money.Amount, label string) error {
+	if !amount.WithinLimit() {
+		return fmt.Errorf("%s %s is more than the maximum of %s", label, amount, money.MaxAmount)
+	}
+	return nil
+}
+
+// recoverOverflow stops the panic of an amount that leaves the range of money.Amount and
+// reports it as an error wrapping sentinel, so huge amounts are rejected rather than
+// failing the request; it must be deferred directly
+func recoverOverflow(err *error, sentinel error) {
+	if r := recover(); r != nil {
+		if cause, ok := r.(error); ok && errors.Is(cause, money.ErrOverflow) {
+			*err = fmt.Errorf("%w: amounts are too large", sentinel)
+			return
+		}
+		panic(r)
+	}
+}
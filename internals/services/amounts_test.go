@@ -0,0 +1,68 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+// Rows stored before amounts were limited can add up to more than an Amount holds. The
+// aggregations over them must report that instead of panicking.
+func TestAggregationsDoNotPanic(t *testing.T) {
+	db := newTestDB(t)
+	huge := money.MustParse("900000000000000")
+	paid := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	reversed := paid.Add(24 * time.Hour)
+
+	customer := &models.Customer{Name: "Big Spender", Email: "big@example.com", Currency: "EUR"}
+	mustCreate(t, db, customer)
+	for i := 0; i < 2; i++ {
+		mustCreate(t, db,
+			&models.Payment{CustomerID: customer.ID, Currency: "EUR", Amount: huge, UnallocatedAmount: huge, PaymentDate: paid, Method: models.PaymentMethodOther},
+			&models.Payment{CustomerID: customer.ID, Currency: "EUR", Amount: huge, PaymentDate: paid, Method: models.PaymentMethodOther, ReversedAt: &reversed},
+		)
+	}
+	for _, number := range []string{"INV-1", "INV-2"} {
+		mustCreate(t, db, &models.Invoice{
+			CustomerID: customer.ID, InvoiceNumber: number, IssueDate: paid, DueDate: paid, Status: models.InvoiceStatusSent,
+			Currency: "EUR", Total: huge, Subtotal: huge, AmountDue: huge, BaseCurrency: "EUR", BaseSubtotal: huge, BaseTotal: huge,
+		})
+	}
+
+	customers := repositories.NewCustomerRepository(db)
+	invoices := repositories.NewInvoiceRepository(db)
+	payments := repositories.NewPaymentRepository(db)
+	creditNotes := repositories.NewCreditNoteRepository(db)
+
+	_, err := NewPaymentService(repositories.NewTransactor(db), payments, invoices, customers, "EUR").GetCustomerCredit(customer.ID)
+	if !errors.Is(err, ErrAmountOutOfRange) {
+		t.Errorf("GetCustomerCredit error = %v; want %v", err, ErrAmountOutOfRange)
+	}
+
+	_, err = NewStatementService(customers, invoices, payments, creditNotes, "EUR").GetCustomerStatement(customer.ID, "", repositories.DateRange{})
+	if !errors.Is(err, ErrAmountOutOfRange) {
+		t.Errorf("GetCustomerStatement error = %v; want %v", err, ErrAmountOutOfRange)
+	}
+
+	_, err = NewReportService(invoices, creditNotes, "EUR").GetRevenueReport(repositories.InvoiceReportFilter{}, "")
+	if !errors.Is(err, ErrAmountOutOfRange) {
+		t.Errorf("GetRevenueReport error = %v; want %v", err, ErrAmountOutOfRange)
+	}
+}
+
+func TestCheckLimit(t *testing.T) {
+	for _, amount := range []money.Amount{money.Zero, money.MaxAmount, money.MaxAmount.Neg()} {
+		if err := checkLimit(amount, "amount"); err != nil {
+			t.Errorf("checkLimit(%s) = %v; want nil", amount, err)
+		}
+	}
+	for _, amount := range []money.Amount{money.MaxAmount.Add(money.FromUnits(1)), money.MaxAmount.Neg().Sub(money.FromUnits(1))} {
+		if err := checkLimit(amount, "amount"); err == nil {
+			t.Errorf("checkLimit(%s) = nil; want an error", amount)
+		}
+	}
+}
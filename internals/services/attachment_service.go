@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/pkg/storage"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrAttachmentNotFound is returned when an attachment, or the record it belongs to, does not exist
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	// ErrInvalidAttachment marks an upload that cannot be accepted, such as an empty file or a disallowed type
+	ErrInvalidAttachment = errors.New("invalid attachment")
+	// ErrAttachmentTooLarge is returned when an upload exceeds the size limit
+	ErrAttachmentTooLarge = errors.New("attachment too large")
+	// ErrAttachmentForbidden is returned when the user may not see or change the attachments of a record
+	ErrAttachmentForbidden = errors.New("not allowed to access these attachments")
+)
+
+// sniffLength is how much of a file is read to detect its type
+const sniffLength = 3072
+
+// maxFileNameLength bounds stored file names, in runes
+const maxFileNameLength = 255
+
+// AvatarTypes are the image types accepted as avatars
+var AvatarTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// attachmentResources maps attachment parents to the permission resource that guards them
+var attachmentResources = map[string]string{
+	models.AttachmentParentInvoice:  models.ResourceInvoice,
+	models.AttachmentParentCustomer: models.ResourceCustomer,
+	models.AttachmentParentUser:     models.ResourceUser,
+}
+
+// AttachmentSettings holds the upload limits
+type AttachmentSettings struct {
+	MaxSize       int64    // bytes per file attached to invoices and customers
+	AllowedTypes  []string // MIME types attached files may have
+	AvatarMaxSize int64    // bytes per avatar
+}
+
+type AttachmentService interface {
+	ListAttachments(actorID uint, parentType string, parentID uint) ([]models.Attachment, error)
+	UploadAttachment(ctx context.Context, actorID uint, parentType string, parentID uint, fileName string, r io.Reader) (*models.Attachment, error)
+	OpenAttachment(ctx context.Context, actorID uint, parentType string, parentID, id uint) (*models.Attachment, io.ReadCloser, error)
+	DeleteAttachment(ctx context.Context, actorID uint, parentType string, parentID, id uint) error
+	OpenAvatar(ctx context.Context, actorID, userID uint) (*models.Attachment, io.ReadCloser, error)
+	DeleteAvatar(ctx context.Context, actorID, userID uint) error
+}
+
+type attachmentService struct {
+	repo         repositories.AttachmentRepository
+	store        storage.Storage
+	userRepo     repositories.UserRepository
+	invoiceRepo  repositories.InvoiceRepository
+	customerRepo repositories.CustomerRepository
+	settings     AttachmentSettings
+}
+
+func NewAttachmentService(
+	repo repositories.AttachmentRepository,
+	store storage.Storage,
+	userRepo repositories.UserRepository,
+	invoiceRepo repositories.InvoiceRepository,
+	customerRepo repositories.CustomerRepository,
+	settings AttachmentSettings,
+) AttachmentService {
+	return &attachmentService{
+		repo:         repo,
+		store:        store,
+		userRepo:     userRepo,
+		invoiceRepo:  invoiceRepo,
+		customerRepo: customerRepo,
+		settings:     settings,
+	}
+}
+
+// ListAttachments returns the attachments of a record the user may read
+func (s *attachmentService) ListAttachments(actorID uint, parentType string, parentID uint) ([]models.Attachment, error) {
+	if err := s.checkAccess(actorID, parentType, parentID, models.ActionRead); err != nil {
+		return nil, err
+	}
+	attachments, err := s.repo.FindByParent(parentType, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// UploadAttachment streams a file into storage and records it for a record the user may
+// update. The file is spooled to a temporary file while its size is checked and its
+// checksum calculated, then its type is sniffed from the content. A new avatar replaces
+// the user's previous one.
+func (s *attachmentService) UploadAttachment(ctx context.Context, actorID uint, parentType string, parentID uint, fileName string, r io.Reader) (*models.Attachment, error) {
+	if err := s.checkAccess(actorID, parentType, parentID, models.ActionUpdate); err != nil {
+		return nil, err
+	}
+	maxSize, allowedTypes := s.settings.MaxSize, s.settings.AllowedTypes
+	if parentType == models.AttachmentParentUser {
+		maxSize, allowedTypes = s.settings.AvatarMaxSize, AvatarTypes
+	}
+
+	spool, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(spool, hash), io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive file: %w", err)
+	}
+	if size > maxSize {
+		return nil, fmt.Errorf("%w: files may be at most %s", ErrAttachmentTooLarge, formatSize(maxSize))
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidAttachment)
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := spool.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	detected := mimetype.Detect(head[:n])
+	if !mimeAllowed(detected, allowedTypes) {
+		return nil, fmt.Errorf("%w: files of type %s are not accepted (allowed: %s)", ErrInvalidAttachment, detected.String(), strings.Join(allowedTypes, ", "))
+	}
+
+	key, err := newStorageKey(parentType, parentID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if err := s.store.Put(ctx, key, spool, size, detected.String()); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	attachment := &models.Attachment{
+		ParentType:   parentType,
+		ParentID:     parentID,
+		FileName:     cleanFileName(fileName, detected.Extension()),
+		ContentType:  detected.String(),
+		Size:         size,
+		Checksum:     hex.EncodeToString(hash.Sum(nil)),
+		StorageKey:   key,
+		UploadedByID: &actorID,
+	}
+	if err := s.repo.Create(attachment); err != nil {
+		s.store.Delete(ctx, key)
+		return nil, fmt.Errorf("failed to save attachment: %w", err)
+	}
+
+	if parentType == models.AttachmentParentUser {
+		previous, err := s.repo.FindByParent(parentType, parentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find previous avatar: %w", err)
+		}
+		for i := range previous {
+			if previous[i].ID == attachment.ID {
+				continue
+			}
+			if err := s.remove(ctx, &previous[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return attachment, nil
+}
+
+// OpenAttachment streams an attachment of a record the user may read; the caller closes
+// the reader
+func (s *attachmentService) OpenAttachment(ctx context.Context, actorID uint, parentType string, parentID, id uint) (*models.Attachment, io.ReadCloser, error) {
+	attachment, err := s.find(actorID, parentType, parentID, id, models.ActionRead)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.open(ctx, attachment)
+}
+
+// DeleteAttachment removes an attachment of a record the user may update, together with
+// its file
+func (s *attachmentService) DeleteAttachment(ctx context.Context, actorID uint, parentType string, parentID, id uint) error {
+	attachment, err := s.find(actorID, parentType, parentID, id, models.ActionUpdate)
+	if err != nil {
+		return err
+	}
+	return s.remove(ctx, attachment)
+}
+
+// OpenAvatar streams the avatar of a user
+func (s *attachmentService) OpenAvatar(ctx context.Context, actorID, userID uint) (*models.Attachment, io.ReadCloser, error) {
+	avatar, err := s.findAvatar(actorID, userID, models.ActionRead)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.open(ctx, avatar)
+}
+
+// DeleteAvatar removes the avatar of a user
+func (s *attachmentService) DeleteAvatar(ctx context.Context, actorID, userID uint) error {
+	avatar, err := s.findAvatar(actorID, userID, models.ActionUpdate)
+	if err != nil {
+		return err
+	}
+	return s.remove(ctx, avatar)
+}
+
+func (s *attachmentService) find(actorID uint, parentType string, parentID, id uint, action string) (*models.Attachment, error) {
+	if err := s.checkAccess(actorID, parentType, parentID, action); err != nil {
+		return nil, err
+	}
+	attachment, err := s.repo.FindByID(parentType, parentID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	return attachment, nil
+}
+
+func (s *attachmentService) findAvatar(actorID, userID uint, action string) (*models.Attachment, error) {
+	if err := s.checkAccess(actorID, models.AttachmentParentUser, userID, action); err != nil {
+		return nil, err
+	}
+	avatars, err := s.repo.FindByParent(models.AttachmentParentUser, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get avatar: %w", err)
+	}
+	if len(avatars) == 0 {
+		return nil, fmt.Errorf("%w: user %d has no avatar", ErrAttachmentNotFound, userID)
+	}
+	return &avatars[0], nil
+}
+
+func (s *attachmentService) open(ctx context.Context, attachment *models.Attachment) (*models.Attachment, io.ReadCloser, error) {
+	reader, err := s.store.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, fmt.Errorf("%w: the file of attachment %d is missing from storage", ErrAttachmentNotFound, attachment.ID)
+		}
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return attachment, reader, nil
+}
+
+// remove deletes the file of an attachment before its record, so a failure leaves the
+// attachment in place to be deleted again
+func (s *attachmentService) remove(ctx context.Context, attachment *models.Attachment) error {
+	if err := s.store.Delete(ctx, attachment.StorageKey); err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	if err := s.repo.Delete(attachment); err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	return nil
+}
+
+// checkAccess derives the permission on attachments from their parent record: reading
+// them needs read access to the record and changing them update access. Users may always
+// manage their own avatar. The record must exist.
+func (s *attachmentService) checkAccess(actorID uint, parentType string, parentID uint, action string) error {
+	resource, ok := attachmentResources[parentType]
+	if !ok {
+		return fmt.Errorf("%w: records of type %q have no attachments", ErrInvalidAttachment, parentType)
+	}
+	if parentType != models.AttachmentParentUser || actorID != parentID {
+		actor, err := s.userRepo.GetUserByIDWithRoles(strconv.FormatUint(uint64(actorID), 10))
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to get user permissions: %w", err)
+		}
+		if err != nil || !actor.HasPermission(resource, action) {
+			return fmt.Errorf("%w: the %s:%s permission is required", ErrAttachmentForbidden, resource, action)
+		}
+	}
+
+	var err error
+	switch parentType {
+	case models.AttachmentParentInvoice:
+		_, err = s.invoiceRepo.FindByID(parentID)
+	case models.AttachmentParentCustomer:
+		_, err = s.customerRepo.FindByID(parentID)
+	case models.AttachmentParentUser:
+		_, err = s.userRepo.GetUserByID(strconv.FormatUint(uint64(parentID), 10))
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: %s %d not found", ErrAttachmentNotFound, parentType, parentID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", parentType, err)
+	}
+	return nil
+}
+
+// mimeAllowed reports whether a detected type, or one of its aliases, is in the allowed list
+func mimeAllowed(detected *mimetype.MIME, allowed []string) bool {
+	for _, allowedType := range allowed {
+		if detected.Is(allowedType) {
+			return true
+		}
+	}
+	return false
+}
+
+// newStorageKey returns a random key for a new file of a record. File names are never
+// part of keys, so uploads cannot choose where they are stored.
+func newStorageKey(parentType string, parentID uint) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate storage key: %w", err)
+	}
+	return fmt.Sprintf("%s/%d/%s", parentType, parentID, hex.EncodeToString(random)), nil
+}
+
+// cleanFileName keeps the last path element of an uploaded file name without control
+// characters and quotes, falling back to "file" with the extension of the detected type
+func cleanFileName(name, extension string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name))
+	if name == "" || name == "." || name == "/" || name == ".." {
+		name = "file" + extension
+	}
+	if runes := []rune(name); len(runes) > maxFileNameLength {
+		name = string(runes[:maxFileNameLength])
+	}
+	return name
+}
+
+// formatSize renders a byte count in whole megabytes or kilobytes where it divides evenly
+func formatSize(bytes int64) string {
+	switch {
+	case bytes >= 1<<20 && bytes%(1<<20) == 0:
+		return fmt.Sprintf("%d MB", bytes>>20)
+	case bytes >= 1<<10 && bytes%(1<<10) == 0:
+		return fmt.Sprintf("%d KB", bytes>>10)
+	}
+	return fmt.Sprintf("%d bytes", bytes)
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/pkg/storage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pngHeader is the start of a PNG file, enough for its type to be detected
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00\x1f\x15\xc4\x89")
+
+// newTestUser stores an active user whose role grants the permissions, given as
+// resource:action pairs
+func newTestUser(t *testing.T, db *gorm.DB, email string, permissions ...string) *models.User {
+	t.Helper()
+	user := &models.User{Name: "Test", Email: email, Password: "x", IsActive: true}
+	role := &models.Role{Name: "role-" + email, IsActive: true}
+	mustCreate(t, db, user, role)
+	for _, name := range permissions {
+		resource, action, _ := strings.Cut(name, ":")
+		permission := &models.Permission{Name: name, Resource: resource, Action: action}
+		if err := db.Where(models.Permission{Name: name}).FirstOrCreate(permission).Error; err != nil {
+			t.Fatalf("create permission: %v", err)
+		}
+		if err := db.Omit(clause.Associations).Create(&models.RolePermission{RoleID: role.ID, PermissionID: permission.ID}).Error; err != nil {
+			t.Fatalf("grant permission: %v", err)
+		}
+	}
+	if err := db.Omit(clause.Associations).Create(&models.UserRole{UserID: user.ID, RoleID: role.ID}).Error; err != nil {
+		t.Fatalf("assign role: %v", err)
+	}
+	return user
+}
+
+func TestUploadAttachmentLimits(t *testing.T) {
+	db := newTestDB(t)
+	dir := t.TempDir()
+	store, err := storage.NewLocal(dir)
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	service := NewAttachmentService(
+		repositories.NewAttachmentRepository(db),
+		store,
+		repositories.NewUserRepository(db),
+		repositories.NewInvoiceRepository(db),
+		repositories.NewCustomerRepository(db),
+		AttachmentSettings{MaxSize: 1 << 10, AllowedTypes: []string{"application/pdf", "text/plain"}, AvatarMaxSize: 64},
+	)
+	clerk := newTestUser(t, db, "clerk@example.com", "customer:update")
+	reader := newTestUser(t, db, "reader@example.com", "customer:read")
+	customer := &models.Customer{Name: "Acme", Email: "acme@example.com", Currency: "EUR"}
+	mustCreate(t, db, customer)
+	ctx := context.Background()
+
+	upload := func(actorID uint, parentType string, parentID uint, name string, data []byte) (*models.Attachment, error) {
+		return service.UploadAttachment(ctx, actorID, parentType, parentID, name, bytes.NewReader(data))
+	}
+
+	attachment, err := upload(clerk.ID, models.AttachmentParentCustomer, customer.ID, "notes.txt", bytes.Repeat([]byte("a"), 1<<10))
+	if err != nil {
+		t.Fatalf("upload of exactly the maximum size: %v", err)
+	}
+	if attachment.Size != 1<<10 || !strings.HasPrefix(attachment.ContentType, "text/plain") {
+		t.Errorf("stored size %d and type %q; want 1024 bytes of text/plain", attachment.Size, attachment.ContentType)
+	}
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(attachment.StorageKey))); err != nil {
+		t.Errorf("stored file: %v", err)
+	}
+
+	rejected := []struct {
+		name     string
+		actorID  uint
+		parent   string
+		parentID uint
+		fileName string
+		data     []byte
+		want     error
+	}{
+		{"one byte over the limit", clerk.ID, models.AttachmentParentCustomer, customer.ID, "big.txt", bytes.Repeat([]byte("a"), 1<<10+1), ErrAttachmentTooLarge},
+		{"empty file", clerk.ID, models.AttachmentParentCustomer, customer.ID, "empty.txt", nil, ErrInvalidAttachment},
+		{"type not allowed", clerk.ID, models.AttachmentParentCustomer, customer.ID, "logo.png", pngHeader, ErrInvalidAttachment},
+		{"type sniffed, not taken from the name", clerk.ID, models.AttachmentParentCustomer, customer.ID, "invoice.pdf", []byte("<html><body>hi</body></html>"), ErrInvalidAttachment},
+		{"read permission only", reader.ID, models.AttachmentParentCustomer, customer.ID, "notes.txt", []byte("hello"), ErrAttachmentForbidden},
+		{"missing record", clerk.ID, models.AttachmentParentCustomer, customer.ID + 100, "notes.txt", []byte("hello"), ErrAttachmentNotFound},
+		{"avatar that is no image", clerk.ID, models.AttachmentParentUser, clerk.ID, "me.txt", []byte("hello"), ErrInvalidAttachment},
+		{"avatar over its own limit", clerk.ID, models.AttachmentParentUser, clerk.ID, "me.png", append(append([]byte{}, pngHeader...), make([]byte, 64)...), ErrAttachmentTooLarge},
+	}
+	for _, c := range rejected {
+		if _, err := upload(c.actorID, c.parent, c.parentID, c.fileName, c.data); !errors.Is(err, c.want) {
+			t.Errorf("%s: error = %v; want %v", c.name, err, c.want)
+		}
+	}
+
+	if _, err := upload(clerk.ID, models.AttachmentParentUser, clerk.ID, "me.png", pngHeader); err != nil {
+		t.Errorf("avatar within its limit: %v", err)
+	}
+
+	// Rejected uploads store nothing
+	var count int64
+	if err := db.Model(&models.Attachment{}).Count(&count).Error; err != nil {
+		t.Fatalf("count attachments: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("%d attachments recorded; want 2", count)
+	}
+	files := 0
+	filepath.WalkDir(dir, func(_ string, entry os.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			files++
+		}
+		return err
+	})
+	if files != 2 {
+		t.Errorf("%d files stored; want 2", files)
+	}
+}
@@ -4,12 +4,14 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/tacheraSasi/go-api-starter/internals/models"
 	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"gorm.io/gorm"
 )
 
 type AuthService interface {
@@ -24,11 +26,13 @@ type AuthService interface {
 
 type authService struct {
 	repo         repositories.UserRepository
+	roleRepo     *repositories.RoleRepository
+	transactor   repositories.Transactor
 	tokenService TokenService
 }
 
-func NewAuthService(repo repositories.UserRepository, tokenService TokenService) AuthService {
-	return &authService{repo: repo, tokenService: tokenService}
+func NewAuthService(repo repositories.UserRepository, roleRepo *repositories.RoleRepository, transactor repositories.Transactor, tokenService TokenService) AuthService {
+	return &authService{repo: repo, roleRepo: roleRepo, transactor: transactor, tokenService: tokenService}
 }
 
 func (s *authService) Login(email, password string) (models.User, error) {
@@ -39,9 +43,6 @@ func (s *authService) Login(email, password string) (models.User, error) {
 	if err := user.CheckPassword(password); err != nil {
 		return models.User{}, err
 	}
-	if user.HasRole(models.RoleAdmin) {
-		user.Role = models.RoleAdmin
-	}
 	return *user, nil
 }
 
@@ -54,7 +55,22 @@ func (s *authService) Register(user *models.User) error {
 	if err := user.HashPassword(); err != nil {
 		return err
 	}
-	return s.repo.CreateUser(user)
+
+	// The user and its default role are stored together, so no user is left without a role
+	return s.transactor.Transaction(func(tx *gorm.DB) error {
+		defaultRole, err := s.roleRepo.WithTx(tx).GetByName(models.RoleUser)
+		if err != nil {
+			return fmt.Errorf("failed to get default role %s: %w", models.RoleUser, err)
+		}
+		users := s.repo.WithTx(tx)
+		if err := users.CreateUser(user); err != nil {
+			return err
+		}
+		if err := users.AddRoleToUser(user.ID, defaultRole.ID); err != nil {
+			return fmt.Errorf("failed to assign default role %s: %w", models.RoleUser, err)
+		}
+		return nil
+	})
 }
 
 func (s *authService) GetUserByID(id string) (*models.User, error) {
@@ -0,0 +1,556 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"github.com/tacheraSasi/go-api-starter/pkg/numbering"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCreditNoteNotFound is returned when a credit note does not exist
+	ErrCreditNoteNotFound = errors.New("credit note not found")
+	// ErrInvalidCreditNote marks a credit note, allocation or refund that cannot be accepted
+	ErrInvalidCreditNote = errors.New("invalid credit note")
+)
+
+// creditNoteScope prefixes the sequence scopes of credit notes, which are numbered apart
+// from invoices
+const creditNoteScope = "credit_note:"
+
+// CreditNoteSettings configures how credit notes are numbered and rounded
+type CreditNoteSettings struct {
+	// Numbering renders the numbers of new credit notes from their own sequence
+	Numbering numbering.Format
+	Rounding  money.RoundingMode
+}
+
+type CreditNoteService interface {
+	ListCreditNotes(customerID, invoiceID uint, page, limit int) ([]models.CreditNote, *utils.Pagination, error)
+	GetCreditNote(id uint) (*models.CreditNote, error)
+	CreateCreditNote(req *dtos.CreateCreditNoteRequest, createdByID *uint) (*models.CreditNote, error)
+	ApplyCreditNote(id uint, allocations []dtos.CreditNoteAllocationRequest, appliedByID *uint) (*models.CreditNote, error)
+	RefundCreditNote(id uint, req *dtos.RefundCreditNoteRequest, refundedByID *uint) (*models.CreditNote, error)
+}
+
+type creditNoteService struct {
+	transactor  repositories.Transactor
+	repo        repositories.CreditNoteRepository
+	invoiceRepo repositories.InvoiceRepository
+	sequences   repositories.InvoiceSequenceRepository
+	settings    CreditNoteSettings
+}
+
+func NewCreditNoteService(transactor repositories.Transactor, repo repositories.CreditNoteRepository, invoiceRepo repositories.InvoiceRepository, sequences repositories.InvoiceSequenceRepository, settings CreditNoteSettings) CreditNoteService {
+	return &creditNoteService{
+		transactor:  transactor,
+		repo:        repo,
+		invoiceRepo: invoiceRepo,
+		sequences:   sequences,
+		settings:    settings,
+	}
+}
+
+func (s *creditNoteService) ListCreditNotes(customerID, invoiceID uint, page, limit int) ([]models.CreditNote, *utils.Pagination, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	notes, total, err := s.repo.FindAll(customerID, invoiceID, page, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list credit notes: %w", err)
+	}
+	return notes, utils.NewPagination(page, limit, total), nil
+}
+
+func (s *creditNoteService) GetCreditNote(id uint) (*models.CreditNote, error) {
+	note, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCreditNoteNotFound
+		}
+		return nil, fmt.Errorf("failed to get credit note: %w", err)
+	}
+	return note, nil
+}
+
+// CreateCreditNote credits some lines of an invoice, or whatever is left of it, under the
+// next credit note number. The credit goes to the credited invoice first while it is open;
+// the rest can be applied to other invoices or refunded.
+func (s *creditNoteService) CreateCreditNote(req *dtos.CreateCreditNoteRequest, createdByID *uint) (*models.CreditNote, error) {
+	issueDate, err := parseCreditNoteDate(req.IssueDate, "issue date")
+	if err != nil {
+		return nil, err
+	}
+
+	var note *models.CreditNote
+	err = s.transactor.Transaction(func(tx *gorm.DB) error {
+		notes, invoices := s.repo.WithTx(tx), s.invoiceRepo.WithTx(tx)
+
+		invoice, err := invoices.FindByIDForUpdate(req.InvoiceID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: invoice %d not found", ErrInvalidCreditNote, req.InvoiceID)
+			}
+			return fmt.Errorf("failed to get invoice: %w", err)
+		}
+		if !isPayable(invoice) && invoice.Status != models.InvoiceStatusPaid && invoice.Status != models.InvoiceStatusCredited {
+			return fmt.Errorf("%w: invoice %s is %s; draft and void invoices cannot be credited", ErrInvalidCreditNote, invoice.InvoiceNumber, invoice.Status)
+		}
+		if calendarDay(issueDate).Before(calendarDay(invoice.IssueDate)) {
+			return fmt.Errorf("%w: issue date must not be before invoice %s was issued", ErrInvalidCreditNote, invoice.InvoiceNumber)
+		}
+
+		previous, err := notes.FindByInvoice(invoice.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get credit notes of invoice %d: %w", invoice.ID, err)
+		}
+		if note, err = s.credit(invoice, previous, req.Items); err != nil {
+			return err
+		}
+		note.IssueDate = issueDate
+		note.Reason = strings.TrimSpace(req.Reason)
+		note.CreatedByID = createdByID
+
+		seq, err := s.sequences.WithTx(tx).Next(creditNoteScope + s.settings.Numbering.Scope(issueDate))
+		if err != nil {
+			return fmt.Errorf("failed to allocate credit note number: %w", err)
+		}
+		note.CreditNoteNumber = s.settings.Numbering.Number(issueDate, seq)
+
+		if amount := money.Min(invoice.AmountDue, note.Total); isPayable(invoice) && amount.IsPositive() {
+			note.Allocations = []models.CreditNoteAllocation{{InvoiceID: invoice.ID, Amount: amount, Date: issueDate, AppliedByID: createdByID}}
+			note.AppliedAmount = amount
+		}
+		updateCreditNoteBalance(note)
+		if err := notes.Create(note); err != nil {
+			return fmt.Errorf("failed to create credit note: %w", err)
+		}
+		return s.settle(invoices, notes, note.Allocations, createdByID, "Credit note "+note.CreditNoteNumber+" issued")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCreditNote(note.ID)
+}
+
+// ApplyCreditNote allocates the remaining credit of a credit note to open invoices of the
+// customer, by default the oldest due first
+func (s *creditNoteService) ApplyCreditNote(id uint, allocations []dtos.CreditNoteAllocationRequest, appliedByID *uint) (*models.CreditNote, error) {
+	err := s.transactor.Transaction(func(tx *gorm.DB) error {
+		notes, invoices := s.repo.WithTx(tx), s.invoiceRepo.WithTx(tx)
+
+		note, err := s.findCreditNote(notes, id)
+		if err != nil {
+			return err
+		}
+		if !note.RemainingAmount.IsPositive() {
+			return fmt.Errorf("%w: credit note %s has no credit left", ErrInvalidCreditNote, note.CreditNoteNumber)
+		}
+
+		// Credit applied after the issue date counts from the day it is applied
+		date := time.Now()
+		if note.IssueDate.After(date) {
+			date = note.IssueDate
+		}
+		applied, err := s.allocate(invoices, note, allocations, date, appliedByID)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			return fmt.Errorf("%w: customer %d has no open %s invoices", ErrInvalidCreditNote, note.CustomerID, note.Currency)
+		}
+		for _, allocation := range applied {
+			note.AppliedAmount = note.AppliedAmount.Add(allocation.Amount)
+		}
+		updateCreditNoteBalance(note)
+		if err := notes.CreateAllocations(applied); err != nil {
+			return fmt.Errorf("failed to apply credit note: %w", err)
+		}
+		if err := notes.Update(note); err != nil {
+			return fmt.Errorf("failed to update credit note: %w", err)
+		}
+		return s.settle(invoices, notes, applied, appliedByID, "Credit note "+note.CreditNoteNumber+" applied")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCreditNote(id)
+}
+
+// RefundCreditNote records credit paid back to the customer, by default all that is left
+func (s *creditNoteService) RefundCreditNote(id uint, req *dtos.RefundCreditNoteRequest, refundedByID *uint) (*models.CreditNote, error) {
+	refundDate, err := parseCreditNoteDate(req.RefundDate, "refund date")
+	if err != nil {
+		return nil, err
+	}
+	method, err := paymentMethod(req.Method)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCreditNote, err)
+	}
+
+	err = s.transactor.Transaction(func(tx *gorm.DB) error {
+		notes := s.repo.WithTx(tx)
+
+		note, err := s.findCreditNote(notes, id)
+		if err != nil {
+			return err
+		}
+		if !note.RemainingAmount.IsPositive() {
+			return fmt.Errorf("%w: credit note %s has no credit left", ErrInvalidCreditNote, note.CreditNoteNumber)
+		}
+		if calendarDay(refundDate).Before(calendarDay(note.IssueDate)) {
+			return fmt.Errorf("%w: refund date must not be before credit note %s was issued", ErrInvalidCreditNote, note.CreditNoteNumber)
+		}
+
+		amount := note.RemainingAmount
+		if req.Amount != nil {
+			amount = *req.Amount
+		}
+		if err := checkAmount(amount, note.Currency, "amount"); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidCreditNote, err)
+		}
+		if amount.Cmp(note.RemainingAmount) > 0 {
+			return fmt.Errorf("%w: %s is more than the %s left on credit note %s", ErrInvalidCreditNote, amount, note.RemainingAmount, note.CreditNoteNumber)
+		}
+
+		refund := &models.CreditNoteRefund{
+			CreditNoteID: note.ID,
+			Amount:       amount,
+			RefundDate:   refundDate,
+			Method:       method,
+			Reference:    strings.TrimSpace(req.Reference),
+			Notes:        strings.TrimSpace(req.Notes),
+			RefundedByID: refundedByID,
+		}
+		if err := notes.CreateRefund(refund); err != nil {
+			return fmt.Errorf("failed to record refund: %w", err)
+		}
+		note.RefundedAmount = note.RefundedAmount.Add(amount)
+		updateCreditNoteBalance(note)
+		if err := notes.Update(note); err != nil {
+			return fmt.Errorf("failed to update credit note: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetCreditNote(id)
+}
+
+// creditLine is what is left to credit of one invoice line
+type creditLine struct {
+	item     *models.InvoiceItem
+	quantity int
+	net      money.Amount
+	tax      money.Amount
+}
+
+func (l creditLine) total() money.Amount {
+	return l.net.Add(l.tax)
+}
+
+// credit prices a credit note for the requested lines of an invoice, or for whatever is
+// left of it when none are requested
+func (s *creditNoteService) credit(invoice *models.Invoice, previous []models.CreditNote, requests []dtos.CreditNoteItemRequest) (*models.CreditNote, error) {
+	lines, charges := s.creditableLines(invoice, previous)
+	note := &models.CreditNote{
+		InvoiceID:  invoice.ID,
+		CustomerID: invoice.CustomerID,
+		Currency:   invoice.Currency,
+	}
+
+	if len(requests) == 0 {
+		for _, line := range lines {
+			if line.total().IsPositive() {
+				note.Items = append(note.Items, creditItem(line, line.quantity, line.net, line.tax))
+			}
+		}
+		note.ChargesTotal = charges
+	} else {
+		byItem := make(map[uint]creditLine, len(lines))
+		for _, line := range lines {
+			byItem[line.item.ID] = line
+		}
+		seen := make(map[uint]bool, len(requests))
+		for i, req := range requests {
+			line, ok := byItem[req.InvoiceItemID]
+			switch {
+			case !ok:
+				return nil, fmt.Errorf("%w: item %d: invoice item %d is not on invoice %s", ErrInvalidCreditNote, i+1, req.InvoiceItemID, invoice.InvoiceNumber)
+			case seen[req.InvoiceItemID]:
+				return nil, fmt.Errorf("%w: item %d: invoice item %d is credited more than once", ErrInvalidCreditNote, i+1, req.InvoiceItemID)
+			case req.Quantity > 0 && req.Amount != nil:
+				return nil, fmt.Errorf("%w: item %d: give either a quantity or an amount", ErrInvalidCreditNote, i+1)
+			case !line.total().IsPositive():
+				return nil, fmt.Errorf("%w: item %d: %q was already credited in full", ErrInvalidCreditNote, i+1, line.item.Description)
+			}
+			seen[req.InvoiceItemID] = true
+
+			item, err := s.creditLineItem(line, req, invoice.Currency)
+			if err != nil {
+				return nil, fmt.Errorf("%w: item %d: %v", ErrInvalidCreditNote, i+1, err)
+			}
+			note.Items = append(note.Items, item)
+		}
+	}
+
+	for _, item := range note.Items {
+		note.Subtotal = note.Subtotal.Add(item.NetAmount)
+		note.TaxAmount = note.TaxAmount.Add(item.TaxAmount)
+	}
+	note.Total = note.Subtotal.Add(note.TaxAmount).Add(note.ChargesTotal)
+	if !note.Total.IsPositive() {
+		return nil, fmt.Errorf("%w: invoice %s was already credited in full", ErrInvalidCreditNote, invoice.InvoiceNumber)
+	}
+
+	if invoice.BaseCurrency != "" && !invoice.ExchangeRate.IsZero() {
+		base, mode := invoice.BaseCurrency, s.settings.Rounding
+		note.BaseCurrency = base
+		note.ExchangeRate = invoice.ExchangeRate
+		note.BaseSubtotal = invoice.ExchangeRate.Convert(note.Subtotal, mode).RoundTo(base, mode)
+		note.BaseTaxAmount = invoice.ExchangeRate.Convert(note.TaxAmount, mode).RoundTo(base, mode)
+		note.BaseChargesTotal = invoice.ExchangeRate.Convert(note.ChargesTotal, mode).RoundTo(base, mode)
+		note.BaseTotal = note.BaseSubtotal.Add(note.BaseTaxAmount).Add(note.BaseChargesTotal)
+	}
+	return note, nil
+}
+
+// creditLineItem credits a quantity of a line, an amount including tax, or what is left
+// of it. Partial credits take their share of the net and tax left on the line, so the
+// last credit of a line takes exactly what remains.
+func (s *creditNoteService) creditLineItem(line creditLine, req dtos.CreditNoteItemRequest, currency money.Currency) (models.CreditNoteItem, error) {
+	switch {
+	case req.Amount != nil:
+		amount := *req.Amount
+		if err := checkAmount(amount, currency, "amount"); err != nil {
+			return models.CreditNoteItem{}, err
+		}
+		remaining := line.total()
+		if amount.Cmp(remaining) > 0 {
+			return models.CreditNoteItem{}, fmt.Errorf("%s is more than the %s left to credit on %q", amount, remaining, line.item.Description)
+		}
+		if amount.Cmp(remaining) == 0 {
+			return creditItem(line, 0, line.net, line.tax), nil
+		}
+		net := s.share(currency, line.net, amount, remaining)
+		return creditItem(line, 0, net, amount.Sub(net)), nil
+
+	case req.Quantity > 0:
+		if req.Quantity > line.quantity {
+			return models.CreditNoteItem{}, fmt.Errorf("only %d of %q are left to credit", line.quantity, line.item.Description)
+		}
+		if req.Quantity == line.quantity {
+			return creditItem(line, line.quantity, line.net, line.tax), nil
+		}
+		quantity, left := money.FromInt(int64(req.Quantity)), money.FromInt(int64(line.quantity))
+		return creditItem(line, req.Quantity, s.share(currency, line.net, quantity, left), s.share(currency, line.tax, quantity, left)), nil
+	}
+	return creditItem(line, line.quantity, line.net, line.tax), nil
+}
+
+// creditableLines returns what is left to credit of every line of an invoice and of its
+// charges after the credit notes issued before. The invoice discount is spread over the
+// lines in proportion to their net amounts and rounding differences of the discount and
+// tax go to the last line, so crediting every line adds up to the invoice total.
+func (s *creditNoteService) creditableLines(invoice *models.Invoice, previous []models.CreditNote) ([]creditLine, money.Amount) {
+	lines := make([]creditLine, len(invoice.Items))
+	net, tax := money.Zero, money.Zero
+	discounted := invoice.Subtotal.Sub(invoice.DiscountAmount)
+	for i := range invoice.Items {
+		item := &invoice.Items[i]
+		lines[i] = creditLine{item: item, quantity: item.Quantity, net: item.NetAmount, tax: item.TaxAmount}
+		if invoice.DiscountAmount.IsPositive() && invoice.Subtotal.IsPositive() {
+			lines[i].net = s.share(invoice.Currency, item.NetAmount, discounted, invoice.Subtotal)
+		}
+		net, tax = net.Add(lines[i].net), tax.Add(lines[i].tax)
+	}
+	if last := len(lines) - 1; last >= 0 {
+		lines[last].net = lines[last].net.Add(discounted.Sub(net))
+		lines[last].tax = lines[last].tax.Add(invoice.TaxAmount.Sub(tax))
+	}
+
+	index := make(map[uint]int, len(lines))
+	for i, line := range lines {
+		index[line.item.ID] = i
+	}
+	charges := invoice.ChargesTotal
+	for _, note := range previous {
+		charges = charges.Sub(note.ChargesTotal)
+		for _, credited := range note.Items {
+			if i, ok := index[credited.InvoiceItemID]; ok {
+				lines[i].quantity -= credited.Quantity
+				lines[i].net = lines[i].net.Sub(credited.NetAmount)
+				lines[i].tax = lines[i].tax.Sub(credited.TaxAmount)
+			}
+		}
+	}
+	return lines, charges
+}
+
+// share returns amount * part / whole rounded to the currency
+func (s *creditNoteService) share(currency money.Currency, amount, part, whole money.Amount) money.Amount {
+	r := new(big.Rat).Mul(amount.Rat(), part.Rat())
+	r.Quo(r, whole.Rat())
+	return money.FromRat(r, currency.Digits(), s.settings.Rounding)
+}
+
+func creditItem(line creditLine, quantity int, net, tax money.Amount) models.CreditNoteItem {
+	return models.CreditNoteItem{
+		InvoiceItemID: line.item.ID,
+		Description:   line.item.Description,
+		Quantity:      quantity,
+		NetAmount:     net,
+		TaxAmount:     tax,
+		Total:         net.Add(tax),
+	}
+}
+
+// allocate validates the requested allocations of the remaining credit of a credit note,
+// or, when none are requested, spreads it over the customer's open invoices, oldest due
+// date first
+func (s *creditNoteService) allocate(invoices repositories.InvoiceRepository, note *models.CreditNote, requests []dtos.CreditNoteAllocationRequest, date time.Time, appliedByID *uint) ([]models.CreditNoteAllocation, error) {
+	var allocations []models.CreditNoteAllocation
+	available := note.RemainingAmount
+
+	if requests == nil {
+		open, err := invoices.FindPayable(note.CustomerID, note.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list open invoices: %w", err)
+		}
+		for _, invoice := range open {
+			amount := money.Min(invoice.AmountDue, available)
+			if !amount.IsPositive() {
+				continue
+			}
+			allocations = append(allocations, models.CreditNoteAllocation{CreditNoteID: note.ID, InvoiceID: invoice.ID, Amount: amount, Date: date, AppliedByID: appliedByID})
+			available = available.Sub(amount)
+		}
+		return allocations, nil
+	}
+
+	seen := make(map[uint]bool, len(requests))
+	for i, req := range requests {
+		if seen[req.InvoiceID] {
+			return nil, fmt.Errorf("%w: invoice %d is allocated more than once", ErrInvalidCreditNote, req.InvoiceID)
+		}
+		seen[req.InvoiceID] = true
+		if err := checkAmount(req.Amount, note.Currency, fmt.Sprintf("allocation %d: amount", i+1)); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCreditNote, err)
+		}
+
+		invoice, err := invoices.FindByIDForUpdate(req.InvoiceID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: invoice %d not found", ErrInvalidCreditNote, req.InvoiceID)
+			}
+			return nil, fmt.Errorf("failed to get invoice: %w", err)
+		}
+		switch {
+		case invoice.CustomerID != note.CustomerID:
+			return nil, fmt.Errorf("%w: invoice %s belongs to another customer", ErrInvalidCreditNote, invoice.InvoiceNumber)
+		case invoice.Currency != note.Currency:
+			return nil, fmt.Errorf("%w: invoice %s is in %s, not %s", ErrInvalidCreditNote, invoice.InvoiceNumber, invoice.Currency, note.Currency)
+		case !isPayable(invoice):
+			return nil, fmt.Errorf("%w: invoice %s is %s; credit can only be applied to sent, partially paid and overdue invoices", ErrInvalidCreditNote, invoice.InvoiceNumber, invoice.Status)
+		case req.Amount.Cmp(invoice.AmountDue) > 0:
+			return nil, fmt.Errorf("%w: %s is more than the %s due on invoice %s", ErrInvalidCreditNote, req.Amount, invoice.AmountDue, invoice.InvoiceNumber)
+		}
+
+		available = available.Sub(req.Amount)
+		if available.IsNegative() {
+			return nil, fmt.Errorf("%w: the allocations add up to more than the %s available", ErrInvalidCreditNote, note.RemainingAmount)
+		}
+		allocations = append(allocations, models.CreditNoteAllocation{CreditNoteID: note.ID, InvoiceID: invoice.ID, Amount: req.Amount, Date: date, AppliedByID: appliedByID})
+	}
+	return allocations, nil
+}
+
+// settle recomputes the amount credited on the invoices of the given allocations and
+// settles them. An invoice that ends up paid is dated by its last payment or credit.
+func (s *creditNoteService) settle(invoices repositories.InvoiceRepository, notes repositories.CreditNoteRepository, allocations []models.CreditNoteAllocation, changedByID *uint, note string) error {
+	settled := make(map[uint]bool, len(allocations))
+	for _, allocation := range allocations {
+		if settled[allocation.InvoiceID] {
+			continue
+		}
+		settled[allocation.InvoiceID] = true
+
+		invoice, err := invoices.FindByIDForUpdate(allocation.InvoiceID)
+		if err != nil {
+			return fmt.Errorf("failed to get invoice %d: %w", allocation.InvoiceID, err)
+		}
+		credited, err := notes.FindInvoiceAllocations(invoice.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get credit notes of invoice %d: %w", invoice.ID, err)
+		}
+
+		var settledAt time.Time
+		for _, paid := range invoice.Payments {
+			if paid.Date.After(settledAt) {
+				settledAt = paid.Date
+			}
+		}
+		invoice.AmountCredited = money.Zero
+		for _, credit := range credited {
+			invoice.AmountCredited = invoice.AmountCredited.Add(credit.Amount)
+			if credit.Date.After(settledAt) {
+				settledAt = credit.Date
+			}
+		}
+		if err := settleInvoice(invoices, invoice, settledAt, changedByID, note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findCreditNote loads a credit note and locks it for the rest of the transaction
+func (s *creditNoteService) findCreditNote(notes repositories.CreditNoteRepository, id uint) (*models.CreditNote, error) {
+	note, err := notes.FindByIDForUpdate(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCreditNoteNotFound
+		}
+		return nil, fmt.Errorf("failed to get credit note: %w", err)
+	}
+	return note, nil
+}
+
+// updateCreditNoteBalance derives the remaining credit and status of a credit note from
+// what was applied and refunded
+func updateCreditNoteBalance(note *models.CreditNote) {
+	note.RemainingAmount = note.Total.Sub(note.AppliedAmount).Sub(note.RefundedAmount)
+	note.Status = models.CreditNoteStatusOpen
+	if !note.RemainingAmount.IsPositive() {
+		note.Status = models.CreditNoteStatusClosed
+	}
+}
+
+// parseCreditNoteDate reads a YYYY-MM-DD date of a credit note or refund, defaulting to now
+func parseCreditNoteDate(raw, label string) (time.Time, error) {
+	if raw == "" {
+		return time.Now(), nil
+	}
+	date, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must be YYYY-MM-DD", ErrInvalidCreditNote, label)
+	}
+	if date.After(time.Now()) {
+		return time.Time{}, fmt.Errorf("%w: %s must not be in the future", ErrInvalidCreditNote, label)
+	}
+	return date, nil
+}
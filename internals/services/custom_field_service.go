@@ -0,0 +1,398 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCustomFieldNotFound is returned when a custom field definition does not exist
+	ErrCustomFieldNotFound = errors.New("custom field not found")
+	// ErrInvalidCustomField is returned when a definition or a custom field value is invalid
+	ErrInvalidCustomField = errors.New("invalid custom field")
+)
+
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+type CustomFieldService interface {
+	CreateDefinition(req *dtos.CreateCustomFieldRequest) (*models.CustomFieldDefinition, error)
+	GetDefinition(id uint) (*models.CustomFieldDefinition, error)
+	ListDefinitions(entityType string) ([]models.CustomFieldDefinition, error)
+	UpdateDefinition(id uint, req *dtos.UpdateCustomFieldRequest) (*models.CustomFieldDefinition, error)
+	DeleteDefinition(id uint) error
+	ResolveValues(entityType string, current []models.CustomFieldValue, input models.CustomFields) ([]models.CustomFieldValue, models.CustomFields, error)
+	ResolveFilter(entityType string, filter map[string]string) (map[string]string, error)
+}
+
+type customFieldService struct {
+	repo repositories.CustomFieldRepository
+}
+
+func NewCustomFieldService(repo repositories.CustomFieldRepository) CustomFieldService {
+	return &customFieldService{repo: repo}
+}
+
+func (s *customFieldService) CreateDefinition(req *dtos.CreateCustomFieldRequest) (*models.CustomFieldDefinition, error) {
+	key := strings.TrimSpace(req.Key)
+	if !customFieldKeyPattern.MatchString(key) {
+		return nil, fmt.Errorf("%w: key must start with a lowercase letter and contain only lowercase letters, digits and underscores", ErrInvalidCustomField)
+	}
+	if _, err := s.repo.FindByKey(req.EntityType, key); err == nil {
+		return nil, fmt.Errorf("%w: %s already has a field %q", ErrInvalidCustomField, req.EntityType, key)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check custom field: %w", err)
+	}
+
+	definition := &models.CustomFieldDefinition{EntityType: req.EntityType, Key: key}
+	applyCustomFieldRequest(definition, dtos.UpdateCustomFieldRequest{
+		Label:    req.Label,
+		Type:     req.Type,
+		Required: req.Required,
+		Options:  req.Options,
+		Pattern:  req.Pattern,
+		Min:      req.Min,
+		Max:      req.Max,
+		Position: req.Position,
+	})
+	if err := validateDefinition(definition); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(definition); err != nil {
+		return nil, fmt.Errorf("failed to create custom field: %w", err)
+	}
+	return definition, nil
+}
+
+func (s *customFieldService) GetDefinition(id uint) (*models.CustomFieldDefinition, error) {
+	definition, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCustomFieldNotFound
+		}
+		return nil, fmt.Errorf("failed to get custom field: %w", err)
+	}
+	return definition, nil
+}
+
+func (s *customFieldService) ListDefinitions(entityType string) ([]models.CustomFieldDefinition, error) {
+	definitions, err := s.repo.FindAll(entityType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list custom fields: %w", err)
+	}
+	return definitions, nil
+}
+
+// UpdateDefinition replaces a definition. The type can only change while no record has a
+// value for the field; tightened validation applies to values written afterwards.
+func (s *customFieldService) UpdateDefinition(id uint, req *dtos.UpdateCustomFieldRequest) (*models.CustomFieldDefinition, error) {
+	definition, err := s.GetDefinition(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Type != definition.Type {
+		count, err := s.repo.CountValues(definition.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count custom field values: %w", err)
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("%w: cannot change the type of %q while %d record(s) have a value", ErrInvalidCustomField, definition.Key, count)
+		}
+	}
+
+	applyCustomFieldRequest(definition, *req)
+	if err := validateDefinition(definition); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(definition); err != nil {
+		return nil, fmt.Errorf("failed to update custom field: %w", err)
+	}
+	return definition, nil
+}
+
+// DeleteDefinition removes a definition and every value stored for it
+func (s *customFieldService) DeleteDefinition(id uint) error {
+	if err := s.repo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCustomFieldNotFound
+		}
+		return fmt.Errorf("failed to delete custom field: %w", err)
+	}
+	return nil
+}
+
+// ResolveValues applies input on top of the current values of a record and validates the
+// result against the definitions of the entity type. A nil input keeps the current values,
+// and a null or empty value removes a field. It returns the complete set of values to
+// store and the resulting custom_fields map.
+func (s *customFieldService) ResolveValues(entityType string, current []models.CustomFieldValue, input models.CustomFields) ([]models.CustomFieldValue, models.CustomFields, error) {
+	definitions, err := s.repo.FindAll(entityType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list custom fields: %w", err)
+	}
+
+	byKey := make(map[string]*models.CustomFieldDefinition, len(definitions))
+	byID := make(map[uint]*models.CustomFieldDefinition, len(definitions))
+	for i := range definitions {
+		byKey[definitions[i].Key] = &definitions[i]
+		byID[definitions[i].ID] = &definitions[i]
+	}
+
+	encoded := make(map[uint]string)
+	for _, value := range current {
+		if _, ok := byID[value.DefinitionID]; ok {
+			encoded[value.DefinitionID] = value.Value
+		}
+	}
+
+	keys := make([]string, 0, len(input))
+	for key := range input {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var problems []string
+	for _, key := range keys {
+		definition, ok := byKey[key]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: unknown field", key))
+			continue
+		}
+		value, err := normalizeCustomFieldValue(definition, input[key])
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		if value == nil {
+			delete(encoded, definition.ID)
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		encoded[definition.ID] = string(data)
+	}
+
+	for _, definition := range definitions {
+		if _, ok := encoded[definition.ID]; definition.Required && !ok {
+			problems = append(problems, fmt.Sprintf("%s: is required", definition.Key))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrInvalidCustomField, strings.Join(problems, "; "))
+	}
+
+	values := make([]models.CustomFieldValue, 0, len(encoded))
+	fields := make(models.CustomFields, len(encoded))
+	for _, definition := range definitions {
+		data, ok := encoded[definition.ID]
+		if !ok {
+			continue
+		}
+		values = append(values, models.CustomFieldValue{DefinitionID: definition.ID, Value: data})
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(data), &decoded); err == nil {
+			fields[definition.Key] = decoded
+		}
+	}
+	return values, fields, nil
+}
+
+// ResolveFilter validates custom field list filters and encodes each value the way it is
+// stored, so the repository can compare them directly
+func (s *customFieldService) ResolveFilter(entityType string, filter map[string]string) (map[string]string, error) {
+	if len(filter) == 0 {
+		return nil, nil
+	}
+
+	resolved := make(map[string]string, len(filter))
+	for key, raw := range filter {
+		definition, err := s.repo.FindByKey(entityType, key)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidCustomField, key)
+			}
+			return nil, fmt.Errorf("failed to get custom field: %w", err)
+		}
+
+		var value interface{} = raw
+		switch definition.Type {
+		case models.CustomFieldTypeNumber:
+			number, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s must be a number", ErrInvalidCustomField, key)
+			}
+			value = number
+		case models.CustomFieldTypeBoolean:
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s must be true or false", ErrInvalidCustomField, key)
+			}
+			value = b
+		case models.CustomFieldTypeDate:
+			if _, err := time.Parse("2006-01-02", raw); err != nil {
+				return nil, fmt.Errorf("%w: %s must be a YYYY-MM-DD date", ErrInvalidCustomField, key)
+			}
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		resolved[key] = string(data)
+	}
+	return resolved, nil
+}
+
+// normalizeCustomFieldValue checks a value against its definition and returns it in its
+// canonical form, or nil when the value is empty
+func normalizeCustomFieldValue(definition *models.CustomFieldDefinition, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if text, ok := value.(string); ok {
+		value = strings.TrimSpace(text)
+		if value == "" {
+			return nil, nil
+		}
+	}
+
+	switch definition.Type {
+	case models.CustomFieldTypeText:
+		text, ok := value.(string)
+		if !ok {
+			return nil, errors.New("must be a string")
+		}
+		length := float64(utf8.RuneCountInString(text))
+		if definition.Min != nil && length < *definition.Min {
+			return nil, fmt.Errorf("must be at least %g characters", *definition.Min)
+		}
+		if definition.Max != nil && length > *definition.Max {
+			return nil, fmt.Errorf("must be at most %g characters", *definition.Max)
+		}
+		if definition.Pattern != "" {
+			if matched, err := regexp.MatchString(definition.Pattern, text); err != nil || !matched {
+				return nil, fmt.Errorf("must match %s", definition.Pattern)
+			}
+		}
+		return text, nil
+
+	case models.CustomFieldTypeNumber:
+		var number float64
+		switch v := value.(type) {
+		case float64:
+			number = v
+		case string:
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, errors.New("must be a number")
+			}
+			number = parsed
+		default:
+			return nil, errors.New("must be a number")
+		}
+		if definition.Min != nil && number < *definition.Min {
+			return nil, fmt.Errorf("must be at least %g", *definition.Min)
+		}
+		if definition.Max != nil && number > *definition.Max {
+			return nil, fmt.Errorf("must be at most %g", *definition.Max)
+		}
+		return number, nil
+
+	case models.CustomFieldTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, errors.New("must be true or false")
+			}
+			return b, nil
+		default:
+			return nil, errors.New("must be true or false")
+		}
+
+	case models.CustomFieldTypeDate:
+		text, ok := value.(string)
+		if !ok {
+			return nil, errors.New("must be a YYYY-MM-DD date")
+		}
+		date, err := time.Parse("2006-01-02", text)
+		if err != nil {
+			if date, err = time.Parse(time.RFC3339, text); err != nil {
+				return nil, errors.New("must be a YYYY-MM-DD date")
+			}
+		}
+		return date.Format("2006-01-02"), nil
+
+	case models.CustomFieldTypeSelect:
+		text, ok := value.(string)
+		if !ok {
+			return nil, errors.New("must be one of the options")
+		}
+		for _, option := range definition.Options {
+			if option == text {
+				return text, nil
+			}
+		}
+		return nil, fmt.Errorf("must be one of %s", strings.Join(definition.Options, ", "))
+	}
+
+	return nil, fmt.Errorf("unsupported field type %q", definition.Type)
+}
+
+func applyCustomFieldRequest(definition *models.CustomFieldDefinition, req dtos.UpdateCustomFieldRequest) {
+	definition.Label = strings.TrimSpace(req.Label)
+	definition.Type = req.Type
+	definition.Required = req.Required
+	definition.Pattern = req.Pattern
+	definition.Min = req.Min
+	definition.Max = req.Max
+	definition.Position = req.Position
+
+	definition.Options = nil
+	seen := make(map[string]bool, len(req.Options))
+	for _, option := range req.Options {
+		if option = strings.TrimSpace(option); option != "" && !seen[option] {
+			seen[option] = true
+			definition.Options = append(definition.Options, option)
+		}
+	}
+}
+
+func validateDefinition(definition *models.CustomFieldDefinition) error {
+	switch {
+	case definition.Type == models.CustomFieldTypeSelect && len(definition.Options) == 0:
+		return fmt.Errorf("%w: select fields need at least one option", ErrInvalidCustomField)
+	case definition.Type != models.CustomFieldTypeSelect && len(definition.Options) > 0:
+		return fmt.Errorf("%w: options are only allowed on select fields", ErrInvalidCustomField)
+	case definition.Pattern != "" && definition.Type != models.CustomFieldTypeText:
+		return fmt.Errorf("%w: a pattern is only allowed on text fields", ErrInvalidCustomField)
+	case (definition.Min != nil || definition.Max != nil) && definition.Type != models.CustomFieldTypeText && definition.Type != models.CustomFieldTypeNumber:
+		return fmt.Errorf("%w: min and max are only allowed on text and number fields", ErrInvalidCustomField)
+	case definition.Min != nil && definition.Max != nil && *definition.Min > *definition.Max:
+		return fmt.Errorf("%w: min must not be greater than max", ErrInvalidCustomField)
+	}
+	if definition.Pattern != "" {
+		if _, err := regexp.Compile(definition.Pattern); err != nil {
+			return fmt.Errorf("%w: invalid pattern: %v", ErrInvalidCustomField, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"gorm.io/gorm"
+)
+
+// ErrAddressNotFound is returned when an address does not exist for the customer
+var ErrAddressNotFound = errors.New("address not found")
+
+type CustomerAddressService interface {
+	CreateAddress(customerID uint, req *dtos.CustomerAddressRequest) (*models.CustomerAddress, error)
+	GetAddress(customerID, id uint) (*models.CustomerAddress, error)
+	ListAddresses(customerID uint, addressType string) ([]models.CustomerAddress, error)
+	UpdateAddress(customerID, id uint, req *dtos.CustomerAddressRequest) (*models.CustomerAddress, error)
+	DeleteAddress(customerID, id uint) error
+}
+
+type customerAddressService struct {
+	repo         repositories.CustomerAddressRepository
+	customerRepo repositories.CustomerRepository
+	transactor   repositories.Transactor
+}
+
+func NewCustomerAddressService(repo repositories.CustomerAddressRepository, customerRepo repositories.CustomerRepository, transactor repositories.Transactor) CustomerAddressService {
+	return &customerAddressService{repo: repo, customerRepo: customerRepo, transactor: transactor}
+}
+
+// CreateAddress adds an address. The first address of a type always becomes its default.
+func (s *customerAddressService) CreateAddress(customerID uint, req *dtos.CustomerAddressRequest) (*models.CustomerAddress, error) {
+	if err := ensureCustomerExists(s.customerRepo, customerID); err != nil {
+		return nil, err
+	}
+
+	address := &models.CustomerAddress{CustomerID: customerID}
+	applyAddressRequest(address, req)
+
+	err := s.transactor.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if !address.IsDefault {
+			_, err := repo.FindDefault(customerID, address.Type)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				address.IsDefault = true
+			} else if err != nil {
+				return err
+			}
+		}
+		if err := repo.Create(address); err != nil {
+			return err
+		}
+		if address.IsDefault {
+			return repo.ClearDefault(customerID, address.Type, address.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create address: %w", err)
+	}
+	return address, nil
+}
+
+func (s *customerAddressService) GetAddress(customerID, id uint) (*models.CustomerAddress, error) {
+	address, err := s.repo.FindByID(customerID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAddressNotFound
+		}
+		return nil, fmt.Errorf("failed to get address: %w", err)
+	}
+	return address, nil
+}
+
+func (s *customerAddressService) ListAddresses(customerID uint, addressType string) ([]models.CustomerAddress, error) {
+	if err := ensureCustomerExists(s.customerRepo, customerID); err != nil {
+		return nil, err
+	}
+
+	addresses, err := s.repo.FindByCustomerID(customerID, addressType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses: %w", err)
+	}
+	return addresses, nil
+}
+
+// UpdateAddress replaces an address. Unsetting the default flag is ignored; another
+// address has to be made the default instead.
+func (s *customerAddressService) UpdateAddress(customerID, id uint, req *dtos.CustomerAddressRequest) (*models.CustomerAddress, error) {
+	address, err := s.GetAddress(customerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	previousType, wasDefault := address.Type, address.IsDefault
+	applyAddressRequest(address, req)
+	if wasDefault && previousType == address.Type {
+		address.IsDefault = true
+	}
+
+	err = s.transactor.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if !address.IsDefault {
+			_, err := repo.FindDefault(customerID, address.Type)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				address.IsDefault = true
+			} else if err != nil {
+				return err
+			}
+		}
+		if err := repo.Update(address); err != nil {
+			return err
+		}
+		if address.IsDefault {
+			if err := repo.ClearDefault(customerID, address.Type, address.ID); err != nil {
+				return err
+			}
+		}
+		if wasDefault && previousType != address.Type {
+			return promoteDefaultAddress(repo, customerID, previousType)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update address: %w", err)
+	}
+	return address, nil
+}
+
+// DeleteAddress removes an address and promotes the oldest remaining address of the
+// same type when the default is deleted. Invoices keep referencing the deleted address.
+func (s *customerAddressService) DeleteAddress(customerID, id uint) error {
+	address, err := s.GetAddress(customerID, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.transactor.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.Delete(customerID, id); err != nil {
+			return err
+		}
+		if address.IsDefault {
+			return promoteDefaultAddress(repo, customerID, address.Type)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete address: %w", err)
+	}
+	return nil
+}
+
+// promoteDefaultAddress makes the oldest address of the type the default, if there is one.
+// It is only called when the type has no default left, so the first address is the oldest.
+func promoteDefaultAddress(repo repositories.CustomerAddressRepository, customerID uint, addressType string) error {
+	addresses, err := repo.FindByCustomerID(customerID, addressType)
+	if err != nil || len(addresses) == 0 {
+		return err
+	}
+	oldest := addresses[0]
+	oldest.IsDefault = true
+	if err := repo.Update(&oldest); err != nil {
+		return err
+	}
+	return repo.ClearDefault(customerID, addressType, oldest.ID)
+}
+
+func applyAddressRequest(address *models.CustomerAddress, req *dtos.CustomerAddressRequest) {
+	address.Type = req.Type
+	if address.Type == "" {
+		address.Type = models.AddressTypeBilling
+	}
+	address.Label = strings.TrimSpace(req.Label)
+	address.Line1 = strings.TrimSpace(req.Line1)
+	address.Line2 = strings.TrimSpace(req.Line2)
+	address.City = strings.TrimSpace(req.City)
+	address.Region = strings.TrimSpace(req.Region)
+	address.PostalCode = strings.TrimSpace(req.PostalCode)
+	address.Country = strings.ToUpper(req.Country)
+	address.IsDefault = req.IsDefault
+}
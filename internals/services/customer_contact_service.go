@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrCustomerNotFound is returned when the parent customer of a nested record does not exist
+	ErrCustomerNotFound = errors.New("customer not found")
+	// ErrContactNotFound is returned when a contact does not exist for the customer
+	ErrContactNotFound = errors.New("contact not found")
+)
+
+type CustomerContactService interface {
+	CreateContact(customerID uint, req *dtos.CustomerContactRequest) (*models.CustomerContact, error)
+	GetContact(customerID, id uint) (*models.CustomerContact, error)
+	ListContacts(customerID uint) ([]models.CustomerContact, error)
+	UpdateContact(customerID, id uint, req *dtos.CustomerContactRequest) (*models.CustomerContact, error)
+	DeleteContact(customerID, id uint) error
+}
+
+type customerContactService struct {
+	repo         repositories.CustomerContactRepository
+	customerRepo repositories.CustomerRepository
+}
+
+func NewCustomerContactService(repo repositories.CustomerContactRepository, customerRepo repositories.CustomerRepository) CustomerContactService {
+	return &customerContactService{repo: repo, customerRepo: customerRepo}
+}
+
+func (s *customerContactService) CreateContact(customerID uint, req *dtos.CustomerContactRequest) (*models.CustomerContact, error) {
+	if err := ensureCustomerExists(s.customerRepo, customerID); err != nil {
+		return nil, err
+	}
+
+	contact := &models.CustomerContact{CustomerID: customerID}
+	applyContactRequest(contact, req)
+	if err := s.repo.Create(contact); err != nil {
+		return nil, fmt.Errorf("failed to create contact: %w", err)
+	}
+	return contact, nil
+}
+
+func (s *customerContactService) GetContact(customerID, id uint) (*models.CustomerContact, error) {
+	contact, err := s.repo.FindByID(customerID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrContactNotFound
+		}
+		return nil, fmt.Errorf("failed to get contact: %w", err)
+	}
+	return contact, nil
+}
+
+func (s *customerContactService) ListContacts(customerID uint) ([]models.CustomerContact, error) {
+	if err := ensureCustomerExists(s.customerRepo, customerID); err != nil {
+		return nil, err
+	}
+
+	contacts, err := s.repo.FindByCustomerID(customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list contacts: %w", err)
+	}
+	return contacts, nil
+}
+
+func (s *customerContactService) UpdateContact(customerID, id uint, req *dtos.CustomerContactRequest) (*models.CustomerContact, error) {
+	contact, err := s.GetContact(customerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	applyContactRequest(contact, req)
+	if err := s.repo.Update(contact); err != nil {
+		return nil, fmt.Errorf("failed to update contact: %w", err)
+	}
+	return contact, nil
+}
+
+func (s *customerContactService) DeleteContact(customerID, id uint) error {
+	if err := s.repo.Delete(customerID, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrContactNotFound
+		}
+		return fmt.Errorf("failed to delete contact: %w", err)
+	}
+	return nil
+}
+
+func applyContactRequest(contact *models.CustomerContact, req *dtos.CustomerContactRequest) {
+	contact.Name = strings.TrimSpace(req.Name)
+	contact.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	contact.Phone = strings.TrimSpace(req.Phone)
+	contact.JobTitle = strings.TrimSpace(req.JobTitle)
+	contact.Role = req.Role
+	if contact.Role == "" {
+		contact.Role = models.ContactRoleOther
+	}
+}
+
+// ensureCustomerExists maps a missing parent customer to ErrCustomerNotFound
+func ensureCustomerExists(repo repositories.CustomerRepository, customerID uint) error {
+	if _, err := repo.FindByID(customerID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCustomerNotFound
+		}
+		return fmt.Errorf("failed to get customer: %w", err)
+	}
+	return nil
+}
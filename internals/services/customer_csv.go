@@ -0,0 +1,402 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"gorm.io/gorm"
+)
+
+// Customer fields a CSV column can be mapped to
+const (
+	CustomerFieldName    = "name"
+	CustomerFieldEmail   = "email"
+	CustomerFieldPhone   = "phone"
+	CustomerFieldAddress = "address"
+	CustomerFieldTags    = "tags"
+)
+
+// MaxCustomerImportRows limits the number of data rows in a single import
+const MaxCustomerImportRows = 10000
+
+// customerTagSeparator separates tags inside a single CSV cell
+const customerTagSeparator = ";"
+
+// Import row actions
+const (
+	ImportActionCreate = "create"
+	ImportActionUpdate = "update"
+	ImportActionError  = "error"
+)
+
+// ErrInvalidCSV is returned when the uploaded file cannot be imported at all
+var ErrInvalidCSV = errors.New("invalid CSV")
+
+var emailValidator = validator.New()
+
+var customerFields = []string{CustomerFieldName, CustomerFieldEmail, CustomerFieldPhone, CustomerFieldAddress, CustomerFieldTags}
+
+// customerHeaderAliases maps normalized CSV headers to customer fields
+var customerHeaderAliases = map[string]string{
+	"name":          CustomerFieldName,
+	"fullname":      CustomerFieldName,
+	"customer":      CustomerFieldName,
+	"customername":  CustomerFieldName,
+	"company":       CustomerFieldName,
+	"companyname":   CustomerFieldName,
+	"email":         CustomerFieldEmail,
+	"emailaddress":  CustomerFieldEmail,
+	"mail":          CustomerFieldEmail,
+	"phone":         CustomerFieldPhone,
+	"phonenumber":   CustomerFieldPhone,
+	"telephone":     CustomerFieldPhone,
+	"tel":           CustomerFieldPhone,
+	"mobile":        CustomerFieldPhone,
+	"address":       CustomerFieldAddress,
+	"streetaddress": CustomerFieldAddress,
+	"tags":          CustomerFieldTags,
+	"tag":           CustomerFieldTags,
+	"labels":        CustomerFieldTags,
+}
+
+// CustomerImportOptions controls a CSV customer import
+type CustomerImportOptions struct {
+	// Mapping maps CSV headers to customer fields and overrides the automatic mapping
+	Mapping map[string]string
+	// DryRun validates and reports without writing anything
+	DryRun bool
+	// Upsert updates customers whose email already exists instead of reporting a duplicate
+	Upsert bool
+}
+
+type customerImportRow struct {
+	line     int
+	customer models.Customer
+	hasTags  bool
+}
+
+// ImportCustomersCSV validates a CSV file row by row and creates or upserts the
+// valid rows in a single transaction. Invalid rows are reported and skipped.
+func (s *customerService) ImportCustomersCSV(r io.Reader, opts CustomerImportOptions) (*dtos.CustomerImportReport, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read header: %v", ErrInvalidCSV, err)
+	}
+	columns, mapping, err := resolveCustomerColumns(header, opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dtos.CustomerImportReport{
+		DryRun:  opts.DryRun,
+		Upsert:  opts.Upsert,
+		Mapping: mapping,
+		Rows:    []dtos.CustomerImportRowResult{},
+		Errors:  []dtos.CustomerImportRowError{},
+	}
+
+	var rows []customerImportRow
+	seenEmails := make(map[string]int)
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		report.TotalRows++
+		if report.TotalRows > MaxCustomerImportRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidCSV, MaxCustomerImportRows)
+		}
+
+		row := parseCustomerRecord(line, record, columns)
+		rowErrors := validateCustomerRow(row)
+		if email := strings.ToLower(row.customer.Email); email != "" && len(rowErrors) == 0 {
+			if first, ok := seenEmails[email]; ok {
+				rowErrors = append(rowErrors, dtos.CustomerImportRowError{
+					Row: line, Field: CustomerFieldEmail, Value: row.customer.Email,
+					Message: fmt.Sprintf("duplicate email, already used on row %d", first),
+				})
+			} else {
+				seenEmails[email] = line
+			}
+		}
+
+		if len(rowErrors) > 0 {
+			report.Errors = append(report.Errors, rowErrors...)
+			report.Rows = append(report.Rows, dtos.CustomerImportRowResult{Row: line, Email: row.customer.Email, Action: ImportActionError})
+			report.Failed++
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	err = s.transactor.Transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		for _, row := range rows {
+			if err := s.importCustomerRow(repo, row, opts, report); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(report.Rows, func(i, j int) bool { return report.Rows[i].Row < report.Rows[j].Row })
+	sort.SliceStable(report.Errors, func(i, j int) bool { return report.Errors[i].Row < report.Errors[j].Row })
+	return report, nil
+}
+
+func (s *customerService) importCustomerRow(repo repositories.CustomerRepository, row customerImportRow, opts CustomerImportOptions, report *dtos.CustomerImportReport) error {
+	fail := func(message string) {
+		report.Errors = append(report.Errors, dtos.CustomerImportRowError{
+			Row: row.line, Field: CustomerFieldEmail, Value: row.customer.Email, Message: message,
+		})
+		report.Rows = append(report.Rows, dtos.CustomerImportRowResult{Row: row.line, Email: row.customer.Email, Action: ImportActionError})
+		report.Failed++
+	}
+
+	existing, err := repo.FindByEmail(row.customer.Email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to look up customer %s: %w", row.customer.Email, err)
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		customer := row.customer
+		customer.Tags = models.NormalizeTags(customer.Tags)
+		values, _, err := s.customFields.ResolveValues(models.TaggableCustomer, nil, nil)
+		if err != nil {
+			if errors.Is(err, ErrInvalidCustomField) {
+				report.Errors = append(report.Errors, dtos.CustomerImportRowError{Row: row.line, Field: "custom_fields", Message: err.Error()})
+				report.Rows = append(report.Rows, dtos.CustomerImportRowResult{Row: row.line, Email: row.customer.Email, Action: ImportActionError})
+				report.Failed++
+				return nil
+			}
+			return err
+		}
+		customer.CustomFieldValues = values
+		if !opts.DryRun {
+			if err := repo.Create(&customer); err != nil {
+				return fmt.Errorf("failed to create customer on row %d: %w", row.line, err)
+			}
+		}
+		report.Created++
+		report.Rows = append(report.Rows, dtos.CustomerImportRowResult{Row: row.line, Email: customer.Email, Action: ImportActionCreate, CustomerID: customer.ID})
+		return nil
+	}
+
+	if existing.DeletedAt.Valid {
+		fail(fmt.Sprintf("email belongs to deleted customer #%d", existing.ID))
+		return nil
+	}
+	if !opts.Upsert {
+		fail(fmt.Sprintf("duplicate email, customer #%d already exists", existing.ID))
+		return nil
+	}
+
+	existing.Name = row.customer.Name
+	if row.customer.Phone != "" {
+		existing.Phone = row.customer.Phone
+	}
+	if row.customer.Address != "" {
+		existing.Address = row.customer.Address
+	}
+	if row.hasTags {
+		existing.Tags = models.NormalizeTags(append(existing.Tags, row.customer.Tags...))
+	}
+	if !opts.DryRun {
+		if err := repo.Update(existing); err != nil {
+			return fmt.Errorf("failed to update customer on row %d: %w", row.line, err)
+		}
+	}
+	report.Updated++
+	report.Rows = append(report.Rows, dtos.CustomerImportRowResult{Row: row.line, Email: existing.Email, Action: ImportActionUpdate, CustomerID: existing.ID})
+	return nil
+}
+
+// ExportCustomersCSV streams every customer matching the filter as CSV
+func (s *customerService) ExportCustomersCSV(w io.Writer, filter repositories.CustomerFilter) error {
+	customFields, err := s.customFields.ResolveFilter(models.TaggableCustomer, filter.CustomFields)
+	if err != nil {
+		return err
+	}
+	filter.CustomFields = customFields
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"id", "name", "email", "phone", "address", "tags", "created_at"}); err != nil {
+		return err
+	}
+
+	err = s.repo.FindInBatches(filter, 500, func(customers []models.Customer) error {
+		for _, customer := range customers {
+			tags := make([]string, len(customer.Tags))
+			for i, tag := range customer.Tags {
+				tags[i] = tag.Name
+			}
+			record := []string{
+				strconv.FormatUint(uint64(customer.ID), 10),
+				sanitizeCSVCell(customer.Name),
+				customer.Email,
+				customer.Phone,
+				sanitizeCSVCell(customer.Address),
+				sanitizeCSVCell(strings.Join(tags, customerTagSeparator)),
+				customer.CreatedAt.UTC().Format(time.RFC3339),
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// resolveCustomerColumns maps each CSV column index to a customer field.
+// Explicit mappings win over the automatic header aliases.
+func resolveCustomerColumns(header []string, explicit map[string]string) (map[int]string, map[string]string, error) {
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\uFEFF")
+	}
+
+	explicitByHeader := make(map[string]string, len(explicit))
+	for column, field := range explicit {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field != "" && !isCustomerField(field) {
+			return nil, nil, fmt.Errorf("%w: unknown field %q in mapping, expected one of %s", ErrInvalidCSV, field, strings.Join(customerFields, ", "))
+		}
+		explicitByHeader[strings.ToLower(strings.TrimSpace(column))] = field
+	}
+
+	columns := make(map[int]string)
+	mapping := make(map[string]string)
+	mapped := make(map[string]string)
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		field, ok := explicitByHeader[strings.ToLower(name)]
+		if !ok {
+			field = customerHeaderAliases[normalizeHeader(name)]
+		}
+		if field == "" {
+			continue
+		}
+		if previous, ok := mapped[field]; ok {
+			return nil, nil, fmt.Errorf("%w: columns %q and %q both map to %s", ErrInvalidCSV, previous, name, field)
+		}
+		columns[i] = field
+		mapping[name] = field
+		mapped[field] = name
+	}
+
+	for _, required := range []string{CustomerFieldName, CustomerFieldEmail} {
+		if _, ok := mapped[required]; !ok {
+			return nil, nil, fmt.Errorf("%w: no column mapped to %s", ErrInvalidCSV, required)
+		}
+	}
+
+	return columns, mapping, nil
+}
+
+func parseCustomerRecord(line int, record []string, columns map[int]string) customerImportRow {
+	row := customerImportRow{line: line}
+	for i, value := range record {
+		field, ok := columns[i]
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch field {
+		case CustomerFieldName:
+			row.customer.Name = value
+		case CustomerFieldEmail:
+			row.customer.Email = strings.ToLower(value)
+		case CustomerFieldPhone:
+			row.customer.Phone = value
+		case CustomerFieldAddress:
+			row.customer.Address = value
+		case CustomerFieldTags:
+			row.hasTags = true
+			for _, tag := range strings.Split(value, customerTagSeparator) {
+				row.customer.Tags = append(row.customer.Tags, models.Tag{Name: tag})
+			}
+		}
+	}
+	return row
+}
+
+func validateCustomerRow(row customerImportRow) []dtos.CustomerImportRowError {
+	var rowErrors []dtos.CustomerImportRowError
+	if row.customer.Name == "" {
+		rowErrors = append(rowErrors, dtos.CustomerImportRowError{Row: row.line, Field: CustomerFieldName, Message: "name is required"})
+	}
+	if row.customer.Email == "" {
+		rowErrors = append(rowErrors, dtos.CustomerImportRowError{Row: row.line, Field: CustomerFieldEmail, Message: "email is required"})
+	} else if err := emailValidator.Var(row.customer.Email, "email"); err != nil {
+		rowErrors = append(rowErrors, dtos.CustomerImportRowError{Row: row.line, Field: CustomerFieldEmail, Value: row.customer.Email, Message: "invalid email format"})
+	}
+	return rowErrors
+}
+
+func isCustomerField(field string) bool {
+	for _, f := range customerFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeHeader lowercases a header and drops everything but letters and digits
+func normalizeHeader(header string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(header) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// sanitizeCSVCell neutralizes values that spreadsheet applications would evaluate as formulas
+func sanitizeCSVCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+	"gorm.io/gorm"
+)
+
+// Duplicate match reasons
+const (
+	DuplicateReasonEmail = "email"
+	DuplicateReasonPhone = "phone"
+	DuplicateReasonName  = "name"
+)
+
+// DefaultDuplicateThreshold is the minimum score of a reported duplicate pair
+const DefaultDuplicateThreshold = 0.85
+
+// phoneMatchScore is the score of two customers whose phone numbers match
+const phoneMatchScore = 0.95
+
+type CustomerMergeService interface {
+	FindDuplicates(threshold float64, limit int) ([]dtos.DuplicateCandidate, error)
+	MergeCustomers(survivorID, duplicateID uint, mergedByID *uint) (*models.CustomerMerge, error)
+	ListMerges(customerID uint, page, limit int) ([]models.CustomerMerge, *utils.Pagination, error)
+}
+
+type customerMergeService struct {
+	transactor    repositories.Transactor
+	customerRepo  repositories.CustomerRepository
+	invoiceRepo   repositories.InvoiceRepository
+	paymentRepo   repositories.PaymentRepository
+	creditRepo    repositories.CreditNoteRepository
+	quoteRepo     repositories.QuoteRepository
+	recurringRepo repositories.RecurringInvoiceRepository
+	fileRepo      repositories.AttachmentRepository
+	contactRepo   repositories.CustomerContactRepository
+	addressRepo   repositories.CustomerAddressRepository
+	mergeRepo     repositories.CustomerMergeRepository
+}
+
+func NewCustomerMergeService(
+	transactor repositories.Transactor,
+	customerRepo repositories.CustomerRepository,
+	invoiceRepo repositories.InvoiceRepository,
+	paymentRepo repositories.PaymentRepository,
+	creditRepo repositories.CreditNoteRepository,
+	quoteRepo repositories.QuoteRepository,
+	recurringRepo repositories.RecurringInvoiceRepository,
+	fileRepo repositories.AttachmentRepository,
+	contactRepo repositories.CustomerContactRepository,
+	addressRepo repositories.CustomerAddressRepository,
+	mergeRepo repositories.CustomerMergeRepository,
+) CustomerMergeService {
+	return &customerMergeService{
+		transactor:    transactor,
+		customerRepo:  customerRepo,
+		invoiceRepo:   invoiceRepo,
+		paymentRepo:   paymentRepo,
+		creditRepo:    creditRepo,
+		quoteRepo:     quoteRepo,
+		recurringRepo: recurringRepo,
+		fileRepo:      fileRepo,
+		contactRepo:   contactRepo,
+		addressRepo:   addressRepo,
+		mergeRepo:     mergeRepo,
+	}
+}
+
+// duplicateKey is a normalized customer used to find and score duplicate pairs
+type duplicateKey struct {
+	customer models.Customer
+	email    string
+	phone    string
+	name     string
+}
+
+// FindDuplicates returns pairs of customers that share a normalized email or phone
+// number, or whose normalized names are similar, ordered by descending score.
+// Only customers that share an email, phone suffix or name word prefix are compared.
+func (s *customerMergeService) FindDuplicates(threshold float64, limit int) ([]dtos.DuplicateCandidate, error) {
+	customers, err := s.customerRepo.FindAllForDuplicateScan()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customers: %w", err)
+	}
+
+	keys := make([]duplicateKey, len(customers))
+	blocks := make(map[string][]int)
+	for i, customer := range customers {
+		tokens := nameTokens(customer.Name)
+		keys[i] = duplicateKey{
+			customer: customer,
+			email:    normalizeEmail(customer.Email),
+			phone:    normalizePhone(customer.Phone),
+			name:     strings.Join(tokens, " "),
+		}
+
+		var blockKeys []string
+		if keys[i].email != "" {
+			blockKeys = append(blockKeys, "e:"+keys[i].email)
+		}
+		if phone := keys[i].phone; len(phone) >= 7 {
+			blockKeys = append(blockKeys, "p:"+phone[len(phone)-7:])
+		}
+		for _, token := range tokens {
+			if runes := []rune(token); len(runes) > 3 {
+				token = string(runes[:3])
+			}
+			blockKeys = append(blockKeys, "n:"+token)
+		}
+		for _, key := range blockKeys {
+			blocks[key] = append(blocks[key], i)
+		}
+	}
+
+	seen := make(map[[2]int]bool)
+	var candidates []dtos.DuplicateCandidate
+	for _, members := range blocks {
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				pair := [2]int{members[x], members[y]}
+				if seen[pair] {
+					continue
+				}
+				seen[pair] = true
+
+				if candidate, ok := scoreDuplicate(keys[pair[0]], keys[pair[1]], threshold); ok {
+					candidates = append(candidates, candidate)
+				}
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		if candidates[i].Customer.ID != candidates[j].Customer.ID {
+			return candidates[i].Customer.ID < candidates[j].Customer.ID
+		}
+		return candidates[i].Duplicate.ID < candidates[j].Duplicate.ID
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates, nil
+}
+
+// scoreDuplicate scores a pair; the older customer is reported as the suggested survivor
+func scoreDuplicate(a, b duplicateKey, threshold float64) (dtos.DuplicateCandidate, bool) {
+	if a.customer.ID > b.customer.ID {
+		a, b = b, a
+	}
+
+	candidate := dtos.DuplicateCandidate{
+		Customer:  duplicateCustomer(a.customer),
+		Duplicate: duplicateCustomer(b.customer),
+		Reasons:   []string{},
+	}
+	if a.email != "" && a.email == b.email {
+		candidate.Score = 1
+		candidate.Reasons = append(candidate.Reasons, DuplicateReasonEmail)
+	}
+	if phonesMatch(a.phone, b.phone) {
+		candidate.Score = math.Max(candidate.Score, phoneMatchScore)
+		candidate.Reasons = append(candidate.Reasons, DuplicateReasonPhone)
+	}
+	if similarity := nameSimilarity(a.name, b.name); similarity >= threshold {
+		candidate.Score = math.Max(candidate.Score, similarity)
+		candidate.Reasons = append(candidate.Reasons, DuplicateReasonName)
+	}
+	candidate.Score = math.Round(candidate.Score*1000) / 1000
+
+	return candidate, candidate.Score >= threshold
+}
+
+func duplicateCustomer(customer models.Customer) dtos.DuplicateCustomer {
+	return dtos.DuplicateCustomer{
+		ID:        customer.ID,
+		Name:      customer.Name,
+		Email:     customer.Email,
+		Phone:     customer.Phone,
+		CreatedAt: customer.CreatedAt,
+	}
+}
+
+// MergeCustomers moves the invoices, payments, credit notes, quotes, recurring invoices, contacts,
+// addresses and tags of the duplicate to the survivor, fills the survivor's empty phone, address and
+// custom fields from the duplicate, records the merge and soft-deletes the duplicate, all in one
+// transaction. The survivor keeps its default addresses when it has them.
+func (s *customerMergeService) MergeCustomers(survivorID, duplicateID uint, mergedByID *uint) (*models.CustomerMerge, error) {
+	if survivorID == duplicateID {
+		return nil, errors.New("a customer cannot be merged into itself")
+	}
+
+	var merge *models.CustomerMerge
+	err := s.transactor.Transaction(func(tx *gorm.DB) error {
+		customers := s.customerRepo.WithTx(tx)
+		addresses := s.addressRepo.WithTx(tx)
+
+		survivor, err := customers.FindByID(survivorID)
+		if err != nil {
+			return fmt.Errorf("survivor: %w", err)
+		}
+		duplicate, err := customers.FindByID(duplicateID)
+		if err != nil {
+			return fmt.Errorf("duplicate: %w", err)
+		}
+
+		merge = &models.CustomerMerge{
+			SurvivorID:       survivor.ID,
+			MergedCustomerID: duplicate.ID,
+			MergedName:       duplicate.Name,
+			MergedEmail:      duplicate.Email,
+			MergedPhone:      duplicate.Phone,
+			MergedByID:       mergedByID,
+		}
+
+		if merge.InvoicesMoved, err = s.invoiceRepo.WithTx(tx).ReassignCustomer(duplicate.ID, survivor.ID); err != nil {
+			return fmt.Errorf("failed to move invoices: %w", err)
+		}
+		if merge.PaymentsMoved, err = s.paymentRepo.WithTx(tx).ReassignCustomer(duplicate.ID, survivor.ID); err != nil {
+			return fmt.Errorf("failed to move payments: %w", err)
+		}
+		if merge.CreditNotesMoved, err = s.creditRepo.WithTx(tx).ReassignCustomer(duplicate.ID, survivor.ID); err != nil {
+			return fmt.Errorf("failed to move credit notes: %w", err)
+		}
+		if merge.QuotesMoved, err = s.quoteRepo.WithTx(tx).ReassignCustomer(duplicate.ID, survivor.ID); err != nil {
+			return fmt.Errorf("failed to move quotes: %w", err)
+		}
+		if merge.RecurringInvoicesMoved, err = s.recurringRepo.WithTx(tx).ReassignCustomer(duplicate.ID, survivor.ID); err != nil {
+			return fmt.Errorf("failed to move recurring invoices: %w", err)
+		}
+		if merge.AttachmentsMoved, err = s.fileRepo.WithTx(tx).ReassignParent(models.AttachmentParentCustomer, duplicate.ID, survivor.ID); err != nil {
+			return fmt.Errorf("failed to move attachments: %w", err)
+		}
+		if merge.ContactsMoved, err = s.contactRepo.WithTx(tx).ReassignCustomer(duplicate.ID, survivor.ID); err != nil {
+			return fmt.Errorf("failed to move contacts: %w", err)
+		}
+		if merge.AddressesMoved, err = addresses.ReassignCustomer(duplicate.ID, survivor.ID); err != nil {
+			return fmt.Errorf("failed to move addresses: %w", err)
+		}
+		for _, addressType := range []string{models.AddressTypeBilling, models.AddressTypeShipping} {
+			if err := keepSurvivorDefault(addresses, survivor, addressType); err != nil {
+				return fmt.Errorf("failed to update default addresses: %w", err)
+			}
+		}
+
+		if survivor.Phone == "" {
+			survivor.Phone = duplicate.Phone
+		}
+		if survivor.Address == "" {
+			survivor.Address = duplicate.Address
+		}
+		if survivor.TaxID == "" {
+			survivor.TaxID = duplicate.TaxID
+		}
+		if survivor.PeppolID == "" {
+			survivor.PeppolID = duplicate.PeppolID
+		}
+		survivor.Tags = models.NormalizeTags(append(survivor.Tags, duplicate.Tags...))
+		survivor.CustomFieldValues = mergeCustomFieldValues(survivor.CustomFieldValues, duplicate.CustomFieldValues)
+		if err := customers.Update(survivor); err != nil {
+			return fmt.Errorf("failed to update survivor: %w", err)
+		}
+
+		if err := customers.Delete(duplicate.ID); err != nil {
+			return fmt.Errorf("failed to delete duplicate: %w", err)
+		}
+		if err := s.mergeRepo.WithTx(tx).Create(merge); err != nil {
+			return fmt.Errorf("failed to record merge: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %v", ErrCustomerNotFound, err)
+		}
+		return nil, err
+	}
+
+	return merge, nil
+}
+
+// mergeCustomFieldValues adds the duplicate's values for fields the survivor has no value for
+func mergeCustomFieldValues(survivor, duplicate []models.CustomFieldValue) []models.CustomFieldValue {
+	has := make(map[uint]bool, len(survivor))
+	for _, value := range survivor {
+		has[value.DefinitionID] = true
+	}
+	for _, value := range duplicate {
+		if !has[value.DefinitionID] {
+			survivor = append(survivor, value)
+		}
+	}
+	return survivor
+}
+
+// keepSurvivorDefault makes the survivor's previous default address of a type the only
+// default, or keeps the moved default when the survivor had none
+func keepSurvivorDefault(addresses repositories.CustomerAddressRepository, survivor *models.Customer, addressType string) error {
+	for _, address := range survivor.Addresses {
+		if address.Type == addressType && address.IsDefault {
+			return addresses.ClearDefault(survivor.ID, addressType, address.ID)
+		}
+	}
+	return nil
+}
+
+func (s *customerMergeService) ListMerges(customerID uint, page, limit int) ([]models.CustomerMerge, *utils.Pagination, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > repositories.MaxListLimit {
+		limit = 10
+	}
+
+	merges, total, err := s.mergeRepo.FindAll(customerID, page, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list merges: %w", err)
+	}
+
+	return merges, utils.NewPagination(page, limit, total), nil
+}
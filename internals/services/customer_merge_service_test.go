@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"gorm.io/gorm"
+)
+
+func newTestCustomerMergeService(db *gorm.DB) CustomerMergeService {
+	return NewCustomerMergeService(
+		repositories.NewTransactor(db),
+		repositories.NewCustomerRepository(db),
+		repositories.NewInvoiceRepository(db),
+		repositories.NewPaymentRepository(db),
+		repositories.NewCreditNoteRepository(db),
+		repositories.NewQuoteRepository(db),
+		repositories.NewRecurringInvoiceRepository(db),
+		repositories.NewAttachmentRepository(db),
+		repositories.NewCustomerContactRepository(db),
+		repositories.NewCustomerAddressRepository(db),
+		repositories.NewCustomerMergeRepository(db),
+	)
+}
+
+// A merge moves the recurring invoices of the duplicate, so the next run invoices the survivor
+func TestMergeCustomersMovesRecurringInvoices(t *testing.T) {
+	db := newTestDB(t)
+	survivor := &models.Customer{Name: "Acme", Email: "acme@example.com", Currency: "EUR"}
+	duplicate := &models.Customer{Name: "Acme Inc", Email: "ACME@example.com", Currency: "EUR"}
+	mustCreate(t, db, survivor, duplicate)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, name := range []string{"Hosting", "Support"} {
+		recurring := &models.RecurringInvoice{Name: name, CustomerID: duplicate.ID, Interval: "monthly", StartDate: start, DueDays: 14}
+		if err := db.Omit("Customer").Create(recurring).Error; err != nil {
+			t.Fatalf("create recurring invoice: %v", err)
+		}
+	}
+
+	merge, err := newTestCustomerMergeService(db).MergeCustomers(survivor.ID, duplicate.ID, nil)
+	if err != nil {
+		t.Fatalf("merge customers: %v", err)
+	}
+	if merge.RecurringInvoicesMoved != 2 {
+		t.Errorf("recurring invoices moved = %d; want 2", merge.RecurringInvoicesMoved)
+	}
+
+	var left int64
+	if err := db.Model(&models.RecurringInvoice{}).Where("customer_id = ?", duplicate.ID).Count(&left).Error; err != nil {
+		t.Fatalf("count recurring invoices: %v", err)
+	}
+	if left != 0 {
+		t.Errorf("%d recurring invoices still belong to the merged customer", left)
+	}
+
+	var recorded models.CustomerMerge
+	if err := db.First(&recorded, merge.ID).Error; err != nil {
+		t.Fatalf("load merge record: %v", err)
+	}
+	if recorded.RecurringInvoicesMoved != 2 {
+		t.Errorf("recorded recurring invoices moved = %d; want 2", recorded.RecurringInvoicesMoved)
+	}
+}
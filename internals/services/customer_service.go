@@ -2,29 +2,61 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"io"
+	"strings"
 
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
 	"github.com/tacheraSasi/go-api-starter/internals/models"
 	"github.com/tacheraSasi/go-api-starter/internals/repositories"
 	"github.com/tacheraSasi/go-api-starter/internals/utils"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"github.com/tacheraSasi/go-api-starter/pkg/ubl"
 )
 
+// ErrInvalidCustomer marks customer input that cannot be accepted, such as an unknown currency
+var ErrInvalidCustomer = errors.New("invalid customer")
+
 type CustomerService interface {
 	CreateCustomer(customer *models.Customer) error
 	GetCustomerByID(id uint) (*models.Customer, error)
-	GetAllCustomers(page, limit int) ([]models.Customer, *utils.Pagination, error)
+	GetAllCustomers(filter repositories.CustomerFilter) ([]models.Customer, *utils.Pagination, error)
 	UpdateCustomer(id uint, updatedCustomer *models.Customer) error
 	DeleteCustomer(id uint) error
+	ImportCustomersCSV(r io.Reader, opts CustomerImportOptions) (*dtos.CustomerImportReport, error)
+	ExportCustomersCSV(w io.Writer, filter repositories.CustomerFilter) error
 }
 
 type customerService struct {
-	repo repositories.CustomerRepository
+	repo         repositories.CustomerRepository
+	transactor   repositories.Transactor
+	customFields CustomFieldService
 }
 
-func NewCustomerService(repo repositories.CustomerRepository) CustomerService {
-	return &customerService{repo: repo}
+func NewCustomerService(repo repositories.CustomerRepository, transactor repositories.Transactor, customFields CustomFieldService) CustomerService {
+	return &customerService{repo: repo, transactor: transactor, customFields: customFields}
 }
 
 func (s *customerService) CreateCustomer(customer *models.Customer) error {
+	customer.Tags = models.NormalizeTags(customer.Tags)
+	// Contacts and addresses are managed through their own endpoints
+	customer.Contacts = nil
+	customer.Addresses = nil
+	if err := normalizeCustomerCurrency(customer); err != nil {
+		return err
+	}
+	normalizeTaxExemption(customer)
+	if err := normalizeEInvoicing(customer); err != nil {
+		return err
+	}
+
+	values, fields, err := s.customFields.ResolveValues(models.TaggableCustomer, nil, customer.CustomFields)
+	if err != nil {
+		return err
+	}
+	customer.CustomFieldValues = values
+	customer.CustomFields = fields
+
 	return s.repo.Create(customer)
 }
 
@@ -32,20 +64,21 @@ func (s *customerService) GetCustomerByID(id uint) (*models.Customer, error) {
 	return s.repo.FindByID(id)
 }
 
-func (s *customerService) GetAllCustomers(page, limit int) ([]models.Customer, *utils.Pagination, error) {
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 {
-		limit = 10
+func (s *customerService) GetAllCustomers(filter repositories.CustomerFilter) ([]models.Customer, *utils.Pagination, error) {
+	filter.Normalize()
+
+	customFields, err := s.customFields.ResolveFilter(models.TaggableCustomer, filter.CustomFields)
+	if err != nil {
+		return nil, nil, err
 	}
+	filter.CustomFields = customFields
 
-	customers, total, err := s.repo.FindAll(page, limit)
+	customers, total, err := s.repo.FindAll(filter)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	pagination := utils.NewPagination(page, limit, total)
+	pagination := utils.NewPagination(filter.Page, filter.Limit, total)
 	return customers, pagination, nil
 }
 
@@ -59,6 +92,27 @@ func (s *customerService) UpdateCustomer(id uint, updatedCustomer *models.Custom
 	existingCustomer.Email = updatedCustomer.Email
 	existingCustomer.Phone = updatedCustomer.Phone
 	existingCustomer.Address = updatedCustomer.Address
+	existingCustomer.Tags = models.NormalizeTags(updatedCustomer.Tags)
+	existingCustomer.Currency = updatedCustomer.Currency
+	if err := normalizeCustomerCurrency(existingCustomer); err != nil {
+		return err
+	}
+	existingCustomer.TaxExempt = updatedCustomer.TaxExempt
+	existingCustomer.TaxExemptReason = updatedCustomer.TaxExemptReason
+	normalizeTaxExemption(existingCustomer)
+	existingCustomer.DunningOptOut = updatedCustomer.DunningOptOut
+	existingCustomer.TaxID = updatedCustomer.TaxID
+	existingCustomer.PeppolID = updatedCustomer.PeppolID
+	if err := normalizeEInvoicing(existingCustomer); err != nil {
+		return err
+	}
+
+	values, fields, err := s.customFields.ResolveValues(models.TaggableCustomer, existingCustomer.CustomFieldValues, updatedCustomer.CustomFields)
+	if err != nil {
+		return err
+	}
+	existingCustomer.CustomFieldValues = values
+	updatedCustomer.CustomFields = fields
 
 	return s.repo.Update(existingCustomer)
 }
@@ -66,3 +120,40 @@ func (s *customerService) UpdateCustomer(id uint, updatedCustomer *models.Custom
 func (s *customerService) DeleteCustomer(id uint) error {
 	return s.repo.Delete(id)
 }
+
+// normalizeCustomerCurrency uppercases and checks the optional default currency of a customer
+func normalizeCustomerCurrency(customer *models.Customer) error {
+	if customer.Currency == "" {
+		return nil
+	}
+	currency, err := money.ParseCurrency(string(customer.Currency))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCustomer, err)
+	}
+	customer.Currency = currency
+	return nil
+}
+
+// normalizeTaxExemption trims the exemption reason and drops it from customers that are not exempt
+func normalizeTaxExemption(customer *models.Customer) {
+	customer.TaxExemptReason = strings.TrimSpace(customer.TaxExemptReason)
+	if !customer.TaxExempt {
+		customer.TaxExemptReason = ""
+	}
+}
+
+// normalizeEInvoicing uppercases the VAT identifier of a customer and checks that its
+// Peppol ID is written as scheme:identifier with a known scheme
+func normalizeEInvoicing(customer *models.Customer) error {
+	customer.TaxID = strings.ToUpper(strings.Join(strings.Fields(customer.TaxID), ""))
+	customer.PeppolID = strings.TrimSpace(customer.PeppolID)
+	if customer.PeppolID == "" {
+		return nil
+	}
+	endpoint, err := ubl.ParseEndpoint(customer.PeppolID)
+	if err != nil {
+		return fmt.Errorf("%w: peppol_id: %v", ErrInvalidCustomer, err)
+	}
+	customer.PeppolID = endpoint.String()
+	return nil
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// companySuffixes are dropped from names before comparing them
+var companySuffixes = map[string]bool{
+	"inc": true, "incorporated": true, "llc": true, "ltd": true, "limited": true,
+	"gmbh": true, "ag": true, "sa": true, "sarl": true, "bv": true, "nv": true,
+	"plc": true, "corp": true, "corporation": true, "co": true, "company": true, "the": true,
+}
+
+// normalizeEmail lowercases an email and removes "+tag" suffixes, and dots for Gmail addresses
+func normalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return email
+	}
+	local, domain := email[:at], email[at+1:]
+	if plus := strings.Index(local, "+"); plus > 0 {
+		local = local[:plus]
+	}
+	if domain == "googlemail.com" {
+		domain = "gmail.com"
+	}
+	if domain == "gmail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+	return local + "@" + domain
+}
+
+// normalizePhone keeps only the digits of a phone number, without international or trunk prefixes
+func normalizePhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimLeft(b.String(), "0")
+}
+
+// phonesMatch treats numbers as equal when one ends with the other, so the same number with
+// and without a country code matches, as long as at least 7 digits are compared
+func phonesMatch(a, b string) bool {
+	if len(a) < 7 || len(b) < 7 {
+		return false
+	}
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	return strings.HasSuffix(a, b)
+}
+
+// nameTokens lowercases a name, strips punctuation and company suffixes, and sorts the words
+func nameTokens(name string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := fields[:0]
+	for _, field := range fields {
+		if !companySuffixes[field] {
+			tokens = append(tokens, field)
+		}
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+// nameSimilarity is 1 minus the edit distance of the normalized names relative to the longer one
+func nameSimilarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens an empty, migrated SQLite database that lives as long as the test
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	err = db.AutoMigrate(
+		&models.User{},
+		&models.Role{},
+		&models.Permission{},
+		&models.UserRole{},
+		&models.RolePermission{},
+		&models.Customer{},
+		&models.Tag{},
+		&models.CustomFieldDefinition{},
+		&models.CustomFieldValue{},
+		&models.CustomerContact{},
+		&models.CustomerAddress{},
+		&models.CustomerMerge{},
+		&models.Invoice{},
+		&models.InvoiceSequence{},
+		&models.InvoiceItem{},
+		&models.InvoiceCharge{},
+		&models.TaxRate{},
+		&models.Product{},
+		&models.InvoiceTaxLine{},
+		&models.InvoiceStatusChange{},
+		&models.Payment{},
+		&models.PaymentAllocation{},
+		&models.ExchangeRate{},
+		&models.InvoiceShareLink{},
+		&models.RecurringInvoice{},
+		&models.RecurringInvoiceItem{},
+		&models.InvoiceReminder{},
+		&models.CreditNote{},
+		&models.CreditNoteItem{},
+		&models.CreditNoteAllocation{},
+		&models.CreditNoteRefund{},
+		&models.Quote{},
+		&models.QuoteItem{},
+		&models.QuoteTaxLine{},
+		&models.QuoteCharge{},
+		&models.Attachment{},
+	)
+	if err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+	return db
+}
+
+// mustCreate stores rows directly, skipping the validation of the services
+func mustCreate(t *testing.T, db *gorm.DB, rows ...interface{}) {
+	t.Helper()
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("create %T: %v", row, err)
+		}
+	}
+}
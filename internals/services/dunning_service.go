@@ -0,0 +1,192 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/pkg/dunning"
+	"github.com/tacheraSasi/go-api-starter/pkg/mailer"
+	"gorm.io/gorm"
+)
+
+// maxReminderAttempts is how often a reminder that could not be sent is tried
+const maxReminderAttempts = 3
+
+// reminderDateLayout formats dates in reminder emails
+const reminderDateLayout = "2 January 2006"
+
+// DunningSettings holds the reminder schedule and what reminders say about the sender
+type DunningSettings struct {
+	Schedule            dunning.Schedule
+	Templates           *dunning.Templates
+	CompanyName         string
+	CompanyEmail        string
+	PaymentInstructions string
+}
+
+type DunningService interface {
+	SendReminders(now time.Time) (int, error)
+	ListReminders(invoiceID uint) ([]models.InvoiceReminder, error)
+}
+
+type dunningService struct {
+	repo        repositories.InvoiceReminderRepository
+	invoiceRepo repositories.InvoiceRepository
+	mailer      mailer.Mailer
+	settings    DunningSettings
+}
+
+func NewDunningService(repo repositories.InvoiceReminderRepository, invoiceRepo repositories.InvoiceRepository, sender mailer.Mailer, settings DunningSettings) DunningService {
+	return &dunningService{repo: repo, invoiceRepo: invoiceRepo, mailer: sender, settings: settings}
+}
+
+// SendReminders emails every unpaid invoice the latest step of the schedule that has come
+// and was not sent yet. Steps missed while the job did not run are skipped rather than
+// sent late, so a customer gets one reminder at a time. A reminder that fails is tried
+// again on later runs, up to maxReminderAttempts times. Customers that opted out of
+// dunning get no reminders.
+func (s *dunningService) SendReminders(now time.Time) (int, error) {
+	schedule := s.settings.Schedule
+	if len(schedule) == 0 {
+		return 0, nil
+	}
+	first, last := schedule[0], schedule[len(schedule)-1]
+	invoices, err := s.invoiceRepo.FindForDunning(calendarDay(now).AddDate(0, 0, 1-first.Days), last.Days)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list unpaid invoices: %w", err)
+	}
+
+	sent := 0
+	var errs []error
+	for i := range invoices {
+		ok, err := s.remind(&invoices[i], now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invoice %s: %w", invoices[i].InvoiceNumber, err))
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+// remind sends the current reminder of an invoice, unless it was sent, gave up on or
+// claimed by another instance already. It reports whether a reminder was sent.
+func (s *dunningService) remind(invoice *models.Invoice, now time.Time) (bool, error) {
+	step, ok := s.settings.Schedule.Current(invoice.DueDate, now)
+	if !ok {
+		return false, nil
+	}
+
+	reminder, err := s.repo.FindByStep(invoice.ID, step.Days)
+	switch {
+	case err == nil:
+		if reminder.SentAt != nil || reminder.Error == "" || reminder.Attempts >= maxReminderAttempts {
+			return false, nil
+		}
+		claimed, err := s.repo.ClaimRetry(reminder)
+		if err != nil {
+			return false, fmt.Errorf("failed to retry reminder: %w", err)
+		}
+		if !claimed {
+			return false, nil
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		reminder = &models.InvoiceReminder{
+			InvoiceID:   invoice.ID,
+			DaysFromDue: step.Days,
+			Level:       string(step.Level),
+			Attempts:    1,
+		}
+		if err := s.repo.Create(reminder); err != nil {
+			// The unique step index rejects the reminder when another instance claimed it first
+			if _, findErr := s.repo.FindByStep(invoice.ID, step.Days); findErr == nil {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to record reminder: %w", err)
+		}
+	default:
+		return false, fmt.Errorf("failed to get reminder: %w", err)
+	}
+
+	reminder.Recipients = reminderRecipients(&invoice.Customer)
+	subject, body, err := s.settings.Templates.Render(step.Level, s.reminderData(invoice, now))
+	if err == nil {
+		reminder.Subject = subject
+		err = s.mailer.Send(mailer.Message{To: reminder.Recipients, Subject: subject, Body: body})
+	}
+	if err != nil {
+		reminder.Error = err.Error()
+		if saveErr := s.repo.Update(reminder); saveErr != nil {
+			return false, fmt.Errorf("failed to send %s reminder: %w (and to record the failure: %v)", step.Level, err, saveErr)
+		}
+		return false, fmt.Errorf("failed to send %s reminder: %w", step.Level, err)
+	}
+
+	sentAt := now
+	reminder.SentAt = &sentAt
+	if err := s.repo.Update(reminder); err != nil {
+		return true, fmt.Errorf("failed to record sent reminder: %w", err)
+	}
+	return true, nil
+}
+
+func (s *dunningService) reminderData(invoice *models.Invoice, now time.Time) dunning.Data {
+	days := int(calendarDay(now).Sub(calendarDay(invoice.DueDate)).Hours() / 24)
+	if days < 0 {
+		days = -days
+	}
+	return dunning.Data{
+		CompanyName:         s.settings.CompanyName,
+		CompanyEmail:        s.settings.CompanyEmail,
+		CustomerName:        invoice.Customer.Name,
+		InvoiceNumber:       invoice.InvoiceNumber,
+		IssueDate:           invoice.IssueDate.Format(reminderDateLayout),
+		DueDate:             invoice.DueDate.Format(reminderDateLayout),
+		Total:               invoice.Total.Format(invoice.Currency) + " " + invoice.Currency.String(),
+		AmountDue:           invoice.AmountDue.Format(invoice.Currency) + " " + invoice.Currency.String(),
+		Days:                days,
+		PaymentInstructions: s.settings.PaymentInstructions,
+	}
+}
+
+// reminderRecipients returns the email of a customer and of its billing contacts, without
+// duplicates
+func reminderRecipients(customer *models.Customer) []string {
+	var recipients []string
+	seen := map[string]bool{}
+	add := func(email string) {
+		email = strings.TrimSpace(email)
+		key := strings.ToLower(email)
+		if email != "" && !seen[key] {
+			seen[key] = true
+			recipients = append(recipients, email)
+		}
+	}
+	add(customer.Email)
+	for _, contact := range customer.Contacts {
+		if contact.Role == models.ContactRoleBilling {
+			add(contact.Email)
+		}
+	}
+	return recipients
+}
+
+// ListReminders returns the reminders of an invoice, oldest first
+func (s *dunningService) ListReminders(invoiceID uint) ([]models.InvoiceReminder, error) {
+	if _, err := s.invoiceRepo.FindByID(invoiceID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	reminders, err := s.repo.FindByInvoice(invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reminders: %w", err)
+	}
+	return reminders, nil
+}
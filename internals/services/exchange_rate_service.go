@@ -0,0 +1,488 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"gorm.io/gorm"
+)
+
+// Exchange rate import formats
+const (
+	ExchangeRateFormatCSV = "csv"
+	ExchangeRateFormatECB = "ecb"
+)
+
+// ecbBaseCurrency is the currency ECB reference rates are quoted against
+const ecbBaseCurrency money.Currency = "EUR"
+
+// MaxExchangeRateImportRates limits the number of rates in a single import
+const MaxExchangeRateImportRates = 500000
+
+var (
+	// ErrExchangeRateNotFound is returned when no rate is stored for a pair, directly or through a cross rate
+	ErrExchangeRateNotFound = errors.New("exchange rate not found")
+	// ErrInvalidExchangeRate marks a rate or rate file that cannot be accepted
+	ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+)
+
+// exchangeRateHeaderAliases maps normalized CSV headers of long format files to columns
+var exchangeRateHeaderAliases = map[string]string{
+	"date":          "date",
+	"day":           "date",
+	"time":          "date",
+	"base":          "base",
+	"basecurrency":  "base",
+	"from":          "base",
+	"quote":         "quote",
+	"quotecurrency": "quote",
+	"currency":      "quote",
+	"to":            "quote",
+	"target":        "quote",
+	"rate":          "rate",
+	"exchangerate":  "rate",
+	"value":         "rate",
+}
+
+// exchangeRateDateLayouts are the date formats accepted in rate files; ECB CSV files
+// use "17 October 2025"
+var exchangeRateDateLayouts = []string{"2006-01-02", "2 January 2006", "02 January 2006"}
+
+type ExchangeRateService interface {
+	ListRates(filter repositories.ExchangeRateFilter) ([]models.ExchangeRate, *utils.Pagination, error)
+	SaveRate(rate *models.ExchangeRate) error
+	DeleteRate(id uint) error
+	ImportRates(r io.Reader, opts ExchangeRateImportOptions) (*dtos.ExchangeRateImportReport, error)
+	GetRate(from, to money.Currency, on time.Time) (*dtos.ExchangeRateQuote, error)
+	Convert(amount money.Amount, from, to money.Currency, on time.Time) (*dtos.ExchangeRateQuote, error)
+}
+
+// ExchangeRateImportOptions controls an exchange rate import
+type ExchangeRateImportOptions struct {
+	// Format is csv or ecb; empty detects it from the content
+	Format string
+	// BaseCurrency is the base of wide CSV files with one column per currency. Defaults to EUR, as in ECB files.
+	BaseCurrency money.Currency
+}
+
+type exchangeRateService struct {
+	repo     repositories.ExchangeRateRepository
+	rounding money.RoundingMode
+}
+
+func NewExchangeRateService(repo repositories.ExchangeRateRepository, rounding money.RoundingMode) ExchangeRateService {
+	return &exchangeRateService{repo: repo, rounding: rounding}
+}
+
+func (s *exchangeRateService) ListRates(filter repositories.ExchangeRateFilter) ([]models.ExchangeRate, *utils.Pagination, error) {
+	filter.Normalize()
+
+	rates, total, err := s.repo.FindAll(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pagination := utils.NewPagination(filter.Page, filter.Limit, total)
+	return rates, pagination, nil
+}
+
+// SaveRate validates a single rate and stores it, replacing the rate of the pair on that date
+func (s *exchangeRateService) SaveRate(rate *models.ExchangeRate) error {
+	if err := normalizeExchangeRate(rate); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidExchangeRate, err)
+	}
+	if rate.Source == "" {
+		rate.Source = models.ExchangeRateSourceManual
+	}
+
+	if err := s.repo.Upsert([]models.ExchangeRate{*rate}); err != nil {
+		return fmt.Errorf("failed to save exchange rate: %w", err)
+	}
+	saved, err := s.repo.FindLatest(rate.BaseCurrency, rate.QuoteCurrency, rate.Date)
+	if err != nil {
+		return fmt.Errorf("failed to get exchange rate: %w", err)
+	}
+	*rate = *saved
+	return nil
+}
+
+func (s *exchangeRateService) DeleteRate(id uint) error {
+	if err := s.repo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrExchangeRateNotFound
+		}
+		return fmt.Errorf("failed to delete exchange rate: %w", err)
+	}
+	return nil
+}
+
+// GetRate finds the rate converting from into to on a date: the most recent stored rate
+// of the pair, the inverse of the opposite pair, or a cross rate through a common base
+// currency, in that order
+func (s *exchangeRateService) GetRate(from, to money.Currency, on time.Time) (*dtos.ExchangeRateQuote, error) {
+	day := rateDate(on)
+	quote := &dtos.ExchangeRateQuote{From: from, To: to, Date: day}
+	if from == to {
+		quote.Rate, quote.RateDate, quote.Method = money.One, day, "identity"
+		return quote, nil
+	}
+
+	direct, err := s.findLatest(from, to, day)
+	if err != nil {
+		return nil, err
+	}
+	if direct != nil {
+		quote.Rate, quote.RateDate, quote.Method = direct.Rate, direct.Date, "direct"
+		return quote, nil
+	}
+
+	inverse, err := s.findLatest(to, from, day)
+	if err != nil {
+		return nil, err
+	}
+	if inverse != nil {
+		quote.Rate, quote.RateDate, quote.Method = inverse.Rate.Inverse(s.rounding), inverse.Date, "inverse"
+		return quote, nil
+	}
+
+	pivots, err := s.repo.FindBaseCurrencies()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list base currencies: %w", err)
+	}
+	for _, pivot := range pivots {
+		if pivot == from || pivot == to {
+			continue
+		}
+		fromLeg, err := s.findLatest(pivot, from, day)
+		if err != nil {
+			return nil, err
+		}
+		toLeg, err := s.findLatest(pivot, to, day)
+		if err != nil {
+			return nil, err
+		}
+		if fromLeg == nil || toLeg == nil {
+			continue
+		}
+
+		// Prefer the pivot whose older leg is the most recent
+		rateDate := fromLeg.Date
+		if toLeg.Date.Before(rateDate) {
+			rateDate = toLeg.Date
+		}
+		if quote.Method == "" || rateDate.After(quote.RateDate) {
+			quote.Rate, quote.RateDate, quote.Method, quote.Via = toLeg.Rate.Div(fromLeg.Rate, s.rounding), rateDate, "cross", pivot
+		}
+	}
+	if quote.Method == "" {
+		return nil, fmt.Errorf("%w: no rate from %s to %s on or before %s", ErrExchangeRateNotFound, from, to, day.Format("2006-01-02"))
+	}
+	return quote, nil
+}
+
+// Convert converts an amount at the rate GetRate finds, rounded to the minor unit of to
+func (s *exchangeRateService) Convert(amount money.Amount, from, to money.Currency, on time.Time) (_ *dtos.ExchangeRateQuote, err error) {
+	defer recoverOverflow(&err, ErrInvalidExchangeRate)
+
+	if err := checkLimit(amount, "amount"); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidExchangeRate, err)
+	}
+	quote, err := s.GetRate(from, to, on)
+	if err != nil {
+		return nil, err
+	}
+	converted := quote.Rate.Convert(amount, s.rounding).RoundTo(to, s.rounding)
+	quote.Amount, quote.Converted = &amount, &converted
+	return quote, nil
+}
+
+// findLatest returns the latest rate of a pair, or nil when there is none
+func (s *exchangeRateService) findLatest(base, quote money.Currency, on time.Time) (*models.ExchangeRate, error) {
+	rate, err := s.repo.FindLatest(base, quote, on)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get exchange rate: %w", err)
+	}
+	return rate, nil
+}
+
+// ImportRates reads rates from a CSV or ECB XML file and stores every valid rate.
+// Invalid entries are reported and skipped; rates already stored for a pair and date
+// are replaced.
+func (s *exchangeRateService) ImportRates(r io.Reader, opts ExchangeRateImportOptions) (*dtos.ExchangeRateImportReport, error) {
+	reader := bufio.NewReader(r)
+	format := strings.ToLower(strings.TrimSpace(opts.Format))
+	if format == "" {
+		format = detectExchangeRateFormat(reader)
+	}
+
+	report := &dtos.ExchangeRateImportReport{Format: format, Errors: []dtos.ExchangeRateImportError{}}
+	var (
+		rates []models.ExchangeRate
+		err   error
+	)
+	switch format {
+	case ExchangeRateFormatECB:
+		rates, err = parseECBRates(reader, report)
+	case ExchangeRateFormatCSV:
+		base := opts.BaseCurrency
+		if base == "" {
+			base = ecbBaseCurrency
+		}
+		rates, err = parseCSVRates(reader, base, report)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q, expected csv or ecb", ErrInvalidExchangeRate, opts.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rates = dedupeExchangeRates(rates)
+	if len(rates) > MaxExchangeRateImportRates {
+		return nil, fmt.Errorf("%w: file has more than %d rates", ErrInvalidExchangeRate, MaxExchangeRateImportRates)
+	}
+	for i := range rates {
+		if report.From == nil || rates[i].Date.Before(*report.From) {
+			report.From = &rates[i].Date
+		}
+		if report.To == nil || rates[i].Date.After(*report.To) {
+			report.To = &rates[i].Date
+		}
+	}
+
+	if err := s.repo.Upsert(rates); err != nil {
+		return nil, fmt.Errorf("failed to save exchange rates: %w", err)
+	}
+	report.Imported = len(rates)
+	report.Failed = len(report.Errors)
+	return report, nil
+}
+
+// detectExchangeRateFormat treats files starting with "<" as ECB XML and everything else as CSV
+func detectExchangeRateFormat(reader *bufio.Reader) string {
+	head, _ := reader.Peek(512)
+	head = bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\uFEFF")), " \t\r\n")
+	if bytes.HasPrefix(head, []byte("<")) {
+		return ExchangeRateFormatECB
+	}
+	return ExchangeRateFormatCSV
+}
+
+// ecbEnvelope is the layout of the ECB euro foreign exchange reference rate files
+// (eurofxref-daily.xml, eurofxref-hist.xml)
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+func parseECBRates(r io.Reader, report *dtos.ExchangeRateImportReport) ([]models.ExchangeRate, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("%w: failed to parse ECB XML: %v", ErrInvalidExchangeRate, err)
+	}
+	if len(envelope.Days) == 0 {
+		return nil, fmt.Errorf("%w: no rates found in ECB XML", ErrInvalidExchangeRate)
+	}
+
+	var rates []models.ExchangeRate
+	for _, day := range envelope.Days {
+		for _, entry := range day.Rates {
+			rate, err := buildExchangeRate(day.Time, string(ecbBaseCurrency), entry.Currency, entry.Rate, models.ExchangeRateSourceECB)
+			if err != nil {
+				report.Errors = append(report.Errors, dtos.ExchangeRateImportError{Date: day.Time, Currency: entry.Currency, Message: err.Error()})
+				continue
+			}
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+// parseCSVRates reads either a long file with date, base, quote and rate columns, or a
+// wide file like the ECB CSV with a date column followed by one column per currency
+func parseCSVRates(r io.Reader, base money.Currency, report *dtos.ExchangeRateImportReport) ([]models.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read header: %v", ErrInvalidCSV, err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\uFEFF")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		if column, ok := exchangeRateHeaderAliases[normalizeHeader(name)]; ok {
+			if _, seen := columns[column]; !seen {
+				columns[column] = i
+			}
+		}
+	}
+	dateColumn, hasDate := columns["date"]
+	if !hasDate {
+		return nil, fmt.Errorf("%w: missing date column", ErrInvalidCSV)
+	}
+	_, long := columns["rate"]
+	if long {
+		if _, ok := columns["quote"]; !ok {
+			return nil, fmt.Errorf("%w: missing quote currency column", ErrInvalidCSV)
+		}
+	}
+
+	var rates []models.ExchangeRate
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: row %d: %v", ErrInvalidCSV, row, err)
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		date := csvField(record, dateColumn)
+
+		if long {
+			rowBase := string(base)
+			if column, ok := columns["base"]; ok {
+				rowBase = csvField(record, column)
+			}
+			quote := csvField(record, columns["quote"])
+			rate, err := buildExchangeRate(date, rowBase, quote, csvField(record, columns["rate"]), models.ExchangeRateSourceCSV)
+			if err != nil {
+				report.Errors = append(report.Errors, dtos.ExchangeRateImportError{Row: row, Date: date, Currency: quote, Message: err.Error()})
+				continue
+			}
+			rates = append(rates, rate)
+			continue
+		}
+
+		for i, name := range header {
+			value := csvField(record, i)
+			name = strings.TrimSpace(name)
+			// ECB files leave a trailing empty column and mark missing rates with N/A
+			if i == dateColumn || name == "" || value == "" || strings.EqualFold(value, "N/A") {
+				continue
+			}
+			rate, err := buildExchangeRate(date, string(base), name, value, models.ExchangeRateSourceCSV)
+			if err != nil {
+				report.Errors = append(report.Errors, dtos.ExchangeRateImportError{Row: row, Date: date, Currency: name, Message: err.Error()})
+				continue
+			}
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+func buildExchangeRate(date, base, quote, value, source string) (models.ExchangeRate, error) {
+	day, err := parseExchangeRateDate(date)
+	if err != nil {
+		return models.ExchangeRate{}, err
+	}
+	parsed, err := money.ParseRate(value)
+	if err != nil {
+		return models.ExchangeRate{}, fmt.Errorf("invalid rate %q: %v", value, err)
+	}
+
+	rate := models.ExchangeRate{
+		Date:          day,
+		BaseCurrency:  money.Currency(base),
+		QuoteCurrency: money.Currency(quote),
+		Rate:          parsed,
+		Source:        source,
+	}
+	if err := normalizeExchangeRate(&rate); err != nil {
+		return models.ExchangeRate{}, err
+	}
+	return rate, nil
+}
+
+// normalizeExchangeRate checks the currencies and rate and truncates the date to a UTC day
+func normalizeExchangeRate(rate *models.ExchangeRate) error {
+	base, err := money.ParseCurrency(string(rate.BaseCurrency))
+	if err != nil {
+		return err
+	}
+	quote, err := money.ParseCurrency(string(rate.QuoteCurrency))
+	if err != nil {
+		return err
+	}
+	if base == quote {
+		return errors.New("base and quote currency must differ")
+	}
+	if rate.Rate.IsZero() {
+		return money.ErrInvalidRate
+	}
+
+	rate.BaseCurrency, rate.QuoteCurrency = base, quote
+	rate.Date = rateDate(rate.Date)
+	return nil
+}
+
+func parseExchangeRateDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range exchangeRateDateLayouts {
+		if day, err := time.Parse(layout, value); err == nil {
+			return day, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// rateDate truncates a time to the start of its UTC day, the date rates are stored under
+func rateDate(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// dedupeExchangeRates keeps the last rate given for each pair and date
+func dedupeExchangeRates(rates []models.ExchangeRate) []models.ExchangeRate {
+	type key struct {
+		base, quote money.Currency
+		date        time.Time
+	}
+	index := make(map[key]int, len(rates))
+	var result []models.ExchangeRate
+	for _, rate := range rates {
+		k := key{rate.BaseCurrency, rate.QuoteCurrency, rate.Date}
+		if i, ok := index[k]; ok {
+			result[i] = rate
+			continue
+		}
+		index[k] = len(result)
+		result = append(result, rate)
+	}
+	return result
+}
+
+// csvField returns the trimmed value of a column, or "" when the record is too short
+func csvField(record []string, column int) string {
+	if column < len(record) {
+		return strings.TrimSpace(record[column])
+	}
+	return ""
+}
@@ -0,0 +1,455 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"github.com/tacheraSasi/go-api-starter/pkg/pdf"
+	"gorm.io/gorm"
+)
+
+// InvoiceIssuer is the business that issues invoices, printed at the top of every invoice
+type InvoiceIssuer struct {
+	Name    string
+	Address []string
+	Email   string
+	Phone   string
+	TaxID   string
+}
+
+// InvoicePDFSettings control the content and look of invoice PDFs
+type InvoicePDFSettings struct {
+	Issuer              InvoiceIssuer
+	PaymentInstructions string // printed on invoices with an amount due; newlines are kept
+	PageSize            pdf.Size
+	BrandColor          pdf.Color  // title, table headers and amount due
+	Logo                *pdf.Image // optional, drawn at the top left
+}
+
+type InvoicePDFService interface {
+	RenderInvoice(id uint) (*models.Invoice, []byte, error)
+}
+
+type invoicePDFService struct {
+	invoiceRepo repositories.InvoiceRepository
+	settings    InvoicePDFSettings
+}
+
+func NewInvoicePDFService(invoiceRepo repositories.InvoiceRepository, settings InvoicePDFSettings) InvoicePDFService {
+	return &invoicePDFService{invoiceRepo: invoiceRepo, settings: settings}
+}
+
+// RenderInvoice returns an invoice together with its PDF
+func (s *invoicePDFService) RenderInvoice(id uint) (*models.Invoice, []byte, error) {
+	invoice, err := s.invoiceRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvoiceNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	data, err := RenderInvoicePDF(invoice, s.settings)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to render invoice: %w", err)
+	}
+	return invoice, data, nil
+}
+
+// Invoice PDF layout, in points
+const (
+	pdfMargin     = 48.0
+	pdfFooter     = 56.0 // space kept free at the bottom of each page
+	pdfLineHeight = 13.0
+	pdfRowHeight  = 20.0
+	pdfBodySize   = 9.5
+	pdfSmallSize  = 8.0
+	pdfLogoWidth  = 160.0
+	pdfLogoHeight = 48.0
+	pdfTotalsSize = 230.0 // width of the totals block
+)
+
+var (
+	pdfTextColor  = pdf.Color{R: 33, G: 37, B: 41}
+	pdfMutedColor = pdf.Color{R: 108, G: 117, B: 125}
+	pdfRuleColor  = pdf.Color{R: 222, G: 226, B: 230}
+	pdfShadeColor = pdf.Color{R: 243, G: 244, B: 246}
+)
+
+// RenderInvoicePDF lays out an invoice loaded with its customer, billing address, items,
+// tax lines and charges. The result only depends on the invoice and the settings, so the
+// same invoice always renders to the same bytes.
+func RenderInvoicePDF(invoice *models.Invoice, settings InvoicePDFSettings) ([]byte, error) {
+	if settings.PageSize == (pdf.Size{}) {
+		settings.PageSize = pdf.A4
+	}
+	l := &invoiceLayout{
+		doc:      pdf.NewDocument(settings.PageSize, "Invoice "+invoice.InvoiceNumber),
+		settings: settings,
+		invoice:  invoice,
+		width:    settings.PageSize.Width - 2*pdfMargin,
+	}
+	l.newPage()
+	l.header()
+	l.parties()
+	l.items()
+	l.summary()
+	l.notes()
+	l.footers()
+
+	var buf bytes.Buffer
+	if _, err := l.doc.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// invoiceLayout draws an invoice top to bottom, starting new pages as it runs out of room
+type invoiceLayout struct {
+	doc      *pdf.Document
+	page     *pdf.Page
+	settings InvoicePDFSettings
+	invoice  *models.Invoice
+	width    float64 // width between the margins
+	y        float64 // top of the free space on the current page
+}
+
+func (l *invoiceLayout) newPage() {
+	l.page = l.doc.AddPage()
+	l.page.Rect(0, 0, l.settings.PageSize.Width, 6, l.settings.BrandColor)
+	l.y = pdfMargin
+}
+
+// ensure starts a new page unless height fits above the footer, and reports whether it did
+func (l *invoiceLayout) ensure(height float64) bool {
+	if l.y+height <= l.settings.PageSize.Height-pdfFooter {
+		return false
+	}
+	l.newPage()
+	return true
+}
+
+func (l *invoiceLayout) left() float64  { return pdfMargin }
+func (l *invoiceLayout) right() float64 { return pdfMargin + l.width }
+
+func (l *invoiceLayout) money(amount money.Amount) string {
+	return amount.Format(l.invoice.Currency)
+}
+
+// header draws the logo and issuer on the left and the invoice details on the right
+func (l *invoiceLayout) header() {
+	top := l.y
+	y := top
+	if logo := l.settings.Logo; logo != nil && logo.Width() > 0 && logo.Height() > 0 {
+		scale := math.Min(pdfLogoWidth/float64(logo.Width()), pdfLogoHeight/float64(logo.Height()))
+		width, height := float64(logo.Width())*scale, float64(logo.Height())*scale
+		l.page.Image(logo, l.left(), y, width, height)
+		y += height + 12
+	}
+
+	issuer := l.settings.Issuer
+	if issuer.Name != "" {
+		y += 12
+		l.page.Text(l.left(), y, pdf.HelveticaBold, 12, pdfTextColor, issuer.Name)
+	}
+	for _, line := range nonEmpty(append(append([]string{}, issuer.Address...), issuer.Email, issuer.Phone)...) {
+		y += pdfLineHeight
+		l.page.Text(l.left(), y, pdf.Helvetica, pdfBodySize, pdfMutedColor, line)
+	}
+	if issuer.TaxID != "" {
+		y += pdfLineHeight
+		l.page.Text(l.left(), y, pdf.Helvetica, pdfBodySize, pdfMutedColor, "Tax ID: "+issuer.TaxID)
+	}
+
+	right := top + 22
+	l.page.TextRight(l.right(), right, pdf.HelveticaBold, 24, l.settings.BrandColor, "INVOICE")
+	right += 10
+	for _, field := range [][2]string{
+		{"Invoice number", l.invoice.InvoiceNumber},
+		{"Issue date", l.invoice.IssueDate.Format("2006-01-02")},
+		{"Due date", l.invoice.DueDate.Format("2006-01-02")},
+		{"Status", invoiceStatusLabel(l.invoice.Status)},
+		{"Currency", l.invoice.Currency.String()},
+	} {
+		right += pdfLineHeight
+		l.page.TextRight(l.right()-110, right, pdf.Helvetica, pdfBodySize, pdfMutedColor, field[0])
+		l.page.TextRight(l.right(), right, pdf.HelveticaBold, pdfBodySize, pdfTextColor, field[1])
+	}
+
+	l.y = math.Max(y, right) + 28
+}
+
+// parties draws the customer and billing address on the left and the amount due on the right
+func (l *invoiceLayout) parties() {
+	customer := l.invoice.Customer
+	y := l.y
+	l.page.Text(l.left(), y, pdf.HelveticaBold, pdfSmallSize, l.settings.BrandColor, "BILL TO")
+	y += 15
+	l.page.Text(l.left(), y, pdf.HelveticaBold, 11, pdfTextColor, customer.Name)
+
+	var lines []string
+	if address := l.invoice.BillingAddress; address != nil {
+		lines = append(lines, address.Lines()...)
+	} else if customer.Address != "" {
+		lines = append(lines, strings.Split(customer.Address, "\n")...)
+	}
+	lines = append(lines, customer.Email)
+	if l.invoice.TaxExempt {
+		exempt := "Tax exempt"
+		if customer.TaxExemptReason != "" {
+			exempt += ": " + customer.TaxExemptReason
+		}
+		lines = append(lines, exempt)
+	}
+	for _, line := range nonEmpty(lines...) {
+		for _, wrapped := range pdf.Wrap(pdf.Helvetica, pdfBodySize, line, l.width-pdfTotalsSize-24) {
+			y += pdfLineHeight
+			l.page.Text(l.left(), y, pdf.Helvetica, pdfBodySize, pdfMutedColor, wrapped)
+		}
+	}
+
+	boxX, boxY := l.right()-pdfTotalsSize, l.y-12
+	l.page.Rect(boxX, boxY, pdfTotalsSize, 62, pdfShadeColor)
+	l.page.Text(boxX+12, boxY+18, pdf.HelveticaBold, pdfSmallSize, pdfMutedColor, "AMOUNT DUE")
+	l.page.Text(boxX+12, boxY+40, pdf.HelveticaBold, 18, l.settings.BrandColor, l.invoice.Currency.String()+" "+l.money(l.invoice.AmountDue))
+	l.page.Text(boxX+12, boxY+54, pdf.Helvetica, pdfSmallSize, pdfMutedColor, "Due "+l.invoice.DueDate.Format("2006-01-02"))
+
+	l.y = math.Max(y, boxY+62) + 28
+}
+
+// Item table columns: description on the left, then right aligned numbers ending at these
+// offsets from the right margin
+const (
+	pdfColAmount   = 0.0
+	pdfColDiscount = 80.0
+	pdfColPrice    = 160.0
+	pdfColQuantity = 230.0
+)
+
+func (l *invoiceLayout) itemsHeader() {
+	l.page.Rect(l.left(), l.y, l.width, pdfRowHeight, l.settings.BrandColor)
+	baseline := l.y + 13.5
+	l.page.Text(l.left()+8, baseline, pdf.HelveticaBold, pdfSmallSize, pdf.White, "DESCRIPTION")
+	for _, column := range []struct {
+		offset float64
+		label  string
+	}{
+		{pdfColQuantity, "QTY"},
+		{pdfColPrice, "UNIT PRICE"},
+		{pdfColDiscount, "DISCOUNT"},
+		{pdfColAmount, "AMOUNT"},
+	} {
+		l.page.TextRight(l.right()-column.offset-8, baseline, pdf.HelveticaBold, pdfSmallSize, pdf.White, column.label)
+	}
+	l.y += pdfRowHeight
+}
+
+// items draws the item table, repeating its header on every page it spans
+func (l *invoiceLayout) items() {
+	l.ensure(2 * pdfRowHeight)
+	l.itemsHeader()
+
+	descriptionWidth := l.width - pdfColQuantity - 40
+	for i, item := range l.invoice.Items {
+		lines := pdf.Wrap(pdf.Helvetica, pdfBodySize, item.Description, descriptionWidth)
+		height := float64(len(lines)-1)*pdfLineHeight + pdfRowHeight
+		if l.ensure(height) {
+			l.itemsHeader()
+		}
+		if i%2 == 1 {
+			l.page.Rect(l.left(), l.y, l.width, height, pdfShadeColor)
+		}
+
+		baseline := l.y + 13.5
+		for j, line := range lines {
+			l.page.Text(l.left()+8, baseline+float64(j)*pdfLineHeight, pdf.Helvetica, pdfBodySize, pdfTextColor, line)
+		}
+		discount := ""
+		if !item.DiscountAmount.IsZero() {
+			discount = "-" + l.money(item.DiscountAmount)
+		}
+		for _, column := range []struct {
+			offset float64
+			value  string
+		}{
+			{pdfColQuantity, fmt.Sprintf("%d", item.Quantity)},
+			{pdfColPrice, l.money(item.UnitPrice)},
+			{pdfColDiscount, discount},
+			{pdfColAmount, l.money(item.Total)},
+		} {
+			l.page.TextRight(l.right()-column.offset-8, baseline, pdf.Helvetica, pdfBodySize, pdfTextColor, column.value)
+		}
+		l.y += height
+	}
+	l.page.Line(l.left(), l.y, l.right(), l.y, 0.75, pdfRuleColor)
+	l.y += 20
+}
+
+// summary draws the tax breakdown on the left and the totals on the right
+func (l *invoiceLayout) summary() {
+	invoice := l.invoice
+	inclusive := false
+	for _, line := range invoice.TaxLines {
+		inclusive = inclusive || line.Inclusive
+	}
+
+	subtotal := "Subtotal"
+	if inclusive {
+		subtotal = "Subtotal (excl. tax)"
+	}
+	totals := [][2]string{{subtotal, l.money(invoice.Subtotal)}}
+	if !invoice.DiscountAmount.IsZero() {
+		label := "Discount"
+		if invoice.DiscountType == models.DiscountTypePercent {
+			label = "Discount (" + percentLabel(invoice.DiscountValue) + ")"
+		}
+		totals = append(totals, [2]string{label, "-" + l.money(invoice.DiscountAmount)})
+	}
+	if len(invoice.TaxLines) > 0 || !invoice.TaxAmount.IsZero() {
+		totals = append(totals, [2]string{"Tax", l.money(invoice.TaxAmount)})
+	}
+	for _, charge := range invoice.Charges {
+		totals = append(totals, [2]string{charge.Description, l.money(charge.Amount)})
+	}
+
+	taxRows := len(invoice.TaxLines)
+	if taxRows > 0 {
+		taxRows++ // header
+	}
+	if inclusive {
+		taxRows++
+	}
+	height := math.Max(float64(len(totals)+3), float64(taxRows)) * pdfRowHeight
+	l.ensure(height)
+	top := l.y
+
+	if len(invoice.TaxLines) > 0 {
+		l.taxBreakdown(top, l.width-pdfTotalsSize-24, inclusive)
+	}
+
+	x := l.right() - pdfTotalsSize
+	y := top
+	row := func(label, value string, font pdf.Font, color pdf.Color) {
+		l.page.Text(x+8, y+13.5, font, pdfBodySize, pdfTextColor, label)
+		l.page.TextRight(l.right()-8, y+13.5, font, pdfBodySize, color, value)
+		y += pdfRowHeight
+	}
+	for _, total := range totals {
+		row(total[0], total[1], pdf.Helvetica, pdfTextColor)
+	}
+	l.page.Line(x, y, l.right(), y, 0.75, pdfRuleColor)
+	row("Total", l.money(invoice.Total), pdf.HelveticaBold, pdfTextColor)
+	if !invoice.AmountPaid.IsZero() {
+		row("Amount paid", "-"+l.money(invoice.AmountPaid), pdf.Helvetica, pdfTextColor)
+	}
+	if !invoice.AmountCredited.IsZero() {
+		row("Amount credited", "-"+l.money(invoice.AmountCredited), pdf.Helvetica, pdfTextColor)
+	}
+	l.page.Rect(x, y, pdfTotalsSize, pdfRowHeight, pdfShadeColor)
+	row("Amount due ("+invoice.Currency.String()+")", l.money(invoice.AmountDue), pdf.HelveticaBold, l.settings.BrandColor)
+
+	l.y = math.Max(l.y, y) + 24
+}
+
+// taxBreakdown draws one row per tax rate with the amount it was charged on
+func (l *invoiceLayout) taxBreakdown(top, width float64, inclusive bool) {
+	x, y := l.left(), top
+	columns := func(font pdf.Font, size float64, color pdf.Color, name, rate, taxable, amount string) {
+		baseline := y + 13.5
+		l.page.Text(x+8, baseline, font, size, color, name)
+		l.page.TextRight(x+width-135, baseline, font, size, color, rate)
+		l.page.TextRight(x+width-70, baseline, font, size, color, taxable)
+		l.page.TextRight(x+width-8, baseline, font, size, color, amount)
+		y += pdfRowHeight
+	}
+
+	l.page.Rect(x, y, width, pdfRowHeight, pdfShadeColor)
+	columns(pdf.HelveticaBold, pdfSmallSize, pdfMutedColor, "TAX", "RATE", "TAXABLE", "AMOUNT")
+	for _, line := range l.invoice.TaxLines {
+		name := line.Name
+		if line.Compound {
+			name += " (compound)"
+		}
+		columns(pdf.Helvetica, pdfBodySize, pdfTextColor, name, percentLabel(line.Rate), l.money(line.TaxableAmount), l.money(line.Amount))
+	}
+	if inclusive {
+		l.page.Text(x+8, y+13.5, pdf.Helvetica, pdfSmallSize, pdfMutedColor, "Line amounts include tax.")
+	}
+}
+
+// notes draws the invoice notes and, while something is owed, the payment instructions
+func (l *invoiceLayout) notes() {
+	l.section("Notes", l.invoice.Notes)
+
+	owed := l.invoice.AmountDue.IsPositive() && l.invoice.Status != models.InvoiceStatusVoid
+	if owed && strings.TrimSpace(l.settings.PaymentInstructions) != "" {
+		instructions := strings.TrimSpace(l.settings.PaymentInstructions) +
+			"\nPlease quote " + l.invoice.InvoiceNumber + " with your payment."
+		l.section("Payment instructions", instructions)
+	}
+}
+
+func (l *invoiceLayout) section(title, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	lines := pdf.Wrap(pdf.Helvetica, pdfBodySize, text, l.width)
+	l.ensure(18 + pdfLineHeight*math.Min(float64(len(lines)), 3))
+	l.page.Text(l.left(), l.y+10, pdf.HelveticaBold, pdfSmallSize, l.settings.BrandColor, strings.ToUpper(title))
+	l.y += 14
+	for _, line := range lines {
+		l.ensure(pdfLineHeight)
+		l.y += pdfLineHeight
+		l.page.Text(l.left(), l.y, pdf.Helvetica, pdfBodySize, pdfTextColor, line)
+	}
+	l.y += 20
+}
+
+// footers writes the invoice number and page count at the bottom of every page
+func (l *invoiceLayout) footers() {
+	pages := l.doc.Pages()
+	y := l.settings.PageSize.Height - pdfMargin + 14
+	for i, page := range pages {
+		page.Line(l.left(), y-14, l.right(), y-14, 0.5, pdfRuleColor)
+		text := "Invoice " + l.invoice.InvoiceNumber
+		if name := l.settings.Issuer.Name; name != "" {
+			text = name + " - " + text
+		}
+		page.Text(l.left(), y, pdf.Helvetica, pdfSmallSize, pdfMutedColor, text)
+		page.TextRight(l.right(), y, pdf.Helvetica, pdfSmallSize, pdfMutedColor, fmt.Sprintf("Page %d of %d", i+1, len(pages)))
+	}
+}
+
+// invoiceStatusLabel turns a status such as partially_paid into "Partially paid"
+func invoiceStatusLabel(status string) string {
+	if status == "" {
+		return ""
+	}
+	label := strings.ReplaceAll(status, "_", " ")
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// percentLabel formats a percentage without trailing zeros, e.g. "20%" or "7.25%"
+func percentLabel(rate money.Amount) string {
+	s := rate.String()
+	if strings.Contains(s, ".") {
+		s = strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+	}
+	return s + "%"
+}
+
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"github.com/tacheraSasi/go-api-starter/pkg/pdf"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenInvoice is an invoice that touches every part of the layout: a billing address,
+// a discounted line, a long description that wraps, a tax breakdown, a charge, a partial
+// payment, notes and characters outside WinAnsiEncoding
+func goldenInvoice() *models.Invoice {
+	issued := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	return &models.Invoice{
+		InvoiceNumber: "INV-2026-00042",
+		Status:        models.InvoiceStatusPartiallyPaid,
+		Currency:      "EUR",
+		IssueDate:     issued,
+		DueDate:       issued.AddDate(0, 0, 30),
+		Customer:      models.Customer{Name: "Café Müller – Ελλάδα", Email: "billing@cafe-muller.example"},
+		BillingAddress: &models.CustomerAddress{
+			Line1:      "Keizersgracht 1",
+			City:       "Amsterdam",
+			PostalCode: "1015 CJ",
+			Country:    "NL",
+		},
+		Items: []models.InvoiceItem{
+			{Description: "Consulting", Quantity: 10, UnitPrice: money.MustParse("95"), DiscountAmount: money.MustParse("50"), Total: money.MustParse("900")},
+			{Description: "Hosting of the web shop, the staging environment and the nightly backups for the first quarter of 2026", Quantity: 3, UnitPrice: money.MustParse("40"), Total: money.MustParse("120")},
+		},
+		TaxLines: []models.InvoiceTaxLine{
+			{Name: "VAT", Rate: money.MustParse("21"), TaxableAmount: money.MustParse("1020"), Amount: money.MustParse("214.2")},
+		},
+		Charges:    []models.InvoiceCharge{{Description: "Shipping", Amount: money.MustParse("15")}},
+		Subtotal:   money.MustParse("1020"),
+		TaxAmount:  money.MustParse("214.2"),
+		Total:      money.MustParse("1249.2"),
+		AmountPaid: money.MustParse("500"),
+		AmountDue:  money.MustParse("749.2"),
+		Notes:      "Thank you for your business.",
+	}
+}
+
+// Rendering is deterministic, so a fixed invoice must render to the bytes in testdata.
+// Run go test -run TestRenderInvoicePDFGolden -update after an intended layout change.
+func TestRenderInvoicePDFGolden(t *testing.T) {
+	got, err := RenderInvoicePDF(goldenInvoice(), InvoicePDFSettings{
+		Issuer: InvoiceIssuer{
+			Name:    "Example B.V.",
+			Address: []string{"Damrak 1", "1012 LG Amsterdam"},
+			Email:   "invoices@example.com",
+			TaxID:   "NL123456789B01",
+		},
+		PaymentInstructions: "IBAN NL91 ABNA 0417 1643 00\nBIC ABNANL2A",
+		PageSize:            pdf.A4,
+		BrandColor:          pdf.Color{R: 29, G: 78, B: 216},
+	})
+	if err != nil {
+		t.Fatalf("render invoice: %v", err)
+	}
+
+	golden := filepath.Join("testdata", "invoice.pdf")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatalf("write golden file: %v", err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("read golden file: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("rendered PDF differs from %s (%d bytes, want %d); run with -update if the change is intended", golden, len(got), len(want))
+	}
+}
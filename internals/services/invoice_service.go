@@ -3,95 +3,476 @@ package services
 import (
 	"errors"
 	"fmt"
+	"math/big"
+	"strings"
 	"time"
 
 	"github.com/tacheraSasi/go-api-starter/internals/models"
 	"github.com/tacheraSasi/go-api-starter/internals/repositories"
 	"github.com/tacheraSasi/go-api-starter/internals/utils"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"github.com/tacheraSasi/go-api-starter/pkg/numbering"
+	"gorm.io/gorm"
 )
 
+var (
+	// ErrInvalidInvoice marks invoice input that cannot be accepted, such as an unknown currency
+	ErrInvalidInvoice = errors.New("invalid invoice")
+	// ErrInvoiceNotFound is returned when an invoice does not exist
+	ErrInvoiceNotFound = errors.New("invoice not found")
+	// ErrInvalidTransition is returned when an invoice cannot move to the requested status
+	ErrInvalidTransition = errors.New("invalid invoice status transition")
+	// ErrInvoiceLocked is returned when a change is not allowed once an invoice has been sent
+	ErrInvoiceLocked = errors.New("invoice is locked")
+	// ErrInvoiceNumberTaken is returned when the next number of the invoice sequence already
+	// belongs to an invoice, such as one numbered by hand in an earlier version
+	ErrInvoiceNumberTaken = errors.New("invoice number already taken")
+)
+
+// legacyInvoiceStatuses maps statuses stored before the invoice lifecycle was enforced
+// to the current ones
+var legacyInvoiceStatuses = map[string]string{
+	"cancelled": models.InvoiceStatusVoid,
+	"":          models.InvoiceStatusDraft,
+}
+
+// InvoiceSettings holds the money defaults used when pricing invoices
+type InvoiceSettings struct {
+	DefaultCurrency money.Currency
+	// BaseCurrency is the reporting currency invoice totals are converted into
+	BaseCurrency money.Currency
+	Rounding     money.RoundingMode
+	TaxRounding  TaxRounding
+	// Numbering renders the numbers of new invoices from their sequence
+	Numbering numbering.Format
+}
+
 type InvoiceService interface {
-	CreateInvoice(invoice *models.Invoice) error
+	CreateInvoice(invoice *models.Invoice, createdByID *uint) error
+	PriceInvoice(invoice *models.Invoice) error
 	GetInvoiceByID(id uint) (*models.Invoice, error)
-	GetAllInvoices(page, limit int) ([]models.Invoice, *utils.Pagination, error)
+	GetAllInvoices(filter repositories.InvoiceFilter) ([]models.Invoice, *utils.Pagination, error)
 	UpdateInvoice(id uint, updatedInvoice *models.Invoice) error
 	DeleteInvoice(id uint) error
+	SendInvoice(id uint, changedByID *uint, note string) (*models.Invoice, error)
+	VoidInvoice(id uint, changedByID *uint, note string) (*models.Invoice, error)
+	MarkOverdueInvoices(now time.Time) (int, error)
+	MigrateLegacyStatuses() (int64, error)
 	GetInvoicesByCustomerID(customerID uint, page, limit int) ([]models.Invoice, *utils.Pagination, error)
-	GenerateInvoiceNumber() (string, error)
+	ConvertToBaseCurrency() (converted, pending int, err error)
 }
 
 type invoiceService struct {
-	repo repositories.InvoiceRepository
+	transactor    repositories.Transactor
+	repo          repositories.InvoiceRepository
+	sequences     repositories.InvoiceSequenceRepository
+	customerRepo  repositories.CustomerRepository
+	addressRepo   repositories.CustomerAddressRepository
+	customFields  CustomFieldService
+	exchangeRates ExchangeRateService
+	taxRates      TaxRateService
+	products      ProductService
+	settings      InvoiceSettings
 }
 
-func NewInvoiceService(repo repositories.InvoiceRepository) InvoiceService {
-	return &invoiceService{repo: repo}
+func NewInvoiceService(
+	transactor repositories.Transactor,
+	repo repositories.InvoiceRepository,
+	sequences repositories.InvoiceSequenceRepository,
+	customerRepo repositories.CustomerRepository,
+	addressRepo repositories.CustomerAddressRepository,
+	customFields CustomFieldService,
+	exchangeRates ExchangeRateService,
+	taxRates TaxRateService,
+	products ProductService,
+	settings InvoiceSettings,
+) InvoiceService {
+	return &invoiceService{
+		transactor:    transactor,
+		repo:          repo,
+		sequences:     sequences,
+		customerRepo:  customerRepo,
+		addressRepo:   addressRepo,
+		customFields:  customFields,
+		exchangeRates: exchangeRates,
+		taxRates:      taxRates,
+		products:      products,
+		settings:      settings,
+	}
 }
 
-func (s *invoiceService) CreateInvoice(invoice *models.Invoice) error {
-	// Generate invoice number if not provided
-	if invoice.InvoiceNumber == "" {
-		invoiceNumber, err := s.GenerateInvoiceNumber()
+// CreateInvoice prices and stores a new invoice. Invoices always start as drafts; the
+// transition methods move them on from there. The invoice takes the next number of its
+// sequence in the same transaction, so a failed create leaves no gap; numbers cannot be
+// chosen by the client. When that number is already taken the create fails rather than
+// skip it.
+func (s *invoiceService) CreateInvoice(invoice *models.Invoice, createdByID *uint) error {
+	if invoice.Status != "" && invoice.Status != models.InvoiceStatusDraft {
+		return fmt.Errorf("%w: invoices are created as drafts; use the send, void and mark-paid endpoints to change the status", ErrInvalidTransition)
+	}
+	if strings.TrimSpace(invoice.InvoiceNumber) != "" {
+		return fmt.Errorf("%w: invoice numbers are assigned from the invoice sequence; omit invoice_number", ErrInvalidInvoice)
+	}
+
+	if err := s.PriceInvoice(invoice); err != nil {
+		return err
+	}
+
+	values, fields, err := s.customFields.ResolveValues(models.TaggableInvoice, nil, invoice.CustomFields)
+	if err != nil {
+		return err
+	}
+	invoice.CustomFieldValues = values
+	invoice.CustomFields = fields
+	invoice.Tags = models.NormalizeTags(invoice.Tags)
+
+	if err := s.convertToBase(invoice); err != nil {
+		return err
+	}
+
+	invoice.Status = models.InvoiceStatusDraft
+	invoice.SentAt, invoice.PaidAt, invoice.VoidedAt = nil, nil, nil
+	invoice.StatusHistory = []models.InvoiceStatusChange{{ToStatus: models.InvoiceStatusDraft, ChangedByID: createdByID}}
+
+	return s.transactor.Transaction(func(tx *gorm.DB) error {
+		invoices := s.repo.WithTx(tx)
+		number, err := s.nextInvoiceNumber(tx, invoice.IssueDate)
 		if err != nil {
 			return err
 		}
-		invoice.InvoiceNumber = invoiceNumber
-	}
-
-	// Calculate totals
-	s.calculateInvoiceTotals(invoice)
+		taken, err := invoices.InvoiceNumberTaken(number)
+		if err != nil {
+			return fmt.Errorf("failed to check invoice number: %w", err)
+		}
+		if taken {
+			return fmt.Errorf("%w: the next number of the invoice sequence, %s, belongs to another invoice", ErrInvoiceNumberTaken, number)
+		}
+		invoice.InvoiceNumber = number
+		return invoices.Create(invoice)
+	})
+}
 
-	// Set default status if not provided
-	if invoice.Status == "" {
-		invoice.Status = "draft"
+// PriceInvoice resolves the customer, billing address and currency of an unsaved invoice
+// and calculates its totals, as CreateInvoice does before storing it. Quotes are priced
+// through it so they always match the invoices they are converted into.
+func (s *invoiceService) PriceInvoice(invoice *models.Invoice) error {
+	customer, err := s.findCustomer(invoice.CustomerID)
+	if err != nil {
+		return err
+	}
+	if err := s.resolveBillingAddress(invoice); err != nil {
+		return err
+	}
+	// Use the customer's currency, then the configured default, unless one is given
+	currency := customer.Currency
+	if currency == "" {
+		currency = s.settings.DefaultCurrency
+	}
+	if err := s.resolveCurrency(invoice, currency); err != nil {
+		return err
 	}
 
-	return s.repo.Create(invoice)
+	// Payments are recorded once the invoice has been sent
+	invoice.AmountPaid, invoice.Payments = money.Zero, nil
+	return s.calculateInvoiceTotals(invoice, customer)
 }
 
 func (s *invoiceService) GetInvoiceByID(id uint) (*models.Invoice, error) {
-	return s.repo.FindByID(id)
+	invoice, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	return invoice, nil
 }
 
-func (s *invoiceService) GetAllInvoices(page, limit int) ([]models.Invoice, *utils.Pagination, error) {
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 {
-		limit = 10
+func (s *invoiceService) GetAllInvoices(filter repositories.InvoiceFilter) ([]models.Invoice, *utils.Pagination, error) {
+	filter.Normalize()
+
+	customFields, err := s.customFields.ResolveFilter(models.TaggableInvoice, filter.CustomFields)
+	if err != nil {
+		return nil, nil, err
 	}
+	filter.CustomFields = customFields
 
-	invoices, total, err := s.repo.FindAll(page, limit)
+	invoices, total, err := s.repo.FindAll(filter)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	pagination := utils.NewPagination(page, limit, total)
+	pagination := utils.NewPagination(filter.Page, filter.Limit, total)
 	return invoices, pagination, nil
 }
 
+// UpdateInvoice replaces a draft invoice. Once an invoice has been sent only its notes,
+// tags and custom fields can change; the status only changes through transitions.
 func (s *invoiceService) UpdateInvoice(id uint, updatedInvoice *models.Invoice) error {
-	existingInvoice, err := s.repo.FindByID(id)
+	existingInvoice, err := s.GetInvoiceByID(id)
 	if err != nil {
-		return errors.New("invoice not found")
+		return err
+	}
+	if updatedInvoice.Status != "" && updatedInvoice.Status != existingInvoice.Status {
+		return fmt.Errorf("%w: use the send, void and mark-paid endpoints to change the status", ErrInvalidTransition)
+	}
+	if existingInvoice.Status != models.InvoiceStatusDraft {
+		return s.updateLockedInvoice(existingInvoice, updatedInvoice)
+	}
+
+	// Keep the billing address unless another one is given or the customer changes
+	billingAddressID := updatedInvoice.BillingAddressID
+	if billingAddressID == nil && updatedInvoice.CustomerID == existingInvoice.CustomerID {
+		billingAddressID = existingInvoice.BillingAddressID
 	}
 
 	// Update fields
 	existingInvoice.IssueDate = updatedInvoice.IssueDate
 	existingInvoice.DueDate = updatedInvoice.DueDate
-	existingInvoice.Status = updatedInvoice.Status
 	existingInvoice.CustomerID = updatedInvoice.CustomerID
+	existingInvoice.BillingAddressID = billingAddressID
+	customer, err := s.findCustomer(existingInvoice.CustomerID)
+	if err != nil {
+		return err
+	}
+	if err := s.resolveBillingAddress(existingInvoice); err != nil {
+		return err
+	}
+	// Keep the invoice currency unless another one is given
+	currency := existingInvoice.Currency
+	existingInvoice.Currency = updatedInvoice.Currency
+	if err := s.resolveCurrency(existingInvoice, currency); err != nil {
+		return err
+	}
 	existingInvoice.Items = updatedInvoice.Items
+	existingInvoice.DiscountType = updatedInvoice.DiscountType
+	existingInvoice.DiscountValue = updatedInvoice.DiscountValue
+	existingInvoice.Charges = updatedInvoice.Charges
 	existingInvoice.Notes = updatedInvoice.Notes
+	existingInvoice.Tags = models.NormalizeTags(updatedInvoice.Tags)
+
+	values, fields, err := s.customFields.ResolveValues(models.TaggableInvoice, existingInvoice.CustomFieldValues, updatedInvoice.CustomFields)
+	if err != nil {
+		return err
+	}
+	existingInvoice.CustomFieldValues = values
+	updatedInvoice.CustomFields = fields
 
 	// Recalculate totals
-	s.calculateInvoiceTotals(existingInvoice)
+	if err := s.calculateInvoiceTotals(existingInvoice, customer); err != nil {
+		return err
+	}
+	if err := s.convertToBase(existingInvoice); err != nil {
+		return err
+	}
 
 	return s.repo.Update(existingInvoice)
 }
 
+// updateLockedInvoice applies the notes, tags and custom fields of a sent, paid or void
+// invoice. Any other field must be omitted or left as it is.
+func (s *invoiceService) updateLockedInvoice(existingInvoice, updatedInvoice *models.Invoice) error {
+	if changed := lockedChanges(existingInvoice, updatedInvoice); len(changed) > 0 {
+		return fmt.Errorf("%w: a %s invoice only accepts changes to notes, tags and custom fields, not %s", ErrInvoiceLocked, existingInvoice.Status, strings.Join(changed, ", "))
+	}
+
+	existingInvoice.Notes = updatedInvoice.Notes
+	existingInvoice.Tags = models.NormalizeTags(updatedInvoice.Tags)
+	values, fields, err := s.customFields.ResolveValues(models.TaggableInvoice, existingInvoice.CustomFieldValues, updatedInvoice.CustomFields)
+	if err != nil {
+		return err
+	}
+	existingInvoice.CustomFieldValues = values
+	updatedInvoice.CustomFields = fields
+
+	return s.repo.UpdateDetails(existingInvoice)
+}
+
+// lockedChanges names the fields of a sent invoice that updated would change. Omitted
+// fields are left as they are, so the invoice can be sent back as it was read.
+func lockedChanges(existing, updated *models.Invoice) []string {
+	var changed []string
+	if updated.CustomerID != 0 && updated.CustomerID != existing.CustomerID {
+		changed = append(changed, "customer_id")
+	}
+	if updated.BillingAddressID != nil && (existing.BillingAddressID == nil || *updated.BillingAddressID != *existing.BillingAddressID) {
+		changed = append(changed, "billing_address_id")
+	}
+	if !updated.IssueDate.IsZero() && !updated.IssueDate.Equal(existing.IssueDate) {
+		changed = append(changed, "issue_date")
+	}
+	if !updated.DueDate.IsZero() && !updated.DueDate.Equal(existing.DueDate) {
+		changed = append(changed, "due_date")
+	}
+	if updated.Currency != "" && !strings.EqualFold(string(updated.Currency), string(existing.Currency)) {
+		changed = append(changed, "currency")
+	}
+	if updated.Items != nil && !sameItems(existing.Items, updated.Items) {
+		changed = append(changed, "items")
+	}
+	if (updated.DiscountType != "" && updated.DiscountType != existing.DiscountType) ||
+		(!updated.DiscountValue.IsZero() && updated.DiscountValue.Cmp(existing.DiscountValue) != 0) {
+		changed = append(changed, "discount")
+	}
+	if updated.Charges != nil && !sameCharges(existing.Charges, updated.Charges) {
+		changed = append(changed, "charges")
+	}
+	return changed
+}
+
+// sameItems reports whether updated lists the same lines as existing, ignoring computed amounts
+func sameItems(existing, updated []models.InvoiceItem) bool {
+	if len(existing) != len(updated) {
+		return false
+	}
+	for i, item := range updated {
+		old := existing[i]
+		if strings.TrimSpace(item.Description) != strings.TrimSpace(old.Description) || item.Quantity != old.Quantity ||
+			item.UnitPrice.Cmp(old.UnitPrice) != 0 || item.DiscountType != old.DiscountType ||
+			item.DiscountValue.Cmp(old.DiscountValue) != 0 {
+			return false
+		}
+		if item.TaxRateIDs != nil && !sameIDs(item.TaxRateIDs, old.TaxRateIDs) {
+			return false
+		}
+		if item.ProductID != nil && (old.ProductID == nil || *item.ProductID != *old.ProductID) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameCharges reports whether updated lists the same charges as existing
+func sameCharges(existing, updated []models.InvoiceCharge) bool {
+	if len(existing) != len(updated) {
+		return false
+	}
+	for i, charge := range updated {
+		if strings.TrimSpace(charge.Description) != existing[i].Description || charge.Amount.Cmp(existing[i].Amount) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func sameIDs(a, b models.UintList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[uint]bool, len(b))
+	for _, id := range b {
+		seen[id] = true
+	}
+	for _, id := range a {
+		if !seen[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// DeleteInvoice refuses to remove an invoice. Every invoice holds a number of the invoice
+// sequence from the moment it is created, and deleting one would leave a gap in the
+// series, so invoices, drafts included, are voided instead and stay on record.
 func (s *invoiceService) DeleteInvoice(id uint) error {
-	return s.repo.Delete(id)
+	invoice, err := s.GetInvoiceByID(id)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: invoice %s holds a number of the invoice sequence; void it instead so the series has no gaps", ErrInvoiceLocked, invoice.InvoiceNumber)
+}
+
+// SendInvoice marks a draft invoice as sent, after which its amounts can no longer change
+func (s *invoiceService) SendInvoice(id uint, changedByID *uint, note string) (*models.Invoice, error) {
+	return s.transition(id, models.InvoiceStatusSent, time.Now(), changedByID, note)
+}
+
+// VoidInvoice cancels a draft or sent invoice, after which nothing is due on it
+func (s *invoiceService) VoidInvoice(id uint, changedByID *uint, note string) (*models.Invoice, error) {
+	return s.transition(id, models.InvoiceStatusVoid, time.Now(), changedByID, note)
+}
+
+// transition moves an invoice to status to, stamps the time of the transition and records
+// it in the status history
+func (s *invoiceService) transition(id uint, to string, at time.Time, changedByID *uint, note string) (*models.Invoice, error) {
+	invoice, err := s.GetInvoiceByID(id)
+	if err != nil {
+		return nil, err
+	}
+	// Overdue invoices can be voided but may be partly paid
+	if to == models.InvoiceStatusVoid && invoice.AmountPaid.IsPositive() {
+		return nil, fmt.Errorf("%w: invoice %s has payments; reverse them before voiding it", ErrInvalidTransition, invoice.InvoiceNumber)
+	}
+	if !models.CanTransition(invoice.Status, to) {
+		if invoice.Status == to {
+			return nil, fmt.Errorf("%w: invoice %s is already %s", ErrInvalidTransition, invoice.InvoiceNumber, to)
+		}
+		return nil, fmt.Errorf("%w: a %s invoice cannot be marked %s", ErrInvalidTransition, invoice.Status, to)
+	}
+	if to == models.InvoiceStatusVoid && invoice.AmountCredited.IsPositive() {
+		return nil, fmt.Errorf("%w: invoice %s was partly credited; credit the rest of it instead of voiding it", ErrInvalidTransition, invoice.InvoiceNumber)
+	}
+
+	switch to {
+	case models.InvoiceStatusSent:
+		invoice.SentAt = &at
+	case models.InvoiceStatusVoid:
+		invoice.VoidedAt = &at
+		invoice.AmountDue = money.Zero
+	}
+	change := &models.InvoiceStatusChange{
+		FromStatus:  invoice.Status,
+		ToStatus:    to,
+		ChangedByID: changedByID,
+		Note:        strings.TrimSpace(note),
+	}
+	invoice.Status = to
+	if err := s.repo.UpdateStatus(invoice, change); err != nil {
+		return nil, fmt.Errorf("failed to update invoice status: %w", err)
+	}
+	return s.GetInvoiceByID(id)
+}
+
+// MarkOverdueInvoices moves the sent and partially paid invoices whose due date ended
+// before now to overdue. An overdue invoice stays overdue until it is paid in full, and
+// returns to overdue when a payment of a paid invoice past its due date is reversed.
+func (s *invoiceService) MarkOverdueInvoices(now time.Time) (int, error) {
+	invoices, err := s.repo.FindPastDue(calendarDay(now))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list past due invoices: %w", err)
+	}
+
+	marked := 0
+	for i := range invoices {
+		change := &models.InvoiceStatusChange{
+			FromStatus: invoices[i].Status,
+			ToStatus:   models.InvoiceStatusOverdue,
+			Note:       "Due date " + invoices[i].DueDate.Format("2006-01-02") + " passed",
+		}
+		updated, err := s.repo.MarkOverdue(&invoices[i], change)
+		if err != nil {
+			return marked, fmt.Errorf("failed to mark invoice %s overdue: %w", invoices[i].InvoiceNumber, err)
+		}
+		if updated {
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+// MigrateLegacyStatuses moves invoices stored before the lifecycle was enforced onto the
+// current statuses: cancelled invoices become void and invoices without a status drafts.
+// It is safe to run repeatedly.
+func (s *invoiceService) MigrateLegacyStatuses() (int64, error) {
+	var migrated int64
+	for from, to := range legacyInvoiceStatuses {
+		count, err := s.repo.ReplaceStatus(from, to)
+		if err != nil {
+			return migrated, fmt.Errorf("failed to migrate %q invoices: %w", from, err)
+		}
+		migrated += count
+	}
+	return migrated, nil
 }
 
 func (s *invoiceService) GetInvoicesByCustomerID(customerID uint, page, limit int) ([]models.Invoice, *utils.Pagination, error) {
@@ -111,27 +492,259 @@ func (s *invoiceService) GetInvoicesByCustomerID(customerID uint, page, limit in
 	return invoices, pagination, nil
 }
 
-func (s *invoiceService) GenerateInvoiceNumber() (string, error) {
-	year := time.Now().Year()
-	month := time.Now().Format("01")
-	
-	// In a real application, you might want to check the last invoice number
-	// and increment it. For simplicity, we're using timestamp here.
-	timestamp := time.Now().Unix()
-	
-	return fmt.Sprintf("INV-%d-%s-%d", year, month, timestamp), nil
+// nextInvoiceNumber takes the next number from the sequence of the issue date. It must run
+// in the transaction that creates the invoice.
+func (s *invoiceService) nextInvoiceNumber(tx *gorm.DB, issueDate time.Time) (string, error) {
+	if issueDate.IsZero() {
+		issueDate = time.Now()
+	}
+	seq, err := s.sequences.WithTx(tx).Next(s.settings.Numbering.Scope(issueDate))
+	if err != nil {
+		return "", fmt.Errorf("failed to allocate invoice number: %w", err)
+	}
+	return s.settings.Numbering.Number(issueDate, seq), nil
+}
+
+// resolveBillingAddress checks that an explicit billing address belongs to the invoice
+// customer, or falls back to the customer's default billing address
+func (s *invoiceService) resolveBillingAddress(invoice *models.Invoice) error {
+	invoice.BillingAddress = nil
+	if invoice.BillingAddressID != nil {
+		if _, err := s.addressRepo.FindByID(invoice.CustomerID, *invoice.BillingAddressID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: billing address %d does not belong to customer %d", ErrInvalidInvoice, *invoice.BillingAddressID, invoice.CustomerID)
+			}
+			return fmt.Errorf("failed to get billing address: %w", err)
+		}
+		return nil
+	}
+
+	address, err := s.addressRepo.FindDefault(invoice.CustomerID, models.AddressTypeBilling)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("failed to get default billing address: %w", err)
+	}
+	invoice.BillingAddressID = &address.ID
+	return nil
+}
+
+// resolveCurrency normalises the invoice currency code, using fallback when none is set
+func (s *invoiceService) resolveCurrency(invoice *models.Invoice, fallback money.Currency) error {
+	if invoice.Currency == "" {
+		invoice.Currency = fallback
+		return nil
+	}
+	currency, err := money.ParseCurrency(string(invoice.Currency))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidInvoice, err)
+	}
+	invoice.Currency = currency
+	return nil
 }
 
-func (s *invoiceService) calculateInvoiceTotals(invoice *models.Invoice) {
-	subtotal := 0.0
+// findCustomer loads the customer an invoice is for
+func (s *invoiceService) findCustomer(id uint) (*models.Customer, error) {
+	customer, err := s.customerRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: customer %d not found", ErrInvalidInvoice, id)
+		}
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+	return customer, nil
+}
+
+// calculateInvoiceTotals prices every line exactly: line discounts first, then the invoice
+// discount spread over the lines in proportion to their amounts, then tax, then untaxed
+// charges. Amounts are rounded to the minor unit of the invoice currency, and the total
+// always equals subtotal - discount + tax + charges. Neither may exceed money.MaxAmount.
+func (s *invoiceService) calculateInvoiceTotals(invoice *models.Invoice, customer *models.Customer) (err error) {
+	defer recoverOverflow(&err, ErrInvalidInvoice)
+
+	// Lines that name a product take its details before they are priced
+	if err := s.products.FillItems(invoice.Items, invoice.Currency); err != nil {
+		if errors.Is(err, ErrInvalidProduct) {
+			return fmt.Errorf("%w: %s", ErrInvalidInvoice, strings.TrimPrefix(err.Error(), ErrInvalidProduct.Error()+": "))
+		}
+		return err
+	}
+
+	calculator := taxCalculator{
+		currency: invoice.Currency,
+		mode:     s.settings.Rounding,
+		rounding: s.settings.TaxRounding,
+		exempt:   customer.TaxExempt,
+	}
+
+	rates := make([][]models.TaxRate, len(invoice.Items))
+	amounts := make([]*big.Rat, len(invoice.Items))
+	quoted := new(big.Rat)
 	for i := range invoice.Items {
 		item := &invoice.Items[i]
-		item.Total = item.UnitPrice * float64(item.Quantity)
-		subtotal += item.Total
-	}
-	
-	invoice.Subtotal = subtotal
-	// For simplicity, tax is 10% of subtotal
-	invoice.TaxAmount = subtotal * 0.1
-	invoice.Total = subtotal + invoice.TaxAmount
-}
\ No newline at end of file
+		lineRates, err := s.taxRates.ResolveRates(item.TaxRateIDs)
+		if err != nil {
+			if errors.Is(err, ErrInvalidTaxRate) {
+				return fmt.Errorf("%w: item %d: %v", ErrInvalidInvoice, i+1, err)
+			}
+			return err
+		}
+		rates[i] = lineRates
+		item.TaxRateIDs = make(models.UintList, 0, len(lineRates))
+		for _, rate := range lineRates {
+			item.TaxRateIDs = append(item.TaxRateIDs, rate.ID)
+		}
+
+		listed := new(big.Rat).Mul(item.UnitPrice.Rat(), big.NewRat(int64(item.Quantity), 1))
+		discount, err := discountOf(item.DiscountType, item.DiscountValue, listed)
+		if err != nil {
+			return fmt.Errorf("%w: item %d: %v", ErrInvalidInvoice, i+1, err)
+		}
+		amounts[i] = listed.Sub(listed, discount)
+		item.Total = calculator.round(amounts[i])
+		item.DiscountAmount = calculator.round(new(big.Rat).Add(amounts[i], discount)).Sub(item.Total)
+		quoted.Add(quoted, amounts[i])
+	}
+
+	discount, err := discountOf(invoice.DiscountType, invoice.DiscountValue, quoted)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidInvoice, err)
+	}
+
+	// The lines before the invoice discount make up the subtotal; the tax is charged on
+	// the discounted lines and the discount is whatever separates the two net amounts
+	undiscounted := calculator.tax(amounts, rates, false)
+	charged := undiscounted
+	if discount.Sign() != 0 {
+		remaining := new(big.Rat).Sub(big.NewRat(1, 1), new(big.Rat).Quo(discount, quoted))
+		discounted := make([]*big.Rat, len(amounts))
+		for i, amount := range amounts {
+			discounted[i] = new(big.Rat).Mul(amount, remaining)
+		}
+		charged = calculator.tax(discounted, rates, true)
+	}
+
+	invoice.Subtotal, invoice.TaxAmount = money.Zero, money.Zero
+	net := money.Zero
+	for i := range invoice.Items {
+		invoice.Items[i].NetAmount = undiscounted.nets[i]
+		invoice.Items[i].TaxAmount = charged.taxes[i]
+		invoice.Subtotal = invoice.Subtotal.Add(undiscounted.nets[i])
+		net = net.Add(charged.nets[i])
+	}
+	invoice.DiscountAmount = invoice.Subtotal.Sub(net)
+	invoice.TaxExempt = customer.TaxExempt
+	invoice.TaxLines = charged.lines
+	for _, line := range invoice.TaxLines {
+		invoice.TaxAmount = invoice.TaxAmount.Add(line.Amount)
+	}
+
+	invoice.ChargesTotal = money.Zero
+	for i := range invoice.Charges {
+		charge := &invoice.Charges[i]
+		charge.Description = strings.TrimSpace(charge.Description)
+		if charge.Description == "" {
+			return fmt.Errorf("%w: charge %d: description is required", ErrInvalidInvoice, i+1)
+		}
+		if charge.Amount.IsNegative() {
+			return fmt.Errorf("%w: charge %d: amount must not be negative", ErrInvalidInvoice, i+1)
+		}
+		charge.Amount = charge.Amount.RoundTo(invoice.Currency, s.settings.Rounding)
+		invoice.ChargesTotal = invoice.ChargesTotal.Add(charge.Amount)
+	}
+
+	invoice.Total = net.Add(invoice.TaxAmount).Add(invoice.ChargesTotal)
+	if err := checkLimit(invoice.Subtotal, "subtotal"); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidInvoice, err)
+	}
+	if err := checkLimit(invoice.Total, "total"); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidInvoice, err)
+	}
+	invoice.AmountDue = invoice.Total.Sub(invoice.AmountPaid)
+	return nil
+}
+
+// discountOf returns the exact discount of the given type and value on amount
+func discountOf(discountType string, value money.Amount, amount *big.Rat) (*big.Rat, error) {
+	switch discountType {
+	case "":
+		if !value.IsZero() {
+			return nil, errors.New("discount_type is required with a discount_value")
+		}
+		return new(big.Rat), nil
+	case models.DiscountTypePercent:
+		if value.IsNegative() || value.Cmp(maxPercentage) > 0 {
+			return nil, errors.New("a percent discount must be between 0 and 100")
+		}
+		return new(big.Rat).Mul(amount, percent(value)), nil
+	case models.DiscountTypeFixed:
+		if value.IsNegative() {
+			return nil, errors.New("a fixed discount must not be negative")
+		}
+		if value.Rat().Cmp(amount) > 0 {
+			return nil, fmt.Errorf("the fixed discount %s is more than the amount %s", value, money.FromRat(amount, money.Scale, money.RoundHalfUp))
+		}
+		return value.Rat(), nil
+	}
+	return nil, fmt.Errorf("unknown discount_type %q (expected percent or fixed)", discountType)
+}
+
+// convertToBase converts the invoice totals into the base currency at the exchange rate
+// in effect on the issue date
+func (s *invoiceService) convertToBase(invoice *models.Invoice) (err error) {
+	defer recoverOverflow(&err, ErrInvalidInvoice)
+
+	quote, err := s.exchangeRates.GetRate(invoice.Currency, s.settings.BaseCurrency, invoice.IssueDate)
+	if err != nil {
+		if errors.Is(err, ErrExchangeRateNotFound) {
+			return fmt.Errorf("%w: %v", ErrInvalidInvoice, err)
+		}
+		return err
+	}
+
+	base, mode := s.settings.BaseCurrency, s.settings.Rounding
+	invoice.BaseCurrency = base
+	invoice.ExchangeRate = quote.Rate
+	invoice.BaseSubtotal = quote.Rate.Convert(invoice.Subtotal, mode).RoundTo(base, mode)
+	invoice.BaseDiscountAmount = quote.Rate.Convert(invoice.DiscountAmount, mode).RoundTo(base, mode)
+	invoice.BaseTaxAmount = quote.Rate.Convert(invoice.TaxAmount, mode).RoundTo(base, mode)
+	invoice.BaseChargesTotal = quote.Rate.Convert(invoice.ChargesTotal, mode).RoundTo(base, mode)
+	invoice.BaseTotal = invoice.BaseSubtotal.Sub(invoice.BaseDiscountAmount).Add(invoice.BaseTaxAmount).Add(invoice.BaseChargesTotal)
+	if err := checkLimit(invoice.BaseSubtotal, "base_subtotal"); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidInvoice, err)
+	}
+	if err := checkLimit(invoice.BaseTotal, "base_total"); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidInvoice, err)
+	}
+	return nil
+}
+
+// ConvertToBaseCurrency fills in the base currency amounts of invoices that were created
+// before multi-currency support or under a different base currency. Invoices without an
+// exchange rate for their issue date are left pending. It is safe to run repeatedly.
+func (s *invoiceService) ConvertToBaseCurrency() (converted, pending int, err error) {
+	invoices, err := s.repo.FindNotInBaseCurrency(s.settings.BaseCurrency)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list invoices: %w", err)
+	}
+
+	for i := range invoices {
+		invoice := &invoices[i]
+		if invoice.Currency == "" {
+			invoice.Currency = s.settings.DefaultCurrency
+		}
+		if err := s.convertToBase(invoice); err != nil {
+			if errors.Is(err, ErrInvalidInvoice) {
+				pending++
+				continue
+			}
+			return converted, pending, err
+		}
+		if err := s.repo.UpdateBaseAmounts(invoice); err != nil {
+			return converted, pending, fmt.Errorf("failed to update invoice %d: %w", invoice.ID, err)
+		}
+		converted++
+	}
+	return converted, pending, nil
+}
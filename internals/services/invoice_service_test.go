@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"github.com/tacheraSasi/go-api-starter/pkg/numbering"
+	"gorm.io/gorm"
+)
+
+// newTestInvoiceService wires an invoice service to db that numbers invoices
+// INV-{YYYY}-{SEQ:5} and prices them in EUR
+func newTestInvoiceService(t *testing.T, db *gorm.DB) InvoiceService {
+	t.Helper()
+	format, err := numbering.Parse("INV-{YYYY}-{SEQ:5}", numbering.ResetYearly)
+	if err != nil {
+		t.Fatalf("parse numbering: %v", err)
+	}
+	taxRates := NewTaxRateService(repositories.NewTaxRateRepository(db))
+	return NewInvoiceService(
+		repositories.NewTransactor(db),
+		repositories.NewInvoiceRepository(db),
+		repositories.NewInvoiceSequenceRepository(db),
+		repositories.NewCustomerRepository(db),
+		repositories.NewCustomerAddressRepository(db),
+		NewCustomFieldService(repositories.NewCustomFieldRepository(db)),
+		NewExchangeRateService(repositories.NewExchangeRateRepository(db), money.RoundHalfUp),
+		taxRates,
+		NewProductService(repositories.NewProductRepository(db), taxRates, "EUR"),
+		InvoiceSettings{DefaultCurrency: "EUR", BaseCurrency: "EUR", Rounding: money.RoundHalfUp, TaxRounding: TaxRoundingLine, Numbering: format},
+	)
+}
+
+// newTestInvoice is an unsaved invoice of one line for the customer
+func newTestInvoice(customerID uint, issueDate time.Time) *models.Invoice {
+	return &models.Invoice{
+		CustomerID: customerID,
+		IssueDate:  issueDate,
+		DueDate:    issueDate.AddDate(0, 0, 30),
+		Items:      []models.InvoiceItem{{Description: "Consulting", Quantity: 2, UnitPrice: money.MustParse("50")}},
+	}
+}
+
+// The invoice series must have no gaps: invoices cannot be deleted, only voided, and a
+// number that is already taken fails the create without using up the counter
+func TestInvoiceSequenceStaysContiguous(t *testing.T) {
+	db := newTestDB(t)
+	service := newTestInvoiceService(t, db)
+	customer := &models.Customer{Name: "Acme", Email: "acme@example.com", Currency: "EUR"}
+	mustCreate(t, db, customer)
+	issued := time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC)
+
+	create := func() (*models.Invoice, error) {
+		invoice := newTestInvoice(customer.ID, issued)
+		return invoice, service.CreateInvoice(invoice, nil)
+	}
+	var ids []uint
+	for i := 0; i < 3; i++ {
+		invoice, err := create()
+		if err != nil {
+			t.Fatalf("create invoice: %v", err)
+		}
+		ids = append(ids, invoice.ID)
+	}
+
+	if err := service.DeleteInvoice(ids[1]); !errors.Is(err, ErrInvoiceLocked) {
+		t.Errorf("DeleteInvoice of a draft: error = %v; want %v", err, ErrInvoiceLocked)
+	}
+	if _, err := service.VoidInvoice(ids[2], nil, "entered twice"); err != nil {
+		t.Fatalf("void invoice: %v", err)
+	}
+
+	// An invoice numbered by hand in an earlier version sits where the counter goes next
+	mustCreate(t, db, &models.Invoice{CustomerID: customer.ID, InvoiceNumber: "INV-2026-00004", IssueDate: issued, DueDate: issued, Status: models.InvoiceStatusDraft})
+	if _, err := create(); !errors.Is(err, ErrInvoiceNumberTaken) {
+		t.Fatalf("create onto a taken number: error = %v; want %v", err, ErrInvoiceNumberTaken)
+	}
+	if err := db.Model(&models.Invoice{}).Where("invoice_number = ?", "INV-2026-00004").Update("invoice_number", "HAND-1").Error; err != nil {
+		t.Fatalf("renumber invoice: %v", err)
+	}
+	if _, err := create(); err != nil {
+		t.Fatalf("create invoice: %v", err)
+	}
+
+	var numbers []string
+	if err := db.Unscoped().Model(&models.Invoice{}).Where("invoice_number LIKE ?", "INV-%").Order("invoice_number").Pluck("invoice_number", &numbers).Error; err != nil {
+		t.Fatalf("list invoice numbers: %v", err)
+	}
+	if len(numbers) != 4 {
+		t.Fatalf("invoice numbers = %v; want 4", numbers)
+	}
+	for i, number := range numbers {
+		if want := fmt.Sprintf("INV-2026-%05d", i+1); number != want {
+			t.Errorf("invoice number %d = %s; want %s", i+1, number, want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/pkg/signing"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrShareLinkNotFound is returned for unknown share links and tokens that were not issued here
+	ErrShareLinkNotFound = errors.New("share link not found")
+	// ErrShareLinkExpired is returned for share links that have expired or were revoked
+	ErrShareLinkExpired = errors.New("share link has expired or was revoked")
+)
+
+// ShareLinkPurpose separates the signing key of invoice share links from other signed tokens
+const ShareLinkPurpose = "invoice-share-link"
+
+type InvoiceShareService interface {
+	CreateShareLink(invoiceID uint, req *dtos.CreateShareLinkRequest, createdByID *uint) (*dtos.InvoiceShareLink, error)
+	ListShareLinks(invoiceID uint) ([]dtos.InvoiceShareLink, error)
+	RevokeShareLink(invoiceID, linkID uint, revokedByID *uint) (*dtos.InvoiceShareLink, error)
+	OpenSharedInvoice(token string) (*models.Invoice, error)
+	RenderSharedInvoicePDF(token string) (*models.Invoice, []byte, error)
+}
+
+type invoiceShareService struct {
+	repo        repositories.InvoiceShareLinkRepository
+	invoiceRepo repositories.InvoiceRepository
+	documents   InvoicePDFService
+	signer      *signing.Signer
+	ttl         time.Duration
+}
+
+func NewInvoiceShareService(repo repositories.InvoiceShareLinkRepository, invoiceRepo repositories.InvoiceRepository, documents InvoicePDFService, signer *signing.Signer, ttl time.Duration) InvoiceShareService {
+	return &invoiceShareService{repo: repo, invoiceRepo: invoiceRepo, documents: documents, signer: signer, ttl: ttl}
+}
+
+// CreateShareLink creates a link to an invoice that expires after the requested number
+// of days. Drafts cannot be shared, since they may still change.
+func (s *invoiceShareService) CreateShareLink(invoiceID uint, req *dtos.CreateShareLinkRequest, createdByID *uint) (*dtos.InvoiceShareLink, error) {
+	invoice, err := s.findInvoice(invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status == models.InvoiceStatusDraft {
+		return nil, fmt.Errorf("%w: draft invoices cannot be shared; send the invoice first", ErrInvalidInvoice)
+	}
+
+	ttl := s.ttl
+	if req.ExpiresInDays > 0 {
+		ttl = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+	link := &models.InvoiceShareLink{
+		InvoiceID:   invoice.ID,
+		ExpiresAt:   time.Now().UTC().Add(ttl).Truncate(time.Second),
+		CreatedByID: createdByID,
+	}
+	if err := s.repo.Create(link); err != nil {
+		return nil, fmt.Errorf("failed to create share link: %w", err)
+	}
+	return s.present(*link), nil
+}
+
+func (s *invoiceShareService) ListShareLinks(invoiceID uint) ([]dtos.InvoiceShareLink, error) {
+	if _, err := s.findInvoice(invoiceID); err != nil {
+		return nil, err
+	}
+	links, err := s.repo.FindByInvoice(invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list share links: %w", err)
+	}
+	result := make([]dtos.InvoiceShareLink, len(links))
+	for i, link := range links {
+		result[i] = *s.present(link)
+	}
+	return result, nil
+}
+
+// RevokeShareLink stops a link from opening the invoice. Revoking a revoked link is a no-op.
+func (s *invoiceShareService) RevokeShareLink(invoiceID, linkID uint, revokedByID *uint) (*dtos.InvoiceShareLink, error) {
+	link, err := s.repo.FindByID(linkID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShareLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+	if link.InvoiceID != invoiceID {
+		return nil, ErrShareLinkNotFound
+	}
+
+	if link.RevokedAt == nil {
+		now := time.Now().UTC()
+		link.RevokedAt = &now
+		link.RevokedByID = revokedByID
+		if err := s.repo.Update(link); err != nil {
+			return nil, fmt.Errorf("failed to revoke share link: %w", err)
+		}
+	}
+	return s.present(*link), nil
+}
+
+// OpenSharedInvoice returns the invoice a share link points to and counts the view
+func (s *invoiceShareService) OpenSharedInvoice(token string) (*models.Invoice, error) {
+	invoice, err := s.resolve(token)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if err := s.invoiceRepo.RecordView(invoice.ID, now); err != nil {
+		return nil, fmt.Errorf("failed to record invoice view: %w", err)
+	}
+	invoice.ViewCount++
+	if invoice.FirstViewedAt == nil {
+		invoice.FirstViewedAt = &now
+	}
+	return invoice, nil
+}
+
+// RenderSharedInvoicePDF returns the PDF of the invoice a share link points to. Downloads
+// are not counted as views.
+func (s *invoiceShareService) RenderSharedInvoicePDF(token string) (*models.Invoice, []byte, error) {
+	invoice, err := s.resolve(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.documents.RenderInvoice(invoice.ID)
+}
+
+// resolve checks a token against its share link and loads the invoice
+func (s *invoiceShareService) resolve(token string) (*models.Invoice, error) {
+	id, expiresAt, err := s.signer.Verify(token, time.Now())
+	if errors.Is(err, signing.ErrExpired) {
+		return nil, ErrShareLinkExpired
+	}
+	if err != nil {
+		return nil, ErrShareLinkNotFound
+	}
+
+	link, err := s.repo.FindByID(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShareLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+	if !link.ExpiresAt.Equal(expiresAt) {
+		return nil, ErrShareLinkNotFound
+	}
+	if !link.Active(time.Now()) {
+		return nil, ErrShareLinkExpired
+	}
+
+	invoice, err := s.invoiceRepo.FindByID(link.InvoiceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShareLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	return invoice, nil
+}
+
+func (s *invoiceShareService) findInvoice(id uint) (*models.Invoice, error) {
+	invoice, err := s.invoiceRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	return invoice, nil
+}
+
+// present adds the token to a link; the handler adds the URL, which depends on the host
+func (s *invoiceShareService) present(link models.InvoiceShareLink) *dtos.InvoiceShareLink {
+	return &dtos.InvoiceShareLink{
+		InvoiceShareLink: link,
+		Active:           link.Active(time.Now()),
+		Token:            s.signer.Sign(uint64(link.ID), link.ExpiresAt),
+	}
+}
@@ -0,0 +1,513 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPaymentNotFound is returned when a payment does not exist
+	ErrPaymentNotFound = errors.New("payment not found")
+	// ErrInvalidPayment marks a payment or allocation that cannot be accepted
+	ErrInvalidPayment = errors.New("invalid payment")
+)
+
+type PaymentService interface {
+	ListPayments(customerID uint, page, limit int) ([]models.Payment, *utils.Pagination, error)
+	GetPayment(id uint) (*models.Payment, error)
+	RecordPayment(req *dtos.CreatePaymentRequest, recordedByID *uint) (*models.Payment, error)
+	ApplyCredit(id uint, allocations []dtos.PaymentAllocationRequest, appliedByID *uint) (*models.Payment, error)
+	ReversePayment(id uint, reason string, reversedByID *uint) (*models.Payment, error)
+	PayInvoice(invoiceID uint, req *dtos.MarkInvoicePaidRequest, recordedByID *uint) (*models.Invoice, error)
+	GetCustomerCredit(customerID uint) (*dtos.CustomerCredit, error)
+	MigrateLegacyInvoices() (int, error)
+}
+
+type paymentService struct {
+	transactor      repositories.Transactor
+	repo            repositories.PaymentRepository
+	invoiceRepo     repositories.InvoiceRepository
+	customerRepo    repositories.CustomerRepository
+	defaultCurrency money.Currency
+}
+
+func NewPaymentService(transactor repositories.Transactor, repo repositories.PaymentRepository, invoiceRepo repositories.InvoiceRepository, customerRepo repositories.CustomerRepository, defaultCurrency money.Currency) PaymentService {
+	return &paymentService{
+		transactor:      transactor,
+		repo:            repo,
+		invoiceRepo:     invoiceRepo,
+		customerRepo:    customerRepo,
+		defaultCurrency: defaultCurrency,
+	}
+}
+
+func (s *paymentService) ListPayments(customerID uint, page, limit int) ([]models.Payment, *utils.Pagination, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	payments, total, err := s.repo.FindAll(customerID, page, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list payments: %w", err)
+	}
+	return payments, utils.NewPagination(page, limit, total), nil
+}
+
+func (s *paymentService) GetPayment(id uint) (*models.Payment, error) {
+	payment, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	return payment, nil
+}
+
+// RecordPayment stores a payment from a customer and allocates it to invoices, which
+// become partially paid or paid. Whatever is not allocated is kept as customer credit.
+func (s *paymentService) RecordPayment(req *dtos.CreatePaymentRequest, recordedByID *uint) (*models.Payment, error) {
+	customer, err := s.customerRepo.FindByID(req.CustomerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: customer %d not found", ErrInvalidPayment, req.CustomerID)
+		}
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	currency := customer.Currency
+	if req.Currency != "" {
+		if currency, err = money.ParseCurrency(req.Currency); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPayment, err)
+		}
+	}
+	if currency == "" {
+		currency = s.defaultCurrency
+	}
+	paymentDate, err := parsePaymentDate(req.PaymentDate)
+	if err != nil {
+		return nil, err
+	}
+	method, err := paymentMethod(req.Method)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayment, err)
+	}
+	if err := checkAmount(req.Amount, currency, "amount"); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayment, err)
+	}
+
+	payment := &models.Payment{
+		CustomerID:        customer.ID,
+		Currency:          currency,
+		Amount:            req.Amount,
+		UnallocatedAmount: req.Amount,
+		PaymentDate:       paymentDate,
+		Method:            method,
+		Reference:         strings.TrimSpace(req.Reference),
+		Notes:             strings.TrimSpace(req.Notes),
+		RecordedByID:      recordedByID,
+	}
+	err = s.transactor.Transaction(func(tx *gorm.DB) error {
+		payments, invoices := s.repo.WithTx(tx), s.invoiceRepo.WithTx(tx)
+
+		allocations, err := s.allocate(invoices, payment, req.Allocations, paymentDate)
+		if err != nil {
+			return err
+		}
+		payment.Allocations = allocations
+		for _, allocation := range allocations {
+			payment.AllocatedAmount = payment.AllocatedAmount.Add(allocation.Amount)
+		}
+		payment.UnallocatedAmount = payment.Amount.Sub(payment.AllocatedAmount)
+		if err := payments.Create(payment); err != nil {
+			return fmt.Errorf("failed to create payment: %w", err)
+		}
+		return s.settle(invoices, payments, allocations, recordedByID, fmt.Sprintf("Payment %d recorded", payment.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPayment(payment.ID)
+}
+
+// ApplyCredit allocates the unallocated amount of a payment to invoices of the customer
+func (s *paymentService) ApplyCredit(id uint, allocations []dtos.PaymentAllocationRequest, appliedByID *uint) (*models.Payment, error) {
+	err := s.transactor.Transaction(func(tx *gorm.DB) error {
+		payments, invoices := s.repo.WithTx(tx), s.invoiceRepo.WithTx(tx)
+
+		payment, err := payments.FindByIDForUpdate(id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPaymentNotFound
+			}
+			return fmt.Errorf("failed to get payment: %w", err)
+		}
+		if payment.ReversedAt != nil {
+			return fmt.Errorf("%w: payment %d was reversed", ErrInvalidPayment, payment.ID)
+		}
+		if !payment.UnallocatedAmount.IsPositive() {
+			return fmt.Errorf("%w: payment %d has no credit left", ErrInvalidPayment, payment.ID)
+		}
+
+		// Credit applied after the payment date counts from the day it is applied
+		date := time.Now()
+		if payment.PaymentDate.After(date) {
+			date = payment.PaymentDate
+		}
+		applied, err := s.allocate(invoices, payment, allocations, date)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			return fmt.Errorf("%w: customer %d has no open %s invoices", ErrInvalidPayment, payment.CustomerID, payment.Currency)
+		}
+		for i := range applied {
+			applied[i].PaymentID = payment.ID
+			payment.AllocatedAmount = payment.AllocatedAmount.Add(applied[i].Amount)
+		}
+		payment.UnallocatedAmount = payment.Amount.Sub(payment.AllocatedAmount)
+		if err := payments.CreateAllocations(applied); err != nil {
+			return fmt.Errorf("failed to allocate payment: %w", err)
+		}
+		if err := payments.Update(payment); err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+		return s.settle(invoices, payments, applied, appliedByID, fmt.Sprintf("Credit from payment %d applied", payment.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPayment(id)
+}
+
+// ReversePayment cancels a payment, for example one that bounced. Its allocations stop
+// counting, so the invoices it paid are owed again.
+func (s *paymentService) ReversePayment(id uint, reason string, reversedByID *uint) (*models.Payment, error) {
+	err := s.transactor.Transaction(func(tx *gorm.DB) error {
+		payments, invoices := s.repo.WithTx(tx), s.invoiceRepo.WithTx(tx)
+
+		payment, err := payments.FindByIDForUpdate(id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPaymentNotFound
+			}
+			return fmt.Errorf("failed to get payment: %w", err)
+		}
+		if payment.ReversedAt != nil {
+			return fmt.Errorf("%w: payment %d was already reversed", ErrInvalidPayment, payment.ID)
+		}
+
+		now := time.Now()
+		payment.ReversedAt = &now
+		payment.ReversedByID = reversedByID
+		payment.ReversalReason = strings.TrimSpace(reason)
+		if err := payments.Update(payment); err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
+		return s.settle(invoices, payments, payment.Allocations, reversedByID, fmt.Sprintf("Payment %d reversed", payment.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPayment(id)
+}
+
+// PayInvoice records a payment of the amount due on an invoice
+func (s *paymentService) PayInvoice(invoiceID uint, req *dtos.MarkInvoicePaidRequest, recordedByID *uint) (*models.Invoice, error) {
+	invoice, err := s.findInvoice(s.invoiceRepo, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if !isPayable(invoice) {
+		return nil, fmt.Errorf("%w: a %s invoice cannot be marked paid", ErrInvalidTransition, invoice.Status)
+	}
+
+	_, err = s.RecordPayment(&dtos.CreatePaymentRequest{
+		CustomerID:  invoice.CustomerID,
+		Amount:      invoice.AmountDue,
+		Currency:    string(invoice.Currency),
+		PaymentDate: req.PaidAt,
+		Method:      req.Method,
+		Reference:   req.Reference,
+		Notes:       req.Note,
+		Allocations: []dtos.PaymentAllocationRequest{{InvoiceID: invoice.ID, Amount: invoice.AmountDue}},
+	}, recordedByID)
+	if err != nil {
+		return nil, err
+	}
+	return s.findInvoice(s.invoiceRepo, invoiceID)
+}
+
+// GetCustomerCredit sums the unallocated amounts of a customer's payments per currency
+func (s *paymentService) GetCustomerCredit(customerID uint) (_ *dtos.CustomerCredit, err error) {
+	defer recoverOverflow(&err, ErrAmountOutOfRange)
+
+	if _, err := s.customerRepo.FindByID(customerID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCustomerNotFound
+		}
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	payments, err := s.repo.FindWithCredit(customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments: %w", err)
+	}
+
+	credit := &dtos.CustomerCredit{CustomerID: customerID, Balances: []dtos.CreditBalance{}}
+	index := make(map[money.Currency]int)
+	for _, payment := range payments {
+		i, ok := index[payment.Currency]
+		if !ok {
+			i = len(credit.Balances)
+			index[payment.Currency] = i
+			credit.Balances = append(credit.Balances, dtos.CreditBalance{Currency: payment.Currency})
+		}
+		credit.Balances[i].Amount = credit.Balances[i].Amount.Add(payment.UnallocatedAmount)
+	}
+	return credit, nil
+}
+
+// MigrateLegacyInvoices fills in the amount due of invoices stored before payments were
+// tracked, and records one payment for the total of each invoice that was marked paid,
+// dated when it was paid or, failing that, last updated. It is safe to run repeatedly.
+func (s *paymentService) MigrateLegacyInvoices() (int, error) {
+	invoices, err := s.invoiceRepo.FindUnsettled()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list invoices: %w", err)
+	}
+
+	migrated := 0
+	for i := range invoices {
+		invoice := &invoices[i]
+		err := s.transactor.Transaction(func(tx *gorm.DB) error {
+			if invoice.Status != models.InvoiceStatusPaid {
+				invoice.AmountDue = invoice.Total.Sub(invoice.AmountPaid)
+				return s.invoiceRepo.WithTx(tx).UpdateStatus(invoice, nil)
+			}
+
+			paidAt := invoice.UpdatedAt
+			if invoice.PaidAt != nil {
+				paidAt = *invoice.PaidAt
+			}
+			currency := invoice.Currency
+			if currency == "" {
+				currency = s.defaultCurrency
+			}
+			payment := &models.Payment{
+				CustomerID:      invoice.CustomerID,
+				Currency:        currency,
+				Amount:          invoice.Total,
+				AllocatedAmount: invoice.Total,
+				PaymentDate:     paidAt,
+				Method:          models.PaymentMethodOther,
+				Reference:       invoice.InvoiceNumber,
+				Notes:           "Recorded from an invoice marked paid before payments were tracked",
+				Allocations:     []models.PaymentAllocation{{InvoiceID: invoice.ID, Amount: invoice.Total, Date: paidAt}},
+			}
+			if err := s.repo.WithTx(tx).Create(payment); err != nil {
+				return err
+			}
+			invoice.AmountPaid, invoice.AmountDue, invoice.PaidAt = invoice.Total, money.Zero, &paidAt
+			return s.invoiceRepo.WithTx(tx).UpdateStatus(invoice, nil)
+		})
+		if err != nil {
+			return migrated, fmt.Errorf("failed to migrate invoice %d: %w", invoice.ID, err)
+		}
+		migrated++
+	}
+	return migrated, nil
+}
+
+// allocate validates the requested allocations of a payment's unallocated amount, or, when
+// none are requested, spreads it over the customer's open invoices, oldest due date first
+func (s *paymentService) allocate(invoices repositories.InvoiceRepository, payment *models.Payment, requests []dtos.PaymentAllocationRequest, date time.Time) ([]models.PaymentAllocation, error) {
+	var allocations []models.PaymentAllocation
+	available := payment.UnallocatedAmount
+
+	if requests == nil {
+		open, err := invoices.FindPayable(payment.CustomerID, payment.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list open invoices: %w", err)
+		}
+		for _, invoice := range open {
+			amount := money.Min(invoice.AmountDue, available)
+			if !amount.IsPositive() {
+				continue
+			}
+			allocations = append(allocations, models.PaymentAllocation{InvoiceID: invoice.ID, Amount: amount, Date: date})
+			available = available.Sub(amount)
+		}
+		return allocations, nil
+	}
+
+	seen := make(map[uint]bool, len(requests))
+	for i, req := range requests {
+		if seen[req.InvoiceID] {
+			return nil, fmt.Errorf("%w: invoice %d is allocated more than once", ErrInvalidPayment, req.InvoiceID)
+		}
+		seen[req.InvoiceID] = true
+		if err := checkAmount(req.Amount, payment.Currency, fmt.Sprintf("allocation %d: amount", i+1)); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPayment, err)
+		}
+
+		invoice, err := s.findInvoice(invoices, req.InvoiceID)
+		if err != nil {
+			if errors.Is(err, ErrInvoiceNotFound) {
+				return nil, fmt.Errorf("%w: invoice %d not found", ErrInvalidPayment, req.InvoiceID)
+			}
+			return nil, err
+		}
+		switch {
+		case invoice.CustomerID != payment.CustomerID:
+			return nil, fmt.Errorf("%w: invoice %s belongs to another customer", ErrInvalidPayment, invoice.InvoiceNumber)
+		case invoice.Currency != payment.Currency:
+			return nil, fmt.Errorf("%w: invoice %s is in %s, not %s", ErrInvalidPayment, invoice.InvoiceNumber, invoice.Currency, payment.Currency)
+		case !isPayable(invoice):
+			return nil, fmt.Errorf("%w: invoice %s is %s; only sent, partially paid and overdue invoices can be paid", ErrInvalidPayment, invoice.InvoiceNumber, invoice.Status)
+		case req.Amount.Cmp(invoice.AmountDue) > 0:
+			return nil, fmt.Errorf("%w: %s is more than the %s due on invoice %s", ErrInvalidPayment, req.Amount, invoice.AmountDue, invoice.InvoiceNumber)
+		}
+
+		available = available.Sub(req.Amount)
+		if available.IsNegative() {
+			return nil, fmt.Errorf("%w: the allocations add up to more than the %s available", ErrInvalidPayment, payment.UnallocatedAmount)
+		}
+		allocations = append(allocations, models.PaymentAllocation{InvoiceID: invoice.ID, Amount: req.Amount, Date: date})
+	}
+	return allocations, nil
+}
+
+// settle recomputes the amount paid on the invoices of the given allocations from the
+// payments that were not reversed and settles them
+func (s *paymentService) settle(invoices repositories.InvoiceRepository, payments repositories.PaymentRepository, allocations []models.PaymentAllocation, changedByID *uint, note string) error {
+	settled := make(map[uint]bool, len(allocations))
+	for _, allocation := range allocations {
+		if settled[allocation.InvoiceID] {
+			continue
+		}
+		settled[allocation.InvoiceID] = true
+
+		invoice, err := s.findInvoice(invoices, allocation.InvoiceID)
+		if err != nil {
+			return err
+		}
+		active, err := payments.FindInvoiceAllocations(invoice.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get payments of invoice %d: %w", invoice.ID, err)
+		}
+
+		var paidAt time.Time
+		invoice.AmountPaid = money.Zero
+		for _, paid := range active {
+			invoice.AmountPaid = invoice.AmountPaid.Add(paid.Amount)
+			if paid.Date.After(paidAt) {
+				paidAt = paid.Date
+			}
+		}
+		if err := settleInvoice(invoices, invoice, paidAt, changedByID, note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settleInvoice recomputes the amount due of an invoice from what was paid and credited,
+// derives its status and stores both, recording the status change, if any. settledAt is
+// the paid date of an invoice that ends up paid.
+func settleInvoice(invoices repositories.InvoiceRepository, invoice *models.Invoice, settledAt time.Time, changedByID *uint, note string) error {
+	invoice.AmountDue = invoice.Total.Sub(invoice.AmountPaid).Sub(invoice.AmountCredited)
+	status := invoice.SettledStatus(time.Now())
+	invoice.PaidAt = nil
+	if status == models.InvoiceStatusPaid {
+		invoice.PaidAt = &settledAt
+	}
+
+	var change *models.InvoiceStatusChange
+	if status != invoice.Status {
+		change = &models.InvoiceStatusChange{FromStatus: invoice.Status, ToStatus: status, ChangedByID: changedByID, Note: note}
+		invoice.Status = status
+	}
+	if err := invoices.UpdateStatus(invoice, change); err != nil {
+		return fmt.Errorf("failed to update invoice %d: %w", invoice.ID, err)
+	}
+	return nil
+}
+
+// findInvoice loads an invoice and, inside a transaction, locks it until the transaction
+// ends, so concurrent payments cannot allocate the same amount due twice
+func (s *paymentService) findInvoice(invoices repositories.InvoiceRepository, id uint) (*models.Invoice, error) {
+	invoice, err := invoices.FindByIDForUpdate(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	return invoice, nil
+}
+
+// isPayable reports whether payments can be allocated to an invoice
+func isPayable(invoice *models.Invoice) bool {
+	for _, status := range models.InvoicePayableStatuses {
+		if invoice.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePaymentDate reads a YYYY-MM-DD payment date, defaulting to now
+func parsePaymentDate(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Now(), nil
+	}
+	date, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: payment date must be YYYY-MM-DD", ErrInvalidPayment)
+	}
+	if date.After(time.Now()) {
+		return time.Time{}, fmt.Errorf("%w: payment date must not be in the future", ErrInvalidPayment)
+	}
+	return date, nil
+}
+
+// paymentMethod checks a payment method, defaulting to other
+func paymentMethod(method string) (string, error) {
+	if method == "" {
+		return models.PaymentMethodOther, nil
+	}
+	for _, known := range models.PaymentMethods {
+		if method == known {
+			return method, nil
+		}
+	}
+	return "", fmt.Errorf("unknown method %q (expected one of %s)", method, strings.Join(models.PaymentMethods, ", "))
+}
+
+// checkAmount requires a positive amount up to money.MaxAmount in whole minor units of
+// the currency
+func checkAmount(amount money.Amount, currency money.Currency, label string) error {
+	if !amount.IsPositive() {
+		return fmt.Errorf("%s must be positive", label)
+	}
+	if err := checkLimit(amount, label); err != nil {
+		return err
+	}
+	if amount.RoundTo(currency, money.RoundHalfUp).Cmp(amount) != 0 {
+		return fmt.Errorf("%s %s has more decimals than %s allows", label, amount, currency)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+func TestRecordPaymentLimit(t *testing.T) {
+	db := newTestDB(t)
+	customer := &models.Customer{Name: "Acme", Email: "acme@example.com", Currency: "EUR"}
+	mustCreate(t, db, customer)
+	service := NewPaymentService(repositories.NewTransactor(db), repositories.NewPaymentRepository(db),
+		repositories.NewInvoiceRepository(db), repositories.NewCustomerRepository(db), "EUR")
+
+	record := func(amount money.Amount) error {
+		_, err := service.RecordPayment(&dtos.CreatePaymentRequest{CustomerID: customer.ID, Amount: amount, Allocations: []dtos.PaymentAllocationRequest{}}, nil)
+		return err
+	}
+	if err := record(money.MaxAmount.Add(money.MustParse("0.01"))); !errors.Is(err, ErrInvalidPayment) {
+		t.Errorf("payment above the maximum: error = %v; want %v", err, ErrInvalidPayment)
+	}
+	if err := record(money.MaxAmount); err != nil {
+		t.Errorf("payment of the maximum: %v", err)
+	}
+}
@@ -5,17 +5,20 @@ import (
 	"fmt"
 
 	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/policy"
 	"github.com/tacheraSasi/go-api-starter/internals/repositories"
 	"gorm.io/gorm"
 )
 
 type PermissionService struct {
 	permissionRepo *repositories.PermissionRepository
+	roleRepo       *repositories.RoleRepository
 }
 
-func NewPermissionService(permissionRepo *repositories.PermissionRepository) *PermissionService {
+func NewPermissionService(permissionRepo *repositories.PermissionRepository, roleRepo *repositories.RoleRepository) *PermissionService {
 	return &PermissionService{
 		permissionRepo: permissionRepo,
+		roleRepo:       roleRepo,
 	}
 }
 
@@ -122,45 +125,52 @@ func (s *PermissionService) DeletePermission(id uint) error {
 	return nil
 }
 
-// InitializeDefaultPermissions creates default permissions if they don't exist
+// InitializeDefaultPermissions creates the permissions of the embedded default policy if they
+// don't exist. A created permission is granted to the existing default roles whose grants,
+// such as "*", cover it; permissions that already exist keep their grants.
 func (s *PermissionService) InitializeDefaultPermissions() error {
-	resources := []string{
-		models.ResourceUser,
-		models.ResourceCustomer,
-		models.ResourceInvoice,
-		models.ResourceRole,
-		models.ResourceSystem,
-	}
-
-	actions := []string{
-		models.ActionCreate,
-		models.ActionRead,
-		models.ActionUpdate,
-		models.ActionDelete,
-		models.ActionList,
-		models.ActionManage,
-	}
-
-	for _, resource := range resources {
-		for _, action := range actions {
-			// Skip creating manage permission for system resource except for admin
-			if resource == models.ResourceSystem && action != models.ActionManage {
-				continue
-			}
+	defaults, err := policy.Default()
+	if err != nil {
+		return fmt.Errorf("failed to load default policy: %w", err)
+	}
 
-			name := fmt.Sprintf("%s:%s", resource, action)
-			description := fmt.Sprintf("Allow %s on %s", action, resource)
+	created := make(map[string]uint)
+	for _, spec := range defaults.Permissions {
+		_, err := s.permissionRepo.GetByResourceAndAction(spec.Resource, spec.Action)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			permission := &models.Permission{
+				Name:        spec.Name,
+				Resource:    spec.Resource,
+				Action:      spec.Action,
+				Description: spec.Description,
+			}
+			if err := s.permissionRepo.Create(permission); err != nil {
+				return fmt.Errorf("failed to create default permission %s: %w", spec.Name, err)
+			}
+			created[spec.Name] = permission.ID
+		}
+	}
+	if len(created) == 0 {
+		return nil
+	}
 
-			_, err := s.permissionRepo.GetByResourceAndAction(resource, action)
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				permission := &models.Permission{
-					Name:        name,
-					Resource:    resource,
-					Action:      action,
-					Description: description,
-				}
-				if err := s.permissionRepo.Create(permission); err != nil {
-					return fmt.Errorf("failed to create default permission %s: %w", name, err)
+	// Roles created later by InitializeDefaultRoles receive all their grants themselves
+	for _, spec := range defaults.Roles {
+		role, err := s.roleRepo.GetByName(spec.Name)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to find default role %s: %w", spec.Name, err)
+		}
+		grants, err := defaults.ResolveGrants(spec)
+		if err != nil {
+			return err
+		}
+		for _, name := range grants {
+			if id, ok := created[name]; ok {
+				if err := s.roleRepo.AddPermission(role.ID, id); err != nil {
+					return fmt.Errorf("failed to grant %s to role %s: %w", name, spec.Name, err)
 				}
 			}
 		}
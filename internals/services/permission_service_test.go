@@ -0,0 +1,78 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+)
+
+// A default permission added after the roles exist is granted to the roles whose grants
+// cover it, while grants an administrator revoked stay revoked
+func TestInitializeDefaultPermissionsGrantsNewPermissions(t *testing.T) {
+	db := newTestDB(t)
+	permissionRepo := repositories.NewPermissionRepository(db)
+	roleRepo := repositories.NewRoleRepository(db)
+	permissions := NewPermissionService(permissionRepo, roleRepo)
+	roles := NewRoleService(roleRepo, permissionRepo)
+	if err := permissions.InitializeDefaultPermissions(); err != nil {
+		t.Fatalf("initialize permissions: %v", err)
+	}
+	if err := roles.InitializeDefaultRoles(); err != nil {
+		t.Fatalf("initialize roles: %v", err)
+	}
+
+	// invoice:read stands for a permission added by a later release
+	added, err := permissionRepo.GetByName("invoice:read")
+	if err != nil {
+		t.Fatalf("find invoice:read: %v", err)
+	}
+	if err := db.Unscoped().Where("permission_id = ?", added.ID).Delete(&models.RolePermission{}).Error; err != nil {
+		t.Fatalf("remove grants: %v", err)
+	}
+	if err := db.Unscoped().Delete(added).Error; err != nil {
+		t.Fatalf("remove permission: %v", err)
+	}
+	user, err := roleRepo.GetByName(models.RoleUser)
+	if err != nil {
+		t.Fatalf("find user role: %v", err)
+	}
+	revoked, err := permissionRepo.GetByName("customer:read")
+	if err != nil {
+		t.Fatalf("find customer:read: %v", err)
+	}
+	if err := roleRepo.RemovePermission(user.ID, revoked.ID); err != nil {
+		t.Fatalf("revoke customer:read: %v", err)
+	}
+
+	if err := permissions.InitializeDefaultPermissions(); err != nil {
+		t.Fatalf("initialize permissions again: %v", err)
+	}
+
+	granted := func(role, permission string) bool {
+		t.Helper()
+		found, err := roleRepo.GetByName(role)
+		if err != nil {
+			t.Fatalf("find role %s: %v", role, err)
+		}
+		for _, p := range found.Permissions {
+			if p.Name == permission {
+				return true
+			}
+		}
+		return false
+	}
+	for _, c := range []struct {
+		role, permission string
+		want             bool
+	}{
+		{models.RoleAdmin, "invoice:read", true},
+		{models.RoleUser, "invoice:read", true},
+		{models.RoleGuest, "invoice:read", false},
+		{models.RoleUser, "customer:read", false},
+	} {
+		if got := granted(c.role, c.permission); got != c.want {
+			t.Errorf("%s granted %s = %v; want %v", c.role, c.permission, got, c.want)
+		}
+	}
+}
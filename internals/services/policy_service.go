@@ -0,0 +1,238 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/policy"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"gorm.io/gorm"
+)
+
+type PolicyService struct {
+	transactor     repositories.Transactor
+	roleRepo       *repositories.RoleRepository
+	permissionRepo *repositories.PermissionRepository
+}
+
+func NewPolicyService(transactor repositories.Transactor, roleRepo *repositories.RoleRepository, permissionRepo *repositories.PermissionRepository) *PolicyService {
+	return &PolicyService{
+		transactor:     transactor,
+		roleRepo:       roleRepo,
+		permissionRepo: permissionRepo,
+	}
+}
+
+// Export builds a policy from the roles and permissions currently in the database
+func (s *PolicyService) Export() (*policy.Policy, error) {
+	return exportPolicy(s.roleRepo, s.permissionRepo)
+}
+
+// Diff lists the changes needed to bring the database in line with the desired policy
+func (s *PolicyService) Diff(desired *policy.Policy, opts policy.DiffOptions) ([]policy.Change, error) {
+	current, err := s.Export()
+	if err != nil {
+		return nil, err
+	}
+	return policy.Diff(current, desired, opts)
+}
+
+// Apply brings the database in line with the desired policy inside a single
+// transaction and returns the changes made. Applying the same policy twice is a no-op.
+func (s *PolicyService) Apply(desired *policy.Policy, opts policy.DiffOptions) ([]policy.Change, error) {
+	var changes []policy.Change
+	err := s.transactor.Transaction(func(tx *gorm.DB) error {
+		roleRepo := s.roleRepo.WithTx(tx)
+		permissionRepo := s.permissionRepo.WithTx(tx)
+
+		current, err := exportPolicy(roleRepo, permissionRepo)
+		if err != nil {
+			return err
+		}
+		changes, err = policy.Diff(current, desired, opts)
+		if err != nil {
+			return err
+		}
+
+		return applyChanges(roleRepo, permissionRepo, desired, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+func exportPolicy(roleRepo *repositories.RoleRepository, permissionRepo *repositories.PermissionRepository) (*policy.Policy, error) {
+	permissions, err := permissionRepo.ListAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list permissions: %w", err)
+	}
+	roles, err := roleRepo.ListAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	p := &policy.Policy{
+		Version:     policy.CurrentVersion,
+		Permissions: []policy.PermissionSpec{},
+		Roles:       []policy.RoleSpec{},
+	}
+	for _, permission := range permissions {
+		p.Permissions = append(p.Permissions, policy.PermissionSpec{
+			Name:        permission.Name,
+			Resource:    permission.Resource,
+			Action:      permission.Action,
+			Description: permission.Description,
+		})
+	}
+	for _, role := range roles {
+		active := role.IsActive
+		spec := policy.RoleSpec{
+			Name:        role.Name,
+			Description: role.Description,
+			Active:      &active,
+			Permissions: []string{},
+		}
+		for _, permission := range role.Permissions {
+			spec.Permissions = append(spec.Permissions, permission.Name)
+		}
+		p.Roles = append(p.Roles, spec)
+	}
+
+	p.Normalize()
+	return p, nil
+}
+
+func applyChanges(roleRepo *repositories.RoleRepository, permissionRepo *repositories.PermissionRepository, desired *policy.Policy, changes []policy.Change) error {
+	permissionIDs := make(map[string]uint)
+	roleIDs := make(map[string]uint)
+
+	permissionID := func(name string) (uint, error) {
+		if id, ok := permissionIDs[name]; ok {
+			return id, nil
+		}
+		permission, err := permissionRepo.GetByName(name)
+		if err != nil {
+			return 0, fmt.Errorf("failed to find permission %s: %w", name, err)
+		}
+		permissionIDs[name] = permission.ID
+		return permission.ID, nil
+	}
+	roleID := func(name string) (uint, error) {
+		if id, ok := roleIDs[name]; ok {
+			return id, nil
+		}
+		role, err := roleRepo.GetByName(name)
+		if err != nil {
+			return 0, fmt.Errorf("failed to find role %s: %w", name, err)
+		}
+		roleIDs[name] = role.ID
+		return role.ID, nil
+	}
+
+	for _, change := range changes {
+		switch {
+		case change.Object == policy.ObjectPermission && change.Type == policy.ChangeCreate:
+			spec := desired.Permission(change.Name)
+			permission := &models.Permission{
+				Name:        spec.Name,
+				Resource:    spec.Resource,
+				Action:      spec.Action,
+				Description: spec.Description,
+			}
+			if err := permissionRepo.Create(permission); err != nil {
+				return fmt.Errorf("failed to create permission %s: %w", spec.Name, err)
+			}
+			permissionIDs[spec.Name] = permission.ID
+
+		case change.Object == policy.ObjectPermission && change.Type == policy.ChangeUpdate:
+			spec := desired.Permission(change.Name)
+			permission, err := permissionRepo.GetByName(change.Name)
+			if err != nil {
+				return fmt.Errorf("failed to find permission %s: %w", change.Name, err)
+			}
+			permission.Resource = spec.Resource
+			permission.Action = spec.Action
+			permission.Description = spec.Description
+			if err := permissionRepo.Update(permission); err != nil {
+				return fmt.Errorf("failed to update permission %s: %w", change.Name, err)
+			}
+
+		case change.Object == policy.ObjectPermission && change.Type == policy.ChangeDelete:
+			id, err := permissionID(change.Name)
+			if err != nil {
+				return err
+			}
+			if err := permissionRepo.ClearRoles(id); err != nil {
+				return fmt.Errorf("failed to revoke permission %s: %w", change.Name, err)
+			}
+			if err := permissionRepo.Delete(id); err != nil {
+				return fmt.Errorf("failed to delete permission %s: %w", change.Name, err)
+			}
+
+		case change.Object == policy.ObjectRole && change.Type == policy.ChangeCreate:
+			spec := desired.Role(change.Name)
+			role := &models.Role{
+				Name:        spec.Name,
+				Description: spec.Description,
+				IsActive:    true,
+			}
+			if err := roleRepo.Create(role); err != nil {
+				return fmt.Errorf("failed to create role %s: %w", spec.Name, err)
+			}
+			// IsActive has a database default of true, so an inactive role needs a second write
+			if !spec.IsActive() {
+				role.IsActive = false
+				if err := roleRepo.Update(role); err != nil {
+					return fmt.Errorf("failed to deactivate role %s: %w", spec.Name, err)
+				}
+			}
+			roleIDs[spec.Name] = role.ID
+
+		case change.Object == policy.ObjectRole && change.Type == policy.ChangeUpdate:
+			spec := desired.Role(change.Name)
+			role, err := roleRepo.GetByName(change.Name)
+			if err != nil {
+				return fmt.Errorf("failed to find role %s: %w", change.Name, err)
+			}
+			role.Description = spec.Description
+			role.IsActive = spec.IsActive()
+			role.Permissions = nil
+			if err := roleRepo.Update(role); err != nil {
+				return fmt.Errorf("failed to update role %s: %w", change.Name, err)
+			}
+
+		case change.Object == policy.ObjectRole && change.Type == policy.ChangeDelete:
+			id, err := roleID(change.Name)
+			if err != nil {
+				return err
+			}
+			if err := roleRepo.ClearPermissions(id); err != nil {
+				return fmt.Errorf("failed to revoke grants of role %s: %w", change.Name, err)
+			}
+			if err := roleRepo.Delete(id); err != nil {
+				return fmt.Errorf("failed to delete role %s: %w", change.Name, err)
+			}
+
+		case change.Object == policy.ObjectGrant:
+			rid, err := roleID(change.Name)
+			if err != nil {
+				return err
+			}
+			pid, err := permissionID(change.Permission)
+			if err != nil {
+				return err
+			}
+			if change.Type == policy.ChangeCreate {
+				err = roleRepo.AddPermission(rid, pid)
+			} else {
+				err = roleRepo.RemovePermission(rid, pid)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to %s grant %s -> %s: %w", change.Type, change.Name, change.Permission, err)
+			}
+		}
+	}
+
+	return nil
+}
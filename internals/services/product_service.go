@@ -0,0 +1,216 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrProductNotFound is returned when a product does not exist
+	ErrProductNotFound = errors.New("product not found")
+	// ErrInvalidProduct marks a product, or a product on an invoice line, that cannot be accepted
+	ErrInvalidProduct = errors.New("invalid product")
+)
+
+type ProductService interface {
+	ListProducts(filter repositories.ProductFilter) ([]models.Product, *utils.Pagination, error)
+	GetProduct(id uint) (*models.Product, error)
+	CreateProduct(req *dtos.ProductRequest) (*models.Product, error)
+	UpdateProduct(id uint, req *dtos.ProductRequest) (*models.Product, error)
+	DeleteProduct(id uint) error
+	FillItems(items []models.InvoiceItem, currency money.Currency) error
+}
+
+type productService struct {
+	repo            repositories.ProductRepository
+	taxRates        TaxRateService
+	defaultCurrency money.Currency
+}
+
+func NewProductService(repo repositories.ProductRepository, taxRates TaxRateService, defaultCurrency money.Currency) ProductService {
+	return &productService{repo: repo, taxRates: taxRates, defaultCurrency: defaultCurrency}
+}
+
+// ListProducts searches the catalog by SKU, name and description
+func (s *productService) ListProducts(filter repositories.ProductFilter) ([]models.Product, *utils.Pagination, error) {
+	filter.Normalize()
+
+	products, total, err := s.repo.FindAll(filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list products: %w", err)
+	}
+
+	pagination := utils.NewPagination(filter.Page, filter.Limit, total)
+	return products, pagination, nil
+}
+
+func (s *productService) GetProduct(id uint) (*models.Product, error) {
+	product, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	return product, nil
+}
+
+func (s *productService) CreateProduct(req *dtos.ProductRequest) (*models.Product, error) {
+	product := &models.Product{Active: true}
+	if err := s.applyRequest(product, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(product); err != nil {
+		return nil, fmt.Errorf("failed to create product: %w", err)
+	}
+	return s.GetProduct(product.ID)
+}
+
+// UpdateProduct replaces a product. Invoice lines keep the name and price they were
+// filled with.
+func (s *productService) UpdateProduct(id uint, req *dtos.ProductRequest) (*models.Product, error) {
+	product, err := s.GetProduct(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRequest(product, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(product); err != nil {
+		return nil, fmt.Errorf("failed to update product: %w", err)
+	}
+	return s.GetProduct(product.ID)
+}
+
+func (s *productService) DeleteProduct(id uint) error {
+	if err := s.repo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductNotFound
+		}
+		return fmt.Errorf("failed to delete product: %w", err)
+	}
+	return nil
+}
+
+// applyRequest validates req and copies it onto product
+func (s *productService) applyRequest(product *models.Product, req *dtos.ProductRequest) error {
+	sku := strings.TrimSpace(req.SKU)
+	if sku == "" {
+		return fmt.Errorf("%w: sku is required", ErrInvalidProduct)
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidProduct)
+	}
+	if req.DefaultPrice.IsNegative() {
+		return fmt.Errorf("%w: default_price must not be negative", ErrInvalidProduct)
+	}
+	if err := checkLimit(req.DefaultPrice, "default_price"); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidProduct, err)
+	}
+	currency := s.defaultCurrency
+	if req.Currency != "" {
+		parsed, err := money.ParseCurrency(req.Currency)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidProduct, err)
+		}
+		currency = parsed
+	}
+
+	if existing, err := s.repo.FindBySKU(sku); err == nil && existing.ID != product.ID {
+		return fmt.Errorf("%w: SKU %s is already taken", ErrInvalidProduct, existing.SKU)
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to check SKU: %w", err)
+	}
+
+	if req.TaxRateID != nil {
+		if _, err := s.taxRates.GetTaxRate(*req.TaxRateID); err != nil {
+			if errors.Is(err, ErrTaxRateNotFound) {
+				return fmt.Errorf("%w: tax rate %d not found", ErrInvalidProduct, *req.TaxRateID)
+			}
+			return err
+		}
+	}
+
+	product.SKU = sku
+	product.Name = name
+	product.Description = strings.TrimSpace(req.Description)
+	product.Unit = strings.TrimSpace(req.Unit)
+	product.Currency = currency
+	product.DefaultPrice = req.DefaultPrice
+	product.TaxRateID = req.TaxRateID
+	product.TaxRate = nil
+	if req.Active != nil {
+		product.Active = *req.Active
+	}
+	return nil
+}
+
+// FillItems copies the catalog details onto the invoice lines that name a product: an
+// empty description gets the product's name and description, a zero unit price its
+// default price and omitted tax rates its tax rate. Lines keep what they were filled
+// with, so changing the product later does not alter them. Inactive and deleted products
+// stay on the lines that already use them but cannot fill anything.
+func (s *productService) FillItems(items []models.InvoiceItem, currency money.Currency) error {
+	var ids []uint
+	for _, item := range items {
+		if item.ProductID != nil {
+			ids = append(ids, *item.ProductID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	products, err := s.repo.FindByIDs(ids)
+	if err != nil {
+		return fmt.Errorf("failed to get products: %w", err)
+	}
+	byID := make(map[uint]*models.Product, len(products))
+	for i := range products {
+		byID[products[i].ID] = &products[i]
+	}
+
+	for i := range items {
+		item := &items[i]
+		if item.ProductID == nil {
+			continue
+		}
+		product, ok := byID[*item.ProductID]
+		if !ok {
+			return fmt.Errorf("%w: item %d: product %d not found", ErrInvalidProduct, i+1, *item.ProductID)
+		}
+
+		item.Description = strings.TrimSpace(item.Description)
+		fillDescription, fillPrice := item.Description == "", item.UnitPrice.IsZero()
+		fillTax := item.TaxRateIDs == nil && product.TaxRateID != nil
+		if !fillDescription && !fillPrice && !fillTax {
+			continue
+		}
+		if !product.Active || product.DeletedAt.Valid {
+			return fmt.Errorf("%w: item %d: product %s is no longer available; give the description, unit_price and tax_rate_ids instead", ErrInvalidProduct, i+1, product.SKU)
+		}
+		if fillPrice && product.Currency != currency {
+			return fmt.Errorf("%w: item %d: product %s is priced in %s; give a unit_price in %s", ErrInvalidProduct, i+1, product.SKU, product.Currency, currency)
+		}
+
+		if fillDescription {
+			item.Description = product.LineDescription()
+		}
+		if fillPrice {
+			item.UnitPrice = product.DefaultPrice
+		}
+		if fillTax {
+			item.TaxRateIDs = models.UintList{*product.TaxRateID}
+		}
+	}
+	return nil
+}
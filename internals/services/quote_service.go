@@ -0,0 +1,590 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"github.com/tacheraSasi/go-api-starter/pkg/numbering"
+	"github.com/tacheraSasi/go-api-starter/pkg/signing"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrQuoteNotFound is returned when a quote does not exist
+	ErrQuoteNotFound = errors.New("quote not found")
+	// ErrInvalidQuote marks quote input that cannot be accepted
+	ErrInvalidQuote = errors.New("invalid quote")
+	// ErrInvalidQuoteTransition is returned when a quote cannot move to the requested status,
+	// or be changed or converted in its current status
+	ErrInvalidQuoteTransition = errors.New("invalid quote status transition")
+)
+
+// QuoteLinkPurpose separates the signing key of quote links from other signed tokens
+const QuoteLinkPurpose = "quote-link"
+
+// quoteScope prefixes the sequence scopes of quotes, which are numbered apart from invoices
+const quoteScope = "quote:"
+
+// convertedInvoiceDueDays is how long after its issue date an invoice converted from a
+// quote is due, unless the conversion sets a due date
+const convertedInvoiceDueDays = 30
+
+// QuoteSettings configures how quotes are numbered and how long they stay valid
+type QuoteSettings struct {
+	// Numbering renders the numbers of new quotes from their own sequence
+	Numbering numbering.Format
+	// ValidityDays is how long after its issue date a quote expires unless it sets an expiry date
+	ValidityDays int
+}
+
+type QuoteService interface {
+	ListQuotes(customerID uint, status string, page, limit int) ([]models.Quote, *utils.Pagination, error)
+	GetQuote(id uint) (*models.Quote, error)
+	CreateQuote(req *dtos.QuoteRequest, createdByID *uint) (*models.Quote, error)
+	UpdateQuote(id uint, req *dtos.QuoteRequest) (*models.Quote, error)
+	DeleteQuote(id uint) error
+	SendQuote(id uint) (*models.Quote, error)
+	AcceptQuote(id uint, respondedByID *uint) (*models.Quote, error)
+	DeclineQuote(id uint, reason string, respondedByID *uint) (*models.Quote, error)
+	ConvertQuote(id uint, req *dtos.ConvertQuoteRequest, convertedByID *uint) (*models.Invoice, error)
+	MarkExpiredQuotes(now time.Time) (int, error)
+	GetQuoteLink(id uint) (*dtos.QuoteLink, error)
+	OpenSharedQuote(token string) (*models.Quote, error)
+	AcceptSharedQuote(token string) (*models.Quote, error)
+	DeclineSharedQuote(token, reason string) (*models.Quote, error)
+}
+
+type quoteService struct {
+	transactor  repositories.Transactor
+	repo        repositories.QuoteRepository
+	invoiceRepo repositories.InvoiceRepository
+	sequences   repositories.InvoiceSequenceRepository
+	invoices    InvoiceService
+	signer      *signing.Signer
+	settings    QuoteSettings
+}
+
+func NewQuoteService(
+	transactor repositories.Transactor,
+	repo repositories.QuoteRepository,
+	invoiceRepo repositories.InvoiceRepository,
+	sequences repositories.InvoiceSequenceRepository,
+	invoices InvoiceService,
+	signer *signing.Signer,
+	settings QuoteSettings,
+) QuoteService {
+	return &quoteService{
+		transactor:  transactor,
+		repo:        repo,
+		invoiceRepo: invoiceRepo,
+		sequences:   sequences,
+		invoices:    invoices,
+		signer:      signer,
+		settings:    settings,
+	}
+}
+
+func (s *quoteService) ListQuotes(customerID uint, status string, page, limit int) ([]models.Quote, *utils.Pagination, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	if status != "" && !knownQuoteStatus(status) {
+		return nil, nil, fmt.Errorf("%w: unknown status %q (expected %s)", ErrInvalidQuote, status, strings.Join(models.QuoteStatuses, ", "))
+	}
+
+	quotes, total, err := s.repo.FindAll(customerID, status, page, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list quotes: %w", err)
+	}
+	return quotes, utils.NewPagination(page, limit, total), nil
+}
+
+func (s *quoteService) GetQuote(id uint) (*models.Quote, error) {
+	quote, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrQuoteNotFound
+		}
+		return nil, fmt.Errorf("failed to get quote: %w", err)
+	}
+	return quote, nil
+}
+
+// CreateQuote prices and stores a draft quote under the next quote number
+func (s *quoteService) CreateQuote(req *dtos.QuoteRequest, createdByID *uint) (*models.Quote, error) {
+	quote := &models.Quote{Status: models.QuoteStatusDraft, CreatedByID: createdByID}
+	if err := s.applyRequest(quote, req); err != nil {
+		return nil, err
+	}
+
+	err := s.transactor.Transaction(func(tx *gorm.DB) error {
+		seq, err := s.sequences.WithTx(tx).Next(quoteScope + s.settings.Numbering.Scope(quote.IssueDate))
+		if err != nil {
+			return fmt.Errorf("failed to allocate quote number: %w", err)
+		}
+		quote.QuoteNumber = s.settings.Numbering.Number(quote.IssueDate, seq)
+		if err := s.repo.WithTx(tx).Create(quote); err != nil {
+			return fmt.Errorf("failed to create quote: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetQuote(quote.ID)
+}
+
+// UpdateQuote replaces a draft quote. Quotes that were sent can no longer change.
+func (s *quoteService) UpdateQuote(id uint, req *dtos.QuoteRequest) (*models.Quote, error) {
+	quote, err := s.GetQuote(id)
+	if err != nil {
+		return nil, err
+	}
+	if quote.Status != models.QuoteStatusDraft {
+		return nil, fmt.Errorf("%w: quote %s is %s; only draft quotes can be changed", ErrInvalidQuoteTransition, quote.QuoteNumber, quote.Status)
+	}
+	// Keep the billing address unless another one is given or the customer changes
+	if req.BillingAddressID == nil && req.CustomerID == quote.CustomerID {
+		req.BillingAddressID = quote.BillingAddressID
+	}
+	if err := s.applyRequest(quote, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(quote); err != nil {
+		return nil, fmt.Errorf("failed to update quote: %w", err)
+	}
+	return s.GetQuote(id)
+}
+
+// DeleteQuote removes a draft quote. Quotes that were sent stay on record.
+func (s *quoteService) DeleteQuote(id uint) error {
+	quote, err := s.GetQuote(id)
+	if err != nil {
+		return err
+	}
+	if quote.Status != models.QuoteStatusDraft {
+		return fmt.Errorf("%w: quote %s is %s; only draft quotes can be deleted", ErrInvalidQuoteTransition, quote.QuoteNumber, quote.Status)
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return fmt.Errorf("failed to delete quote: %w", err)
+	}
+	return nil
+}
+
+// SendQuote marks a draft quote as sent, after which it can no longer change and the
+// customer can accept or decline it until it expires
+func (s *quoteService) SendQuote(id uint) (*models.Quote, error) {
+	quote, err := s.GetQuote(id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if quote.Status == models.QuoteStatusDraft && quote.Expired(now) {
+		return nil, fmt.Errorf("%w: the expiry date of quote %s has passed; set a later expiry_date before sending it", ErrInvalidQuote, quote.QuoteNumber)
+	}
+	quote.SentAt = &now
+	return s.transition(quote, models.QuoteStatusSent)
+}
+
+// AcceptQuote records that the customer accepted a sent quote
+func (s *quoteService) AcceptQuote(id uint, respondedByID *uint) (*models.Quote, error) {
+	quote, err := s.GetQuote(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.respond(quote, true, "", respondedByID)
+}
+
+// DeclineQuote records that the customer declined a sent quote
+func (s *quoteService) DeclineQuote(id uint, reason string, respondedByID *uint) (*models.Quote, error) {
+	quote, err := s.GetQuote(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.respond(quote, false, reason, respondedByID)
+}
+
+// respond accepts or declines a sent quote. A quote past its expiry date is marked
+// expired instead.
+func (s *quoteService) respond(quote *models.Quote, accept bool, reason string, respondedByID *uint) (*models.Quote, error) {
+	now := time.Now()
+	if quote.Status == models.QuoteStatusSent && quote.Expired(now) {
+		if _, err := s.expire(quote, now); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: quote %s expired on %s", ErrInvalidQuoteTransition, quote.QuoteNumber, quote.ExpiryDate.Format("2006-01-02"))
+	}
+
+	to := models.QuoteStatusDeclined
+	if accept {
+		to = models.QuoteStatusAccepted
+		quote.AcceptedAt = &now
+	} else {
+		quote.DeclinedAt = &now
+		quote.DeclineReason = strings.TrimSpace(reason)
+	}
+	quote.RespondedByID = respondedByID
+	return s.transition(quote, to)
+}
+
+// transition moves a quote to status to, unless another request changed its status first
+func (s *quoteService) transition(quote *models.Quote, to string) (*models.Quote, error) {
+	if !models.CanTransitionQuote(quote.Status, to) {
+		if quote.Status == to {
+			return nil, fmt.Errorf("%w: quote %s is already %s", ErrInvalidQuoteTransition, quote.QuoteNumber, to)
+		}
+		return nil, fmt.Errorf("%w: a %s quote cannot be marked %s", ErrInvalidQuoteTransition, quote.Status, to)
+	}
+
+	from := quote.Status
+	quote.Status = to
+	updated, err := s.repo.UpdateStatus(quote, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update quote status: %w", err)
+	}
+	if !updated {
+		return nil, fmt.Errorf("%w: quote %s was changed in the meantime; reload it and try again", ErrInvalidQuoteTransition, quote.QuoteNumber)
+	}
+	return s.GetQuote(quote.ID)
+}
+
+// expire moves a sent quote to expired and reports whether it was still sent
+func (s *quoteService) expire(quote *models.Quote, now time.Time) (bool, error) {
+	quote.Status = models.QuoteStatusExpired
+	quote.ExpiredAt = &now
+	updated, err := s.repo.UpdateStatus(quote, models.QuoteStatusSent)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark quote %s expired: %w", quote.QuoteNumber, err)
+	}
+	return updated, nil
+}
+
+// MarkExpiredQuotes moves the sent quotes whose expiry date ended before now to expired
+func (s *quoteService) MarkExpiredQuotes(now time.Time) (int, error) {
+	quotes, err := s.repo.FindExpired(calendarDay(now))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired quotes: %w", err)
+	}
+
+	marked := 0
+	for i := range quotes {
+		updated, err := s.expire(&quotes[i], now)
+		if err != nil {
+			return marked, err
+		}
+		if updated {
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+// ConvertQuote creates a draft invoice from an accepted quote with the same customer,
+// lines, discount, charges and notes, and links the two. A quote is converted once; if its
+// invoice was deleted, it can be converted again.
+func (s *quoteService) ConvertQuote(id uint, req *dtos.ConvertQuoteRequest, convertedByID *uint) (*models.Invoice, error) {
+	quote, err := s.GetQuote(id)
+	if err != nil {
+		return nil, err
+	}
+	if quote.Status != models.QuoteStatusAccepted {
+		return nil, fmt.Errorf("%w: quote %s is %s; only accepted quotes can be converted into invoices", ErrInvalidQuoteTransition, quote.QuoteNumber, quote.Status)
+	}
+	if quote.InvoiceID != nil {
+		// A quote whose invoice was voided, or deleted in an earlier version, can be converted again
+		invoice, err := s.invoiceRepo.FindByID(*quote.InvoiceID)
+		if err == nil && invoice.Status != models.InvoiceStatusVoid {
+			return nil, fmt.Errorf("%w: quote %s was already converted into invoice %s", ErrInvalidQuoteTransition, quote.QuoteNumber, invoice.InvoiceNumber)
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get invoice: %w", err)
+		}
+	}
+
+	issueDate, err := parseQuoteDate(req.IssueDate, "issue_date", calendarDay(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	dueDate, err := parseQuoteDate(req.DueDate, "due_date", issueDate.AddDate(0, 0, convertedInvoiceDueDays))
+	if err != nil {
+		return nil, err
+	}
+	if dueDate.Before(issueDate) {
+		return nil, fmt.Errorf("%w: due_date must not be before issue_date", ErrInvalidQuote)
+	}
+
+	invoice := newQuoteInvoice(quote, issueDate, dueDate, req.CustomFields)
+	if err := s.invoices.CreateInvoice(invoice, convertedByID); err != nil {
+		return nil, err
+	}
+
+	previousInvoiceID := quote.InvoiceID
+	now := time.Now()
+	quote.InvoiceID = &invoice.ID
+	quote.ConvertedAt = &now
+	linked, err := s.repo.SetInvoice(quote, previousInvoiceID)
+	if err == nil && !linked {
+		err = fmt.Errorf("%w: quote %s was converted in the meantime", ErrInvalidQuoteTransition, quote.QuoteNumber)
+	} else if err != nil {
+		err = fmt.Errorf("failed to link quote to invoice: %w", err)
+	}
+	if err != nil {
+		// Void the invoice again so the quote is not invoiced twice; it keeps its number
+		if _, voidErr := s.invoices.VoidInvoice(invoice.ID, convertedByID, "Quote "+quote.QuoteNumber+" was converted into another invoice"); voidErr != nil {
+			return nil, errors.Join(err, voidErr)
+		}
+		return nil, err
+	}
+	return s.invoices.GetInvoiceByID(invoice.ID)
+}
+
+// GetQuoteLink returns the public page of a sent quote, which stays valid until the end
+// of its expiry date
+func (s *quoteService) GetQuoteLink(id uint) (*dtos.QuoteLink, error) {
+	quote, err := s.GetQuote(id)
+	if err != nil {
+		return nil, err
+	}
+	if quote.Status == models.QuoteStatusDraft {
+		return nil, fmt.Errorf("%w: draft quotes cannot be shared; send the quote first", ErrInvalidQuote)
+	}
+	expiresAt := quote.ExpiresAt()
+	return &dtos.QuoteLink{Token: s.signer.Sign(uint64(quote.ID), expiresAt), ExpiresAt: expiresAt}, nil
+}
+
+// OpenSharedQuote returns the quote a link points to
+func (s *quoteService) OpenSharedQuote(token string) (*models.Quote, error) {
+	return s.resolve(token)
+}
+
+// AcceptSharedQuote accepts the quote a link points to on behalf of the customer
+func (s *quoteService) AcceptSharedQuote(token string) (*models.Quote, error) {
+	quote, err := s.resolve(token)
+	if err != nil {
+		return nil, err
+	}
+	return s.respond(quote, true, "", nil)
+}
+
+// DeclineSharedQuote declines the quote a link points to on behalf of the customer
+func (s *quoteService) DeclineSharedQuote(token, reason string) (*models.Quote, error) {
+	quote, err := s.resolve(token)
+	if err != nil {
+		return nil, err
+	}
+	return s.respond(quote, false, reason, nil)
+}
+
+// resolve checks a token and loads the quote it was issued for. Tokens of drafts and of
+// quotes whose expiry date changed are not valid.
+func (s *quoteService) resolve(token string) (*models.Quote, error) {
+	id, expiresAt, err := s.signer.Verify(token, time.Now())
+	if errors.Is(err, signing.ErrExpired) {
+		return nil, ErrShareLinkExpired
+	}
+	if err != nil {
+		return nil, ErrShareLinkNotFound
+	}
+
+	quote, err := s.repo.FindByID(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrShareLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to get quote: %w", err)
+	}
+	if quote.Status == models.QuoteStatusDraft || !quote.ExpiresAt().Equal(expiresAt) {
+		return nil, ErrShareLinkNotFound
+	}
+	return quote, nil
+}
+
+// applyRequest validates req and prices it like an invoice, then copies it onto quote.
+// Custom fields are checked when the quote is converted, since invoice fields such as a
+// purchase order number are often only known once the quote is accepted.
+func (s *quoteService) applyRequest(quote *models.Quote, req *dtos.QuoteRequest) error {
+	issueDate, err := parseQuoteDate(req.IssueDate, "issue_date", calendarDay(time.Now()))
+	if err != nil {
+		return err
+	}
+	expiryDate, err := parseQuoteDate(req.ExpiryDate, "expiry_date", issueDate.AddDate(0, 0, s.settings.ValidityDays))
+	if err != nil {
+		return err
+	}
+	if expiryDate.Before(issueDate) {
+		return fmt.Errorf("%w: expiry_date must not be before issue_date", ErrInvalidQuote)
+	}
+
+	priced := &models.Invoice{
+		CustomerID:       req.CustomerID,
+		BillingAddressID: req.BillingAddressID,
+		Currency:         money.Currency(req.Currency),
+		IssueDate:        issueDate,
+		DiscountType:     req.DiscountType,
+		DiscountValue:    req.DiscountValue,
+		Items:            make([]models.InvoiceItem, len(req.Items)),
+		Charges:          make([]models.InvoiceCharge, len(req.Charges)),
+	}
+	for i, item := range req.Items {
+		if item.UnitPrice.IsNegative() {
+			return fmt.Errorf("%w: item %d: unit_price must not be negative", ErrInvalidQuote, i+1)
+		}
+		if err := checkLimit(item.UnitPrice, "unit_price"); err != nil {
+			return fmt.Errorf("%w: item %d: %v", ErrInvalidQuote, i+1, err)
+		}
+		priced.Items[i] = models.InvoiceItem{
+			ProductID:     item.ProductID,
+			Description:   strings.TrimSpace(item.Description),
+			Quantity:      item.Quantity,
+			UnitPrice:     item.UnitPrice,
+			DiscountType:  item.DiscountType,
+			DiscountValue: item.DiscountValue,
+			TaxRateIDs:    item.TaxRateIDs,
+		}
+	}
+	for i, charge := range req.Charges {
+		priced.Charges[i] = models.InvoiceCharge{Description: charge.Description, Amount: charge.Amount}
+	}
+	if err := s.invoices.PriceInvoice(priced); err != nil {
+		if errors.Is(err, ErrInvalidInvoice) {
+			return fmt.Errorf("%w: %s", ErrInvalidQuote, strings.TrimPrefix(err.Error(), ErrInvalidInvoice.Error()+": "))
+		}
+		return err
+	}
+	for i, item := range priced.Items {
+		if item.Description == "" {
+			return fmt.Errorf("%w: item %d: description is required unless a product_id is given", ErrInvalidQuote, i+1)
+		}
+	}
+
+	quote.IssueDate = issueDate
+	quote.ExpiryDate = expiryDate
+	quote.CustomerID = priced.CustomerID
+	quote.BillingAddressID = priced.BillingAddressID
+	quote.Currency = priced.Currency
+	quote.DiscountType = priced.DiscountType
+	quote.DiscountValue = priced.DiscountValue
+	quote.Notes = req.Notes
+	quote.CustomFields = req.CustomFields
+	copyQuotePricing(quote, priced)
+	return nil
+}
+
+// copyQuotePricing copies the lines and amounts of an invoice priced from a quote back
+// onto the quote
+func copyQuotePricing(quote *models.Quote, priced *models.Invoice) {
+	quote.Items = make([]models.QuoteItem, len(priced.Items))
+	for i, item := range priced.Items {
+		quote.Items[i] = models.QuoteItem{
+			ProductID:      item.ProductID,
+			Description:    item.Description,
+			Quantity:       item.Quantity,
+			UnitPrice:      item.UnitPrice,
+			DiscountType:   item.DiscountType,
+			DiscountValue:  item.DiscountValue,
+			DiscountAmount: item.DiscountAmount,
+			TaxRateIDs:     item.TaxRateIDs,
+			NetAmount:      item.NetAmount,
+			TaxAmount:      item.TaxAmount,
+			Total:          item.Total,
+		}
+	}
+	quote.TaxLines = make([]models.QuoteTaxLine, len(priced.TaxLines))
+	for i, line := range priced.TaxLines {
+		quote.TaxLines[i] = models.QuoteTaxLine{
+			TaxRateID:     line.TaxRateID,
+			Name:          line.Name,
+			Rate:          line.Rate,
+			Compound:      line.Compound,
+			Inclusive:     line.Inclusive,
+			TaxableAmount: line.TaxableAmount,
+			Amount:        line.Amount,
+		}
+	}
+	quote.Charges = make([]models.QuoteCharge, len(priced.Charges))
+	for i, charge := range priced.Charges {
+		quote.Charges[i] = models.QuoteCharge{Description: charge.Description, Amount: charge.Amount}
+	}
+	quote.Subtotal = priced.Subtotal
+	quote.DiscountAmount = priced.DiscountAmount
+	quote.TaxAmount = priced.TaxAmount
+	quote.TaxExempt = priced.TaxExempt
+	quote.ChargesTotal = priced.ChargesTotal
+	quote.Total = priced.Total
+}
+
+// newQuoteInvoice builds the draft invoice of an accepted quote with the tax rates of the
+// quote. CreateInvoice prices it like any other invoice, at the percentages those rates
+// have when the quote is converted.
+func newQuoteInvoice(quote *models.Quote, issueDate, dueDate time.Time, customFields models.CustomFields) *models.Invoice {
+	items := make([]models.InvoiceItem, len(quote.Items))
+	for i, item := range quote.Items {
+		items[i] = models.InvoiceItem{
+			ProductID:     item.ProductID,
+			Description:   item.Description,
+			Quantity:      item.Quantity,
+			UnitPrice:     item.UnitPrice,
+			DiscountType:  item.DiscountType,
+			DiscountValue: item.DiscountValue,
+			TaxRateIDs:    append(models.UintList{}, item.TaxRateIDs...),
+		}
+	}
+	charges := make([]models.InvoiceCharge, len(quote.Charges))
+	for i, charge := range quote.Charges {
+		charges[i] = models.InvoiceCharge{Description: charge.Description, Amount: charge.Amount}
+	}
+	fields := make(models.CustomFields, len(quote.CustomFields)+len(customFields))
+	for key, value := range quote.CustomFields {
+		fields[key] = value
+	}
+	for key, value := range customFields {
+		fields[key] = value
+	}
+
+	quoteID := quote.ID
+	return &models.Invoice{
+		CustomerID:       quote.CustomerID,
+		BillingAddressID: quote.BillingAddressID,
+		Currency:         quote.Currency,
+		Items:            items,
+		DiscountType:     quote.DiscountType,
+		DiscountValue:    quote.DiscountValue,
+		Charges:          charges,
+		Notes:            quote.Notes,
+		CustomFields:     fields,
+		IssueDate:        issueDate,
+		DueDate:          dueDate,
+		QuoteID:          &quoteID,
+	}
+}
+
+func knownQuoteStatus(status string) bool {
+	for _, known := range models.QuoteStatuses {
+		if status == known {
+			return true
+		}
+	}
+	return false
+}
+
+// parseQuoteDate reads a YYYY-MM-DD date, using fallback when it is empty
+func parseQuoteDate(raw, field string, fallback time.Time) (time.Time, error) {
+	if raw == "" {
+		return fallback, nil
+	}
+	date, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must be YYYY-MM-DD", ErrInvalidQuote, field)
+	}
+	return date, nil
+}
@@ -0,0 +1,475 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/internals/utils"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"github.com/tacheraSasi/go-api-starter/pkg/schedule"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrRecurringInvoiceNotFound is returned when a recurring invoice does not exist
+	ErrRecurringInvoiceNotFound = errors.New("recurring invoice not found")
+	// ErrInvalidRecurringInvoice marks recurring invoice input that cannot be accepted
+	ErrInvalidRecurringInvoice = errors.New("invalid recurring invoice")
+)
+
+// maxRecurringRunsPerPass bounds how many missed periods of one recurring invoice are caught
+// up in a single scheduler run, so one far in the past cannot hold up the others
+const maxRecurringRunsPerPass = 50
+
+// Number of upcoming runs previewed by default and at most
+const (
+	defaultRecurringPreviewRuns = 12
+	maxRecurringPreviewRuns     = 100
+)
+
+type RecurringInvoiceService interface {
+	ListRecurringInvoices(customerID uint, page, limit int) ([]models.RecurringInvoice, *utils.Pagination, error)
+	GetRecurringInvoice(id uint) (*models.RecurringInvoice, error)
+	CreateRecurringInvoice(req *dtos.RecurringInvoiceRequest, createdByID *uint) (*models.RecurringInvoice, error)
+	UpdateRecurringInvoice(id uint, req *dtos.RecurringInvoiceRequest) (*models.RecurringInvoice, error)
+	DeleteRecurringInvoice(id uint) error
+	PreviewRuns(id uint, count int) ([]dtos.RecurringInvoiceRun, error)
+	GenerateDueInvoices(now time.Time) (int, error)
+}
+
+type recurringInvoiceService struct {
+	repo         repositories.RecurringInvoiceRepository
+	invoiceRepo  repositories.InvoiceRepository
+	customerRepo repositories.CustomerRepository
+	invoices     InvoiceService
+	taxRates     TaxRateService
+	customFields CustomFieldService
+}
+
+func NewRecurringInvoiceService(
+	repo repositories.RecurringInvoiceRepository,
+	invoiceRepo repositories.InvoiceRepository,
+	customerRepo repositories.CustomerRepository,
+	invoices InvoiceService,
+	taxRates TaxRateService,
+	customFields CustomFieldService,
+) RecurringInvoiceService {
+	return &recurringInvoiceService{
+		repo:         repo,
+		invoiceRepo:  invoiceRepo,
+		customerRepo: customerRepo,
+		invoices:     invoices,
+		taxRates:     taxRates,
+		customFields: customFields,
+	}
+}
+
+func (s *recurringInvoiceService) ListRecurringInvoices(customerID uint, page, limit int) ([]models.RecurringInvoice, *utils.Pagination, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	recurring, total, err := s.repo.FindAll(customerID, page, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list recurring invoices: %w", err)
+	}
+	return recurring, utils.NewPagination(page, limit, total), nil
+}
+
+func (s *recurringInvoiceService) GetRecurringInvoice(id uint) (*models.RecurringInvoice, error) {
+	recurring, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRecurringInvoiceNotFound
+		}
+		return nil, fmt.Errorf("failed to get recurring invoice: %w", err)
+	}
+	return recurring, nil
+}
+
+// CreateRecurringInvoice stores a recurring invoice. Periods that started before now are
+// invoiced on the next scheduler run.
+func (s *recurringInvoiceService) CreateRecurringInvoice(req *dtos.RecurringInvoiceRequest, createdByID *uint) (*models.RecurringInvoice, error) {
+	recurring := &models.RecurringInvoice{CreatedByID: createdByID}
+	if err := s.applyRequest(recurring, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(recurring); err != nil {
+		return nil, fmt.Errorf("failed to create recurring invoice: %w", err)
+	}
+	return s.GetRecurringInvoice(recurring.ID)
+}
+
+// UpdateRecurringInvoice replaces a recurring invoice. Changes apply to periods that have
+// not been invoiced yet; invoices already generated are left as they are.
+func (s *recurringInvoiceService) UpdateRecurringInvoice(id uint, req *dtos.RecurringInvoiceRequest) (*models.RecurringInvoice, error) {
+	recurring, err := s.GetRecurringInvoice(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRequest(recurring, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(recurring); err != nil {
+		return nil, fmt.Errorf("failed to update recurring invoice: %w", err)
+	}
+	return s.GetRecurringInvoice(id)
+}
+
+func (s *recurringInvoiceService) DeleteRecurringInvoice(id uint) error {
+	if err := s.repo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRecurringInvoiceNotFound
+		}
+		return fmt.Errorf("failed to delete recurring invoice: %w", err)
+	}
+	return nil
+}
+
+// PreviewRuns lists the next count periods of a recurring invoice, starting with any that
+// have already started and are waiting for the scheduler
+func (s *recurringInvoiceService) PreviewRuns(id uint, count int) ([]dtos.RecurringInvoiceRun, error) {
+	if count < 1 {
+		count = defaultRecurringPreviewRuns
+	}
+	if count > maxRecurringPreviewRuns {
+		count = maxRecurringPreviewRuns
+	}
+
+	recurring, err := s.GetRecurringInvoice(id)
+	if err != nil {
+		return nil, err
+	}
+	runs := make([]dtos.RecurringInvoiceRun, 0, count)
+	sched, err := recurringSchedule(recurring)
+	if err != nil {
+		return runs, nil
+	}
+
+	now := time.Now()
+	for next := recurring.NextRunAt; next != nil && len(runs) < count; next = nextRecurringRun(recurring, sched, *next) {
+		period := next.UTC()
+		runs = append(runs, dtos.RecurringInvoiceRun{
+			Period:    period,
+			IssueDate: period,
+			DueDate:   period.AddDate(0, 0, recurring.DueDays),
+			Overdue:   !period.After(now),
+		})
+	}
+	return runs, nil
+}
+
+// GenerateDueInvoices invoices every period that has started by now, oldest first, and
+// returns the number of invoices created. Each period is invoiced exactly once: generated
+// invoices carry their recurring invoice and period under a unique index, so a period
+// that was invoiced before a restart, or by another instance, is skipped. Failures are
+// recorded on the recurring invoice and retried on the next run.
+func (s *recurringInvoiceService) GenerateDueInvoices(now time.Time) (int, error) {
+	due, err := s.repo.FindDue(now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list due recurring invoices: %w", err)
+	}
+
+	generated := 0
+	var errs []error
+	for i := range due {
+		created, err := s.generate(&due[i], now)
+		generated += created
+		if err != nil {
+			errs = append(errs, fmt.Errorf("recurring invoice %d: %w", due[i].ID, err))
+		}
+	}
+	return generated, errors.Join(errs...)
+}
+
+// generate invoices the started periods of one recurring invoice, saving its progress
+// after each period
+func (s *recurringInvoiceService) generate(recurring *models.RecurringInvoice, now time.Time) (int, error) {
+	sched, err := recurringSchedule(recurring)
+	if err != nil {
+		return 0, s.recordFailure(recurring, err)
+	}
+
+	generated := 0
+	for runs := 0; runs < maxRecurringRunsPerPass && recurring.NextRunAt != nil && !recurring.NextRunAt.After(now); runs++ {
+		period := recurring.NextRunAt.UTC()
+		created, err := s.invoicePeriod(recurring, period)
+		if created {
+			generated++
+		}
+		if err != nil {
+			return generated, s.recordFailure(recurring, err)
+		}
+
+		recurring.LastRunAt = &period
+		recurring.LastError = ""
+		recurring.NextRunAt = nextRecurringRun(recurring, sched, period)
+		if err := s.repo.UpdateRunState(recurring); err != nil {
+			return generated, fmt.Errorf("failed to save recurring invoice progress: %w", err)
+		}
+	}
+	return generated, nil
+}
+
+// invoicePeriod creates the invoice of one period unless it exists, and sends it when the
+// recurring invoice sends automatically. It reports whether an invoice was created.
+func (s *recurringInvoiceService) invoicePeriod(recurring *models.RecurringInvoice, period time.Time) (bool, error) {
+	created := false
+	invoice, err := s.invoiceRepo.FindByRecurringPeriod(recurring.ID, period)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		invoice = newRecurringPeriodInvoice(recurring, period)
+		if err := s.invoices.CreateInvoice(invoice, recurring.CreatedByID); err != nil {
+			// Another instance may have invoiced the period in the meantime
+			existing, findErr := s.invoiceRepo.FindByRecurringPeriod(recurring.ID, period)
+			if findErr != nil {
+				return false, err
+			}
+			invoice = existing
+		} else {
+			created = true
+		}
+	} else if err != nil {
+		return false, fmt.Errorf("failed to check for the invoice of period %s: %w", period.Format(time.RFC3339), err)
+	}
+
+	// Deleted invoices count as invoiced and are not sent
+	if recurring.AutoSend && invoice.Status == models.InvoiceStatusDraft && !invoice.DeletedAt.Valid {
+		note := fmt.Sprintf("Sent automatically by recurring invoice %q", recurring.Name)
+		if _, err := s.invoices.SendInvoice(invoice.ID, nil, note); err != nil {
+			return created, fmt.Errorf("failed to send invoice %s: %w", invoice.InvoiceNumber, err)
+		}
+	}
+	return created, nil
+}
+
+// recordFailure stores why a period could not be invoiced and returns err
+func (s *recurringInvoiceService) recordFailure(recurring *models.RecurringInvoice, err error) error {
+	recurring.LastError = err.Error()
+	if saveErr := s.repo.UpdateRunState(recurring); saveErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to save recurring invoice error: %w", saveErr))
+	}
+	return err
+}
+
+// newRecurringPeriodInvoice builds the draft invoice of one period. It is issued at the
+// start of the period, and CreateInvoice prices it like any other invoice.
+func newRecurringPeriodInvoice(recurring *models.RecurringInvoice, period time.Time) *models.Invoice {
+	items := make([]models.InvoiceItem, len(recurring.Items))
+	for i, item := range recurring.Items {
+		items[i] = models.InvoiceItem{
+			Description:   item.Description,
+			Quantity:      item.Quantity,
+			UnitPrice:     item.UnitPrice,
+			DiscountType:  item.DiscountType,
+			DiscountValue: item.DiscountValue,
+		}
+		if item.TaxRateIDs != nil {
+			items[i].TaxRateIDs = append(models.UintList{}, *item.TaxRateIDs...)
+		}
+	}
+
+	recurringID := recurring.ID
+	return &models.Invoice{
+		CustomerID:         recurring.CustomerID,
+		Currency:           recurring.Currency,
+		Items:              items,
+		DiscountType:       recurring.DiscountType,
+		DiscountValue:      recurring.DiscountValue,
+		Notes:              recurring.Notes,
+		CustomFields:       recurring.CustomFields,
+		IssueDate:          period,
+		DueDate:            period.AddDate(0, 0, recurring.DueDays),
+		RecurringInvoiceID: &recurringID,
+		RecurringPeriod:    &period,
+	}
+}
+
+// applyRequest validates req and copies it onto recurring, then schedules its next period
+func (s *recurringInvoiceService) applyRequest(recurring *models.RecurringInvoice, req *dtos.RecurringInvoiceRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRecurringInvoice)
+	}
+	if _, err := s.customerRepo.FindByID(req.CustomerID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: customer %d not found", ErrInvalidRecurringInvoice, req.CustomerID)
+		}
+		return fmt.Errorf("failed to get customer: %w", err)
+	}
+	var currency money.Currency
+	if req.Currency != "" {
+		var err error
+		if currency, err = money.ParseCurrency(req.Currency); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidRecurringInvoice, err)
+		}
+	}
+
+	items := make([]models.RecurringInvoiceItem, len(req.Items))
+	quoted := new(big.Rat)
+	for i, line := range req.Items {
+		description := strings.TrimSpace(line.Description)
+		if description == "" {
+			return fmt.Errorf("%w: item %d: description is required", ErrInvalidRecurringInvoice, i+1)
+		}
+		if line.UnitPrice.IsNegative() {
+			return fmt.Errorf("%w: item %d: unit_price must not be negative", ErrInvalidRecurringInvoice, i+1)
+		}
+		if err := checkLimit(line.UnitPrice, "unit_price"); err != nil {
+			return fmt.Errorf("%w: item %d: %v", ErrInvalidRecurringInvoice, i+1, err)
+		}
+		var taxRateIDs *models.UintList
+		if line.TaxRateIDs != nil {
+			ids := models.UintList(line.TaxRateIDs)
+			taxRateIDs = &ids
+			if _, err := s.taxRates.ResolveRates(ids); err != nil {
+				if errors.Is(err, ErrInvalidTaxRate) {
+					return fmt.Errorf("%w: item %d: %v", ErrInvalidRecurringInvoice, i+1, err)
+				}
+				return err
+			}
+		}
+
+		listed := new(big.Rat).Mul(line.UnitPrice.Rat(), big.NewRat(int64(line.Quantity), 1))
+		discount, err := discountOf(line.DiscountType, line.DiscountValue, listed)
+		if err != nil {
+			return fmt.Errorf("%w: item %d: %v", ErrInvalidRecurringInvoice, i+1, err)
+		}
+		quoted.Add(quoted, listed.Sub(listed, discount))
+
+		items[i] = models.RecurringInvoiceItem{
+			Description:   description,
+			Quantity:      line.Quantity,
+			UnitPrice:     line.UnitPrice,
+			DiscountType:  line.DiscountType,
+			DiscountValue: line.DiscountValue,
+			TaxRateIDs:    taxRateIDs,
+		}
+	}
+	if _, err := discountOf(req.DiscountType, req.DiscountValue, quoted); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRecurringInvoice, err)
+	}
+	// Each run would be rejected by CreateInvoice, so refuse the template now
+	if quoted.Cmp(money.MaxAmount.Rat()) > 0 {
+		return fmt.Errorf("%w: the items add up to more than the maximum of %s", ErrInvalidRecurringInvoice, money.MaxAmount)
+	}
+	// Check the custom fields now rather than when the first invoice is created
+	_, customFields, err := s.customFields.ResolveValues(models.TaggableInvoice, nil, req.CustomFields)
+	if err != nil {
+		return err
+	}
+
+	startDate, err := parseRecurringDate(req.StartDate, "start_date")
+	if err != nil {
+		return err
+	}
+	var endDate *time.Time
+	if req.EndDate != "" {
+		end, err := parseRecurringDate(req.EndDate, "end_date")
+		if err != nil {
+			return err
+		}
+		if end.Before(startDate) {
+			return fmt.Errorf("%w: end_date must not be before start_date", ErrInvalidRecurringInvoice)
+		}
+		endDate = &end
+	}
+
+	recurring.Name = name
+	recurring.CustomerID = req.CustomerID
+	recurring.Currency = currency
+	recurring.Items = items
+	recurring.DiscountType = req.DiscountType
+	recurring.DiscountValue = req.DiscountValue
+	recurring.Notes = req.Notes
+	recurring.CustomFields = customFields
+	recurring.Interval = strings.ToLower(strings.TrimSpace(req.Interval))
+	recurring.Cron = strings.TrimSpace(req.Cron)
+	recurring.StartDate = startDate
+	recurring.EndDate = endDate
+	recurring.DueDays = req.DueDays
+	recurring.AutoSend = req.AutoSend
+	recurring.Paused = req.Paused
+	recurring.LastError = ""
+
+	sched, err := recurringSchedule(recurring)
+	if err != nil {
+		return err
+	}
+	// Continue after the last period invoiced, so a changed schedule does not invoice the
+	// past again
+	from := recurring.StartDate.Add(-time.Nanosecond)
+	if recurring.LastRunAt != nil && recurring.LastRunAt.After(from) {
+		from = *recurring.LastRunAt
+	}
+	recurring.NextRunAt = nextRecurringRun(recurring, sched, from)
+	return nil
+}
+
+// recurringSchedule returns the schedule periods of a recurring invoice start on
+func recurringSchedule(recurring *models.RecurringInvoice) (schedule.Schedule, error) {
+	if recurring.Interval != models.RecurringIntervalCron && recurring.Cron != "" {
+		return nil, fmt.Errorf("%w: cron is only used with the cron interval", ErrInvalidRecurringInvoice)
+	}
+
+	switch recurring.Interval {
+	case models.RecurringIntervalWeekly:
+		return schedule.Days(7, recurring.StartDate), nil
+	case models.RecurringIntervalMonthly:
+		return schedule.Months(1, recurring.StartDate), nil
+	case models.RecurringIntervalQuarterly:
+		return schedule.Months(3, recurring.StartDate), nil
+	case models.RecurringIntervalYearly:
+		return schedule.Months(12, recurring.StartDate), nil
+	case models.RecurringIntervalCron:
+		if recurring.Cron == "" {
+			return nil, fmt.Errorf("%w: cron is required with the cron interval", ErrInvalidRecurringInvoice)
+		}
+		cron, err := schedule.ParseCron(recurring.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRecurringInvoice, err)
+		}
+		return cron, nil
+	}
+	return nil, fmt.Errorf("%w: unknown interval %q (expected %s)", ErrInvalidRecurringInvoice, recurring.Interval, strings.Join(models.RecurringIntervals, ", "))
+}
+
+// nextRecurringRun returns the start of the first period after the given time, or nil when
+// the schedule ends before it. The end date is inclusive.
+func nextRecurringRun(recurring *models.RecurringInvoice, sched schedule.Schedule, after time.Time) *time.Time {
+	next := sched.Next(after)
+	if next.IsZero() {
+		return nil
+	}
+	if recurring.EndDate != nil && calendarDay(next).After(calendarDay(*recurring.EndDate)) {
+		return nil
+	}
+	return &next
+}
+
+// calendarDay truncates a time to the start of its UTC day
+func calendarDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// parseRecurringDate reads a YYYY-MM-DD or RFC 3339 date
+func parseRecurringDate(raw, field string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if date, err := time.Parse("2006-01-02", raw); err == nil {
+		return date, nil
+	}
+	date, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must be a YYYY-MM-DD or RFC 3339 date", ErrInvalidRecurringInvoice, field)
+	}
+	return date.UTC(), nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+// ErrInvalidReportFilter is returned for report parameters that cannot be applied
+var ErrInvalidReportFilter = errors.New("invalid report filter")
+
+type ReportService interface {
+	GetRevenueReport(filter repositories.InvoiceReportFilter, groupBy string) (*dtos.RevenueReport, error)
+}
+
+type reportService struct {
+	invoiceRepo    repositories.InvoiceRepository
+	creditNoteRepo repositories.CreditNoteRepository
+	baseCurrency   money.Currency
+}
+
+func NewReportService(invoiceRepo repositories.InvoiceRepository, creditNoteRepo repositories.CreditNoteRepository, baseCurrency money.Currency) ReportService {
+	return &reportService{invoiceRepo: invoiceRepo, creditNoteRepo: creditNoteRepo, baseCurrency: baseCurrency}
+}
+
+// GetRevenueReport sums invoice amounts in the base currency, grouped by issue month,
+// customer, invoice currency or status. Without a status filter, drafts and void
+// invoices are left out and credit notes issued in the period are subtracted; credit
+// notes are grouped under the status credit_note.
+func (s *reportService) GetRevenueReport(filter repositories.InvoiceReportFilter, groupBy string) (_ *dtos.RevenueReport, err error) {
+	defer recoverOverflow(&err, ErrAmountOutOfRange)
+
+	if groupBy == "" {
+		groupBy = dtos.ReportGroupByMonth
+	}
+	keyOf, ok := revenueGroupKeys[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("%w: group_by %q, expected month, customer, currency or status", ErrInvalidReportFilter, groupBy)
+	}
+	if len(filter.Statuses) == 0 {
+		filter.ExcludeStatuses = []string{models.InvoiceStatusDraft, models.InvoiceStatusVoid}
+	}
+
+	invoices, err := s.invoiceRepo.FindForReport(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoices: %w", err)
+	}
+	var creditNotes []models.CreditNote
+	if len(filter.Statuses) == 0 {
+		if creditNotes, err = s.creditNoteRepo.FindForReport(filter); err != nil {
+			return nil, fmt.Errorf("failed to get credit notes: %w", err)
+		}
+	}
+
+	report := &dtos.RevenueReport{
+		BaseCurrency: s.baseCurrency,
+		From:         filter.IssueDate.From,
+		To:           filter.IssueDate.To,
+		GroupBy:      groupBy,
+		Groups:       []dtos.RevenueReportGroup{},
+		Totals:       dtos.RevenueReportGroup{Key: "total", Label: "Total", OriginalTotals: map[money.Currency]money.Amount{}},
+	}
+
+	index := make(map[string]int)
+	group := func(key, label string) *dtos.RevenueReportGroup {
+		position, seen := index[key]
+		if !seen {
+			position = len(report.Groups)
+			index[key] = position
+			report.Groups = append(report.Groups, dtos.RevenueReportGroup{Key: key, Label: label, OriginalTotals: map[money.Currency]money.Amount{}})
+		}
+		return &report.Groups[position]
+	}
+	for i := range invoices {
+		invoice := &invoices[i]
+		if invoice.BaseCurrency != s.baseCurrency {
+			report.Unconverted++
+			continue
+		}
+
+		addToRevenueGroup(group(keyOf(invoice)), invoice)
+		addToRevenueGroup(&report.Totals, invoice)
+	}
+	for i := range creditNotes {
+		note := &creditNotes[i]
+		if note.BaseCurrency != s.baseCurrency {
+			report.Unconverted++
+			continue
+		}
+
+		subtractFromRevenueGroup(group(creditNoteGroupKeys[groupBy](note)), note)
+		subtractFromRevenueGroup(&report.Totals, note)
+	}
+
+	// Months sort chronologically by key, everything else by label
+	sort.SliceStable(report.Groups, func(i, j int) bool {
+		if groupBy == dtos.ReportGroupByMonth {
+			return report.Groups[i].Key < report.Groups[j].Key
+		}
+		return report.Groups[i].Label < report.Groups[j].Label
+	})
+	return report, nil
+}
+
+// revenueGroupKeys return the group key and label of an invoice for each grouping
+var revenueGroupKeys = map[string]func(invoice *models.Invoice) (string, string){
+	dtos.ReportGroupByMonth: func(invoice *models.Invoice) (string, string) {
+		return invoice.IssueDate.UTC().Format("2006-01"), invoice.IssueDate.UTC().Format("January 2006")
+	},
+	dtos.ReportGroupByCustomer: func(invoice *models.Invoice) (string, string) {
+		return strconv.FormatUint(uint64(invoice.CustomerID), 10), invoice.Customer.Name
+	},
+	dtos.ReportGroupByCurrency: func(invoice *models.Invoice) (string, string) {
+		return invoice.Currency.String(), invoice.Currency.String()
+	},
+	dtos.ReportGroupByStatus: func(invoice *models.Invoice) (string, string) {
+		return invoice.Status, invoice.Status
+	},
+}
+
+// creditNoteGroupKeys return the group key and label of a credit note for each grouping
+var creditNoteGroupKeys = map[string]func(note *models.CreditNote) (string, string){
+	dtos.ReportGroupByMonth: func(note *models.CreditNote) (string, string) {
+		return note.IssueDate.UTC().Format("2006-01"), note.IssueDate.UTC().Format("January 2006")
+	},
+	dtos.ReportGroupByCustomer: func(note *models.CreditNote) (string, string) {
+		name := ""
+		if note.Customer != nil {
+			name = note.Customer.Name
+		}
+		return strconv.FormatUint(uint64(note.CustomerID), 10), name
+	},
+	dtos.ReportGroupByCurrency: func(note *models.CreditNote) (string, string) {
+		return note.Currency.String(), note.Currency.String()
+	},
+	dtos.ReportGroupByStatus: func(note *models.CreditNote) (string, string) {
+		return dtos.ReportGroupCreditNote, dtos.ReportGroupCreditNote
+	},
+}
+
+func addToRevenueGroup(group *dtos.RevenueReportGroup, invoice *models.Invoice) {
+	group.InvoiceCount++
+	group.Subtotal = group.Subtotal.Add(invoice.BaseSubtotal)
+	group.DiscountAmount = group.DiscountAmount.Add(invoice.BaseDiscountAmount)
+	group.TaxAmount = group.TaxAmount.Add(invoice.BaseTaxAmount)
+	group.ChargesTotal = group.ChargesTotal.Add(invoice.BaseChargesTotal)
+	group.Total = group.Total.Add(invoice.BaseTotal)
+	group.OriginalTotals[invoice.Currency] = group.OriginalTotals[invoice.Currency].Add(invoice.Total)
+}
+
+// subtractFromRevenueGroup counts a credit note as negative revenue
+func subtractFromRevenueGroup(group *dtos.RevenueReportGroup, note *models.CreditNote) {
+	group.CreditNoteCount++
+	group.Subtotal = group.Subtotal.Sub(note.BaseSubtotal)
+	group.TaxAmount = group.TaxAmount.Sub(note.BaseTaxAmount)
+	group.ChargesTotal = group.ChargesTotal.Sub(note.BaseChargesTotal)
+	group.Total = group.Total.Sub(note.BaseTotal)
+	group.OriginalTotals[note.Currency] = group.OriginalTotals[note.Currency].Sub(note.Total)
+}
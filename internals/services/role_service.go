@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/policy"
 	"github.com/tacheraSasi/go-api-starter/internals/repositories"
 	"gorm.io/gorm"
 )
@@ -192,29 +193,43 @@ func (s *RoleService) RemovePermissionFromRole(roleID, permissionID uint) error
 	return nil
 }
 
-// InitializeDefaultRoles creates default roles if they don't exist
+// InitializeDefaultRoles creates the roles of the embedded default policy if they
+// don't exist. Newly created roles receive their default grants; existing roles
+// are left untouched so grants revoked by an administrator are not restored.
 func (s *RoleService) InitializeDefaultRoles() error {
-	defaultRoles := []struct {
-		name        string
-		description string
-	}{
-		{models.RoleAdmin, "Administrator with full access"},
-		{models.RoleUser, "Regular user with basic access"},
-		{models.RoleModerator, "Moderator with limited admin access"},
-		{models.RoleGuest, "Guest user with read-only access"},
-	}
-
-	for _, roleData := range defaultRoles {
-		_, err := s.roleRepo.GetByName(roleData.name)
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			role := &models.Role{
-				Name:        roleData.name,
-				Description: roleData.description,
-				IsActive:    true,
-			}
-			if err := s.roleRepo.Create(role); err != nil {
-				return fmt.Errorf("failed to create default role %s: %w", roleData.name, err)
+	defaults, err := policy.Default()
+	if err != nil {
+		return fmt.Errorf("failed to load default policy: %w", err)
+	}
+
+	for _, spec := range defaults.Roles {
+		_, err := s.roleRepo.GetByName(spec.Name)
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+
+		grants, err := defaults.ResolveGrants(spec)
+		if err != nil {
+			return err
+		}
+
+		var permissions []models.Permission
+		for _, name := range grants {
+			permission, err := s.permissionRepo.GetByName(name)
+			if err != nil {
+				return fmt.Errorf("failed to find default permission %s for role %s: %w", name, spec.Name, err)
 			}
+			permissions = append(permissions, *permission)
+		}
+
+		role := &models.Role{
+			Name:        spec.Name,
+			Description: spec.Description,
+			IsActive:    true,
+			Permissions: permissions,
+		}
+		if err := s.roleRepo.Create(role); err != nil {
+			return fmt.Errorf("failed to create default role %s: %w", spec.Name, err)
 		}
 	}
 
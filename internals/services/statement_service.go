@@ -0,0 +1,298 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"gorm.io/gorm"
+)
+
+type StatementService interface {
+	GetCustomerStatement(customerID uint, currency money.Currency, period repositories.DateRange) (*dtos.CustomerStatement, error)
+}
+
+type statementService struct {
+	customerRepo    repositories.CustomerRepository
+	invoiceRepo     repositories.InvoiceRepository
+	paymentRepo     repositories.PaymentRepository
+	creditNoteRepo  repositories.CreditNoteRepository
+	defaultCurrency money.Currency
+}
+
+func NewStatementService(customerRepo repositories.CustomerRepository, invoiceRepo repositories.InvoiceRepository, paymentRepo repositories.PaymentRepository, creditNoteRepo repositories.CreditNoteRepository, defaultCurrency money.Currency) StatementService {
+	return &statementService{customerRepo: customerRepo, invoiceRepo: invoiceRepo, paymentRepo: paymentRepo, creditNoteRepo: creditNoteRepo, defaultCurrency: defaultCurrency}
+}
+
+// ledgerEntry is a statement entry before the period and running balance are applied
+type ledgerEntry struct {
+	dtos.StatementEntry
+	order int
+}
+
+// GetCustomerStatement builds the ledger of a customer for the period with a running
+// balance, and the receivables aging at the end of the period. Entries before the
+// period are summed into the opening balance; a missing end means now. A statement covers
+// the invoices, payments and credit notes of one currency, by default the customer's currency.
+func (s *statementService) GetCustomerStatement(customerID uint, currency money.Currency, period repositories.DateRange) (_ *dtos.CustomerStatement, err error) {
+	defer recoverOverflow(&err, ErrAmountOutOfRange)
+
+	customer, err := s.customerRepo.FindByID(customerID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCustomerNotFound
+		}
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	if currency == "" {
+		currency = customer.Currency
+	}
+	if currency == "" {
+		currency = s.defaultCurrency
+	}
+
+	// asOf bounds the queries exclusively; end is the inclusive end reported back
+	asOf := time.Now().UTC()
+	end := asOf
+	if period.To != nil {
+		asOf = *period.To
+		end = inclusiveEnd(asOf)
+	}
+	if period.From != nil && !period.From.Before(asOf) {
+		return nil, errors.New("statement period start must be before its end")
+	}
+
+	invoices, err := s.invoiceRepo.FindLedgerInvoices(customerID, currency, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoices: %w", err)
+	}
+
+	payments, err := s.paymentRepo.FindLedgerPayments(customerID, currency, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payments: %w", err)
+	}
+
+	creditNotes, err := s.creditNoteRepo.FindLedgerCreditNotes(customerID, currency, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit notes: %w", err)
+	}
+
+	entries := ledgerEntries(invoices, payments, creditNotes, asOf)
+	statement := &dtos.CustomerStatement{
+		Customer: *customer,
+		From:     period.From,
+		To:       end,
+		Currency: currency,
+		Entries:  []dtos.StatementEntry{},
+		Aging:    agingSummary(invoices, payments, creditNotes, asOf),
+	}
+	statement.Aging.AsOf = end
+
+	first := 0
+	for first < len(entries) && period.From != nil && entries[first].Date.Before(*period.From) {
+		statement.OpeningBalance = statement.OpeningBalance.Add(entries[first].Debit).Sub(entries[first].Credit)
+		first++
+	}
+
+	balance := statement.OpeningBalance
+	for _, entry := range entries[first:] {
+		balance = balance.Add(entry.Debit).Sub(entry.Credit)
+		entry.Balance = balance
+		statement.TotalDebits = statement.TotalDebits.Add(entry.Debit)
+		statement.TotalCredits = statement.TotalCredits.Add(entry.Credit)
+		statement.Entries = append(statement.Entries, entry.StatementEntry)
+	}
+
+	statement.ClosingBalance = balance
+
+	return statement, nil
+}
+
+// ledgerEntries turns invoices, payments and credit notes into dated debit and credit
+// entries before asOf. A payment is credited in full, including any amount kept as
+// customer credit, and a reversed payment is debited again on the day it was reversed.
+// A credit note is credited in full and its refunds are debited when they were paid.
+func ledgerEntries(invoices []models.Invoice, payments []models.Payment, creditNotes []models.CreditNote, asOf time.Time) []ledgerEntry {
+	var entries []ledgerEntry
+	numbers := make(map[uint]string, len(invoices))
+	for i := range invoices {
+		invoice := &invoices[i]
+		numbers[invoice.ID] = invoice.InvoiceNumber
+		id, dueDate := invoice.ID, invoice.DueDate
+		entries = append(entries, ledgerEntry{
+			StatementEntry: dtos.StatementEntry{
+				Date:        invoice.IssueDate,
+				Type:        dtos.StatementEntryInvoice,
+				Reference:   invoice.InvoiceNumber,
+				Description: "Invoice " + invoice.InvoiceNumber,
+				InvoiceID:   &id,
+				DueDate:     &dueDate,
+				Debit:       invoice.Total,
+			},
+		})
+	}
+
+	for _, payment := range payments {
+		reference := payment.Reference
+		if reference == "" {
+			reference = fmt.Sprintf("Payment %d", payment.ID)
+		}
+		var paid []string
+		for _, allocation := range payment.Allocations {
+			if number, ok := numbers[allocation.InvoiceID]; ok && allocation.Date.Before(asOf) {
+				paid = append(paid, number)
+			}
+		}
+		description := "Payment on account"
+		if len(paid) > 0 {
+			description = "Payment for invoice " + strings.Join(paid, ", ")
+		}
+		entries = append(entries, ledgerEntry{
+			StatementEntry: dtos.StatementEntry{
+				Date:        payment.PaymentDate,
+				Type:        dtos.StatementEntryPayment,
+				Reference:   reference,
+				Description: description,
+				Credit:      payment.Amount,
+			},
+			order: 1,
+		})
+
+		if payment.ReversedAt != nil && payment.ReversedAt.Before(asOf) {
+			entries = append(entries, ledgerEntry{
+				StatementEntry: dtos.StatementEntry{
+					Date:        *payment.ReversedAt,
+					Type:        dtos.StatementEntryReversal,
+					Reference:   reference,
+					Description: "Reversed: " + description,
+					Debit:       payment.Amount,
+				},
+				order: 2,
+			})
+		}
+	}
+
+	for _, note := range creditNotes {
+		invoiceID := note.InvoiceID
+		description := "Credit note"
+		if number, ok := numbers[note.InvoiceID]; ok {
+			description = "Credit note for invoice " + number
+		}
+		entries = append(entries, ledgerEntry{
+			StatementEntry: dtos.StatementEntry{
+				Date:        note.IssueDate,
+				Type:        dtos.StatementEntryCredit,
+				Reference:   note.CreditNoteNumber,
+				Description: description,
+				InvoiceID:   &invoiceID,
+				Credit:      note.Total,
+			},
+			order: 1,
+		})
+
+		for _, refund := range note.Refunds {
+			if !refund.RefundDate.Before(asOf) {
+				continue
+			}
+			reference := refund.Reference
+			if reference == "" {
+				reference = note.CreditNoteNumber
+			}
+			entries = append(entries, ledgerEntry{
+				StatementEntry: dtos.StatementEntry{
+					Date:        refund.RefundDate,
+					Type:        dtos.StatementEntryRefund,
+					Reference:   reference,
+					Description: "Refund of credit note " + note.CreditNoteNumber,
+					Debit:       refund.Amount,
+				},
+				order: 2,
+			})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Date.Equal(entries[j].Date) {
+			return entries[i].Date.Before(entries[j].Date)
+		}
+		return entries[i].order < entries[j].order
+	})
+	return entries
+}
+
+// agingSummary buckets the outstanding amount of every invoice by how many days it
+// was past due at asOf. Invoices that are not yet due count as current. Only payments
+// allocated before asOf and not reversed by then, and credit applied before asOf, reduce
+// what is outstanding.
+func agingSummary(invoices []models.Invoice, payments []models.Payment, creditNotes []models.CreditNote, asOf time.Time) dtos.AgingSummary {
+	outstanding := make(map[uint]money.Amount, len(invoices))
+	for _, invoice := range invoices {
+		outstanding[invoice.ID] = invoice.Total
+	}
+	for _, payment := range payments {
+		if payment.ReversedAt != nil && payment.ReversedAt.Before(asOf) {
+			continue
+		}
+		for _, allocation := range payment.Allocations {
+			if allocation.Date.Before(asOf) {
+				outstanding[allocation.InvoiceID] = outstanding[allocation.InvoiceID].Sub(allocation.Amount)
+			}
+		}
+	}
+
+	for _, note := range creditNotes {
+		for _, allocation := range note.Allocations {
+			if allocation.Date.Before(asOf) {
+				outstanding[allocation.InvoiceID] = outstanding[allocation.InvoiceID].Sub(allocation.Amount)
+			}
+		}
+	}
+
+	aging := dtos.AgingSummary{AsOf: asOf}
+	for _, invoice := range invoices {
+		amount := outstanding[invoice.ID]
+		if !amount.IsPositive() {
+			continue
+		}
+
+		// asOf is an exclusive bound, so age by the last day inside the period
+		switch days := daysPastDue(invoice.DueDate, asOf.Add(-time.Nanosecond)); {
+		case days <= 0:
+			aging.Current = aging.Current.Add(amount)
+		case days <= 30:
+			aging.Days1To30 = aging.Days1To30.Add(amount)
+		case days <= 60:
+			aging.Days31To60 = aging.Days31To60.Add(amount)
+		case days <= 90:
+			aging.Days61To90 = aging.Days61To90.Add(amount)
+		default:
+			aging.Over90 = aging.Over90.Add(amount)
+		}
+		aging.Total = aging.Total.Add(amount)
+	}
+	return aging
+}
+
+// inclusiveEnd turns an exclusive period end back into the last moment it covers. An end
+// at midnight comes from a plain "to" date, which is reported as that date.
+func inclusiveEnd(exclusive time.Time) time.Time {
+	end := exclusive.Add(-time.Nanosecond)
+	if exclusive.Equal(time.Date(exclusive.Year(), exclusive.Month(), exclusive.Day(), 0, 0, 0, 0, exclusive.Location())) {
+		end = exclusive.AddDate(0, 0, -1)
+	}
+	return end
+}
+
+// daysPastDue counts the whole calendar days between the due date and asOf
+func daysPastDue(dueDate, asOf time.Time) int {
+	due := time.Date(dueDate.Year(), dueDate.Month(), dueDate.Day(), 0, 0, 0, 0, time.UTC)
+	day := time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, time.UTC)
+	return int(day.Sub(due).Hours() / 24)
+}
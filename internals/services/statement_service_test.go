@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+// A statement up to to=2026-12-31 includes that whole day, leaves out the next one and
+// reports 2026-12-31 as its end, not the exclusive bound the period is queried with
+func TestStatementPeriodEnd(t *testing.T) {
+	db := newTestDB(t)
+	customer := &models.Customer{Name: "Acme", Email: "acme@example.com", Currency: "EUR"}
+	mustCreate(t, db, customer)
+	lastDay := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+	for _, invoice := range []struct {
+		number string
+		issued time.Time
+	}{
+		{"INV-1", lastDay.Add(23 * time.Hour)},
+		{"INV-2", lastDay.AddDate(0, 0, 1)},
+	} {
+		total := money.MustParse("100")
+		mustCreate(t, db, &models.Invoice{
+			CustomerID: customer.ID, InvoiceNumber: invoice.number, IssueDate: invoice.issued, DueDate: lastDay, Status: models.InvoiceStatusSent,
+			Currency: "EUR", Subtotal: total, Total: total, AmountDue: total, BaseCurrency: "EUR", BaseSubtotal: total, BaseTotal: total,
+		})
+	}
+	service := NewStatementService(repositories.NewCustomerRepository(db), repositories.NewInvoiceRepository(db),
+		repositories.NewPaymentRepository(db), repositories.NewCreditNoteRepository(db), "EUR")
+
+	// The handler turns to=2026-12-31 into the exclusive bound 2027-01-01
+	from, to := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC), lastDay.AddDate(0, 0, 1)
+	statement, err := service.GetCustomerStatement(customer.ID, "", repositories.DateRange{From: &from, To: &to})
+	if err != nil {
+		t.Fatalf("GetCustomerStatement: %v", err)
+	}
+	if len(statement.Entries) != 1 || statement.Entries[0].Reference != "INV-1" {
+		t.Errorf("entries %+v; want INV-1 only", statement.Entries)
+	}
+	if !statement.To.Equal(lastDay) || !statement.Aging.AsOf.Equal(lastDay) {
+		t.Errorf("to %s, aging as of %s; want %s", statement.To, statement.Aging.AsOf, lastDay)
+	}
+	if statement.Aging.Current != money.MustParse("100") || !statement.Aging.Days1To30.IsZero() {
+		t.Errorf("aging %+v; want INV-1 current on its due date", statement.Aging)
+	}
+
+	// A to timestamp is reported as given
+	noon := lastDay.Add(12 * time.Hour)
+	to = noon.Add(time.Nanosecond)
+	statement, err = service.GetCustomerStatement(customer.ID, "", repositories.DateRange{From: &from, To: &to})
+	if err != nil {
+		t.Fatalf("GetCustomerStatement up to noon: %v", err)
+	}
+	if !statement.To.Equal(noon) || len(statement.Entries) != 0 {
+		t.Errorf("to %s with %d entries; want %s with none", statement.To, len(statement.Entries), noon)
+	}
+}
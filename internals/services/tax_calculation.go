@@ -0,0 +1,244 @@
+package services
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+// TaxRounding selects where tax is rounded to the minor unit of the invoice currency
+type TaxRounding string
+
+const (
+	// TaxRoundingLine rounds the tax of every line and adds up the rounded amounts
+	TaxRoundingLine TaxRounding = "line"
+	// TaxRoundingInvoice adds up the exact tax of every line and rounds each tax total once
+	TaxRoundingInvoice TaxRounding = "invoice"
+)
+
+// ParseTaxRounding reads a TAX_ROUNDING setting
+func ParseTaxRounding(s string) (TaxRounding, error) {
+	switch rounding := TaxRounding(s); rounding {
+	case TaxRoundingLine, TaxRoundingInvoice:
+		return rounding, nil
+	}
+	return "", fmt.Errorf("unknown tax rounding %q (expected line or invoice)", s)
+}
+
+var (
+	hundred       = big.NewRat(100, 1)
+	maxPercentage = money.FromInt(100)
+)
+
+// taxCalculator taxes invoice lines with their tax rates
+type taxCalculator struct {
+	currency money.Currency
+	mode     money.RoundingMode
+	rounding TaxRounding
+	exempt   bool
+}
+
+// taxResult holds the rounded net amount and tax of every line and the tax lines
+type taxResult struct {
+	nets  []money.Amount
+	taxes []money.Amount
+	lines []models.InvoiceTaxLine
+}
+
+// taxTotal accumulates one tax rate across the lines of an invoice
+type taxTotal struct {
+	line    models.InvoiceTaxLine
+	taxable *big.Rat // exact sums, used when rounding per invoice
+	amount  *big.Rat
+}
+
+// tax computes the tax on exact line amounts, where rates holds the rates of each line in
+// the order they are applied. Inclusive lines keep their rounded amount: the net absorbs
+// rounding differences, so net plus tax adds up to what the customer was quoted. Exempt
+// invoices are not charged tax, and tax included in their prices is taken out.
+//
+// With balance, the rounded amounts of the lines add up to the rounded sum of the exact
+// amounts, which keeps an invoice discount spread over several lines exact.
+func (c taxCalculator) tax(amounts []*big.Rat, rates [][]models.TaxRate, balance bool) taxResult {
+	var (
+		totals        []*taxTotal
+		byRate        = make(map[uint]*taxTotal)
+		grosses       = make([]money.Amount, len(amounts))
+		exactNets     = make([]*big.Rat, len(amounts))
+		lastInclusive = -1
+		result        = taxResult{
+			nets:  make([]money.Amount, len(amounts)),
+			taxes: make([]money.Amount, len(amounts)),
+		}
+	)
+	// Taxed inclusive lines are priced by their gross amount, every other line by its net
+	isGross := func(i int) bool { return !c.exempt && isInclusiveLine(rates[i]) }
+
+	for i, amount := range amounts {
+		lineRates := rates[i]
+		grosses[i] = c.round(amount)
+		net := amount
+		if isInclusiveLine(lineRates) {
+			net = new(big.Rat).Quo(amount, grossFactor(lineRates))
+		}
+		exactNets[i] = net
+		result.nets[i] = c.round(net)
+		if c.exempt || len(lineRates) == 0 {
+			continue
+		}
+
+		applied := new(big.Rat)
+		lineTax := money.Zero
+		for _, rate := range lineRates {
+			taxable := net
+			if rate.Compound {
+				taxable = new(big.Rat).Add(net, applied)
+			}
+			tax := new(big.Rat).Mul(taxable, percent(rate.Rate))
+			applied.Add(applied, tax)
+
+			total, ok := byRate[rate.ID]
+			if !ok {
+				total = &taxTotal{
+					line: models.InvoiceTaxLine{
+						TaxRateID: rate.ID,
+						Name:      rate.Name,
+						Rate:      rate.Rate,
+						Compound:  rate.Compound,
+						Inclusive: rate.Inclusive,
+					},
+					taxable: new(big.Rat),
+					amount:  new(big.Rat),
+				}
+				byRate[rate.ID] = total
+				totals = append(totals, total)
+			}
+			if c.rounding == TaxRoundingLine {
+				rounded := c.round(tax)
+				total.line.TaxableAmount = total.line.TaxableAmount.Add(c.round(taxable))
+				total.line.Amount = total.line.Amount.Add(rounded)
+				lineTax = lineTax.Add(rounded)
+			} else {
+				total.taxable.Add(total.taxable, taxable)
+				total.amount.Add(total.amount, tax)
+			}
+		}
+		if c.rounding != TaxRoundingLine {
+			lineTax = c.round(applied)
+		}
+		result.taxes[i] = lineTax
+		if isGross(i) {
+			lastInclusive = i
+		}
+	}
+
+	if balance {
+		c.balance(grosses, amounts, isGross)
+		c.balance(result.nets, exactNets, func(i int) bool { return !isGross(i) })
+	}
+	for i := range amounts {
+		if isGross(i) {
+			result.nets[i] = grosses[i].Sub(result.taxes[i])
+		}
+	}
+
+	inclusiveTax := money.Zero
+	for _, total := range totals {
+		if c.rounding != TaxRoundingLine {
+			total.line.TaxableAmount = c.round(total.taxable)
+			total.line.Amount = c.round(total.amount)
+		}
+		if total.line.Inclusive {
+			inclusiveTax = inclusiveTax.Add(total.line.Amount)
+		}
+	}
+
+	// Rounding tax totals once can differ from the rounded tax of each line by a few
+	// minor units; move the difference onto the last inclusive line so totals still match
+	if lastInclusive >= 0 {
+		difference := inclusiveTax.Neg()
+		for i := range amounts {
+			if isGross(i) {
+				difference = difference.Add(result.taxes[i])
+			}
+		}
+		if !difference.IsZero() {
+			result.taxes[lastInclusive] = result.taxes[lastInclusive].Sub(difference)
+			result.nets[lastInclusive] = result.nets[lastInclusive].Add(difference)
+		}
+	}
+
+	// Non-compound rates are charged on the net amounts, so report exactly the line nets
+	// as their taxable amount
+	for _, total := range totals {
+		if !total.line.Compound {
+			total.line.TaxableAmount = money.Zero
+		}
+	}
+	for i := range amounts {
+		for _, rate := range rates[i] {
+			if total := byRate[rate.ID]; total != nil && !rate.Compound {
+				total.line.TaxableAmount = total.line.TaxableAmount.Add(result.nets[i])
+			}
+		}
+	}
+
+	result.lines = make([]models.InvoiceTaxLine, 0, len(totals))
+	for _, total := range totals {
+		result.lines = append(result.lines, total.line)
+	}
+	sort.SliceStable(result.lines, func(a, b int) bool { return !result.lines[a].Compound && result.lines[b].Compound })
+	return result
+}
+
+// balance adjusts the last rounded amount selected by include so the selected rounded
+// amounts add up to their rounded exact sum
+func (c taxCalculator) balance(rounded []money.Amount, exact []*big.Rat, include func(int) bool) {
+	last := -1
+	sum := new(big.Rat)
+	roundedSum := money.Zero
+	for i := range rounded {
+		if include(i) {
+			last = i
+			sum.Add(sum, exact[i])
+			roundedSum = roundedSum.Add(rounded[i])
+		}
+	}
+	if last >= 0 {
+		rounded[last] = rounded[last].Add(c.round(sum).Sub(roundedSum))
+	}
+}
+
+// round rounds an exact amount to the minor unit of the invoice currency
+func (c taxCalculator) round(r *big.Rat) money.Amount {
+	return money.FromRat(r, c.currency.Digits(), c.mode)
+}
+
+// grossFactor is the ratio of a gross amount to its net amount under the given rates:
+// one plus the non-compound rates, multiplied by one plus each compound rate
+func grossFactor(rates []models.TaxRate) *big.Rat {
+	factor := big.NewRat(1, 1)
+	for _, rate := range rates {
+		if !rate.Compound {
+			factor.Add(factor, percent(rate.Rate))
+		}
+	}
+	for _, rate := range rates {
+		if rate.Compound {
+			factor.Mul(factor, new(big.Rat).Add(big.NewRat(1, 1), percent(rate.Rate)))
+		}
+	}
+	return factor
+}
+
+func isInclusiveLine(rates []models.TaxRate) bool {
+	return len(rates) > 0 && rates[0].Inclusive
+}
+
+// percent converts a percentage into a fraction
+func percent(rate money.Amount) *big.Rat {
+	return new(big.Rat).Quo(rate.Rat(), hundred)
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrTaxRateNotFound is returned when a tax rate does not exist
+	ErrTaxRateNotFound = errors.New("tax rate not found")
+	// ErrInvalidTaxRate marks a tax rate, or a combination of rates on a line, that cannot be accepted
+	ErrInvalidTaxRate = errors.New("invalid tax rate")
+)
+
+// legacyTaxRate reproduces the flat 10% tax invoices were charged before tax rates were configurable
+var legacyTaxRate = models.TaxRate{Name: "Tax", Rate: money.FromInt(10), IsDefault: true}
+
+type TaxRateService interface {
+	ListTaxRates() ([]models.TaxRate, error)
+	GetTaxRate(id uint) (*models.TaxRate, error)
+	CreateTaxRate(req *dtos.TaxRateRequest) (*models.TaxRate, error)
+	UpdateTaxRate(id uint, req *dtos.TaxRateRequest) (*models.TaxRate, error)
+	DeleteTaxRate(id uint) error
+	ResolveRates(ids models.UintList) ([]models.TaxRate, error)
+	InitializeDefaultTaxRates() (bool, error)
+}
+
+type taxRateService struct {
+	repo repositories.TaxRateRepository
+}
+
+func NewTaxRateService(repo repositories.TaxRateRepository) TaxRateService {
+	return &taxRateService{repo: repo}
+}
+
+func (s *taxRateService) ListTaxRates() ([]models.TaxRate, error) {
+	rates, err := s.repo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tax rates: %w", err)
+	}
+	return rates, nil
+}
+
+func (s *taxRateService) GetTaxRate(id uint) (*models.TaxRate, error) {
+	rate, err := s.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaxRateNotFound
+		}
+		return nil, fmt.Errorf("failed to get tax rate: %w", err)
+	}
+	return rate, nil
+}
+
+func (s *taxRateService) CreateTaxRate(req *dtos.TaxRateRequest) (*models.TaxRate, error) {
+	rate := &models.TaxRate{}
+	if err := s.applyRequest(rate, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(rate); err != nil {
+		return nil, fmt.Errorf("failed to create tax rate: %w", err)
+	}
+	return rate, nil
+}
+
+// UpdateTaxRate replaces a tax rate. Invoices keep the percentage they were priced with
+// until they are updated.
+func (s *taxRateService) UpdateTaxRate(id uint, req *dtos.TaxRateRequest) (*models.TaxRate, error) {
+	rate, err := s.GetTaxRate(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRequest(rate, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(rate); err != nil {
+		return nil, fmt.Errorf("failed to update tax rate: %w", err)
+	}
+	return rate, nil
+}
+
+func (s *taxRateService) DeleteTaxRate(id uint) error {
+	if err := s.repo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTaxRateNotFound
+		}
+		return fmt.Errorf("failed to delete tax rate: %w", err)
+	}
+	return nil
+}
+
+// applyRequest validates req and copies it onto rate
+func (s *taxRateService) applyRequest(rate *models.TaxRate, req *dtos.TaxRateRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidTaxRate)
+	}
+	if req.Rate == nil || req.Rate.IsNegative() || req.Rate.Cmp(maxPercentage) > 0 {
+		return fmt.Errorf("%w: rate must be a percentage between 0 and 100", ErrInvalidTaxRate)
+	}
+
+	if existing, err := s.repo.FindByName(name); err == nil && existing.ID != rate.ID {
+		return fmt.Errorf("%w: a tax rate named %q already exists", ErrInvalidTaxRate, existing.Name)
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to check tax rate: %w", err)
+	}
+
+	// Default rates are applied together, so they must agree on whether prices include tax
+	if req.IsDefault {
+		defaults, err := s.repo.FindDefaults()
+		if err != nil {
+			return fmt.Errorf("failed to list default tax rates: %w", err)
+		}
+		for _, other := range defaults {
+			if other.ID != rate.ID && other.Inclusive != req.Inclusive {
+				return fmt.Errorf("%w: default tax rates must all be tax inclusive or all tax exclusive, but %q is %s", ErrInvalidTaxRate, other.Name, inclusiveLabel(other.Inclusive))
+			}
+		}
+	}
+
+	rate.Name = name
+	rate.Rate = *req.Rate
+	rate.Compound = req.Compound
+	rate.Inclusive = req.Inclusive
+	rate.IsDefault = req.IsDefault
+	return nil
+}
+
+// ResolveRates returns the tax rates of an invoice line in the order they are applied:
+// non-compound rates first, then compound rates. A nil list means the default rates and
+// an empty list means no tax.
+func (s *taxRateService) ResolveRates(ids models.UintList) ([]models.TaxRate, error) {
+	var (
+		rates []models.TaxRate
+		err   error
+	)
+	if ids == nil {
+		rates, err = s.repo.FindDefaults()
+	} else {
+		rates, err = s.repo.FindByIDs(ids)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tax rates: %w", err)
+	}
+
+	found := make(map[uint]bool, len(rates))
+	for _, rate := range rates {
+		found[rate.ID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return nil, fmt.Errorf("%w: tax rate %d not found", ErrInvalidTaxRate, id)
+		}
+	}
+
+	ordered := make([]models.TaxRate, 0, len(rates))
+	for _, compound := range []bool{false, true} {
+		for _, rate := range rates {
+			if rate.Compound == compound {
+				ordered = append(ordered, rate)
+			}
+		}
+	}
+	for _, rate := range ordered {
+		if rate.Inclusive != ordered[0].Inclusive {
+			return nil, fmt.Errorf("%w: %q is %s but %q is %s", ErrInvalidTaxRate, ordered[0].Name, inclusiveLabel(ordered[0].Inclusive), rate.Name, inclusiveLabel(rate.Inclusive))
+		}
+	}
+	return ordered, nil
+}
+
+// InitializeDefaultTaxRates creates a 10% default rate on installations that have never
+// had tax rates, so invoices keep being taxed the way they were before rates existed
+func (s *taxRateService) InitializeDefaultTaxRates() (bool, error) {
+	count, err := s.repo.CountAll()
+	if err != nil {
+		return false, fmt.Errorf("failed to count tax rates: %w", err)
+	}
+	if count > 0 {
+		return false, nil
+	}
+	rate := legacyTaxRate
+	if err := s.repo.Create(&rate); err != nil {
+		return false, fmt.Errorf("failed to create default tax rate: %w", err)
+	}
+	return true, nil
+}
+
+func inclusiveLabel(inclusive bool) string {
+	if inclusive {
+		return "tax inclusive"
+	}
+	return "tax exclusive"
+}
@@ -0,0 +1,918 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"github.com/tacheraSasi/go-api-starter/pkg/ubl"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidUBL is returned when an uploaded document is not a readable UBL invoice
+var ErrInvalidUBL = errors.New("invalid UBL document")
+
+// Rules of the issues raised while mapping invoices to and from UBL, next to the EN 16931
+// and Peppol rules checked by ubl.Validate
+const (
+	ublRuleTax      = "MAPPING-TAX"
+	ublRuleLine     = "MAPPING-LINE"
+	ublRuleCustomer = "MAPPING-CUSTOMER"
+	ublRuleSeller   = "MAPPING-SELLER"
+	ublRuleInvoice  = "MAPPING-INVOICE"
+	ublRuleTotal    = "MAPPING-TOTAL"
+)
+
+// ublUnitCodes maps common product units to UN/ECE Recommendation 20 codes; other units
+// are exported as C62, "one"
+var ublUnitCodes = map[string]string{
+	"hour": "HUR", "hours": "HUR", "hr": "HUR", "h": "HUR",
+	"day": "DAY", "days": "DAY",
+	"week": "WEE", "weeks": "WEE",
+	"month": "MON", "months": "MON",
+	"year": "ANN", "years": "ANN",
+	"piece": "H87", "pieces": "H87", "pc": "H87", "pcs": "H87",
+	"kg": "KGM", "g": "GRM", "l": "LTR", "m": "MTR", "km": "KMT",
+}
+
+// UBLSettings describe the seller on exported e-invoices
+type UBLSettings struct {
+	Issuer InvoiceIssuer
+	// City and PostalCode of the seller; the issuer address line that holds them is left
+	// out of the street
+	City                string
+	PostalCode          string
+	Country             string          // ISO 3166-1 alpha-2 code of the seller
+	Endpoint            *ubl.Identifier // Peppol electronic address of the seller
+	PaymentInstructions string
+	// BuyerReferenceField is the key of the invoice custom field exported as the buyer
+	// reference; the invoice number is used when it is empty
+	BuyerReferenceField string
+}
+
+// UBLImportOptions control an import of a UBL invoice
+type UBLImportOptions struct {
+	// DryRun validates and prices the invoice without storing it
+	DryRun bool
+	// CustomFields are set on the imported invoice, e.g. fields the document cannot carry
+	CustomFields models.CustomFields
+	// AllowDuplicate imports a document whose number matches an invoice that is not void
+	AllowDuplicate bool
+	CreatedByID    *uint
+}
+
+type UBLService interface {
+	ExportInvoice(id uint) (*models.Invoice, []byte, *dtos.UBLValidationReport, error)
+	ImportInvoice(r io.Reader, opts UBLImportOptions) (*dtos.UBLImportReport, error)
+}
+
+type ublService struct {
+	invoiceRepo  repositories.InvoiceRepository
+	customerRepo repositories.CustomerRepository
+	addressRepo  repositories.CustomerAddressRepository
+	productRepo  repositories.ProductRepository
+	invoices     InvoiceService
+	taxRates     TaxRateService
+	customFields CustomFieldService
+	settings     UBLSettings
+}
+
+func NewUBLService(
+	invoiceRepo repositories.InvoiceRepository,
+	customerRepo repositories.CustomerRepository,
+	addressRepo repositories.CustomerAddressRepository,
+	productRepo repositories.ProductRepository,
+	invoices InvoiceService,
+	taxRates TaxRateService,
+	customFields CustomFieldService,
+	settings UBLSettings,
+) UBLService {
+	return &ublService{
+		invoiceRepo:  invoiceRepo,
+		customerRepo: customerRepo,
+		addressRepo:  addressRepo,
+		productRepo:  productRepo,
+		invoices:     invoices,
+		taxRates:     taxRates,
+		customFields: customFields,
+		settings:     settings,
+	}
+}
+
+// ExportInvoice renders an invoice as a Peppol BIS Billing 3.0 UBL document and validates
+// it. The document is returned even when it breaks rules, together with the report.
+func (s *ublService) ExportInvoice(id uint) (*models.Invoice, []byte, *dtos.UBLValidationReport, error) {
+	invoice, err := s.invoiceRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil, ErrInvoiceNotFound
+		}
+		return nil, nil, nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	document, issues, err := s.buildDocument(invoice)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	issues = append(issues, ubl.Validate(document)...)
+
+	data, err := document.Marshal()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return invoice, data, newUBLReport(issues), nil
+}
+
+// ublCategory accumulates the lines, allowances and charges of one VAT category and rate
+type ublCategory struct {
+	category   *ubl.TaxCategory
+	lines      money.Amount // net amounts of the lines
+	rated      money.Amount // net amounts of the lines whose tax lines give their taxable amount
+	taxable    money.Amount // taxable amount of those lines after the invoice discount
+	unrated    money.Amount // net amounts of the other lines
+	allowances money.Amount
+	charges    money.Amount // untaxed invoice charges
+	tax        money.Amount
+}
+
+// buildDocument maps an invoice onto a UBL invoice. Every line gets one VAT category:
+// standard rated (S) with its rate, zero rated (Z) when it has no or a zero rate, exempt
+// (E) or reverse charge (AE) for tax exempt customers, and not subject to VAT (O) when the
+// company has no VAT identifier and charges no tax. The invoice discount becomes one
+// document allowance per category and untaxed charges are charges of the untaxed category.
+func (s *ublService) buildDocument(invoice *models.Invoice) (*ubl.Invoice, []ubl.Issue, error) {
+	var issues []ubl.Issue
+	currency := invoice.Currency
+	amount := func(a money.Amount) *ubl.Amount {
+		return &ubl.Amount{Value: a.Format(currency), CurrencyID: string(currency)}
+	}
+
+	taxLines := make(map[uint]models.InvoiceTaxLine, len(invoice.TaxLines))
+	taxed := false
+	for _, line := range invoice.TaxLines {
+		taxLines[line.TaxRateID] = line
+		taxed = taxed || line.Rate.IsPositive()
+	}
+
+	// Lines without tax and untaxed charges share one category
+	untaxed := &ubl.TaxCategory{ID: ubl.CategoryZero, Percent: "0"}
+	switch {
+	case invoice.TaxExempt && strings.Contains(strings.ToLower(invoice.Customer.TaxExemptReason), "reverse charge"):
+		untaxed = &ubl.TaxCategory{ID: ubl.CategoryReverseCharge, Percent: "0", ExemptionReasonCode: "VATEX-EU-AE", ExemptionReason: "Reverse charge"}
+	case invoice.TaxExempt:
+		reason := invoice.Customer.TaxExemptReason
+		if reason == "" {
+			reason = "Exempt from VAT"
+		}
+		untaxed = &ubl.TaxCategory{ID: ubl.CategoryExempt, Percent: "0", ExemptionReason: reason}
+	case s.settings.Issuer.TaxID == "" && !taxed:
+		untaxed = &ubl.TaxCategory{ID: ubl.CategoryOutOfScope, ExemptionReasonCode: "VATEX-EU-O", ExemptionReason: "Not subject to VAT"}
+	}
+	untaxed.TaxScheme.ID = ubl.TaxSchemeVAT
+
+	var categories []*ublCategory
+	byKey := make(map[string]*ublCategory)
+	categoryOf := func(category *ubl.TaxCategory) *ublCategory {
+		key := category.ID + "|" + category.Percent
+		if c, ok := byKey[key]; ok {
+			return c
+		}
+		c := &ublCategory{category: category}
+		byKey[key] = c
+		categories = append(categories, c)
+		return c
+	}
+	rateCategory := func(rate money.Amount) *ubl.TaxCategory {
+		category := &ubl.TaxCategory{ID: ubl.CategoryStandard, Percent: ubl.FormatDecimal(rate.Rat())}
+		if !rate.IsPositive() {
+			category.ID = ubl.CategoryZero
+		}
+		category.TaxScheme.ID = ubl.TaxSchemeVAT
+		return category
+	}
+
+	products, err := s.lineProducts(invoice.Items)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lines := make([]ubl.InvoiceLine, 0, len(invoice.Items))
+	for i := range invoice.Items {
+		item := &invoice.Items[i]
+		label := fmt.Sprintf("line %d", i+1)
+
+		vat, rated, inclusive := untaxed, false, false
+		if !invoice.TaxExempt && untaxed.ID != ubl.CategoryOutOfScope && len(item.TaxRateIDs) > 0 {
+			if len(item.TaxRateIDs) > 1 {
+				issues = append(issues, ubl.Issue{Rule: ublRuleTax, Severity: ubl.SeverityError,
+					Message: fmt.Sprintf("%s has %d tax rates; a UBL invoice line has exactly one VAT category and rate", label, len(item.TaxRateIDs))})
+			}
+			if line, ok := taxLines[item.TaxRateIDs[0]]; ok {
+				vat = rateCategory(line.Rate)
+				rated, inclusive = len(item.TaxRateIDs) == 1 && !line.Compound, line.Inclusive
+			} else {
+				issues = append(issues, ubl.Issue{Rule: ublRuleTax, Severity: ubl.SeverityError,
+					Message: fmt.Sprintf("%s has tax rate %d, which is missing from the tax lines of the invoice", label, item.TaxRateIDs[0])})
+			}
+		}
+		category := categoryOf(vat)
+		category.lines = category.lines.Add(item.NetAmount)
+		if rated {
+			category.rated = category.rated.Add(item.NetAmount)
+		} else {
+			category.unrated = category.unrated.Add(item.NetAmount)
+		}
+
+		line := ubl.InvoiceLine{
+			ID:                  strconv.Itoa(i + 1),
+			InvoicedQuantity:    &ubl.Quantity{Value: strconv.Itoa(item.Quantity), UnitCode: ubl.UnitOne},
+			LineExtensionAmount: amount(item.NetAmount),
+			Item: &ubl.Item{
+				Name:                  item.Description,
+				ClassifiedTaxCategory: plainCategory(category.category),
+			},
+		}
+		if item.ProductID != nil {
+			if product, ok := products[*item.ProductID]; ok {
+				line.Item.SellersItemIdentification = &ubl.ItemIdentification{ID: product.SKU}
+				if code, ok := ublUnitCodes[strings.ToLower(strings.TrimSpace(product.Unit))]; ok {
+					line.InvoicedQuantity.UnitCode = code
+				}
+			}
+		}
+
+		// Lines priced without tax show their unit price and discount. Inclusive lines,
+		// and lines whose rounding does not add up that way, show their net amount as
+		// the price of the whole quantity.
+		price := new(big.Rat).Mul(item.UnitPrice.Rat(), big.NewRat(int64(item.Quantity), 1))
+		price.Sub(price, item.DiscountAmount.Rat())
+		if !inclusive && !invoice.TaxExempt && money.FromRat(price, currency.Digits(), money.RoundHalfUp).Cmp(item.NetAmount) == 0 {
+			line.Price = &ubl.Price{PriceAmount: &ubl.Amount{Value: item.UnitPrice.String(), CurrencyID: string(currency)}}
+			if item.DiscountAmount.IsPositive() {
+				line.AllowanceCharges = []ubl.AllowanceCharge{{
+					ChargeIndicator: "false",
+					ReasonCode:      ubl.AllowanceReasonDiscount,
+					Reason:          "Discount",
+					Amount:          amount(item.DiscountAmount),
+				}}
+			}
+		} else {
+			line.Price = &ubl.Price{PriceAmount: amount(item.NetAmount.Abs())}
+			if item.Quantity != 0 && item.Quantity != 1 {
+				quantity := item.Quantity
+				if quantity < 0 {
+					quantity = -quantity
+				}
+				line.Price.BaseQuantity = &ubl.Quantity{Value: strconv.Itoa(quantity), UnitCode: line.InvoicedQuantity.UnitCode}
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	// Tax lines give the taxable amount after the invoice discount of the lines taxed at
+	// their rate, so the discount on those lines is the difference. The rest of the discount
+	// is spread over the other lines in proportion to their amounts.
+	for _, line := range invoice.TaxLines {
+		category := byKey[ubl.CategoryStandard+"|"+ubl.FormatDecimal(line.Rate.Rat())]
+		if !line.Rate.IsPositive() {
+			category = byKey[ubl.CategoryZero+"|0"]
+		}
+		if category == nil {
+			continue
+		}
+		category.tax = category.tax.Add(line.Amount)
+		if !line.Compound {
+			category.taxable = category.taxable.Add(line.TaxableAmount)
+		}
+	}
+	remaining, unrated := invoice.DiscountAmount, money.Zero
+	for _, category := range categories {
+		if category.rated.IsZero() {
+			category.taxable = money.Zero
+		}
+		category.allowances = category.rated.Sub(category.taxable)
+		remaining = remaining.Sub(category.allowances)
+		unrated = unrated.Add(category.unrated)
+	}
+	if !remaining.IsZero() && len(categories) > 0 {
+		var last *ublCategory
+		spread := money.Zero
+		for _, category := range categories {
+			if category.unrated.IsZero() && !unrated.IsZero() {
+				continue
+			}
+			share := remaining
+			if !unrated.IsZero() {
+				exact := new(big.Rat).Quo(new(big.Rat).Mul(remaining.Rat(), category.unrated.Rat()), unrated.Rat())
+				share = money.FromRat(exact, currency.Digits(), money.RoundHalfUp)
+			}
+			category.allowances = category.allowances.Add(share)
+			spread = spread.Add(share)
+			last = category
+			if unrated.IsZero() {
+				break
+			}
+		}
+		last.allowances = last.allowances.Add(remaining.Sub(spread))
+	}
+	if invoice.ChargesTotal.IsPositive() {
+		categoryOf(untaxed).charges = invoice.ChargesTotal
+	}
+
+	document := &ubl.Invoice{
+		CustomizationID:      ubl.CustomizationID,
+		ProfileID:            ubl.ProfileID,
+		ID:                   invoice.InvoiceNumber,
+		IssueDate:            invoice.IssueDate.Format("2006-01-02"),
+		DueDate:              invoice.DueDate.Format("2006-01-02"),
+		InvoiceTypeCode:      ubl.InvoiceTypeCommercial,
+		DocumentCurrencyCode: string(currency),
+		BuyerReference:       s.buyerReference(invoice),
+		Supplier:             &ubl.PartyRole{Party: s.sellerParty()},
+		Lines:                lines,
+	}
+	if notes := strings.TrimSpace(invoice.Notes); notes != "" {
+		document.Notes = []string{notes}
+	}
+	if strings.TrimSpace(s.settings.City) == "" && len(s.settings.Issuer.Address) > 1 {
+		issues = append(issues, ubl.Issue{Rule: ublRuleSeller, Severity: ubl.SeverityWarning, Message: "COMPANY_CITY is not set, so the seller's city is exported as a street line; set COMPANY_CITY and COMPANY_POSTAL_CODE"})
+	}
+	buyer, err := s.buyerParty(invoice)
+	if err != nil {
+		return nil, nil, err
+	}
+	document.Customer = &ubl.PartyRole{Party: *buyer}
+	if s.settings.PaymentInstructions != "" {
+		document.PaymentTerms = &ubl.PaymentTerms{Note: s.settings.PaymentInstructions}
+	}
+
+	allowanceTotal, chargeTotal := money.Zero, money.Zero
+	breakdown := &ubl.TaxTotal{TaxAmount: amount(invoice.TaxAmount)}
+	for _, category := range categories {
+		switch {
+		case category.allowances.IsPositive():
+			document.AllowanceCharges = append(document.AllowanceCharges, ubl.AllowanceCharge{
+				ChargeIndicator: "false",
+				ReasonCode:      ubl.AllowanceReasonDiscount,
+				Reason:          "Discount",
+				Amount:          amount(category.allowances),
+				TaxCategory:     plainCategory(category.category),
+			})
+			allowanceTotal = allowanceTotal.Add(category.allowances)
+		case category.allowances.IsNegative():
+			// Rounding can leave the discounted amount of a category a minor unit above
+			// its undiscounted amount
+			rounding := category.allowances.Neg()
+			document.AllowanceCharges = append(document.AllowanceCharges, ubl.AllowanceCharge{
+				ChargeIndicator: "true",
+				Reason:          "Rounding",
+				Amount:          amount(rounding),
+				TaxCategory:     plainCategory(category.category),
+			})
+			chargeTotal = chargeTotal.Add(rounding)
+		}
+		breakdown.Subtotals = append(breakdown.Subtotals, ubl.TaxSubtotal{
+			TaxableAmount: amount(category.lines.Sub(category.allowances).Add(category.charges)),
+			TaxAmount:     amount(category.tax),
+			TaxCategory:   category.category,
+		})
+	}
+	for _, charge := range invoice.Charges {
+		document.AllowanceCharges = append(document.AllowanceCharges, ubl.AllowanceCharge{
+			ChargeIndicator: "true",
+			Reason:          charge.Description,
+			Amount:          amount(charge.Amount),
+			TaxCategory:     plainCategory(untaxed),
+		})
+		chargeTotal = chargeTotal.Add(charge.Amount)
+	}
+	document.TaxTotals = []ubl.TaxTotal{*breakdown}
+
+	exclusive := invoice.Subtotal.Sub(allowanceTotal).Add(chargeTotal)
+	inclusive := exclusive.Add(invoice.TaxAmount)
+	prepaid := invoice.AmountPaid.Add(invoice.AmountCredited)
+	totals := &ubl.MonetaryTotal{
+		LineExtensionAmount: amount(invoice.Subtotal),
+		TaxExclusiveAmount:  amount(exclusive),
+		TaxInclusiveAmount:  amount(inclusive),
+		PayableAmount:       amount(inclusive.Sub(prepaid)),
+	}
+	if allowanceTotal.IsPositive() {
+		totals.AllowanceTotalAmount = amount(allowanceTotal)
+	}
+	if chargeTotal.IsPositive() {
+		totals.ChargeTotalAmount = amount(chargeTotal)
+	}
+	if prepaid.IsPositive() {
+		totals.PrepaidAmount = amount(prepaid)
+	}
+	document.LegalMonetaryTotal = totals
+	if inclusive.Cmp(invoice.Total) != 0 {
+		issues = append(issues, ubl.Issue{Rule: ublRuleTotal, Severity: ubl.SeverityError,
+			Message: fmt.Sprintf("the exported total %s does not match the invoice total %s", inclusive.Format(currency), invoice.Total.Format(currency))})
+	}
+	return document, issues, nil
+}
+
+// lineProducts loads the products invoice lines were filled from, including deleted ones
+func (s *ublService) lineProducts(items []models.InvoiceItem) (map[uint]models.Product, error) {
+	var ids []uint
+	for _, item := range items {
+		if item.ProductID != nil {
+			ids = append(ids, *item.ProductID)
+		}
+	}
+	products := make(map[uint]models.Product, len(ids))
+	if len(ids) == 0 {
+		return products, nil
+	}
+	found, err := s.productRepo.FindByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+	for _, product := range found {
+		products[product.ID] = product
+	}
+	return products, nil
+}
+
+// buyerReference returns the invoice custom field configured as the buyer reference, or
+// the invoice number when the invoice does not have it
+func (s *ublService) buyerReference(invoice *models.Invoice) string {
+	if value, ok := invoice.CustomFields[s.settings.BuyerReferenceField]; ok && value != nil {
+		if reference := strings.TrimSpace(fmt.Sprint(value)); reference != "" {
+			return reference
+		}
+	}
+	return invoice.InvoiceNumber
+}
+
+func (s *ublService) sellerParty() ubl.Party {
+	issuer := s.settings.Issuer
+	party := ubl.Party{
+		PostalAddress: &ubl.Address{},
+		LegalEntity:   &ubl.LegalEntity{RegistrationName: issuer.Name},
+	}
+	if s.settings.Endpoint != nil {
+		endpoint := *s.settings.Endpoint
+		party.EndpointID = &endpoint
+	}
+	if issuer.Name != "" {
+		party.PartyName = &ubl.PartyName{Name: issuer.Name}
+	}
+	fillAddressLines(party.PostalAddress, s.streetLines())
+	party.PostalAddress.CityName = strings.TrimSpace(s.settings.City)
+	party.PostalAddress.PostalZone = strings.TrimSpace(s.settings.PostalCode)
+	if s.settings.Country != "" {
+		party.PostalAddress.Country = &ubl.Country{IdentificationCode: s.settings.Country}
+	}
+	if issuer.TaxID != "" {
+		party.PartyTaxSchemes = []ubl.PartyTaxScheme{{CompanyID: issuer.TaxID, TaxScheme: ubl.TaxScheme{ID: ubl.TaxSchemeVAT}}}
+	}
+	if issuer.Email != "" || issuer.Phone != "" {
+		party.Contact = &ubl.Contact{Telephone: issuer.Phone, ElectronicMail: issuer.Email}
+	}
+	return party
+}
+
+// streetLines returns the issuer address lines without the one holding the postal code,
+// or the city when there is no postal code, which are exported in their own fields
+func (s *ublService) streetLines() []string {
+	city, postalCode := strings.ToLower(strings.TrimSpace(s.settings.City)), strings.ToLower(strings.TrimSpace(s.settings.PostalCode))
+	var lines []string
+	for _, line := range s.settings.Issuer.Address {
+		lower := strings.ToLower(strings.TrimSpace(line))
+		if postalCode != "" && strings.Contains(lower, postalCode) || postalCode == "" && city != "" && strings.HasSuffix(lower, city) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// buyerParty describes the customer of an invoice at the billing address of the invoice,
+// or the customer's default billing address
+func (s *ublService) buyerParty(invoice *models.Invoice) (*ubl.Party, error) {
+	customer := invoice.Customer
+	party := &ubl.Party{
+		PartyName:     &ubl.PartyName{Name: customer.Name},
+		PostalAddress: &ubl.Address{},
+		LegalEntity:   &ubl.LegalEntity{RegistrationName: customer.Name},
+	}
+	switch {
+	case customer.PeppolID != "":
+		endpoint, err := ubl.ParseEndpoint(customer.PeppolID)
+		if err == nil {
+			party.EndpointID = endpoint
+		}
+	case customer.Email != "":
+		party.EndpointID = &ubl.Identifier{Value: customer.Email, SchemeID: ubl.EndpointSchemeEmail}
+	}
+
+	address := invoice.BillingAddress
+	if address == nil {
+		found, err := s.addressRepo.FindDefault(invoice.CustomerID, models.AddressTypeBilling)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to get default billing address: %w", err)
+		}
+		if err == nil {
+			address = found
+		}
+	}
+	if address != nil {
+		party.PostalAddress = &ubl.Address{
+			StreetName:           address.Line1,
+			AdditionalStreetName: address.Line2,
+			CityName:             address.City,
+			PostalZone:           address.PostalCode,
+			CountrySubentity:     address.Region,
+		}
+		if address.Country != "" {
+			party.PostalAddress.Country = &ubl.Country{IdentificationCode: strings.ToUpper(address.Country)}
+		}
+	} else {
+		fillAddressLines(party.PostalAddress, strings.Split(customer.Address, "\n"))
+	}
+
+	if customer.TaxID != "" {
+		party.PartyTaxSchemes = []ubl.PartyTaxScheme{{CompanyID: customer.TaxID, TaxScheme: ubl.TaxScheme{ID: ubl.TaxSchemeVAT}}}
+	}
+	if customer.Email != "" || customer.Phone != "" {
+		party.Contact = &ubl.Contact{Telephone: customer.Phone, ElectronicMail: customer.Email}
+	}
+	return party, nil
+}
+
+// fillAddressLines puts free-form address lines into the street fields of an address
+func fillAddressLines(address *ubl.Address, lines []string) {
+	var parts []string
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			parts = append(parts, line)
+		}
+	}
+	if len(parts) > 0 {
+		address.StreetName = parts[0]
+	}
+	if len(parts) > 1 {
+		address.AdditionalStreetName = parts[1]
+	}
+	if len(parts) > 2 {
+		address.AddressLine = &ubl.AddressLine{Line: strings.Join(parts[2:], ", ")}
+	}
+}
+
+// plainCategory copies a VAT category without the exemption reason, which only the VAT
+// breakdown carries
+func plainCategory(category *ubl.TaxCategory) *ubl.TaxCategory {
+	return &ubl.TaxCategory{ID: category.ID, Percent: category.Percent, TaxScheme: category.TaxScheme}
+}
+
+// ImportInvoice reads a UBL invoice into a draft invoice. The document must pass
+// validation, its buyer must match an existing customer, its VAT categories must map
+// onto tax rates, and unless duplicates are allowed its number may not match an invoice
+// that is not void; otherwise the report lists why and nothing is stored.
+func (s *ublService) ImportInvoice(r io.Reader, opts UBLImportOptions) (*dtos.UBLImportReport, error) {
+	document, err := ubl.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidUBL, err)
+	}
+
+	report := &dtos.UBLImportReport{DryRun: opts.DryRun}
+	issues := ubl.Validate(document)
+	if ubl.HasErrors(issues) {
+		report.UBLValidationReport = *newUBLReport(issues)
+		return report, nil
+	}
+
+	invoice, matchedBy, mapped, err := s.readDocument(document, opts)
+	if err != nil {
+		return nil, err
+	}
+	issues = append(issues, mapped...)
+	report.CustomerMatchedBy = matchedBy
+	if ubl.HasErrors(issues) {
+		report.UBLValidationReport = *newUBLReport(issues)
+		return report, nil
+	}
+
+	// Invoices are numbered from the sequence, so the document number is kept as the
+	// imported number and a document that was imported or exported before is refused
+	number := invoice.ImportedNumber
+	duplicate, err := s.invoiceRepo.FindImportDuplicate(number)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check invoice number: %w", err)
+	}
+	message := fmt.Sprintf("the document number %s is not kept; the invoice takes the next number of the invoice sequence", number)
+	if err == nil {
+		message = fmt.Sprintf("document %s was already imported as invoice %s", number, duplicate.InvoiceNumber)
+		if duplicate.InvoiceNumber == number {
+			message = fmt.Sprintf("invoice %s already exists", number)
+		}
+		if !opts.AllowDuplicate {
+			issues = append(issues, ubl.Issue{Rule: ublRuleInvoice, Severity: ubl.SeverityError, Message: message + "; void it or pass allow_duplicate=true to import the document again"})
+			report.UBLValidationReport = *newUBLReport(issues)
+			return report, nil
+		}
+		message += "; the import creates another invoice with the next number of the invoice sequence"
+	}
+	issues = append(issues, ubl.Issue{Rule: ublRuleInvoice, Severity: ubl.SeverityWarning, Message: message})
+
+	if opts.DryRun {
+		err = s.invoices.PriceInvoice(invoice)
+		if err == nil {
+			var fields models.CustomFields
+			_, fields, err = s.customFields.ResolveValues(models.TaggableInvoice, nil, invoice.CustomFields)
+			invoice.CustomFields = fields
+		}
+	} else {
+		err = s.invoices.CreateInvoice(invoice, opts.CreatedByID)
+	}
+	if err != nil {
+		if !errors.Is(err, ErrInvalidInvoice) && !errors.Is(err, ErrInvalidCustomField) {
+			return nil, err
+		}
+		issues = append(issues, ubl.Issue{Rule: ublRuleInvoice, Severity: ubl.SeverityError, Message: err.Error()})
+		report.UBLValidationReport = *newUBLReport(issues)
+		return report, nil
+	}
+
+	issues = append(issues, compareTotals(document, invoice)...)
+	report.UBLValidationReport = *newUBLReport(issues)
+	report.Invoice = invoice
+	return report, nil
+}
+
+// readDocument maps a valid UBL invoice onto an unsaved invoice
+func (s *ublService) readDocument(document *ubl.Invoice, opts UBLImportOptions) (*models.Invoice, string, []ubl.Issue, error) {
+	var issues []ubl.Issue
+	errorf := func(rule, format string, args ...interface{}) {
+		issues = append(issues, ubl.Issue{Rule: rule, Severity: ubl.SeverityError, Message: fmt.Sprintf(format, args...)})
+	}
+	warnf := func(rule, format string, args ...interface{}) {
+		issues = append(issues, ubl.Issue{Rule: rule, Severity: ubl.SeverityWarning, Message: fmt.Sprintf(format, args...)})
+	}
+
+	invoice := &models.Invoice{
+		Currency:       money.Currency(strings.TrimSpace(document.DocumentCurrencyCode)),
+		Notes:          strings.TrimSpace(strings.Join(document.Notes, "\n")),
+		ImportedNumber: strings.TrimSpace(document.ID),
+		CustomFields:   models.CustomFields{},
+	}
+	invoice.IssueDate, _ = ubl.ParseDate(document.IssueDate)
+	invoice.DueDate = invoice.IssueDate.AddDate(0, 0, 30)
+	if document.DueDate != "" {
+		invoice.DueDate, _ = ubl.ParseDate(document.DueDate)
+	}
+
+	customer, matchedBy, err := s.matchCustomer(&document.Customer.Party)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if customer == nil {
+		errorf(ublRuleCustomer, "no customer matches the buyer %q by Peppol ID, VAT identifier or email; create the customer first", document.Customer.Party.Name())
+	} else {
+		invoice.CustomerID = customer.ID
+	}
+
+	if seller, company := normalizeVATID(document.Supplier.Party.VATID()), normalizeVATID(s.settings.Issuer.TaxID); seller != "" && company != "" && seller != company {
+		warnf(ublRuleSeller, "the seller VAT identifier %s is not the company's %s", seller, company)
+	}
+
+	// The buyer reference is kept in its custom field unless it only repeats the number
+	reference := strings.TrimSpace(document.BuyerReference)
+	if reference != "" && reference != strings.TrimSpace(document.ID) {
+		definitions, err := s.customFields.ListDefinitions(models.TaggableInvoice)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		for _, definition := range definitions {
+			if definition.Key == s.settings.BuyerReferenceField {
+				invoice.CustomFields[definition.Key] = reference
+			}
+		}
+	}
+	for key, value := range opts.CustomFields {
+		invoice.CustomFields[key] = value
+	}
+
+	rates, err := s.taxRates.ListTaxRates()
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	for i := range document.Lines {
+		line := &document.Lines[i]
+		label := fmt.Sprintf("line %d", i+1)
+		item := models.InvoiceItem{Description: line.Item.Name}
+		if description := strings.TrimSpace(line.Item.Description); description != "" && description != item.Description {
+			item.Description += " - " + description
+		}
+
+		quantity, _ := ubl.ParseDecimal(line.InvoicedQuantity.Value)
+		if !quantity.IsInt() || quantity.Sign() <= 0 || !quantity.Num().IsInt64() || quantity.Num().Int64() > math.MaxInt32 {
+			errorf(ublRuleLine, "%s quantity %s must be a positive whole number", label, strings.TrimSpace(line.InvoicedQuantity.Value))
+			continue
+		}
+		item.Quantity = int(quantity.Num().Int64())
+
+		price, _ := ubl.ParseDecimal(line.Price.PriceAmount.Value)
+		if line.Price.BaseQuantity != nil {
+			base, _ := ubl.ParseDecimal(line.Price.BaseQuantity.Value)
+			price.Quo(price, base)
+		}
+		item.UnitPrice = money.FromRat(price, money.Scale, money.RoundHalfUp)
+		if item.UnitPrice.Rat().Cmp(price) != 0 {
+			warnf(ublRuleLine, "%s unit price was rounded to %s", label, item.UnitPrice)
+		}
+
+		discount := money.Zero
+		for _, entry := range line.AllowanceCharges {
+			value, _ := ubl.ParseDecimal(entry.Amount.Value)
+			if entry.IsCharge() {
+				errorf(ublRuleLine, "%s has a charge; charges on lines are not supported", label)
+				continue
+			}
+			discount = discount.Add(money.FromRat(value, money.Scale, money.RoundHalfUp))
+		}
+		if discount.IsPositive() {
+			item.DiscountType, item.DiscountValue = models.DiscountTypeFixed, discount
+		}
+
+		ids, ok := matchTaxRate(rates, line.Item.ClassifiedTaxCategory)
+		if !ok {
+			errorf(ublRuleTax, "%s is taxed at %s%% but there is no tax rate of that percentage that is neither compound nor inclusive", label, strings.TrimSpace(line.Item.ClassifiedTaxCategory.Percent))
+		}
+		item.TaxRateIDs = ids
+
+		// A zero price would be filled from the product, so such lines are not linked
+		if line.Item.SellersItemIdentification != nil && item.UnitPrice.IsPositive() {
+			if sku := strings.TrimSpace(line.Item.SellersItemIdentification.ID); sku != "" {
+				product, err := s.productRepo.FindBySKU(sku)
+				if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil, "", nil, fmt.Errorf("failed to get product: %w", err)
+				}
+				if err == nil && !product.DeletedAt.Valid {
+					item.ProductID = &product.ID
+				}
+			}
+		}
+		invoice.Items = append(invoice.Items, item)
+	}
+
+	discount := money.Zero
+	for i, entry := range document.AllowanceCharges {
+		value, _ := ubl.ParseDecimal(entry.Amount.Value)
+		amount := money.FromRat(value, money.Scale, money.RoundHalfUp)
+		if !entry.IsCharge() {
+			discount = discount.Add(amount)
+			continue
+		}
+		if percent, ok := ubl.ParseDecimal(entry.TaxCategory.Percent); ok && percent.Sign() > 0 {
+			errorf(ublRuleTax, "document charge %d is taxed; only untaxed charges such as shipping are supported", i+1)
+			continue
+		}
+		description := strings.TrimSpace(entry.Reason)
+		if description == "" {
+			description = "Charge " + strings.TrimSpace(entry.ReasonCode)
+		}
+		invoice.Charges = append(invoice.Charges, models.InvoiceCharge{Description: description, Amount: amount})
+	}
+	if discount.IsPositive() {
+		invoice.DiscountType, invoice.DiscountValue = models.DiscountTypeFixed, discount
+		// A percentage that reproduces the allowances spreads over the lines like the original
+		if total := document.LegalMonetaryTotal.LineExtensionAmount; total != nil {
+			if lines, ok := ubl.ParseDecimal(total.Value); ok && lines.Sign() > 0 {
+				rate := new(big.Rat).Quo(discount.Rat(), lines)
+				percentage := money.FromRat(rate.Mul(rate, big.NewRat(100, 1)), 2, money.RoundHalfUp)
+				exact := new(big.Rat).Mul(lines, new(big.Rat).Quo(percentage.Rat(), big.NewRat(100, 1)))
+				if money.FromRat(exact, 2, money.RoundHalfUp).Cmp(discount) == 0 {
+					invoice.DiscountType, invoice.DiscountValue = models.DiscountTypePercent, percentage
+				}
+			}
+		}
+	}
+
+	if prepaid := document.LegalMonetaryTotal.PrepaidAmount; prepaid != nil {
+		if value, ok := ubl.ParseDecimal(prepaid.Value); ok && value.Sign() > 0 {
+			warnf(ublRuleTotal, "the paid amount %s is not imported; record the payment once the invoice is sent", strings.TrimSpace(prepaid.Value))
+		}
+	}
+	return invoice, matchedBy, issues, nil
+}
+
+// matchCustomer finds the customer of a buyer by Peppol ID, then VAT identifier, then email
+func (s *ublService) matchCustomer(party *ubl.Party) (*models.Customer, string, error) {
+	var emails []string
+	if endpoint := party.EndpointID; endpoint != nil && strings.TrimSpace(endpoint.Value) != "" {
+		if strings.TrimSpace(endpoint.SchemeID) == ubl.EndpointSchemeEmail {
+			emails = append(emails, strings.TrimSpace(endpoint.Value))
+		} else {
+			customer, err := s.customerRepo.FindByPeppolID(endpoint.String())
+			if err == nil {
+				return customer, "peppol_id", nil
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, "", fmt.Errorf("failed to get customer: %w", err)
+			}
+		}
+	}
+	if vat := normalizeVATID(party.VATID()); vat != "" {
+		customer, err := s.customerRepo.FindByTaxID(vat)
+		if err == nil {
+			return customer, "tax_id", nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", fmt.Errorf("failed to get customer: %w", err)
+		}
+	}
+	if party.Contact != nil && strings.TrimSpace(party.Contact.ElectronicMail) != "" {
+		emails = append(emails, strings.TrimSpace(party.Contact.ElectronicMail))
+	}
+	for _, email := range emails {
+		customer, err := s.customerRepo.FindByEmail(email)
+		if err == nil && !customer.DeletedAt.Valid {
+			return customer, "email", nil
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", fmt.Errorf("failed to get customer: %w", err)
+		}
+	}
+	return nil, "", nil
+}
+
+// matchTaxRate returns the tax rates of a VAT category: a rate of the same percentage that
+// is neither compound nor inclusive, preferring a default rate, or none for categories
+// without tax. It reports false when a taxed category has no such rate.
+func matchTaxRate(rates []models.TaxRate, category *ubl.TaxCategory) (models.UintList, bool) {
+	percent, ok := ubl.ParseDecimal(category.Percent)
+	if !ok {
+		percent = new(big.Rat)
+	}
+	var match *models.TaxRate
+	for i := range rates {
+		rate := &rates[i]
+		if rate.Compound || rate.Inclusive || rate.Rate.Rat().Cmp(percent) != 0 {
+			continue
+		}
+		if match == nil || (rate.IsDefault && !match.IsDefault) {
+			match = rate
+		}
+	}
+	switch {
+	case match != nil && percent.Sign() > 0:
+		return models.UintList{match.ID}, true
+	case percent.Sign() > 0:
+		return models.UintList{}, false
+	case match != nil && strings.TrimSpace(category.ID) == ubl.CategoryZero:
+		return models.UintList{match.ID}, true
+	}
+	return models.UintList{}, true
+}
+
+// compareTotals warns when the imported invoice does not add up to the document's totals
+func compareTotals(document *ubl.Invoice, invoice *models.Invoice) []ubl.Issue {
+	var issues []ubl.Issue
+	compare := func(label string, expected *ubl.Amount, actual money.Amount) {
+		if expected == nil {
+			return
+		}
+		value, ok := ubl.ParseDecimal(expected.Value)
+		if ok && value.Cmp(actual.Rat()) != 0 {
+			issues = append(issues, ubl.Issue{Rule: ublRuleTotal, Severity: ubl.SeverityWarning,
+				Message: fmt.Sprintf("the %s is %s on the document but %s once imported", label, strings.TrimSpace(expected.Value), actual.Format(invoice.Currency))})
+		}
+	}
+	if len(document.TaxTotals) > 0 {
+		compare("VAT total", document.TaxTotals[0].TaxAmount, invoice.TaxAmount)
+	}
+	compare("total with VAT", document.LegalMonetaryTotal.TaxInclusiveAmount, invoice.Total)
+	return issues
+}
+
+// normalizeVATID uppercases a VAT identifier and drops its spaces
+func normalizeVATID(id string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(id), ""))
+}
+
+// newUBLReport splits issues into errors and warnings
+func newUBLReport(issues []ubl.Issue) *dtos.UBLValidationReport {
+	report := &dtos.UBLValidationReport{Errors: []ubl.Issue{}, Warnings: []ubl.Issue{}}
+	for _, issue := range issues {
+		if issue.Severity == ubl.SeverityError {
+			report.Errors = append(report.Errors, issue)
+		} else {
+			report.Warnings = append(report.Warnings, issue)
+		}
+	}
+	report.Valid = len(report.Errors) == 0
+	return report
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
+	"github.com/tacheraSasi/go-api-starter/internals/models"
+	"github.com/tacheraSasi/go-api-starter/internals/repositories"
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+	"github.com/tacheraSasi/go-api-starter/pkg/ubl"
+	"gorm.io/gorm"
+)
+
+// newTestUBLService wires a UBL service for a Dutch seller on top of invoices
+func newTestUBLService(db *gorm.DB, invoices InvoiceService) UBLService {
+	return NewUBLService(
+		repositories.NewInvoiceRepository(db),
+		repositories.NewCustomerRepository(db),
+		repositories.NewCustomerAddressRepository(db),
+		repositories.NewProductRepository(db),
+		invoices,
+		NewTaxRateService(repositories.NewTaxRateRepository(db)),
+		NewCustomFieldService(repositories.NewCustomFieldRepository(db)),
+		UBLSettings{
+			Issuer: InvoiceIssuer{
+				Name:    "Example B.V.",
+				Address: []string{"Damrak 1", "1012 LG Amsterdam"},
+				Email:   "invoices@example.com",
+				TaxID:   "NL123456789B01",
+			},
+			City:       "Amsterdam",
+			PostalCode: "1012 LG",
+			Country:    "NL",
+			Endpoint:   &ubl.Identifier{Value: "invoices@example.com", SchemeID: ubl.EndpointSchemeEmail},
+		},
+	)
+}
+
+// ublFixture stores a customer with a billing address, a 21% VAT rate and one invoice
+func ublFixture(t *testing.T, db *gorm.DB, invoices InvoiceService) *models.Invoice {
+	t.Helper()
+	customer := &models.Customer{Name: "Acme GmbH", Email: "ap@acme.example", Currency: "EUR", TaxID: "DE123456789"}
+	mustCreate(t, db, customer)
+	mustCreate(t, db, &models.CustomerAddress{CustomerID: customer.ID, Type: models.AddressTypeBilling, Line1: "Hauptstraße 5", City: "Berlin", PostalCode: "10115", Country: "DE", IsDefault: true})
+	vat := &models.TaxRate{Name: "VAT", Rate: money.MustParse("21")}
+	mustCreate(t, db, vat)
+
+	issued := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	invoice := &models.Invoice{
+		CustomerID:    customer.ID,
+		IssueDate:     issued,
+		DueDate:       issued.AddDate(0, 0, 14),
+		DiscountType:  models.DiscountTypePercent,
+		DiscountValue: money.MustParse("10"),
+		Notes:         "Thank you",
+		Items: []models.InvoiceItem{
+			{Description: "Consulting", Quantity: 8, UnitPrice: money.MustParse("95"), TaxRateIDs: models.UintList{vat.ID}},
+			{Description: "Licence", Quantity: 1, UnitPrice: money.MustParse("249.99"), TaxRateIDs: models.UintList{vat.ID}},
+		},
+		Charges: []models.InvoiceCharge{{Description: "Shipping", Amount: money.MustParse("12.50")}},
+	}
+	if err := invoices.CreateInvoice(invoice, nil); err != nil {
+		t.Fatalf("create invoice: %v", err)
+	}
+	return invoice
+}
+
+func countInvoices(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(&models.Invoice{}).Count(&count).Error; err != nil {
+		t.Fatalf("count invoices: %v", err)
+	}
+	return count
+}
+
+func hasIssue(issues []ubl.Issue, rule, text string) bool {
+	for _, issue := range issues {
+		if issue.Rule == rule && strings.Contains(issue.Message, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// An exported invoice validates, maps the seller address onto its own fields and imports
+// back into an invoice with the same customer, lines and totals
+func TestUBLExportImportRoundTrip(t *testing.T) {
+	db := newTestDB(t)
+	invoices := newTestInvoiceService(t, db)
+	service := newTestUBLService(db, invoices)
+	original := ublFixture(t, db, invoices)
+
+	_, data, report, err := service.ExportInvoice(original.ID)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if !report.Valid || len(report.Warnings) > 0 {
+		t.Fatalf("export report: errors %v, warnings %v", report.Errors, report.Warnings)
+	}
+
+	document, err := ubl.Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("parse export: %v", err)
+	}
+	if issues := ubl.Validate(document); len(issues) > 0 {
+		t.Errorf("parsed export breaks rules: %v", issues)
+	}
+	seller := document.Supplier.Party.PostalAddress
+	if seller.StreetName != "Damrak 1" || seller.AdditionalStreetName != "" || seller.CityName != "Amsterdam" || seller.PostalZone != "1012 LG" || seller.Country.IdentificationCode != "NL" {
+		t.Errorf("seller address = %+v; want street Damrak 1, city Amsterdam, postal zone 1012 LG in NL", *seller)
+	}
+	buyer := document.Customer.Party.PostalAddress
+	if buyer.StreetName != "Hauptstraße 5" || buyer.CityName != "Berlin" || buyer.PostalZone != "10115" {
+		t.Errorf("buyer address = %+v", *buyer)
+	}
+
+	imported, err := service.ImportInvoice(bytes.NewReader(data), UBLImportOptions{AllowDuplicate: true})
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if !imported.Valid || imported.Invoice == nil {
+		t.Fatalf("import report: errors %v", imported.Errors)
+	}
+	for _, warning := range imported.Warnings {
+		if warning.Rule == ublRuleTotal {
+			t.Errorf("totals differ after the round trip: %s", warning.Message)
+		}
+	}
+
+	copy := imported.Invoice
+	if copy.InvoiceNumber != "INV-2026-00002" || copy.ImportedNumber != original.InvoiceNumber {
+		t.Errorf("imported as %s from %s; want INV-2026-00002 from %s", copy.InvoiceNumber, copy.ImportedNumber, original.InvoiceNumber)
+	}
+	if copy.CustomerID != original.CustomerID || imported.CustomerMatchedBy != "tax_id" {
+		t.Errorf("customer %d matched by %q; want %d by tax_id", copy.CustomerID, imported.CustomerMatchedBy, original.CustomerID)
+	}
+	if len(copy.Items) != len(original.Items) || len(copy.Charges) != len(original.Charges) {
+		t.Errorf("%d lines and %d charges; want %d and %d", len(copy.Items), len(copy.Charges), len(original.Items), len(original.Charges))
+	}
+	for _, amount := range []struct {
+		name      string
+		got, want money.Amount
+	}{
+		{"subtotal", copy.Subtotal, original.Subtotal},
+		{"discount", copy.DiscountAmount, original.DiscountAmount},
+		{"tax", copy.TaxAmount, original.TaxAmount},
+		{"charges", copy.ChargesTotal, original.ChargesTotal},
+		{"total", copy.Total, original.Total},
+	} {
+		if amount.got != amount.want {
+			t.Errorf("%s = %s; want %s", amount.name, amount.got, amount.want)
+		}
+	}
+}
+
+// Importing a document twice is refused unless duplicates are allowed, and allowed again
+// once the earlier invoice is void
+func TestUBLImportRejectsDuplicates(t *testing.T) {
+	db := newTestDB(t)
+	invoices := newTestInvoiceService(t, db)
+	service := newTestUBLService(db, invoices)
+	original := ublFixture(t, db, invoices)
+
+	_, data, _, err := service.ExportInvoice(original.ID)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	importDocument := func(data []byte, opts UBLImportOptions) *dtos.UBLImportReport {
+		t.Helper()
+		report, err := service.ImportInvoice(bytes.NewReader(data), opts)
+		if err != nil {
+			t.Fatalf("import: %v", err)
+		}
+		return report
+	}
+
+	// The export of an existing invoice is that invoice
+	report := importDocument(data, UBLImportOptions{})
+	if report.Valid || !hasIssue(report.Errors, ublRuleInvoice, "invoice "+original.InvoiceNumber+" already exists") {
+		t.Errorf("import of an exported invoice: valid %v, errors %v", report.Valid, report.Errors)
+	}
+
+	// A document from elsewhere is refused the second time by its imported number
+	external := bytes.Replace(data, []byte("<cbc:ID>"+original.InvoiceNumber+"</cbc:ID>"), []byte("<cbc:ID>EXT-7</cbc:ID>"), 1)
+	first := importDocument(external, UBLImportOptions{})
+	if !first.Valid {
+		t.Fatalf("first import of EXT-7: %v", first.Errors)
+	}
+	second := importDocument(external, UBLImportOptions{})
+	if second.Valid || !hasIssue(second.Errors, ublRuleInvoice, "document EXT-7 was already imported as invoice "+first.Invoice.InvoiceNumber) {
+		t.Errorf("second import of EXT-7: valid %v, errors %v", second.Valid, second.Errors)
+	}
+	if dryRun := importDocument(external, UBLImportOptions{DryRun: true}); dryRun.Valid {
+		t.Error("a dry run of a duplicate reported it as importable")
+	}
+	if count := countInvoices(t, db); count != 2 {
+		t.Fatalf("%d invoices after the refused imports; want 2", count)
+	}
+
+	allowed := importDocument(external, UBLImportOptions{AllowDuplicate: true})
+	if !allowed.Valid || !hasIssue(allowed.Warnings, ublRuleInvoice, "the import creates another invoice") {
+		t.Errorf("import with allow_duplicate: valid %v, errors %v, warnings %v", allowed.Valid, allowed.Errors, allowed.Warnings)
+	}
+
+	// Once the imports are void, the document can be imported again
+	for _, invoice := range []*models.Invoice{first.Invoice, allowed.Invoice} {
+		if _, err := invoices.VoidInvoice(invoice.ID, nil, "imported twice"); err != nil {
+			t.Fatalf("void %s: %v", invoice.InvoiceNumber, err)
+		}
+	}
+	if again := importDocument(external, UBLImportOptions{}); !again.Valid {
+		t.Errorf("import after voiding the earlier imports: %v", again.Errors)
+	}
+}
+
+// Without COMPANY_CITY the seller's city line stays in the street and the export says so
+func TestUBLExportWarnsWithoutCompanyCity(t *testing.T) {
+	db := newTestDB(t)
+	invoices := newTestInvoiceService(t, db)
+	original := ublFixture(t, db, invoices)
+	service := newTestUBLService(db, invoices).(*ublService)
+	service.settings.City, service.settings.PostalCode = "", ""
+
+	_, _, report, err := service.ExportInvoice(original.ID)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if !hasIssue(report.Warnings, ublRuleSeller, "COMPANY_CITY") {
+		t.Errorf("warnings %v; want one about COMPANY_CITY", report.Warnings)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/tacheraSasi/go-api-starter/internals/dtos"
 	"github.com/tacheraSasi/go-api-starter/internals/models"
 	"github.com/tacheraSasi/go-api-starter/internals/repositories"
 	"gorm.io/gorm"
@@ -38,7 +39,6 @@ func (s *UserService) CreateUser(name, email, password string) (*models.User, er
 		Email:    email,
 		Password: password,
 		IsActive: true,
-		Role:     models.RoleUser, // Legacy field
 	}
 
 	// Hash password
@@ -279,6 +279,87 @@ func (s *UserService) CheckUserPermission(userID, resource, action string) (bool
 	return user.HasPermission(resource, action), nil
 }
 
+// CheckUserPermissions evaluates many resource/action pairs for a user in one call
+func (s *UserService) CheckUserPermissions(userID string, checks []dtos.PermissionCheckRequest) (*dtos.BatchPermissionCheckResponse, error) {
+	user, err := s.GetUserWithRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dtos.BatchPermissionCheckResponse{
+		UserID:  user.ID,
+		Results: make([]models.PermissionDecision, 0, len(checks)),
+	}
+	for _, check := range checks {
+		response.Results = append(response.Results, user.ExplainPermission(check.Resource, check.Action))
+	}
+
+	return response, nil
+}
+
+// ExplainUserPermissions lists a user's effective permissions with the roles they
+// come from. When resource and action are given the check is explained as well.
+func (s *UserService) ExplainUserPermissions(userID, resource, action string) (*dtos.PermissionExplainResponse, error) {
+	user, err := s.GetUserWithRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &dtos.PermissionExplainResponse{
+		UserID:        user.ID,
+		UserActive:    user.IsActive,
+		ActiveRoles:   []string{},
+		InactiveRoles: []string{},
+		Permissions:   user.GetEffectivePermissions(),
+	}
+	for _, role := range user.Roles {
+		if role.IsActive {
+			response.ActiveRoles = append(response.ActiveRoles, role.Name)
+		} else {
+			response.InactiveRoles = append(response.InactiveRoles, role.Name)
+		}
+	}
+	if resource != "" && action != "" {
+		decision := user.ExplainPermission(resource, action)
+		response.Decision = &decision
+	}
+
+	return response, nil
+}
+
+// MigrateLegacyRoles converts the legacy User.Role column into user_roles rows
+// and clears it. Users whose legacy role does not exist are left untouched.
+// It is safe to run repeatedly and returns the number of users migrated.
+func (s *UserService) MigrateLegacyRoles() (int, error) {
+	users, err := s.userRepo.ListUsersWithLegacyRole()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list users with legacy roles: %w", err)
+	}
+
+	migrated := 0
+	for _, user := range users {
+		role, err := s.roleRepo.GetByName(user.Role)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return migrated, fmt.Errorf("failed to get role %s: %w", user.Role, err)
+		}
+
+		if !user.HasRole(role.Name) {
+			if err := s.userRepo.AddRoleToUser(user.ID, role.ID); err != nil {
+				return migrated, fmt.Errorf("failed to assign role %s to user %d: %w", role.Name, user.ID, err)
+			}
+		}
+		if err := s.userRepo.ClearLegacyRole(user.ID); err != nil {
+			return migrated, fmt.Errorf("failed to clear legacy role of user %d: %w", user.ID, err)
+		}
+		migrated++
+	}
+
+	return migrated, nil
+}
+
 // UpdateLastLogin updates the user's last login timestamp
 func (s *UserService) UpdateLastLogin(userID string) error {
 	// Convert userID string to uint
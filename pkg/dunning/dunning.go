@@ -0,0 +1,254 @@
+// Package dunning decides which payment reminder an unpaid invoice is due for and renders
+// it. A schedule lists reminder days relative to the due date, such as "-3,0,7,14"; each
+// step uses a template whose tone escalates from a friendly heads-up to a final notice.
+package dunning
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Level names the template of a reminder
+type Level string
+
+const (
+	// LevelUpcoming reminds the customer of an invoice that falls due soon
+	LevelUpcoming Level = "upcoming"
+	// LevelDue reminds the customer that an invoice is due today
+	LevelDue Level = "due"
+	// LevelOverdue asks the customer to pay an invoice past its due date
+	LevelOverdue Level = "overdue"
+	// LevelFinal is the last reminder of a schedule
+	LevelFinal Level = "final"
+)
+
+// Levels lists every template level from the mildest to the most insistent
+var Levels = []Level{LevelUpcoming, LevelDue, LevelOverdue, LevelFinal}
+
+// Off disables reminders when used as a schedule
+const Off = "off"
+
+// maxDays bounds how far before or after the due date a step can be
+const maxDays = 365
+
+// Step is one reminder of a schedule
+type Step struct {
+	Days  int   // days after the due date; negative days are before it
+	Level Level // template of the reminder
+}
+
+// Schedule lists the reminders of an invoice in order of their day. An empty schedule
+// sends no reminders.
+type Schedule []Step
+
+// ParseSchedule reads a comma separated list of days relative to the due date, each
+// optionally followed by :level, e.g. "-3,0,7,14" or "-5:upcoming,10:final". Without a
+// level, steps before the due date are upcoming, on the due date due and after it overdue,
+// except that the last of two or more overdue steps is final. "off" disables reminders.
+func ParseSchedule(s string) (Schedule, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, Off) {
+		return nil, nil
+	}
+
+	var schedule Schedule
+	explicit := map[int]bool{}
+	seen := map[int]bool{}
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		rawDays, rawLevel, hasLevel := strings.Cut(field, ":")
+		days, err := strconv.Atoi(strings.TrimSpace(rawDays))
+		if err != nil {
+			return nil, fmt.Errorf("invalid step %q: days must be a whole number", field)
+		}
+		if days < -maxDays || days > maxDays {
+			return nil, fmt.Errorf("invalid step %q: days must be between -%d and %d", field, maxDays, maxDays)
+		}
+		if seen[days] {
+			return nil, fmt.Errorf("day %d appears more than once", days)
+		}
+		seen[days] = true
+
+		step := Step{Days: days, Level: defaultLevel(days)}
+		if hasLevel {
+			level, err := ParseLevel(strings.TrimSpace(rawLevel))
+			if err != nil {
+				return nil, fmt.Errorf("invalid step %q: %w", field, err)
+			}
+			step.Level = level
+			explicit[days] = true
+		}
+		schedule = append(schedule, step)
+	}
+	sort.Slice(schedule, func(i, j int) bool { return schedule[i].Days < schedule[j].Days })
+
+	last := len(schedule) - 1
+	if !explicit[schedule[last].Days] && last > 0 &&
+		schedule[last].Level == LevelOverdue && schedule[last-1].Level == LevelOverdue {
+		schedule[last].Level = LevelFinal
+	}
+	return schedule, nil
+}
+
+// ParseLevel reads a template level
+func ParseLevel(s string) (Level, error) {
+	for _, level := range Levels {
+		if Level(s) == level {
+			return level, nil
+		}
+	}
+	return "", fmt.Errorf("unknown level %q (expected upcoming, due, overdue or final)", s)
+}
+
+func defaultLevel(days int) Level {
+	switch {
+	case days < 0:
+		return LevelUpcoming
+	case days == 0:
+		return LevelDue
+	default:
+		return LevelOverdue
+	}
+}
+
+// Current returns the latest step whose day has come by now for an invoice due on due,
+// and false when the first step is still ahead, or when the latest step is on or before
+// the due date and that has passed, since such a reminder would be out of date. Days are
+// whole UTC calendar days, so a step 7 days after the due date starts at midnight a week
+// after it.
+func (s Schedule) Current(due, now time.Time) (Step, bool) {
+	today := day(now)
+	for i := len(s) - 1; i >= 0; i-- {
+		if today.Before(s.Date(s[i], due)) {
+			continue
+		}
+		if s[i].Days <= 0 && today.After(day(due)) {
+			return Step{}, false
+		}
+		return s[i], true
+	}
+	return Step{}, false
+}
+
+// Date returns the day a step is sent for an invoice due on due
+func (s Schedule) Date(step Step, due time.Time) time.Time {
+	return day(due).AddDate(0, 0, step.Days)
+}
+
+// String returns the schedule with the level of every step, or "off" when it is empty
+func (s Schedule) String() string {
+	if len(s) == 0 {
+		return Off
+	}
+	steps := make([]string, len(s))
+	for i, step := range s {
+		steps[i] = strconv.Itoa(step.Days) + ":" + string(step.Level)
+	}
+	return strings.Join(steps, ",")
+}
+
+func day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Data is what reminder templates can refer to
+type Data struct {
+	CompanyName         string
+	CompanyEmail        string
+	CustomerName        string
+	InvoiceNumber       string
+	IssueDate           string
+	DueDate             string
+	Total               string // with the currency, e.g. 1250.00 USD
+	AmountDue           string
+	Days                int // days until the due date before it, days overdue after it
+	PaymentInstructions string
+}
+
+// Templates renders the subject and body of each level
+type Templates struct {
+	subjects map[Level]*template.Template
+	bodies   map[Level]*template.Template
+}
+
+// DefaultTemplates returns the built-in English templates
+func DefaultTemplates() *Templates {
+	t := &Templates{subjects: map[Level]*template.Template{}, bodies: map[Level]*template.Template{}}
+	for level, text := range defaultTemplates {
+		if err := t.set(level, text); err != nil {
+			panic(fmt.Sprintf("dunning: default %s template: %v", level, err))
+		}
+	}
+	return t
+}
+
+// LoadTemplates returns the built-in templates, replacing those of which dir holds a file
+// named after the level, such as overdue.txt. A template file starts with a
+// "Subject: ..." line followed by an empty line and the body, and uses text/template
+// syntax with the fields of Data, e.g. {{.InvoiceNumber}}.
+func LoadTemplates(dir string) (*Templates, error) {
+	t := DefaultTemplates()
+	for _, level := range Levels {
+		path := filepath.Join(dir, string(level)+".txt")
+		text, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := t.set(level, string(text)); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return t, nil
+}
+
+func (t *Templates) set(level Level, text string) error {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	header, body, _ := strings.Cut(text, "\n")
+	subject, ok := strings.CutPrefix(header, "Subject:")
+	if !ok {
+		return errors.New(`the first line must start with "Subject:"`)
+	}
+	body = strings.TrimPrefix(body, "\n")
+
+	subjectTemplate, err := template.New(string(level) + " subject").Parse(strings.TrimSpace(subject))
+	if err != nil {
+		return err
+	}
+	bodyTemplate, err := template.New(string(level)).Parse(body)
+	if err != nil {
+		return err
+	}
+	t.subjects[level] = subjectTemplate
+	t.bodies[level] = bodyTemplate
+	return nil
+}
+
+// Render returns the subject and body of a reminder
+func (t *Templates) Render(level Level, data Data) (subject, body string, err error) {
+	subjectTemplate, ok := t.subjects[level]
+	if !ok {
+		return "", "", fmt.Errorf("no template for level %q", level)
+	}
+	var b strings.Builder
+	if err := subjectTemplate.Execute(&b, data); err != nil {
+		return "", "", err
+	}
+	subject = strings.Join(strings.Fields(b.String()), " ")
+
+	b.Reset()
+	if err := t.bodies[level].Execute(&b, data); err != nil {
+		return "", "", err
+	}
+	return subject, b.String(), nil
+}
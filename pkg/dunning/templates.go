@@ -0,0 +1,58 @@
+package dunning
+
+var defaultTemplates = map[Level]string{
+	LevelUpcoming: `Subject: Invoice {{.InvoiceNumber}} is due on {{.DueDate}}
+
+Hello {{.CustomerName}},
+
+This is a friendly reminder that invoice {{.InvoiceNumber}} of {{.IssueDate}} falls due in {{.Days}} day(s), on {{.DueDate}}. The amount due is {{.AmountDue}}.
+{{with .PaymentInstructions}}
+{{.}}
+{{end}}
+If you have already paid, please disregard this message.
+
+Kind regards,
+{{or .CompanyName "Accounts receivable"}}
+`,
+	LevelDue: `Subject: Invoice {{.InvoiceNumber}} is due today
+
+Hello {{.CustomerName}},
+
+Invoice {{.InvoiceNumber}} of {{.IssueDate}} is due today, {{.DueDate}}. The amount due is {{.AmountDue}}.
+{{with .PaymentInstructions}}
+{{.}}
+{{end}}
+If you have already paid, please disregard this message.
+
+Kind regards,
+{{or .CompanyName "Accounts receivable"}}
+`,
+	LevelOverdue: `Subject: Invoice {{.InvoiceNumber}} is overdue
+
+Hello {{.CustomerName}},
+
+Our records show that invoice {{.InvoiceNumber}}, due on {{.DueDate}}, is now {{.Days}} day(s) overdue. The outstanding amount is {{.AmountDue}}.
+
+Please arrange payment at your earliest convenience.
+{{with .PaymentInstructions}}
+{{.}}
+{{end}}
+If you have already paid, thank you, and please disregard this message.{{with .CompanyEmail}} If you have questions about this invoice, contact us at {{.}}.{{end}}
+
+Kind regards,
+{{or .CompanyName "Accounts receivable"}}
+`,
+	LevelFinal: `Subject: Final notice: invoice {{.InvoiceNumber}} is {{.Days}} days overdue
+
+Hello {{.CustomerName}},
+
+Invoice {{.InvoiceNumber}}, due on {{.DueDate}}, remains unpaid and is now {{.Days}} days overdue. The outstanding amount is {{.AmountDue}}.
+
+This is our final reminder. Please pay the outstanding amount immediately, or contact us{{with .CompanyEmail}} at {{.}}{{end}} if there is a problem with this invoice.
+{{with .PaymentInstructions}}
+{{.}}
+{{end}}
+Regards,
+{{or .CompanyName "Accounts receivable"}}
+`,
+}
@@ -0,0 +1,159 @@
+// Package mailer sends plain text email through an SMTP server, or writes it to the log
+// when no server is configured so development setups need no mail server.
+package mailer
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message is one plain text email
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Mailer sends messages
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Config holds the settings of an SMTP server
+type Config struct {
+	Host     string
+	Port     int // 465 uses implicit TLS; other ports upgrade with STARTTLS when offered
+	Username string
+	Password string
+	From     string // address, optionally with a name: Billing <billing@example.com>
+}
+
+// New returns an SMTP mailer, or a mailer that logs messages when cfg has no host
+func New(cfg Config) (Mailer, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %w", cfg.From, err)
+	}
+	if cfg.Host == "" {
+		return &logMailer{from: from}, nil
+	}
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		return nil, fmt.Errorf("invalid SMTP port %d", cfg.Port)
+	}
+	return &smtpMailer{cfg: cfg, from: from}, nil
+}
+
+type smtpMailer struct {
+	cfg  Config
+	from *mail.Address
+}
+
+func (m *smtpMailer) Send(msg Message) error {
+	data, err := compose(m.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	var conn net.Conn
+	if m.cfg.Port == 465 {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", addr, &tls.Config{ServerName: m.cfg.Host})
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, 30*time.Second)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(time.Minute))
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && m.cfg.Port != 465 {
+		if err := client.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if m.cfg.Username != "" {
+		// PlainAuth refuses to send the password over a connection without TLS, except to localhost
+		if err := client.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+	if err := client.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("sender rejected: %w", err)
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+	return client.Quit()
+}
+
+type logMailer struct {
+	from *mail.Address
+}
+
+func (m *logMailer) Send(msg Message) error {
+	if _, err := compose(m.from, msg, time.Now()); err != nil {
+		return err
+	}
+	log.Printf("Email to %s: %s\n%s", strings.Join(msg.To, ", "), msg.Subject, msg.Body)
+	return nil
+}
+
+// compose renders a message with its headers, using CRLF line endings
+func compose(from *mail.Address, msg Message, date time.Time) ([]byte, error) {
+	if len(msg.To) == 0 {
+		return nil, errors.New("message has no recipients")
+	}
+	to := make([]string, len(msg.To))
+	for i, addr := range msg.To {
+		parsed, err := mail.ParseAddress(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", addr, err)
+		}
+		to[i] = parsed.String()
+	}
+
+	var b strings.Builder
+	b.WriteString("From: " + from.String() + "\r\n")
+	b.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	// The SMTP data writer escapes lines starting with a dot
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	for _, line := range strings.Split(body, "\n") {
+		b.WriteString(line + "\r\n")
+	}
+	return []byte(b.String()), nil
+}
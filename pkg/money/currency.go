@@ -0,0 +1,43 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Currency is an ISO 4217 alphabetic currency code such as "USD"
+type Currency string
+
+// minorUnitExceptions lists currencies whose minor unit is not two decimal places
+var minorUnitExceptions = map[Currency]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// ParseCurrency normalises and checks a three letter currency code
+func ParseCurrency(code string) (Currency, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", fmt.Errorf("invalid currency code %q", code)
+	}
+	for i := 0; i < len(code); i++ {
+		if code[i] < 'A' || code[i] > 'Z' {
+			return "", fmt.Errorf("invalid currency code %q", code)
+		}
+	}
+	return Currency(code), nil
+}
+
+// Digits returns the number of decimal places of the currency's minor unit
+func (c Currency) Digits() int {
+	if digits, ok := minorUnitExceptions[c]; ok {
+		return digits
+	}
+	return 2
+}
+
+func (c Currency) String() string {
+	return string(c)
+}
@@ -0,0 +1,412 @@
+// Package money provides an exact fixed-point decimal type for monetary amounts.
+//
+// An Amount stores a value as an integer number of 1/10000 units, so additions and
+// multiplications by quantities are exact and rounding only happens where it is
+// asked for, with an explicit RoundingMode.
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Scale is the number of decimal places an Amount can hold
+const Scale = 4
+
+const unit = 10000 // 10^Scale
+
+var (
+	ErrInvalidAmount = errors.New("invalid amount")
+	ErrPrecision     = errors.New("too many decimal places")
+	ErrOverflow      = errors.New("amount out of range")
+)
+
+// Amount is a signed decimal with Scale fractional digits. The zero value is 0.
+type Amount struct {
+	units int64
+}
+
+// Zero is the zero amount
+var Zero = Amount{}
+
+// MaxAmount is the largest amount a price, payment or document total may hold: one
+// trillion. Input is checked against it so stored amounts stay far inside the range of
+// Amount and thousands of them can still be added up.
+var MaxAmount = FromInt(1_000_000_000_000)
+
+// FromInt returns the amount for a whole number
+func FromInt(n int64) Amount {
+	return Amount{units: mulInt64(n, unit)}
+}
+
+// FromUnits returns the amount for a number of 1/10000 units
+func FromUnits(units int64) Amount {
+	return Amount{units: units}
+}
+
+// Parse reads a plain decimal such as "12", "-0.5" or "1234.5678". More than
+// Scale fractional digits is an error rather than being silently rounded.
+func Parse(s string) (Amount, error) {
+	return parse(s, false)
+}
+
+// MustParse is like Parse but panics on error; meant for constants
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// parse reads a decimal; with roundExcess, extra fractional digits are rounded half up
+// instead of rejected, which is used when scanning floating point database values
+func parse(s string, roundExcess bool) (Amount, error) {
+	units, err := parseUnits(s, Scale, roundExcess)
+	if err != nil {
+		return Zero, err
+	}
+	return Amount{units: units}, nil
+}
+
+// parseUnits reads a plain decimal as an integer number of 10^-scale units
+func parseUnits(s string, scale int, roundExcess bool) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("%w: empty", ErrInvalidAmount)
+	}
+	input := s
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if (whole == "" && frac == "") || !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, input)
+	}
+
+	roundUp := false
+	if len(frac) > scale {
+		excess := frac[scale:]
+		if !roundExcess && strings.Trim(excess, "0") != "" {
+			return 0, fmt.Errorf("%w in %q (at most %d)", ErrPrecision, input, scale)
+		}
+		roundUp = excess[0] >= '5'
+		frac = frac[:scale]
+	}
+	frac += strings.Repeat("0", scale-len(frac))
+
+	// Digits are accumulated as a magnitude, which may reach 2^63 for negative values
+	limit := uint64(math.MaxInt64)
+	if negative {
+		limit++
+	}
+	var units uint64
+	for _, digits := range []string{whole, frac} {
+		for _, d := range digits {
+			digit := uint64(d - '0')
+			if units > (limit-digit)/10 {
+				return 0, ErrOverflow
+			}
+			units = units*10 + digit
+		}
+	}
+	if roundUp {
+		if units == limit {
+			return 0, ErrOverflow
+		}
+		units++
+	}
+	if negative {
+		return int64(-units), nil // two's complement, exact for 2^63 too
+	}
+	return int64(units), nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Units returns the amount as a number of 1/10000 units
+func (a Amount) Units() int64 { return a.units }
+
+// Add returns a + b
+func (a Amount) Add(b Amount) Amount {
+	sum := a.units + b.units
+	if (sum > a.units) != (b.units > 0) {
+		panic(ErrOverflow)
+	}
+	return Amount{units: sum}
+}
+
+// Sub returns a - b
+func (a Amount) Sub(b Amount) Amount {
+	return a.Add(b.Neg())
+}
+
+// Neg returns -a
+func (a Amount) Neg() Amount {
+	if a.units == math.MinInt64 {
+		panic(ErrOverflow)
+	}
+	return Amount{units: -a.units}
+}
+
+// Abs returns |a|
+func (a Amount) Abs() Amount {
+	if a.units < 0 {
+		return a.Neg()
+	}
+	return a
+}
+
+// MulInt returns a * n, which is always exact
+func (a Amount) MulInt(n int64) Amount {
+	return Amount{units: mulInt64(a.units, n)}
+}
+
+// Mul returns a * b rounded back to Scale digits with the given mode
+func (a Amount) Mul(b Amount, mode RoundingMode) Amount {
+	product := new(big.Int).Mul(big.NewInt(a.units), big.NewInt(b.units))
+	return Amount{units: divRound(product, big.NewInt(unit), mode)}
+}
+
+// Div returns a / b rounded to Scale digits with the given mode. It panics when b is zero.
+func (a Amount) Div(b Amount, mode RoundingMode) Amount {
+	if b.units == 0 {
+		panic("money: division by zero")
+	}
+	numerator := new(big.Int).Mul(big.NewInt(a.units), big.NewInt(unit))
+	return Amount{units: divRound(numerator, big.NewInt(b.units), mode)}
+}
+
+// Round rounds a to the given number of decimal places (0 to Scale) with the given mode
+func (a Amount) Round(places int, mode RoundingMode) Amount {
+	if places >= Scale {
+		return a
+	}
+	if places < 0 {
+		places = 0
+	}
+	step := int64(math.Pow10(Scale - places))
+	units := divRound(big.NewInt(a.units), big.NewInt(step), mode)
+	return Amount{units: mulInt64(units, step)}
+}
+
+// RoundTo rounds a to the minor unit of the currency
+func (a Amount) RoundTo(currency Currency, mode RoundingMode) Amount {
+	return a.Round(currency.Digits(), mode)
+}
+
+// Rat returns the exact value of a as a rational number
+func (a Amount) Rat() *big.Rat {
+	return new(big.Rat).SetFrac64(a.units, unit)
+}
+
+// FromRat rounds a rational number to the given number of decimal places (0 to Scale)
+// with the given mode. Rounding once avoids the double rounding of Round after Mul.
+func FromRat(r *big.Rat, places int, mode RoundingMode) Amount {
+	if places > Scale {
+		places = Scale
+	}
+	if places < 0 {
+		places = 0
+	}
+	step := int64(math.Pow10(Scale - places))
+	numerator := new(big.Int).Mul(r.Num(), big.NewInt(unit))
+	denominator := new(big.Int).Mul(r.Denom(), big.NewInt(step))
+	return Amount{units: mulInt64(divRound(numerator, denominator, mode), step)}
+}
+
+// Cmp returns -1, 0 or +1 depending on whether a is less than, equal to or greater than b
+func (a Amount) Cmp(b Amount) int {
+	switch {
+	case a.units < b.units:
+		return -1
+	case a.units > b.units:
+		return 1
+	}
+	return 0
+}
+
+// Sign returns -1, 0 or +1
+func (a Amount) Sign() int { return a.Cmp(Zero) }
+
+// WithinLimit reports whether a lies between -MaxAmount and MaxAmount
+func (a Amount) WithinLimit() bool {
+	return a.units >= -MaxAmount.units && a.units <= MaxAmount.units
+}
+
+// IsZero reports whether a is 0
+func (a Amount) IsZero() bool { return a.units == 0 }
+
+// IsNegative reports whether a is below 0
+func (a Amount) IsNegative() bool { return a.units < 0 }
+
+// IsPositive reports whether a is above 0
+func (a Amount) IsPositive() bool { return a.units > 0 }
+
+// Min returns the smaller of a and b
+func Min(a, b Amount) Amount {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+// Max returns the larger of a and b
+func Max(a, b Amount) Amount {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+// Sum adds up the amounts
+func Sum(amounts ...Amount) Amount {
+	total := Zero
+	for _, a := range amounts {
+		total = total.Add(a)
+	}
+	return total
+}
+
+// String formats the amount with at least two and at most Scale decimal places
+func (a Amount) String() string {
+	s := a.StringFixed(Scale)
+	trimmed := strings.TrimRight(s, "0")
+	if dot := strings.IndexByte(s, '.'); len(trimmed) < dot+3 {
+		return s[:dot+3]
+	}
+	return trimmed
+}
+
+// StringFixed formats the amount with exactly the given number of decimal places,
+// rounding half up when places is below Scale
+func (a Amount) StringFixed(places int) string {
+	if places > Scale {
+		places = Scale
+	}
+	if places < 0 {
+		places = 0
+	}
+	return formatUnits(a.Round(places, RoundHalfUp).units, Scale, places)
+}
+
+// formatUnits formats a number of 10^-scale units with the first places decimals
+func formatUnits(units int64, scale, places int) string {
+	sign := ""
+	if units < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absUint64(units), 10)
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	whole, frac := digits[:len(digits)-scale], digits[len(digits)-scale:]
+	if places == 0 {
+		return sign + whole
+	}
+	return sign + whole + "." + frac[:places]
+}
+
+// Format formats the amount in the minor unit of the currency, e.g. "12.50" for USD
+func (a Amount) Format(currency Currency) string {
+	return a.StringFixed(currency.Digits())
+}
+
+// MarshalJSON encodes the amount as a JSON string so clients do not parse it as a float
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON accepts a JSON string or number holding a plain decimal
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := strings.TrimSpace(string(data))
+	if text == "null" {
+		*a = Zero
+		return nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+	parsed, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Value stores the amount as an exact decimal string, which decimal columns accept on
+// SQLite, Postgres and MySQL
+func (a Amount) Value() (driver.Value, error) {
+	return a.StringFixed(Scale), nil
+}
+
+// Scan reads a decimal column. Postgres and MySQL return the exact text; SQLite
+// returns an integer or a float, which is converted through its shortest decimal form.
+func (a *Amount) Scan(value interface{}) error {
+	var (
+		parsed Amount
+		err    error
+	)
+	switch v := value.(type) {
+	case nil:
+		parsed = Zero
+	case int64:
+		parsed = FromInt(v)
+	case float64:
+		parsed, err = parse(strconv.FormatFloat(v, 'f', -1, 64), true)
+	case []byte:
+		parsed, err = parse(string(v), true)
+	case string:
+		parsed, err = parse(v, true)
+	default:
+		return fmt.Errorf("money: cannot scan %T into Amount", value)
+	}
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// GormDataType makes decimal the default column type for amounts
+func (Amount) GormDataType() string {
+	return "decimal(19,4)"
+}
+
+func mulInt64(a, b int64) int64 {
+	product := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+	if !product.IsInt64() {
+		panic(ErrOverflow)
+	}
+	return product.Int64()
+}
+
+func absUint64(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
@@ -0,0 +1,276 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+var roundingModes = []RoundingMode{RoundHalfUp, RoundHalfEven, RoundHalfDown, RoundDown, RoundUp}
+
+// small generates amounts within ±2^60 units, so the sum of three never leaves the range
+type small struct{ Amount }
+
+func (small) Generate(r *rand.Rand, _ int) reflect.Value {
+	return reflect.ValueOf(small{Amount{units: r.Int63n(1<<61) - 1<<60}})
+}
+
+// anyAmount generates amounts over the whole range, biased towards its ends
+type anyAmount struct{ Amount }
+
+func (anyAmount) Generate(r *rand.Rand, _ int) reflect.Value {
+	units := int64(r.Uint64())
+	switch r.Intn(4) {
+	case 0:
+		units = math.MaxInt64 - r.Int63n(1000)
+	case 1:
+		units = math.MinInt64 + r.Int63n(1000)
+	}
+	return reflect.ValueOf(anyAmount{Amount{units: units}})
+}
+
+// overflows runs f and reports whether it panicked with ErrOverflow
+func overflows(t *testing.T, f func()) (overflowed bool) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			err, ok := r.(error)
+			if !ok || !errors.Is(err, ErrOverflow) {
+				t.Fatalf("unexpected panic: %v", r)
+			}
+			overflowed = true
+		}
+	}()
+	f()
+	return false
+}
+
+func check(t *testing.T, property interface{}) {
+	t.Helper()
+	if err := quick.Check(property, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	check(t, func(a anyAmount) bool {
+		parsed, err := Parse(a.StringFixed(Scale))
+		return err == nil && parsed == a.Amount
+	})
+	check(t, func(a anyAmount) bool {
+		parsed, err := Parse(a.String())
+		return err == nil && parsed == a.Amount
+	})
+	check(t, func(a anyAmount) bool {
+		data, err := json.Marshal(a.Amount)
+		if err != nil {
+			return false
+		}
+		var decoded Amount
+		return json.Unmarshal(data, &decoded) == nil && decoded == a.Amount
+	})
+	check(t, func(a anyAmount, mode uint8) bool {
+		return FromRat(a.Rat(), Scale, roundingModes[int(mode)%len(roundingModes)]) == a.Amount
+	})
+}
+
+func TestParse(t *testing.T) {
+	valid := map[string]int64{
+		"12":                    120000,
+		"-0.5":                  -5000,
+		"+1.25":                 12500,
+		".5":                    5000,
+		"3.":                    30000,
+		"-0":                    0,
+		"922337203685477.5807":  math.MaxInt64,
+		"-922337203685477.5808": math.MinInt64,
+	}
+	for input, units := range valid {
+		if a, err := Parse(input); err != nil || a.Units() != units {
+			t.Errorf("Parse(%q) = %d, %v; want %d", input, a.Units(), err, units)
+		}
+	}
+
+	invalid := map[string]error{
+		"":                      ErrInvalidAmount,
+		"1.2.3":                 ErrInvalidAmount,
+		"1e5":                   ErrInvalidAmount,
+		".":                     ErrInvalidAmount,
+		"0.00001":               ErrPrecision,
+		"922337203685477.5808":  ErrOverflow,
+		"-922337203685477.5809": ErrOverflow,
+		"99999999999999999999":  ErrOverflow,
+	}
+	for input, want := range invalid {
+		if _, err := Parse(input); !errors.Is(err, want) {
+			t.Errorf("Parse(%q) error = %v; want %v", input, err, want)
+		}
+	}
+
+	// Rounding the excess digits of a scanned value must not wrap around
+	if _, err := parse("922337203685477.58075", true); !errors.Is(err, ErrOverflow) {
+		t.Errorf("parse rounding past the maximum: error = %v; want %v", err, ErrOverflow)
+	}
+}
+
+func TestParseOverflowDetection(t *testing.T) {
+	// Any decimal with Scale digits parses exactly when its value fits in an int64
+	check(t, func(whole uint64, frac uint16, negative bool) bool {
+		text := new(big.Int).SetUint64(whole).String() + "." + leftPad(int64(frac)%unit)
+		if negative {
+			text = "-" + text
+		}
+		exact, _ := new(big.Rat).SetString(text)
+		units := new(big.Rat).Mul(exact, big.NewRat(unit, 1))
+		fits := units.Num().IsInt64()
+
+		a, err := Parse(text)
+		if !fits {
+			return errors.Is(err, ErrOverflow)
+		}
+		return err == nil && a.Units() == units.Num().Int64()
+	})
+}
+
+func leftPad(frac int64) string {
+	s := big.NewInt(frac).String()
+	for len(s) < Scale {
+		s = "0" + s
+	}
+	return s
+}
+
+func TestRoundingModes(t *testing.T) {
+	cases := []struct {
+		value string
+		want  map[RoundingMode]string
+	}{
+		{"2.5", map[RoundingMode]string{RoundHalfUp: "3", RoundHalfEven: "2", RoundHalfDown: "2", RoundDown: "2", RoundUp: "3"}},
+		{"3.5", map[RoundingMode]string{RoundHalfUp: "4", RoundHalfEven: "4", RoundHalfDown: "3", RoundDown: "3", RoundUp: "4"}},
+		{"-2.5", map[RoundingMode]string{RoundHalfUp: "-3", RoundHalfEven: "-2", RoundHalfDown: "-2", RoundDown: "-2", RoundUp: "-3"}},
+		{"2.4999", map[RoundingMode]string{RoundHalfUp: "2", RoundHalfEven: "2", RoundHalfDown: "2", RoundDown: "2", RoundUp: "3"}},
+		{"-2.5001", map[RoundingMode]string{RoundHalfUp: "-3", RoundHalfEven: "-3", RoundHalfDown: "-3", RoundDown: "-2", RoundUp: "-3"}},
+		{"7", map[RoundingMode]string{RoundHalfUp: "7", RoundHalfEven: "7", RoundHalfDown: "7", RoundDown: "7", RoundUp: "7"}},
+	}
+	for _, c := range cases {
+		for mode, want := range c.want {
+			if got := MustParse(c.value).Round(0, mode); got != MustParse(want) {
+				t.Errorf("%s.Round(0, %s) = %s; want %s", c.value, mode, got, want)
+			}
+		}
+	}
+
+	// Every mode rounds to the nearest step in its direction, as exact arithmetic says
+	check(t, func(a small, places uint8, mode uint8) bool {
+		p := int(places) % (Scale + 1)
+		m := roundingModes[int(mode)%len(roundingModes)]
+		step := new(big.Rat).SetFrac64(1, int64(math.Pow10(p)))
+		rounded := a.Round(p, m).Rat()
+
+		// The result is a whole number of steps less than one step away
+		steps := new(big.Rat).Quo(rounded, step)
+		distance := new(big.Rat).Sub(rounded, a.Rat())
+		if !steps.IsInt() || new(big.Rat).Abs(distance).Cmp(step) >= 0 {
+			return false
+		}
+
+		half := new(big.Rat).Quo(step, big.NewRat(2, 1))
+		gap := new(big.Rat).Abs(distance).Cmp(half)
+		away := new(big.Rat).Abs(rounded).Cmp(new(big.Rat).Abs(a.Rat())) > 0
+		switch m {
+		case RoundDown:
+			return !away
+		case RoundUp:
+			return distance.Sign() == 0 || away
+		case RoundHalfUp:
+			return gap < 0 || (gap == 0 && away)
+		case RoundHalfDown:
+			return gap < 0 || (gap == 0 && !away)
+		default: // RoundHalfEven
+			even := new(big.Int).Rem(steps.Num(), big.NewInt(2)).Sign() == 0
+			return gap < 0 || (gap == 0 && even)
+		}
+	})
+}
+
+func TestAddMulProperties(t *testing.T) {
+	check(t, func(a, b, c small) bool {
+		return a.Add(b.Amount).Add(c.Amount) == a.Add(b.Add(c.Amount))
+	})
+	check(t, func(a, b small) bool {
+		return a.Add(b.Amount) == b.Add(a.Amount) && a.Add(b.Amount).Sub(b.Amount) == a.Amount
+	})
+	check(t, func(a, b int32, n int16) bool {
+		x, y := FromUnits(int64(a)), FromUnits(int64(b))
+		return x.Add(y).MulInt(int64(n)) == x.MulInt(int64(n)).Add(y.MulInt(int64(n)))
+	})
+	check(t, func(a, b, c int16) bool {
+		// Exact multiplications are associative as well
+		x, y, z := FromUnits(int64(a)), FromInt(int64(b)), FromInt(int64(c))
+		return x.Mul(y, RoundHalfUp).Mul(z, RoundHalfUp) == x.Mul(y.Mul(z, RoundHalfUp), RoundHalfUp)
+	})
+	check(t, func(a small) bool {
+		return a.Add(Zero) == a.Amount && a.Neg().Neg() == a.Amount && a.Add(a.Neg()).IsZero()
+	})
+}
+
+func TestOverflowDetection(t *testing.T) {
+	check(t, func(a, b anyAmount) bool {
+		exact := new(big.Int).Add(big.NewInt(a.units), big.NewInt(b.units))
+		var sum Amount
+		overflowed := overflows(t, func() { sum = a.Add(b.Amount) })
+		if !exact.IsInt64() {
+			return overflowed
+		}
+		return !overflowed && sum.units == exact.Int64()
+	})
+	check(t, func(a, b anyAmount) bool {
+		exact := new(big.Int).Sub(big.NewInt(a.units), big.NewInt(b.units))
+		var difference Amount
+		overflowed := overflows(t, func() { difference = a.Sub(b.Amount) })
+		// b = MinInt64 cannot be negated even when the difference would fit
+		if !exact.IsInt64() || b.units == math.MinInt64 {
+			return overflowed
+		}
+		return !overflowed && difference.units == exact.Int64()
+	})
+	check(t, func(a anyAmount, n int64) bool {
+		exact := new(big.Int).Mul(big.NewInt(a.units), big.NewInt(n))
+		var product Amount
+		overflowed := overflows(t, func() { product = a.MulInt(n) })
+		if !exact.IsInt64() {
+			return overflowed
+		}
+		return !overflowed && product.units == exact.Int64()
+	})
+	check(t, func(a, b anyAmount) bool {
+		exact := new(big.Rat).Mul(a.Rat(), b.Rat())
+		fits := ratFits(exact)
+		overflowed := overflows(t, func() { a.Mul(b.Amount, RoundDown) })
+		return overflowed == !fits
+	})
+
+	if !overflows(t, func() { FromUnits(math.MinInt64).Neg() }) {
+		t.Error("negating the minimum amount did not overflow")
+	}
+	if !overflows(t, func() { FromInt(math.MaxInt64 / 1000) }) {
+		t.Error("FromInt beyond the range did not overflow")
+	}
+	if !overflows(t, func() { Sum(FromUnits(math.MaxInt64), FromUnits(1)) }) {
+		t.Error("Sum beyond the range did not overflow")
+	}
+	if !overflows(t, func() { FromUnits(math.MaxInt64).Round(2, RoundUp) }) {
+		t.Error("rounding the maximum amount up did not overflow")
+	}
+}
+
+// ratFits reports whether r truncated to Scale digits fits in an Amount
+func ratFits(r *big.Rat) bool {
+	units := new(big.Int).Quo(new(big.Int).Mul(r.Num(), big.NewInt(unit)), r.Denom())
+	return units.IsInt64()
+}
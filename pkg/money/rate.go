@@ -0,0 +1,151 @@
+package money
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RateScale is the number of decimal places an exchange rate can hold
+const RateScale = 10
+
+const rateUnit = 10000000000 // 10^RateScale
+
+// ErrInvalidRate is returned for rates that are not positive
+var ErrInvalidRate = errors.New("exchange rate must be positive")
+
+// Rate is a positive exchange rate with RateScale fractional digits: the number of
+// units of one currency that one unit of another currency buys.
+type Rate struct {
+	units int64
+}
+
+// One is the rate between a currency and itself
+var One = Rate{units: rateUnit}
+
+// ParseRate reads a positive plain decimal such as "1.0865" or "161.23"
+func ParseRate(s string) (Rate, error) {
+	return parseRate(s, false)
+}
+
+// MustParseRate is like ParseRate but panics on error
+func MustParseRate(s string) Rate {
+	r, err := ParseRate(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func parseRate(s string, roundExcess bool) (Rate, error) {
+	units, err := parseUnits(s, RateScale, roundExcess)
+	if err != nil {
+		return Rate{}, err
+	}
+	if units <= 0 {
+		return Rate{}, ErrInvalidRate
+	}
+	return Rate{units: units}, nil
+}
+
+// IsZero reports whether the rate is unset
+func (r Rate) IsZero() bool { return r.units == 0 }
+
+// Inverse returns 1/r, rounded to RateScale digits with the given mode
+func (r Rate) Inverse(mode RoundingMode) Rate {
+	return One.Div(r, mode)
+}
+
+// Div returns r/other, e.g. EUR->GBP divided by EUR->USD gives USD->GBP
+func (r Rate) Div(other Rate, mode RoundingMode) Rate {
+	numerator := new(big.Int).Mul(big.NewInt(r.units), big.NewInt(rateUnit))
+	return Rate{units: divRound(numerator, big.NewInt(other.units), mode)}
+}
+
+// Mul chains two rates, e.g. USD->EUR times EUR->GBP gives USD->GBP
+func (r Rate) Mul(other Rate, mode RoundingMode) Rate {
+	product := new(big.Int).Mul(big.NewInt(r.units), big.NewInt(other.units))
+	return Rate{units: divRound(product, big.NewInt(rateUnit), mode)}
+}
+
+// Convert returns amount * r, rounded to Scale digits with the given mode
+func (r Rate) Convert(amount Amount, mode RoundingMode) Amount {
+	product := new(big.Int).Mul(big.NewInt(amount.units), big.NewInt(r.units))
+	return Amount{units: divRound(product, big.NewInt(rateUnit), mode)}
+}
+
+// String formats the rate without trailing zeros
+func (r Rate) String() string {
+	s := formatUnits(r.units, RateScale, RateScale)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// MarshalJSON encodes the rate as a JSON string
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON accepts a JSON string or number holding a positive decimal
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	text := strings.TrimSpace(string(data))
+	if text == "null" {
+		*r = Rate{}
+		return nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseRate(text)
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// Value stores the rate as an exact decimal string; an unset rate is stored as NULL
+func (r Rate) Value() (driver.Value, error) {
+	if r.IsZero() {
+		return nil, nil
+	}
+	return formatUnits(r.units, RateScale, RateScale), nil
+}
+
+// Scan reads a decimal column, see Amount.Scan
+func (r *Rate) Scan(value interface{}) error {
+	var (
+		parsed Rate
+		err    error
+	)
+	switch v := value.(type) {
+	case nil:
+		parsed = Rate{}
+	case int64:
+		parsed, err = parseRate(strconv.FormatInt(v, 10), true)
+	case float64:
+		parsed, err = parseRate(strconv.FormatFloat(v, 'f', -1, 64), true)
+	case []byte:
+		parsed, err = parseRate(string(v), true)
+	case string:
+		parsed, err = parseRate(v, true)
+	default:
+		return fmt.Errorf("money: cannot scan %T into Rate", value)
+	}
+	if err != nil {
+		return err
+	}
+	*r = parsed
+	return nil
+}
+
+// GormDataType makes decimal the default column type for rates
+func (Rate) GormDataType() string {
+	return "decimal(24,10)"
+}
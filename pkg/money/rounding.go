@@ -0,0 +1,83 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// RoundingMode decides how a value between two representable amounts is rounded
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds ties away from zero (commercial rounding)
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds ties to the nearest even digit (banker's rounding)
+	RoundHalfEven
+	// RoundHalfDown rounds ties towards zero
+	RoundHalfDown
+	// RoundDown truncates towards zero
+	RoundDown
+	// RoundUp rounds away from zero
+	RoundUp
+)
+
+var roundingModeNames = map[RoundingMode]string{
+	RoundHalfUp:   "half_up",
+	RoundHalfEven: "half_even",
+	RoundHalfDown: "half_down",
+	RoundDown:     "down",
+	RoundUp:       "up",
+}
+
+// ParseRoundingMode reads a mode name such as "half_up" or "half_even"
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for mode, modeName := range roundingModeNames {
+		if modeName == name {
+			return mode, nil
+		}
+	}
+	return RoundHalfUp, fmt.Errorf("unknown rounding mode %q", name)
+}
+
+func (m RoundingMode) String() string {
+	if name, ok := roundingModeNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("RoundingMode(%d)", int(m))
+}
+
+// divRound returns n / d rounded to an integer with the given mode. d must be positive
+// or negative but not zero, and the result must fit in an int64.
+func divRound(n, d *big.Int, mode RoundingMode) int64 {
+	quotient, remainder := new(big.Int).QuoRem(n, d, new(big.Int))
+	if remainder.Sign() != 0 {
+		// The exact result lies between quotient and quotient+direction
+		direction := int64(n.Sign() * d.Sign())
+		twice := new(big.Int).Abs(remainder)
+		twice.Lsh(twice, 1)
+		half := twice.Cmp(new(big.Int).Abs(d)) // <0 below half, 0 tie, >0 above half
+
+		away := false
+		switch mode {
+		case RoundUp:
+			away = true
+		case RoundDown:
+			away = false
+		case RoundHalfUp:
+			away = half >= 0
+		case RoundHalfDown:
+			away = half > 0
+		case RoundHalfEven:
+			away = half > 0 || (half == 0 && quotient.Bit(0) == 1)
+		}
+		if away {
+			quotient.Add(quotient, big.NewInt(direction))
+		}
+	}
+	if !quotient.IsInt64() {
+		panic(ErrOverflow)
+	}
+	return quotient.Int64()
+}
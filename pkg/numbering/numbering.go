@@ -0,0 +1,148 @@
+// Package numbering formats sequential document numbers from templates such as
+// "INV-{YYYY}-{SEQ:5}" and decides when their counter starts over.
+package numbering
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reset decides when the sequence of a format starts over at 1
+type Reset string
+
+const (
+	// ResetNever keeps one sequence forever
+	ResetNever Reset = "never"
+	// ResetYearly starts a new sequence every calendar year
+	ResetYearly Reset = "yearly"
+	// ResetMonthly starts a new sequence every calendar month
+	ResetMonthly Reset = "monthly"
+)
+
+// maxWidth is the widest zero padding of a sequence number, enough for any int64
+const maxWidth = 19
+
+// Template tokens
+const (
+	tokenYear      = "YYYY"
+	tokenShortYear = "YY"
+	tokenMonth     = "MM"
+	tokenSeq       = "SEQ"
+)
+
+// Format renders document numbers. The zero value is not usable; use Parse.
+type Format struct {
+	parts []part
+	reset Reset
+}
+
+// part is a literal or a token of a template
+type part struct {
+	literal string
+	token   string
+	width   int // zero padding of {SEQ:n}
+}
+
+// Parse reads a template made of literal text and the tokens {YYYY}, {YY}, {MM} and
+// exactly one {SEQ} or {SEQ:n}, where n pads the sequence with zeros to n digits. The
+// template must contain the year for yearly resets and the year and month for monthly
+// resets, so numbers from different sequences cannot collide.
+func Parse(template string, reset Reset) (Format, error) {
+	format := Format{reset: reset}
+	switch reset {
+	case ResetNever, ResetYearly, ResetMonthly:
+	default:
+		return Format{}, fmt.Errorf("unknown reset %q (expected never, yearly or monthly)", reset)
+	}
+
+	seqs, hasYear, hasMonth := 0, false, false
+	rest := template
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if close := strings.IndexByte(rest, '}'); close >= 0 && (open < 0 || close < open) {
+			return Format{}, fmt.Errorf("unexpected } in %q", template)
+		}
+		if open < 0 {
+			format.parts = append(format.parts, part{literal: rest})
+			break
+		}
+		if open > 0 {
+			format.parts = append(format.parts, part{literal: rest[:open]})
+		}
+		close := strings.IndexByte(rest[open:], '}')
+		if close < 0 {
+			return Format{}, fmt.Errorf("unclosed { in %q", template)
+		}
+
+		token := rest[open+1 : open+close]
+		rest = rest[open+close+1:]
+		name, arg, hasArg := strings.Cut(token, ":")
+		p := part{token: name}
+		switch name {
+		case tokenYear, tokenShortYear:
+			hasYear = true
+		case tokenMonth:
+			hasMonth = true
+		case tokenSeq:
+			seqs++
+			if hasArg {
+				width, err := strconv.Atoi(arg)
+				if err != nil || width < 1 || width > maxWidth {
+					return Format{}, fmt.Errorf("invalid padding in {%s}: expected 1 to %d digits", token, maxWidth)
+				}
+				p.width = width
+			}
+		default:
+			return Format{}, fmt.Errorf("unknown token {%s} (expected YYYY, YY, MM or SEQ)", token)
+		}
+		if hasArg && name != tokenSeq {
+			return Format{}, fmt.Errorf("token {%s} takes no argument", token)
+		}
+		format.parts = append(format.parts, p)
+	}
+
+	if seqs != 1 {
+		return Format{}, fmt.Errorf("template %q must contain {SEQ} exactly once", template)
+	}
+	if reset != ResetNever && !hasYear {
+		return Format{}, fmt.Errorf("a %s sequence needs {YYYY} or {YY} in the template", reset)
+	}
+	if reset == ResetMonthly && !hasMonth {
+		return Format{}, fmt.Errorf("a monthly sequence needs {MM} in the template")
+	}
+	return format, nil
+}
+
+// Scope names the sequence a document dated date belongs to: "all", the year ("2026")
+// or the year and month ("2026-03")
+func (f Format) Scope(date time.Time) string {
+	switch f.reset {
+	case ResetYearly:
+		return date.Format("2006")
+	case ResetMonthly:
+		return date.Format("2006-01")
+	}
+	return "all"
+}
+
+// Number renders the document number with sequence number seq for a document dated date
+func (f Format) Number(date time.Time, seq int64) string {
+	var b strings.Builder
+	for _, p := range f.parts {
+		switch p.token {
+		case "":
+			b.WriteString(p.literal)
+		case tokenYear:
+			b.WriteString(date.Format("2006"))
+		case tokenShortYear:
+			b.WriteString(date.Format("06"))
+		case tokenMonth:
+			b.WriteString(date.Format("01"))
+		case tokenSeq:
+			b.WriteString(fmt.Sprintf("%0*d", p.width, seq))
+		}
+	}
+	return b.String()
+}
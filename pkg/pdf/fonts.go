@@ -0,0 +1,138 @@
+package pdf
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Font is one of the standard fonts every PDF reader provides, so nothing is embedded
+type Font int
+
+const (
+	// Helvetica is the regular sans-serif standard font
+	Helvetica Font = iota
+	// HelveticaBold is the bold sans-serif standard font
+	HelveticaBold
+)
+
+// fonts lists the standard fonts in the order of their resource names /F1, /F2, ...
+var fonts = []struct {
+	baseFont string
+	widths   *[95]int
+}{
+	Helvetica:     {"Helvetica", &helveticaWidths},
+	HelveticaBold: {"Helvetica-Bold", &helveticaBoldWidths},
+}
+
+// helveticaWidths are the advance widths of the characters 32 to 126 in thousandths of
+// the font size, from the Adobe font metrics of Helvetica
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 to ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ to O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P to _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` to o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p to ~
+}
+
+// helveticaBoldWidths are the advance widths of the characters 32 to 126 in thousandths
+// of the font size, from the Adobe font metrics of Helvetica-Bold
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611, // 0 to ?
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778, // @ to O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556, // P to _
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611, // ` to o
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584, // p to ~
+}
+
+// defaultWidth is used for characters outside the ASCII range; accented letters are
+// close to the width of a lower case letter
+const defaultWidth = 556
+
+// winAnsi maps the characters of WinAnsiEncoding above 127 that differ from Latin-1
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b,
+	'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// encode converts text to WinAnsiEncoding, the encoding of the standard fonts. Characters
+// the encoding does not have become '?' on purpose: an invoice with a customer name in
+// another script still renders, and showing those names would need an embedded font.
+func encode(text string) []byte {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r >= 32 && r <= 126, r >= 160 && r <= 255:
+			out = append(out, byte(r))
+		case r == '\t':
+			out = append(out, ' ')
+		default:
+			if b, ok := winAnsi[r]; ok {
+				out = append(out, b)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+// TextWidth returns the width of text set in font at size points
+func TextWidth(font Font, size float64, text string) float64 {
+	widths := fonts[font].widths
+	total := 0
+	for _, b := range encode(text) {
+		if b >= 32 && b <= 126 {
+			total += widths[b-32]
+		} else {
+			total += defaultWidth
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Wrap breaks text into lines no wider than width, breaking at spaces where possible and
+// inside words that are wider than a line on their own. Newlines in text are kept.
+func Wrap(font Font, size float64, text string, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if TextWidth(font, size, candidate) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = word
+			for TextWidth(font, size, line) > width {
+				head := fitPrefix(font, size, line, width)
+				lines = append(lines, head)
+				line = line[len(head):]
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// fitPrefix returns the longest prefix of text, at least one character, that fits in width
+func fitPrefix(font Font, size float64, text string, width float64) string {
+	end := 0
+	for end < len(text) {
+		_, n := utf8.DecodeRuneInString(text[end:])
+		if end > 0 && TextWidth(font, size, text[:end+n]) > width {
+			break
+		}
+		end += n
+	}
+	return text[:end]
+}
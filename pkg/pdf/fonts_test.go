@@ -0,0 +1,23 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Text outside WinAnsiEncoding is printed as '?' rather than failing the document
+func TestEncode(t *testing.T) {
+	cases := map[string][]byte{
+		"Invoice 42":  []byte("Invoice 42"),
+		"Café Müller": {'C', 'a', 'f', 0xe9, ' ', 'M', 0xfc, 'l', 'l', 'e', 'r'},
+		"€ 10 – net":  {0x80, ' ', '1', '0', ' ', 0x96, ' ', 'n', 'e', 't'},
+		"a\tb":        []byte("a b"),
+		"Ελλάδα":      []byte("??????"),
+		"東京 ✓":        []byte("?? ?"),
+	}
+	for text, want := range cases {
+		if got := encode(text); !bytes.Equal(got, want) {
+			t.Errorf("encode(%q) = %q; want %q", text, got, want)
+		}
+	}
+}
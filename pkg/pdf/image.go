@@ -0,0 +1,118 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // registers the JPEG decoder with image.DecodeConfig
+	"image/png"
+	"os"
+)
+
+// Image is a JPEG or PNG image that can be drawn on pages. JPEG data is embedded as is;
+// PNG images are decoded and stored compressed, with their transparency as a soft mask.
+type Image struct {
+	width      int
+	height     int
+	colorSpace string
+	filter     string
+	data       []byte
+	mask       *Image
+}
+
+// LoadImage reads a JPEG or PNG image from a file
+func LoadImage(path string) (*Image, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeImage(data)
+}
+
+// DecodeImage reads a JPEG or PNG image
+func DecodeImage(data []byte) (*Image, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported image: %w", err)
+	}
+	switch format {
+	case "jpeg":
+		img := &Image{width: config.Width, height: config.Height, filter: "DCTDecode", data: data}
+		switch config.ColorModel {
+		case color.GrayModel:
+			img.colorSpace = "DeviceGray"
+		case color.YCbCrModel:
+			img.colorSpace = "DeviceRGB"
+		default:
+			return nil, fmt.Errorf("unsupported image: CMYK JPEG images are not supported")
+		}
+		return img, nil
+	case "png":
+		decoded, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("unsupported image: %w", err)
+		}
+		return fromImage(decoded)
+	}
+	return nil, fmt.Errorf("unsupported image format %q (expected JPEG or PNG)", format)
+}
+
+// Width returns the width of the image in pixels
+func (img *Image) Width() int {
+	return img.width
+}
+
+// Height returns the height of the image in pixels
+func (img *Image) Height() int {
+	return img.height
+}
+
+// fromImage stores the pixels of a decoded image as compressed RGB samples and, unless the
+// image is opaque, its alpha channel as a grayscale soft mask
+func fromImage(src image.Image) (*Image, error) {
+	bounds := src.Bounds()
+	rgb := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	alpha := make([]byte, 0, bounds.Dx()*bounds.Dy())
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(src.At(x, y)).(color.NRGBA)
+			rgb = append(rgb, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			if c.A != 0xff {
+				opaque = false
+			}
+		}
+	}
+
+	img := &Image{width: bounds.Dx(), height: bounds.Dy(), colorSpace: "DeviceRGB", filter: "FlateDecode"}
+	var err error
+	if img.data, err = deflate(rgb); err != nil {
+		return nil, err
+	}
+	if !opaque {
+		img.mask = &Image{width: img.width, height: img.height, colorSpace: "DeviceGray", filter: "FlateDecode"}
+		if img.mask.data, err = deflate(alpha); err != nil {
+			return nil, err
+		}
+	}
+	return img, nil
+}
+
+func (img *Image) dictionary() string {
+	return fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /%s", img.width, img.height, img.colorSpace, img.filter)
+}
+
+func deflate(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
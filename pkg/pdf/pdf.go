@@ -0,0 +1,289 @@
+// Package pdf writes simple PDF documents: text in the standard Helvetica fonts, filled
+// rectangles, lines and JPEG or PNG images.
+//
+// Page coordinates are in points (1/72 inch) with the origin at the top left corner and y
+// growing downwards. Output is deterministic: the same drawing calls always produce the
+// same bytes, because no timestamps or random identifiers are written.
+//
+// Text is written in WinAnsiEncoding (Windows-1252), the only encoding the standard fonts
+// have, and nothing is embedded: characters outside it, such as Greek, Cyrillic, CJK or
+// emoji, are printed as '?' rather than failing the document.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Page sizes in points
+var (
+	A4     = Size{Width: 595.28, Height: 841.89}
+	Letter = Size{Width: 612, Height: 792}
+)
+
+// Size is the width and height of a page in points
+type Size struct {
+	Width  float64
+	Height float64
+}
+
+// ParseSize returns the page size named a4 or letter
+func ParseSize(name string) (Size, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "a4":
+		return A4, nil
+	case "letter":
+		return Letter, nil
+	}
+	return Size{}, fmt.Errorf("unknown page size %q (expected a4 or letter)", name)
+}
+
+// Color is an RGB color
+type Color struct {
+	R, G, B uint8
+}
+
+// Common colors
+var (
+	Black = Color{0, 0, 0}
+	White = Color{255, 255, 255}
+)
+
+// ParseColor reads a color written as #rrggbb or #rgb
+func ParseColor(hex string) (Color, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	if len(s) == 3 {
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	}
+	if len(s) != 6 {
+		return Color{}, fmt.Errorf("invalid color %q (expected #rrggbb)", hex)
+	}
+	value, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return Color{}, fmt.Errorf("invalid color %q (expected #rrggbb)", hex)
+	}
+	return Color{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value)}, nil
+}
+
+// Document is a PDF document under construction
+type Document struct {
+	size   Size
+	title  string
+	pages  []*Page
+	images []*Image
+}
+
+// NewDocument creates an empty document whose pages have the given size
+func NewDocument(size Size, title string) *Document {
+	return &Document{size: size, title: title}
+}
+
+// Size returns the page size of the document
+func (d *Document) Size() Size {
+	return d.size
+}
+
+// AddPage appends a blank page and returns it for drawing
+func (d *Document) AddPage() *Page {
+	page := &Page{doc: d}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Pages returns the pages added so far, in order
+func (d *Document) Pages() []*Page {
+	return d.pages
+}
+
+// Page is one page of a document. Drawing calls append to its content stream.
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+	images  []*Image
+}
+
+// Text draws text with its baseline at y, starting at x. Characters outside
+// WinAnsiEncoding are drawn as '?'.
+func (p *Page) Text(x, y float64, font Font, size float64, color Color, text string) {
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(&p.content, "BT %s rg /F%d %s Tf %s %s Td ", rgb(color), font+1, num(size), num(x), num(p.doc.size.Height-y))
+	p.content.WriteString(literal(encode(text)))
+	p.content.WriteString(" Tj ET\n")
+}
+
+// TextRight draws text with its baseline at y, ending at x
+func (p *Page) TextRight(x, y float64, font Font, size float64, color Color, text string) {
+	p.Text(x-TextWidth(font, size, text), y, font, size, color, text)
+}
+
+// Rect fills a rectangle whose top left corner is at x, y
+func (p *Page) Rect(x, y, width, height float64, color Color) {
+	fmt.Fprintf(&p.content, "%s rg %s %s %s %s re f\n", rgb(color), num(x), num(p.doc.size.Height-y-height), num(width), num(height))
+}
+
+// Line draws a straight line
+func (p *Page) Line(x1, y1, x2, y2, width float64, color Color) {
+	fmt.Fprintf(&p.content, "%s RG %s w %s %s m %s %s l S\n", rgb(color), num(width), num(x1), num(p.doc.size.Height-y1), num(x2), num(p.doc.size.Height-y2))
+}
+
+// Image draws img scaled into a box whose top left corner is at x, y
+func (p *Page) Image(img *Image, x, y, width, height float64) {
+	index := p.doc.imageIndex(img)
+	if !containsImage(p.images, img) {
+		p.images = append(p.images, img)
+	}
+	fmt.Fprintf(&p.content, "q %s 0 0 %s %s %s cm /Im%d Do Q\n", num(width), num(height), num(x), num(p.doc.size.Height-y-height), index+1)
+}
+
+func (d *Document) imageIndex(img *Image) int {
+	for i, existing := range d.images {
+		if existing == img {
+			return i
+		}
+	}
+	d.images = append(d.images, img)
+	return len(d.images) - 1
+}
+
+func containsImage(images []*Image, img *Image) bool {
+	for _, existing := range images {
+		if existing == img {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteTo writes the document as PDF 1.4
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	out := &writer{}
+	out.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects are numbered in a fixed order: catalog, page tree, info, fonts, images
+	// (each followed by its soft mask), then each page followed by its content stream
+	const catalogID, pagesID, infoID, firstFontID = 1, 2, 3, 4
+	imageIDs := make([]int, len(d.images))
+	next := firstFontID + len(fonts)
+	for i, img := range d.images {
+		imageIDs[i] = next
+		next++
+		if img.mask != nil {
+			next++
+		}
+	}
+	pageIDs := make([]int, len(d.pages))
+	for i := range d.pages {
+		pageIDs[i] = next
+		next += 2
+	}
+
+	out.object(catalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	kids := make([]string, len(pageIDs))
+	for i, id := range pageIDs {
+		kids[i] = fmt.Sprintf("%d 0 R", id)
+	}
+	out.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 %s %s] >>", strings.Join(kids, " "), len(pageIDs), num(d.size.Width), num(d.size.Height)))
+	out.object(infoID, fmt.Sprintf("<< /Title %s /Producer (go-api-starter) >>", literal(encode(d.title))))
+	for i, font := range fonts {
+		out.object(firstFontID+i, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font.baseFont))
+	}
+	for i, img := range d.images {
+		mask := ""
+		if img.mask != nil {
+			mask = fmt.Sprintf(" /SMask %d 0 R", imageIDs[i]+1)
+			out.stream(imageIDs[i]+1, img.mask.dictionary(), img.mask.data)
+		}
+		out.stream(imageIDs[i], img.dictionary()+mask, img.data)
+	}
+
+	fontRefs := make([]string, len(fonts))
+	for i := range fonts {
+		fontRefs[i] = fmt.Sprintf("/F%d %d 0 R", i+1, firstFontID+i)
+	}
+	for i, page := range d.pages {
+		resources := "/Font << " + strings.Join(fontRefs, " ") + " >>"
+		if len(page.images) > 0 {
+			refs := make([]string, len(page.images))
+			for j, img := range page.images {
+				index := d.imageIndex(img)
+				refs[j] = fmt.Sprintf("/Im%d %d 0 R", index+1, imageIDs[index])
+			}
+			resources += " /XObject << " + strings.Join(refs, " ") + " >>"
+		}
+		out.object(pageIDs[i], fmt.Sprintf("<< /Type /Page /Parent %d 0 R /Resources << %s >> /Contents %d 0 R >>", pagesID, resources, pageIDs[i]+1))
+		out.stream(pageIDs[i]+1, "", page.content.Bytes())
+	}
+
+	xref := out.buf.Len()
+	fmt.Fprintf(&out.buf, "xref\n0 %d\n0000000000 65535 f \n", next)
+	for id := 1; id < next; id++ {
+		fmt.Fprintf(&out.buf, "%010d 00000 n \n", out.offsets[id])
+	}
+	fmt.Fprintf(&out.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", next, catalogID, infoID, xref)
+	return out.buf.WriteTo(w)
+}
+
+// writer accumulates objects and remembers where each one starts for the xref table
+type writer struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+func (w *writer) object(id int, body string) {
+	w.begin(id)
+	w.buf.WriteString(body)
+	w.buf.WriteString("\nendobj\n")
+}
+
+func (w *writer) stream(id int, dictionary string, data []byte) {
+	w.begin(id)
+	fmt.Fprintf(&w.buf, "<< %s /Length %d >>\nstream\n", strings.TrimSpace(dictionary), len(data))
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+func (w *writer) begin(id int) {
+	if w.offsets == nil {
+		w.offsets = make(map[int]int)
+	}
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n", id)
+}
+
+// num formats a coordinate with at most two decimals and no trailing zeros
+func num(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+func rgb(c Color) string {
+	return fmt.Sprintf("%s %s %s", num(float64(c.R)/255), num(float64(c.G)/255), num(float64(c.B)/255))
+}
+
+// literal writes encoded text as a PDF string, escaping everything outside printable ASCII
+func literal(text []byte) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, c := range text {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 32 || c > 126:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
@@ -0,0 +1,200 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchYears bounds the search for the next run of expressions that can never match,
+// such as 0 0 30 2 *
+const cronSearchYears = 5
+
+// cronMacros are the shorthand expressions accepted in place of five fields
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var weekdayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// cronField describes the range and names of one field of a cron expression
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var cronFields = [5]cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: monthNames},
+	{name: "day of week", min: 0, max: 7, names: weekdayNames}, // 7 is Sunday too
+}
+
+// Cron is a schedule given by a standard five-field cron expression
+type Cron struct {
+	expr   string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	anyDom bool
+	anyDow bool
+}
+
+// ParseCron reads a cron expression: minute, hour, day of month, month and day of week,
+// separated by spaces. Fields accept *, numbers, ranges (1-5), lists (1,15), steps (*/15,
+// 1-31/2) and, for months and weekdays, three-letter English names. The macros @yearly,
+// @monthly, @weekly, @daily and @hourly are accepted too. As in Vixie cron, when both the
+// day of month and the day of week are restricted, a day matching either one runs.
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	fields := strings.Fields(expr)
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		fields = strings.Fields(macro)
+	}
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields: minute, hour, day of month, month and day of week", expr)
+	}
+
+	var sets [5]uint64
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+		sets[i] = set
+	}
+	// Sunday may be written as 0 or 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+
+	return &Cron{
+		expr:   expr,
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		anyDom: fields[2] == "*" || strings.HasPrefix(fields[2], "*/"),
+		anyDow: fields[4] == "*" || strings.HasPrefix(fields[4], "*/"),
+	}, nil
+}
+
+// parseCronField returns the values a field matches as a bit set
+func parseCronField(field string, spec cronField) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepPart, spec.name)
+			}
+			step = n
+		}
+
+		var low, high int
+		switch {
+		case rangePart == "*":
+			low, high = spec.min, spec.max
+		case strings.Contains(rangePart, "-"):
+			lowPart, highPart, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = parseCronValue(lowPart, spec); err != nil {
+				return 0, err
+			}
+			if high, err = parseCronValue(highPart, spec); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangePart, spec.name)
+			}
+		default:
+			value, err := parseCronValue(rangePart, spec)
+			if err != nil {
+				return 0, err
+			}
+			low, high = value, value
+			// 5/15 means from 5 to the end in steps of 15
+			if hasStep {
+				high = spec.max
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func parseCronValue(value string, spec cronField) (int, error) {
+	if n, ok := spec.names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < spec.min || n > spec.max {
+		return 0, fmt.Errorf("invalid value %q in %s field (expected %d-%d)", value, spec.name, spec.min, spec.max)
+	}
+	return n, nil
+}
+
+// String returns the expression the schedule was parsed from
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// Next returns the first minute strictly after the given time that matches the expression
+func (c *Cron) Next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, time.UTC)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *Cron) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.anyDom || c.anyDow {
+		return dom && dow
+	}
+	return dom || dow
+}
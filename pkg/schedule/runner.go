@@ -0,0 +1,57 @@
+package schedule
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Runner runs background jobs one after another at a fixed interval
+type Runner struct {
+	interval time.Duration
+	jobs     []job
+}
+
+type job struct {
+	name string
+	run  func(now time.Time) error
+}
+
+// NewRunner creates a Runner that runs its jobs every interval
+func NewRunner(interval time.Duration) *Runner {
+	return &Runner{interval: interval}
+}
+
+// Add registers a job. Jobs run in the order they were added and are passed the time the
+// round started; a job that fails is logged and tried again in the next round.
+func (r *Runner) Add(name string, run func(now time.Time) error) {
+	r.jobs = append(r.jobs, job{name: name, run: run})
+}
+
+// Run runs every job once right away, so work missed while the process was stopped is
+// caught up on start, and then every interval until ctx is cancelled
+func (r *Runner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.runOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) runOnce(ctx context.Context) {
+	now := time.Now().UTC()
+	for _, job := range r.jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := job.run(now); err != nil {
+			log.Printf("Warning: %s job failed: %v", job.name, err)
+		}
+	}
+}
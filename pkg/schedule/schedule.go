@@ -0,0 +1,83 @@
+// Package schedule computes the run times of repeating work, either at a fixed interval
+// from an anchor time or from a cron expression, and runs background jobs periodically.
+//
+// All times are handled in UTC.
+package schedule
+
+import "time"
+
+// Schedule returns the run times of a repeating schedule
+type Schedule interface {
+	// Next returns the first run time strictly after the given time, or the zero time when
+	// the schedule never runs again
+	Next(after time.Time) time.Time
+}
+
+// Months returns a schedule that runs every n months on the day and time of anchor,
+// starting at anchor. Days missing from shorter months fall on the last day of the month,
+// so a schedule anchored on January 31 runs on February 28 and then March 31.
+func Months(n int, anchor time.Time) Schedule {
+	return monthSchedule{step: n, anchor: anchor.UTC()}
+}
+
+// Days returns a schedule that runs every n days at the time of anchor, starting at anchor
+func Days(n int, anchor time.Time) Schedule {
+	return daySchedule{step: n, anchor: anchor.UTC()}
+}
+
+type monthSchedule struct {
+	step   int
+	anchor time.Time
+}
+
+func (s monthSchedule) Next(after time.Time) time.Time {
+	if after.Before(s.anchor) {
+		return s.anchor
+	}
+	if s.step < 1 {
+		return time.Time{}
+	}
+
+	// Start one step before the estimate, since month lengths make it approximate
+	after = after.UTC()
+	months := (after.Year()-s.anchor.Year())*12 + int(after.Month()-s.anchor.Month())
+	k := months/s.step - 1
+	if k < 0 {
+		k = 0
+	}
+	for {
+		next := addMonths(s.anchor, k*s.step)
+		if next.After(after) {
+			return next
+		}
+		k++
+	}
+}
+
+// addMonths moves t by the given number of months, keeping the day of the month where
+// possible and using the last day of shorter months otherwise
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+type daySchedule struct {
+	step   int
+	anchor time.Time
+}
+
+func (s daySchedule) Next(after time.Time) time.Time {
+	if after.Before(s.anchor) {
+		return s.anchor
+	}
+	if s.step < 1 {
+		return time.Time{}
+	}
+
+	k := int(after.Sub(s.anchor)/(time.Duration(s.step)*24*time.Hour)) + 1
+	return s.anchor.AddDate(0, 0, k*s.step)
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalPutOpenDelete(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "uploads")
+	store, err := NewLocal(dir)
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	ctx := context.Background()
+	key := "invoice/12/3f9c"
+
+	if err := store.Put(ctx, key, strings.NewReader("first"), 5, "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := store.Put(ctx, key, strings.NewReader("second"), 6, "text/plain"); err != nil {
+		t.Fatalf("Put replacing the object: %v", err)
+	}
+	reader, err := store.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil || string(data) != "second" {
+		t.Errorf("Open read %q, %v; want %q", data, err, "second")
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "invoice", "12")); len(entries) != 1 {
+		t.Errorf("%d files in the object directory; want only the object", len(entries))
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open after Delete error = %v; want %v", err, ErrNotFound)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("deleting a missing object: %v", err)
+	}
+}
+
+// A short or cancelled upload leaves neither the object nor its temporary file behind
+func TestLocalPutIncomplete(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocal(dir)
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+
+	if err := store.Put(context.Background(), "customer/1/a", strings.NewReader("abc"), 10, "text/plain"); err == nil {
+		t.Error("Put of fewer bytes than the size succeeded")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := store.Put(ctx, "customer/1/b", strings.NewReader("abc"), 3, "text/plain"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Put with a cancelled context error = %v; want %v", err, context.Canceled)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "customer", "1"))
+	if err != nil {
+		t.Fatalf("read object directory: %v", err)
+	}
+	for _, entry := range entries {
+		t.Errorf("%s was left behind", entry.Name())
+	}
+}
+
+func TestLocalRejectsKeysOutsideRoot(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocal(filepath.Join(dir, "root"))
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	for _, key := range []string{"", "/etc/passwd", "../escape", "a/../../escape", "a//b", "a/./b", "a/", `a\..\b`} {
+		if err := store.Put(context.Background(), key, strings.NewReader("x"), 1, "text/plain"); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+		if _, err := store.Open(context.Background(), key); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Open(%q) error = %v; want an invalid key", key, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("a file was written outside the storage root")
+	}
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// unsignedPayload lets uploads stream without hashing the body before sending it
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	// emptyPayloadHash is the SHA-256 of an empty body
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	signedHeaders    = "host;x-amz-content-sha256;x-amz-date"
+)
+
+// S3Config holds the settings of an S3-compatible bucket
+type S3Config struct {
+	Endpoint        string // e.g. https://s3.eu-west-1.amazonaws.com or http://localhost:9000
+	Region          string // defaults to us-east-1
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// ForcePathStyle addresses the bucket as endpoint/bucket instead of bucket.endpoint,
+	// as MinIO and most self-hosted stores expect
+	ForcePathStyle bool
+}
+
+// S3 stores objects in a bucket through the S3 REST API, signing requests with AWS
+// Signature Version 4
+type S3 struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3 returns a storage backend for the bucket described by cfg
+func NewS3(cfg S3Config) (*S3, error) {
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" || endpoint.Path != "" {
+		return nil, fmt.Errorf("S3 endpoint must be an http or https URL without a path, got %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, errors.New("S3 bucket must not be empty")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, errors.New("S3 access key ID and secret access key must not be empty")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	return &S3{cfg: cfg, endpoint: endpoint, client: &http.Client{}}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	resp, err := s.do(ctx, http.MethodPut, key, r, size, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError(http.MethodPut, key, resp)
+	}
+	return nil
+}
+
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, 0, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, responseError(http.MethodGet, key, resp)
+	}
+	return resp.Body, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, 0, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return responseError(http.MethodDelete, key, resp)
+	}
+	return nil
+}
+
+// do sends a signed request for the object stored under key
+func (s *S3) do(ctx context.Context, method, key string, body io.Reader, size int64, contentType string) (*http.Response, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	host, path := s.endpoint.Host, "/"+s.cfg.Bucket+"/"+encodePath(key)
+	if !s.cfg.ForcePathStyle {
+		host, path = s.cfg.Bucket+"."+s.endpoint.Host, "/"+encodePath(key)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint.Scheme+"://"+host+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build S3 request: %w", err)
+	}
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		req.ContentLength = size
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+		payloadHash = unsignedPayload
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, payloadHash, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("S3 %s %s failed: %w", method, key, err)
+	}
+	return resp, nil
+}
+
+// sign adds the Signature Version 4 authorization header to req
+func (s *S3) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\nx-amz-content-sha256:" + payloadHash + "\nx-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.cfg.Region + "/s3/aws4_request"
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), date)
+	for _, part := range []string{s.cfg.Region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// encodePath percent-encodes an object key the way Signature Version 4 expects: every
+// byte except unreserved characters and the slashes between segments
+func encodePath(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// responseError reports the error code and message of a failed S3 response
+func responseError(method, key string, resp *http.Response) error {
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	if xml.Unmarshal(data, &body) == nil && body.Code != "" {
+		return fmt.Errorf("S3 %s %s failed with %s: %s: %s", method, key, resp.Status, body.Code, body.Message)
+	}
+	return fmt.Errorf("S3 %s %s failed with %s", method, key, resp.Status)
+}
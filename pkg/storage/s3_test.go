@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testAccessKey = "AKIDEXAMPLE"
+	testSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	testRegion    = "eu-west-1"
+)
+
+// fakeS3 is an in-memory bucket that checks the Signature Version 4 of every request
+// against its own canonical request, built from what arrived on the wire
+type fakeS3 struct {
+	t      *testing.T
+	bucket string
+	// rejectQuietly answers bad signatures with 403 without failing the test
+	rejectQuietly bool
+	mu            sync.Mutex
+	objects       map[string][]byte
+	types         map[string]string
+	last          struct {
+		canonicalRequest string
+		authorization    string
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rawPath := strings.SplitN(r.RequestURI, "?", 2)[0]
+	canonical, err := f.verify(r, rawPath)
+	if err != nil {
+		if !f.rejectQuietly {
+			f.t.Errorf("%s %s: %v", r.Method, rawPath, err)
+		}
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, "<Error><Code>SignatureDoesNotMatch</Code><Message>"+err.Error()+"</Message></Error>")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.last.canonicalRequest, f.last.authorization = canonical, r.Header.Get("Authorization")
+
+	// Path-style requests name the bucket in the path, virtual-hosted ones in the host
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	if !strings.HasPrefix(r.Host, f.bucket+".") {
+		if !strings.HasPrefix(key, f.bucket+"/") {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>NoSuchBucket</Code><Message>The specified bucket does not exist</Message></Error>")
+			return
+		}
+		key = strings.TrimPrefix(key, f.bucket+"/")
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		if int64(len(data)) != r.ContentLength {
+			f.t.Errorf("PUT %s: got %d bytes, Content-Length %d", key, len(data), r.ContentLength)
+		}
+		f.objects[key], f.types[key] = data, r.Header.Get("Content-Type")
+	case http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, "<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>")
+			return
+		}
+		w.Write(data)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// verify recomputes the signature of r with the test secret and returns the canonical request
+func (f *fakeS3) verify(r *http.Request, rawPath string) (string, error) {
+	amzDate := r.Header.Get("X-Amz-Date")
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	signed, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil {
+		return "", errors.New("missing or malformed X-Amz-Date")
+	}
+	if d := time.Since(signed); d < -time.Minute || d > time.Minute {
+		return "", errors.New("X-Amz-Date is not the current time")
+	}
+	want := emptyPayloadHash
+	if r.Method == http.MethodPut {
+		want = unsignedPayload
+	}
+	if payloadHash != want {
+		return "", errors.New("X-Amz-Content-Sha256 is " + payloadHash + ", want " + want)
+	}
+
+	canonical := strings.Join([]string{
+		r.Method,
+		rawPath,
+		r.URL.RawQuery,
+		"host:" + r.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		"host;x-amz-content-sha256;x-amz-date",
+		payloadHash,
+	}, "\n")
+	scope := amzDate[:8] + "/" + testRegion + "/s3/aws4_request"
+	hashed := sha256.Sum256([]byte(canonical))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashed[:])
+
+	key := []byte("AWS4" + testSecretKey)
+	for _, part := range []string{amzDate[:8], testRegion, "s3", "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	wantAuth := "AWS4-HMAC-SHA256 Credential=" + testAccessKey + "/" + scope +
+		", SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=" + hex.EncodeToString(key)
+	if got := r.Header.Get("Authorization"); got != wantAuth {
+		return "", errors.New("Authorization is " + got + ", want " + wantAuth)
+	}
+	return canonical, nil
+}
+
+// newFakeS3 starts a fake bucket and returns a backend talking to it
+func newFakeS3(t *testing.T, forcePathStyle bool) (*S3, *fakeS3) {
+	t.Helper()
+	fake := &fakeS3{t: t, bucket: "invoices", objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	store, err := NewS3(S3Config{
+		Endpoint:        server.URL,
+		Region:          testRegion,
+		Bucket:          fake.bucket,
+		AccessKeyID:     testAccessKey,
+		SecretAccessKey: testSecretKey,
+		ForcePathStyle:  forcePathStyle,
+	})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	// Virtual-hosted requests go to invoices.127.0.0.1:port; send them to the test server
+	addr := server.Listener.Addr().String()
+	store.client = &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+	return store, fake
+}
+
+func TestS3PutOpenDelete(t *testing.T) {
+	for _, pathStyle := range []bool{true, false} {
+		store, fake := newFakeS3(t, pathStyle)
+		ctx := context.Background()
+		key := "invoice/12/3f9c 2024+report~v1.pdf"
+		body := "%PDF-1.4 test"
+
+		if err := store.Put(ctx, key, strings.NewReader(body), int64(len(body)), "application/pdf"); err != nil {
+			t.Fatalf("path style %v: Put: %v", pathStyle, err)
+		}
+		if got := fake.types[key]; got != "application/pdf" {
+			t.Errorf("path style %v: stored content type %q; want application/pdf", pathStyle, got)
+		}
+
+		wantPath := "/invoice/12/3f9c%202024%2Breport~v1.pdf"
+		if pathStyle {
+			wantPath = "/invoices" + wantPath
+		}
+		if lines := strings.Split(fake.last.canonicalRequest, "\n"); lines[0] != "PUT" || lines[1] != wantPath || lines[len(lines)-1] != unsignedPayload {
+			t.Errorf("path style %v: canonical request\n%s\nwant PUT %s with an unsigned payload", pathStyle, fake.last.canonicalRequest, wantPath)
+		}
+		if !strings.HasPrefix(fake.last.authorization, "AWS4-HMAC-SHA256 Credential="+testAccessKey+"/") {
+			t.Errorf("path style %v: Authorization = %q", pathStyle, fake.last.authorization)
+		}
+
+		reader, err := store.Open(ctx, key)
+		if err != nil {
+			t.Fatalf("path style %v: Open: %v", pathStyle, err)
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil || string(data) != body {
+			t.Errorf("path style %v: Open read %q, %v; want %q", pathStyle, data, err, body)
+		}
+		if lines := strings.Split(fake.last.canonicalRequest, "\n"); lines[0] != "GET" || lines[len(lines)-1] != emptyPayloadHash {
+			t.Errorf("path style %v: canonical request of Open\n%s", pathStyle, fake.last.canonicalRequest)
+		}
+
+		if err := store.Delete(ctx, key); err != nil {
+			t.Fatalf("path style %v: Delete: %v", pathStyle, err)
+		}
+		if len(fake.objects) != 0 {
+			t.Errorf("path style %v: %d objects left after Delete", pathStyle, len(fake.objects))
+		}
+		if _, err := store.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("path style %v: Open after Delete error = %v; want %v", pathStyle, err, ErrNotFound)
+		}
+		if err := store.Delete(ctx, key); err != nil {
+			t.Errorf("path style %v: deleting a missing object: %v", pathStyle, err)
+		}
+	}
+}
+
+func TestS3ErrorResponse(t *testing.T) {
+	store, fake := newFakeS3(t, true)
+	store.cfg.SecretAccessKey = "wrong"
+	fake.rejectQuietly = true
+
+	err := store.Put(context.Background(), "invoice/1/a", strings.NewReader("x"), 1, "text/plain")
+	if err == nil || !strings.Contains(err.Error(), "403 Forbidden: SignatureDoesNotMatch") {
+		t.Errorf("Put with a wrong secret error = %v; want the S3 error code", err)
+	}
+}
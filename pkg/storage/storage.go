@@ -0,0 +1,61 @@
+// Package storage keeps uploaded files in a directory on the local filesystem or in a
+// bucket of an S3-compatible object store such as AWS S3 or MinIO.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// Storage stores objects under slash separated keys such as invoice/12/3f9c...
+type Storage interface {
+	// Put stores size bytes read from r under key, replacing any object with that key
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open streams the object stored under key; the caller closes it
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key; a missing object is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// Drivers
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// Config selects and configures a storage backend
+type Config struct {
+	Driver   string // local or s3
+	LocalDir string // directory of the local driver, created when missing
+	S3       S3Config
+}
+
+// New returns the storage backend selected by cfg
+func New(cfg Config) (Storage, error) {
+	switch cfg.Driver {
+	case DriverLocal:
+		return NewLocal(cfg.LocalDir)
+	case DriverS3:
+		return NewS3(cfg.S3)
+	}
+	return nil, fmt.Errorf("unknown storage driver %q (expected local or s3)", cfg.Driver)
+}
+
+// checkKey rejects keys that could escape the storage root or do not map to an object
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid object key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid object key %q", key)
+		}
+	}
+	return nil
+}
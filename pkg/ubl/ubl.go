@@ -0,0 +1,357 @@
+// Package ubl reads and writes UBL 2.1 invoices following the Peppol BIS Billing 3.0
+// profile of the European e-invoicing standard EN 16931, and checks them against the
+// main rules of that profile.
+package ubl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Namespaces of UBL invoices
+const (
+	InvoiceNamespace    = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	CreditNoteNamespace = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"
+	CACNamespace        = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	CBCNamespace        = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+)
+
+// Peppol BIS Billing 3.0 identifiers
+const (
+	CustomizationID = "urn:cen.eu:en16931:2017#compliant#urn:fdc:peppol.eu:2017:poacc:billing:3.0"
+	ProfileID       = "urn:fdc:peppol.eu:2017:poacc:billing:01:1.0"
+)
+
+const (
+	// InvoiceTypeCommercial is the UNCL1001 code of a commercial invoice
+	InvoiceTypeCommercial = "380"
+	// TaxSchemeVAT identifies value added tax
+	TaxSchemeVAT = "VAT"
+	// UnitOne is the UN/ECE Recommendation 20 code of a unit without a measure, "one"
+	UnitOne = "C62"
+	// AllowanceReasonDiscount is the UNCL5189 code of a discount
+	AllowanceReasonDiscount = "95"
+	// EndpointSchemeEmail is the electronic address scheme of email addresses
+	EndpointSchemeEmail = "EM"
+)
+
+// VAT category codes (UNCL5305)
+const (
+	CategoryStandard       = "S"
+	CategoryZero           = "Z"
+	CategoryExempt         = "E"
+	CategoryReverseCharge  = "AE"
+	CategoryIntraCommunity = "K"
+	CategoryExport         = "G"
+	CategoryOutOfScope     = "O"
+	CategoryCanaryIslands  = "L"
+	CategoryCeutaMelilla   = "M"
+)
+
+// ErrUnsupportedDocument is returned when a document is well-formed XML but not a UBL invoice
+var ErrUnsupportedDocument = errors.New("unsupported document")
+
+// Invoice is a UBL 2.1 invoice, limited to the elements used by Peppol BIS Billing 3.0.
+// Fields are declared in the order the UBL schema requires.
+type Invoice struct {
+	XMLName              xml.Name          `xml:"urn:oasis:names:specification:ubl:schema:xsd:Invoice-2 Invoice"`
+	XMLNSCAC             string            `xml:"xmlns:cac,attr,omitempty"`
+	XMLNSCBC             string            `xml:"xmlns:cbc,attr,omitempty"`
+	CustomizationID      string            `xml:"cbc:CustomizationID,omitempty"`
+	ProfileID            string            `xml:"cbc:ProfileID,omitempty"`
+	ID                   string            `xml:"cbc:ID,omitempty"`
+	IssueDate            string            `xml:"cbc:IssueDate,omitempty"`
+	DueDate              string            `xml:"cbc:DueDate,omitempty"`
+	InvoiceTypeCode      string            `xml:"cbc:InvoiceTypeCode,omitempty"`
+	Notes                []string          `xml:"cbc:Note"`
+	DocumentCurrencyCode string            `xml:"cbc:DocumentCurrencyCode,omitempty"`
+	BuyerReference       string            `xml:"cbc:BuyerReference,omitempty"`
+	OrderReference       *OrderReference   `xml:"cac:OrderReference"`
+	Supplier             *PartyRole        `xml:"cac:AccountingSupplierParty"`
+	Customer             *PartyRole        `xml:"cac:AccountingCustomerParty"`
+	PaymentTerms         *PaymentTerms     `xml:"cac:PaymentTerms"`
+	AllowanceCharges     []AllowanceCharge `xml:"cac:AllowanceCharge"`
+	TaxTotals            []TaxTotal        `xml:"cac:TaxTotal"`
+	LegalMonetaryTotal   *MonetaryTotal    `xml:"cac:LegalMonetaryTotal"`
+	Lines                []InvoiceLine     `xml:"cac:InvoiceLine"`
+}
+
+// OrderReference is the purchase order an invoice refers to
+type OrderReference struct {
+	ID string `xml:"cbc:ID"`
+}
+
+// PartyRole wraps the party of the seller or the buyer
+type PartyRole struct {
+	Party Party `xml:"cac:Party"`
+}
+
+type Party struct {
+	EndpointID      *Identifier      `xml:"cbc:EndpointID"`
+	PartyName       *PartyName       `xml:"cac:PartyName"`
+	PostalAddress   *Address         `xml:"cac:PostalAddress"`
+	PartyTaxSchemes []PartyTaxScheme `xml:"cac:PartyTaxScheme"`
+	LegalEntity     *LegalEntity     `xml:"cac:PartyLegalEntity"`
+	Contact         *Contact         `xml:"cac:Contact"`
+}
+
+// VATID returns the VAT identifier of a party, if it has one
+func (p *Party) VATID() string {
+	for _, scheme := range p.PartyTaxSchemes {
+		if strings.TrimSpace(scheme.TaxScheme.ID) == TaxSchemeVAT {
+			return strings.TrimSpace(scheme.CompanyID)
+		}
+	}
+	return ""
+}
+
+// Name returns the legal name of a party, or its trading name when it has none
+func (p *Party) Name() string {
+	if p.LegalEntity != nil && strings.TrimSpace(p.LegalEntity.RegistrationName) != "" {
+		return strings.TrimSpace(p.LegalEntity.RegistrationName)
+	}
+	if p.PartyName != nil {
+		return strings.TrimSpace(p.PartyName.Name)
+	}
+	return ""
+}
+
+// Identifier is an identifier with an optional scheme, such as an electronic address
+type Identifier struct {
+	Value    string `xml:",chardata"`
+	SchemeID string `xml:"schemeID,attr,omitempty"`
+}
+
+type PartyName struct {
+	Name string `xml:"cbc:Name"`
+}
+
+type Address struct {
+	StreetName           string       `xml:"cbc:StreetName,omitempty"`
+	AdditionalStreetName string       `xml:"cbc:AdditionalStreetName,omitempty"`
+	CityName             string       `xml:"cbc:CityName,omitempty"`
+	PostalZone           string       `xml:"cbc:PostalZone,omitempty"`
+	CountrySubentity     string       `xml:"cbc:CountrySubentity,omitempty"`
+	AddressLine          *AddressLine `xml:"cac:AddressLine"`
+	Country              *Country     `xml:"cac:Country"`
+}
+
+type AddressLine struct {
+	Line string `xml:"cbc:Line"`
+}
+
+type Country struct {
+	IdentificationCode string `xml:"cbc:IdentificationCode"` // ISO 3166-1 alpha-2
+}
+
+type PartyTaxScheme struct {
+	CompanyID string    `xml:"cbc:CompanyID"`
+	TaxScheme TaxScheme `xml:"cac:TaxScheme"`
+}
+
+type TaxScheme struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type LegalEntity struct {
+	RegistrationName string      `xml:"cbc:RegistrationName,omitempty"`
+	CompanyID        *Identifier `xml:"cbc:CompanyID"`
+}
+
+type Contact struct {
+	Name           string `xml:"cbc:Name,omitempty"`
+	Telephone      string `xml:"cbc:Telephone,omitempty"`
+	ElectronicMail string `xml:"cbc:ElectronicMail,omitempty"`
+}
+
+type PaymentTerms struct {
+	Note string `xml:"cbc:Note"`
+}
+
+// AllowanceCharge is a discount (ChargeIndicator false) or a charge (true) on the whole
+// invoice or on one line
+type AllowanceCharge struct {
+	ChargeIndicator string       `xml:"cbc:ChargeIndicator"`
+	ReasonCode      string       `xml:"cbc:AllowanceChargeReasonCode,omitempty"`
+	Reason          string       `xml:"cbc:AllowanceChargeReason,omitempty"`
+	Amount          *Amount      `xml:"cbc:Amount"`
+	TaxCategory     *TaxCategory `xml:"cac:TaxCategory"`
+}
+
+// IsCharge reports whether the entry is a charge rather than an allowance
+func (a *AllowanceCharge) IsCharge() bool {
+	return strings.TrimSpace(a.ChargeIndicator) == "true"
+}
+
+type TaxTotal struct {
+	TaxAmount *Amount       `xml:"cbc:TaxAmount"`
+	Subtotals []TaxSubtotal `xml:"cac:TaxSubtotal"`
+}
+
+// TaxSubtotal is the VAT breakdown of one VAT category and rate
+type TaxSubtotal struct {
+	TaxableAmount *Amount      `xml:"cbc:TaxableAmount"`
+	TaxAmount     *Amount      `xml:"cbc:TaxAmount"`
+	TaxCategory   *TaxCategory `xml:"cac:TaxCategory"`
+}
+
+type TaxCategory struct {
+	ID                  string    `xml:"cbc:ID"`
+	Percent             string    `xml:"cbc:Percent,omitempty"`
+	ExemptionReasonCode string    `xml:"cbc:TaxExemptionReasonCode,omitempty"`
+	ExemptionReason     string    `xml:"cbc:TaxExemptionReason,omitempty"`
+	TaxScheme           TaxScheme `xml:"cac:TaxScheme"`
+}
+
+type MonetaryTotal struct {
+	LineExtensionAmount   *Amount `xml:"cbc:LineExtensionAmount"`
+	TaxExclusiveAmount    *Amount `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusiveAmount    *Amount `xml:"cbc:TaxInclusiveAmount"`
+	AllowanceTotalAmount  *Amount `xml:"cbc:AllowanceTotalAmount"`
+	ChargeTotalAmount     *Amount `xml:"cbc:ChargeTotalAmount"`
+	PrepaidAmount         *Amount `xml:"cbc:PrepaidAmount"`
+	PayableRoundingAmount *Amount `xml:"cbc:PayableRoundingAmount"`
+	PayableAmount         *Amount `xml:"cbc:PayableAmount"`
+}
+
+type InvoiceLine struct {
+	ID                  string            `xml:"cbc:ID"`
+	Note                string            `xml:"cbc:Note,omitempty"`
+	InvoicedQuantity    *Quantity         `xml:"cbc:InvoicedQuantity"`
+	LineExtensionAmount *Amount           `xml:"cbc:LineExtensionAmount"`
+	AllowanceCharges    []AllowanceCharge `xml:"cac:AllowanceCharge"`
+	Item                *Item             `xml:"cac:Item"`
+	Price               *Price            `xml:"cac:Price"`
+}
+
+type Item struct {
+	Description               string              `xml:"cbc:Description,omitempty"`
+	Name                      string              `xml:"cbc:Name,omitempty"`
+	SellersItemIdentification *ItemIdentification `xml:"cac:SellersItemIdentification"`
+	ClassifiedTaxCategory     *TaxCategory        `xml:"cac:ClassifiedTaxCategory"`
+}
+
+type ItemIdentification struct {
+	ID string `xml:"cbc:ID"`
+}
+
+// Price is the net price of BaseQuantity units, one unit when it is omitted
+type Price struct {
+	PriceAmount  *Amount   `xml:"cbc:PriceAmount"`
+	BaseQuantity *Quantity `xml:"cbc:BaseQuantity"`
+}
+
+// Amount is a decimal amount in a currency
+type Amount struct {
+	Value      string `xml:",chardata"`
+	CurrencyID string `xml:"currencyID,attr"`
+}
+
+// Quantity is a decimal quantity in a unit of measure
+type Quantity struct {
+	Value    string `xml:",chardata"`
+	UnitCode string `xml:"unitCode,attr,omitempty"`
+}
+
+// Marshal renders the invoice as an XML document with the cac and cbc prefixes
+func (inv *Invoice) Marshal() ([]byte, error) {
+	inv.XMLNSCAC, inv.XMLNSCBC = CACNamespace, CBCNamespace
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(inv); err != nil {
+		return nil, fmt.Errorf("failed to render UBL invoice: %w", err)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// Parse reads a UBL invoice. Elements are matched by namespace, whatever prefixes the
+// document uses; unknown elements are ignored. The standard library parser does not
+// resolve external entities, so documents cannot read local files or URLs.
+func Parse(r io.Reader) (*Invoice, error) {
+	var inv Invoice
+	if err := xml.NewTokenDecoder(&prefixer{decoder: xml.NewDecoder(r)}).Decode(&inv); err != nil {
+		if errors.Is(err, ErrUnsupportedDocument) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid XML: %w", err)
+	}
+	return &inv, nil
+}
+
+// prefixer renames cac and cbc elements to the prefixed names Invoice declares, so
+// documents decode the same whether they use those prefixes, others or none
+type prefixer struct {
+	decoder *xml.Decoder
+	started bool
+}
+
+func (p *prefixer) Token() (xml.Token, error) {
+	token, err := p.decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := token.(type) {
+	case xml.StartElement:
+		if !p.started {
+			p.started = true
+			switch {
+			case t.Name.Space == CreditNoteNamespace && t.Name.Local == "CreditNote":
+				return nil, fmt.Errorf("%w: UBL credit notes are not supported, only invoices", ErrUnsupportedDocument)
+			case t.Name.Space != InvoiceNamespace || t.Name.Local != "Invoice":
+				return nil, fmt.Errorf("%w: expected a UBL Invoice element in namespace %s, got %s", ErrUnsupportedDocument, InvoiceNamespace, t.Name.Local)
+			}
+		}
+		t.Name = prefixed(t.Name)
+		attrs := make([]xml.Attr, 0, len(t.Attr))
+		for _, attr := range t.Attr {
+			if attr.Name.Space == "" {
+				attrs = append(attrs, attr)
+			}
+		}
+		t.Attr = attrs
+		return xml.CopyToken(t), nil
+	case xml.EndElement:
+		t.Name = prefixed(t.Name)
+		return t, nil
+	}
+	return xml.CopyToken(token), nil
+}
+
+func prefixed(name xml.Name) xml.Name {
+	switch name.Space {
+	case CACNamespace:
+		return xml.Name{Local: "cac:" + name.Local}
+	case CBCNamespace:
+		return xml.Name{Local: "cbc:" + name.Local}
+	}
+	return name
+}
+
+// ParseEndpoint reads an electronic address written as scheme:identifier, such as
+// 0088:5790000435951 or 0192:987654321, and checks the scheme against the Peppol list
+func ParseEndpoint(s string) (*Identifier, error) {
+	scheme, id, ok := strings.Cut(strings.TrimSpace(s), ":")
+	scheme, id = strings.ToUpper(strings.TrimSpace(scheme)), strings.TrimSpace(id)
+	if !ok || scheme == "" || id == "" {
+		return nil, fmt.Errorf("electronic address %q must be written as scheme:identifier, e.g. 0088:5790000435951", s)
+	}
+	if !endpointSchemes[scheme] {
+		return nil, fmt.Errorf("unknown electronic address scheme %q", scheme)
+	}
+	return &Identifier{Value: id, SchemeID: scheme}, nil
+}
+
+// String writes an identifier as scheme:identifier
+func (i *Identifier) String() string {
+	if i.SchemeID == "" {
+		return strings.TrimSpace(i.Value)
+	}
+	return strings.TrimSpace(i.SchemeID) + ":" + strings.TrimSpace(i.Value)
+}
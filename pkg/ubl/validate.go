@@ -0,0 +1,665 @@
+package ubl
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/go-api-starter/pkg/money"
+)
+
+// Issue severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue is one rule an invoice breaks. Rule is the identifier of the EN 16931 or Peppol
+// rule where there is one, such as BR-CO-10 or PEPPOL-EN16931-R003.
+type Issue struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// HasErrors reports whether any of the issues is an error
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// invoiceTypeCodes are the UNCL1001 invoice type codes Peppol BIS Billing 3.0 accepts
+var invoiceTypeCodes = map[string]bool{
+	"71": true, "80": true, "82": true, "84": true, "102": true, "218": true, "219": true,
+	"331": true, "380": true, "382": true, "383": true, "386": true, "388": true, "393": true,
+	"395": true, "553": true, "575": true, "623": true, "780": true, "817": true, "870": true,
+	"875": true, "876": true, "877": true,
+}
+
+// categoryRules maps VAT category codes to the prefix of their EN 16931 rules
+var categoryRules = map[string]string{
+	CategoryStandard:       "BR-S",
+	CategoryZero:           "BR-Z",
+	CategoryExempt:         "BR-E",
+	CategoryReverseCharge:  "BR-AE",
+	CategoryIntraCommunity: "BR-IC",
+	CategoryExport:         "BR-G",
+	CategoryOutOfScope:     "BR-O",
+	CategoryCanaryIslands:  "BR-IG",
+	CategoryCeutaMelilla:   "BR-IP",
+}
+
+// endpointSchemes is the Peppol list of electronic address schemes (EAS)
+var endpointSchemes = map[string]bool{}
+
+func init() {
+	for _, scheme := range strings.Fields(`0002 0007 0009 0037 0060 0088 0096 0097 0106 0130 0135
+		0142 0147 0151 0154 0158 0170 0177 0183 0184 0188 0190 0191 0192 0193 0194 0195 0196 0198
+		0199 0200 0201 0202 0203 0204 0205 0208 0209 0210 0211 0212 0213 0215 0216 0217 0218 0219
+		0220 0221 0225 0230 0235 0240 0242 0244 9901 9910 9913 9914 9915 9918 9919 9920 9922 9923
+		9924 9925 9926 9927 9928 9929 9930 9931 9932 9933 9934 9935 9936 9937 9938 9939 9940 9941
+		9942 9943 9944 9945 9946 9947 9948 9949 9950 9951 9952 9953 9957 9959 AN AQ AS AU EM`) {
+		endpointSchemes[scheme] = true
+	}
+}
+
+var (
+	countryCode  = regexp.MustCompile(`^[A-Z]{2}$`)
+	vatPrefix    = regexp.MustCompile(`^[A-Z]{2}`)
+	unitCode     = regexp.MustCompile(`^[A-Z0-9]{2,3}$`)
+	decimalValue = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+	// tolerance is the rounding difference accepted between a VAT amount and its rate
+	// applied to the taxable amount
+	tolerance = big.NewRat(1, 100)
+)
+
+// Validate checks an invoice against the cardinalities, code lists and calculation rules
+// of EN 16931 and Peppol BIS Billing 3.0 that matter for the elements Invoice models. It
+// does not replace the official Schematron, which also covers elements not read here.
+func Validate(inv *Invoice) []Issue {
+	v := &validator{currency: strings.TrimSpace(inv.DocumentCurrencyCode)}
+	v.header(inv)
+	seller := v.party(inv.Supplier, "seller", "BR-06", "BR-08", "BR-09", "PEPPOL-EN16931-R020", "BR-62")
+	buyer := v.party(inv.Customer, "buyer", "BR-07", "BR-10", "BR-11", "PEPPOL-EN16931-R010", "BR-63")
+
+	breakdown := newBreakdown()
+	lineTotal, _ := v.lines(inv, breakdown)
+	allowances, charges := v.documentAllowanceCharges(inv, breakdown)
+	v.totals(inv, lineTotal, allowances, charges)
+	v.taxes(inv, breakdown, seller, buyer)
+	return v.issues
+}
+
+type validator struct {
+	currency string
+	issues   []Issue
+}
+
+func (v *validator) errorf(rule, format string, args ...interface{}) {
+	v.issues = append(v.issues, Issue{Rule: rule, Severity: SeverityError, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) warnf(rule, format string, args ...interface{}) {
+	v.issues = append(v.issues, Issue{Rule: rule, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) header(inv *Invoice) {
+	switch customization := strings.TrimSpace(inv.CustomizationID); customization {
+	case CustomizationID:
+	case "":
+		v.errorf("BR-01", "the specification identifier (CustomizationID) is missing")
+	default:
+		v.errorf("PEPPOL-EN16931-R004", "the specification identifier must be %s, got %s", CustomizationID, customization)
+	}
+	if strings.TrimSpace(inv.ProfileID) == "" {
+		v.errorf("PEPPOL-EN16931-R001", "the business process (ProfileID) is missing")
+	}
+	if strings.TrimSpace(inv.ID) == "" {
+		v.errorf("BR-02", "the invoice number (ID) is missing")
+	}
+	if strings.TrimSpace(inv.IssueDate) == "" {
+		v.errorf("BR-03", "the issue date is missing")
+	} else if _, err := ParseDate(inv.IssueDate); err != nil {
+		v.errorf("BR-03", "the issue date %q is not a YYYY-MM-DD date", inv.IssueDate)
+	}
+	if strings.TrimSpace(inv.DueDate) != "" {
+		if _, err := ParseDate(inv.DueDate); err != nil {
+			v.errorf("BR-CO-25", "the due date %q is not a YYYY-MM-DD date", inv.DueDate)
+		}
+	}
+	switch typeCode := strings.TrimSpace(inv.InvoiceTypeCode); {
+	case typeCode == "":
+		v.errorf("BR-04", "the invoice type code is missing")
+	case !invoiceTypeCodes[typeCode]:
+		v.errorf("BR-CL-01", "invoice type code %s is not an invoice type Peppol accepts", typeCode)
+	}
+	if v.currency == "" {
+		v.errorf("BR-05", "the document currency code is missing")
+	} else if _, err := money.ParseCurrency(v.currency); err != nil || strings.ToUpper(v.currency) != v.currency {
+		v.errorf("BR-CL-04", "document currency %q is not an ISO 4217 code", v.currency)
+	}
+	if strings.TrimSpace(inv.BuyerReference) == "" && (inv.OrderReference == nil || strings.TrimSpace(inv.OrderReference.ID) == "") {
+		v.errorf("PEPPOL-EN16931-R003", "a buyer reference or a purchase order reference is required")
+	}
+}
+
+// party checks the seller or buyer and returns it, or nil when it is missing
+func (v *validator) party(role *PartyRole, label, nameRule, addressRule, countryRule, endpointRule, schemeRule string) *Party {
+	if role == nil {
+		v.errorf(nameRule, "the %s is missing", label)
+		return nil
+	}
+	party := &role.Party
+	if party.LegalEntity == nil || strings.TrimSpace(party.LegalEntity.RegistrationName) == "" {
+		v.errorf(nameRule, "the %s name (PartyLegalEntity/RegistrationName) is missing", label)
+	}
+	switch {
+	case party.PostalAddress == nil:
+		v.errorf(addressRule, "the %s postal address is missing", label)
+	case party.PostalAddress.Country == nil || strings.TrimSpace(party.PostalAddress.Country.IdentificationCode) == "":
+		v.errorf(countryRule, "the %s country code is missing", label)
+	case !countryCode.MatchString(strings.TrimSpace(party.PostalAddress.Country.IdentificationCode)):
+		v.errorf("BR-CL-14", "the %s country code %q is not an ISO 3166-1 alpha-2 code", label, party.PostalAddress.Country.IdentificationCode)
+	}
+	switch {
+	case party.EndpointID == nil || strings.TrimSpace(party.EndpointID.Value) == "":
+		v.errorf(endpointRule, "the %s electronic address (EndpointID) is missing", label)
+	case strings.TrimSpace(party.EndpointID.SchemeID) == "":
+		v.errorf(schemeRule, "the %s electronic address has no scheme identifier", label)
+	case !endpointSchemes[strings.TrimSpace(party.EndpointID.SchemeID)]:
+		v.errorf("PEPPOL-EN16931-CL008", "the %s electronic address scheme %q is not on the Peppol list", label, party.EndpointID.SchemeID)
+	}
+	for _, scheme := range party.PartyTaxSchemes {
+		if strings.TrimSpace(scheme.TaxScheme.ID) == TaxSchemeVAT && !vatPrefix.MatchString(strings.TrimSpace(scheme.CompanyID)) {
+			v.errorf("BR-CO-09", "the %s VAT identifier %q must start with a country code", label, scheme.CompanyID)
+		}
+	}
+	return party
+}
+
+// lines checks the invoice lines, adds their net amounts to the VAT breakdown and
+// returns their sum
+func (v *validator) lines(inv *Invoice, breakdown *breakdown) (*big.Rat, bool) {
+	total := new(big.Rat)
+	if len(inv.Lines) == 0 {
+		v.errorf("BR-16", "the invoice has no lines")
+		return total, false
+	}
+	complete := true
+	for i := range inv.Lines {
+		line := &inv.Lines[i]
+		label := fmt.Sprintf("line %d", i+1)
+		if strings.TrimSpace(line.ID) == "" {
+			v.errorf("BR-21", "%s has no identifier", label)
+		}
+
+		var quantity *big.Rat
+		if line.InvoicedQuantity == nil || strings.TrimSpace(line.InvoicedQuantity.Value) == "" {
+			v.errorf("BR-22", "%s has no invoiced quantity", label)
+		} else if q, ok := ParseDecimal(line.InvoicedQuantity.Value); !ok {
+			v.errorf("BR-22", "%s quantity %q is not a decimal number", label, line.InvoicedQuantity.Value)
+		} else {
+			quantity = q
+		}
+		if line.InvoicedQuantity != nil && !unitCode.MatchString(strings.TrimSpace(line.InvoicedQuantity.UnitCode)) {
+			v.errorf("BR-23", "%s has no valid unit of measure code", label)
+		}
+
+		net := v.amount("BR-24", label+" net amount", line.LineExtensionAmount, true)
+		if net != nil {
+			total.Add(total, net)
+		} else {
+			complete = false
+		}
+
+		if line.Item == nil || strings.TrimSpace(line.Item.Name) == "" {
+			v.errorf("BR-25", "%s has no item name", label)
+		}
+		var category *TaxCategory
+		if line.Item == nil || line.Item.ClassifiedTaxCategory == nil || strings.TrimSpace(line.Item.ClassifiedTaxCategory.ID) == "" {
+			v.errorf("BR-CO-04", "%s has no VAT category", label)
+		} else {
+			category = line.Item.ClassifiedTaxCategory
+			v.category(label, category, "BR-CO-04")
+		}
+
+		var price, base *big.Rat
+		if line.Price == nil || line.Price.PriceAmount == nil {
+			v.errorf("BR-26", "%s has no item net price", label)
+		} else if price = v.decimal("BR-26", label+" price", line.Price.PriceAmount); price != nil && price.Sign() < 0 {
+			v.errorf("BR-27", "%s price must not be negative", label)
+		}
+		base = big.NewRat(1, 1)
+		if line.Price != nil && line.Price.BaseQuantity != nil {
+			if b, ok := ParseDecimal(line.Price.BaseQuantity.Value); !ok || b.Sign() <= 0 {
+				v.errorf("BR-26", "%s price base quantity %q must be a positive number", label, line.Price.BaseQuantity.Value)
+				base = nil
+			} else {
+				base = b
+			}
+		}
+
+		adjustments := new(big.Rat)
+		for j := range line.AllowanceCharges {
+			entry := &line.AllowanceCharges[j]
+			entryLabel := fmt.Sprintf("%s allowance or charge %d", label, j+1)
+			amount := v.allowanceCharge(entry, entryLabel, "BR-41", "BR-42", "BR-43", "BR-44")
+			if amount == nil {
+				continue
+			}
+			if entry.IsCharge() {
+				adjustments.Add(adjustments, amount)
+			} else {
+				adjustments.Sub(adjustments, amount)
+			}
+		}
+
+		// The line net amount is the quantity times the price per unit plus line charges
+		// less line allowances, rounded to two decimals
+		if net != nil && quantity != nil && price != nil && base != nil {
+			expected := new(big.Rat).Mul(quantity, new(big.Rat).Quo(price, base))
+			expected.Add(expected, adjustments)
+			if roundCents(expected).Cmp(net) != 0 {
+				v.errorf("PEPPOL-EN16931-R120", "%s net amount %s does not equal quantity × price ± allowances and charges (%s)", label, formatRat(net), formatRat(roundCents(expected)))
+			}
+		}
+		if net != nil && category != nil {
+			breakdown.add(category, net)
+		}
+	}
+	return total, complete
+}
+
+// documentAllowanceCharges checks the allowances and charges on the whole invoice, adds
+// them to the VAT breakdown and returns their sums
+func (v *validator) documentAllowanceCharges(inv *Invoice, breakdown *breakdown) (*big.Rat, *big.Rat) {
+	allowances, charges := new(big.Rat), new(big.Rat)
+	for i := range inv.AllowanceCharges {
+		entry := &inv.AllowanceCharges[i]
+		label := fmt.Sprintf("document allowance or charge %d", i+1)
+		amountRule, categoryRule, reasonRule := "BR-31", "BR-32", "BR-33"
+		if entry.IsCharge() {
+			amountRule, categoryRule, reasonRule = "BR-36", "BR-37", "BR-38"
+		}
+		amount := v.allowanceCharge(entry, label, amountRule, reasonRule, "", "")
+		if entry.TaxCategory == nil || strings.TrimSpace(entry.TaxCategory.ID) == "" {
+			v.errorf(categoryRule, "%s has no VAT category", label)
+		} else {
+			v.category(label, entry.TaxCategory, categoryRule)
+		}
+		if amount == nil {
+			continue
+		}
+		if entry.IsCharge() {
+			charges.Add(charges, amount)
+		} else {
+			allowances.Add(allowances, amount)
+			amount = new(big.Rat).Neg(amount)
+		}
+		if entry.TaxCategory != nil && strings.TrimSpace(entry.TaxCategory.ID) != "" {
+			breakdown.add(entry.TaxCategory, amount)
+		}
+	}
+	return allowances, charges
+}
+
+// allowanceCharge checks the indicator, amount and reason of an allowance or charge; the
+// charge rules are used when the entry is a charge and given
+func (v *validator) allowanceCharge(entry *AllowanceCharge, label, amountRule, reasonRule, chargeAmountRule, chargeReasonRule string) *big.Rat {
+	if indicator := strings.TrimSpace(entry.ChargeIndicator); indicator != "true" && indicator != "false" {
+		v.errorf("UBL-CR-ChargeIndicator", "%s charge indicator must be true or false, got %q", label, entry.ChargeIndicator)
+		return nil
+	}
+	if entry.IsCharge() && chargeAmountRule != "" {
+		amountRule, reasonRule = chargeAmountRule, chargeReasonRule
+	}
+	if strings.TrimSpace(entry.Reason) == "" && strings.TrimSpace(entry.ReasonCode) == "" {
+		v.errorf(reasonRule, "%s has neither a reason nor a reason code", label)
+	}
+	amount := v.amount(amountRule, label+" amount", entry.Amount, true)
+	if amount != nil && amount.Sign() < 0 {
+		v.errorf(amountRule, "%s amount must not be negative", label)
+	}
+	return amount
+}
+
+// category checks the code, scheme and rate of a VAT category
+func (v *validator) category(label string, category *TaxCategory, rule string) {
+	code := strings.TrimSpace(category.ID)
+	if categoryRules[code] == "" {
+		v.errorf("BR-CL-18", "%s VAT category %q is not a UNCL5305 code", label, category.ID)
+		return
+	}
+	if strings.TrimSpace(category.TaxScheme.ID) != TaxSchemeVAT {
+		v.errorf(rule, "%s VAT category must use the VAT tax scheme", label)
+	}
+	percent, hasPercent := ParseDecimal(category.Percent)
+	switch {
+	case code == CategoryOutOfScope && strings.TrimSpace(category.Percent) != "":
+		v.errorf("BR-O-05", "%s is not subject to VAT and must not have a VAT rate", label)
+	case code == CategoryOutOfScope:
+	case !hasPercent:
+		v.errorf(categoryRules[code]+"-05", "%s VAT category %s needs a VAT rate", label, code)
+	case code == CategoryStandard && percent.Sign() <= 0:
+		v.errorf("BR-S-05", "%s standard rated VAT must have a rate above zero", label)
+	case code != CategoryStandard && code != CategoryCanaryIslands && code != CategoryCeutaMelilla && percent.Sign() != 0:
+		v.errorf(categoryRules[code]+"-05", "%s VAT category %s must have a rate of zero", label, code)
+	}
+}
+
+func (v *validator) totals(inv *Invoice, lineTotal, allowances, charges *big.Rat) {
+	totals := inv.LegalMonetaryTotal
+	if totals == nil {
+		v.errorf("BR-12", "the document totals (LegalMonetaryTotal) are missing")
+		return
+	}
+	lines := v.amount("BR-12", "sum of line net amounts", totals.LineExtensionAmount, true)
+	exclusive := v.amount("BR-13", "total without VAT", totals.TaxExclusiveAmount, true)
+	inclusive := v.amount("BR-14", "total with VAT", totals.TaxInclusiveAmount, true)
+	payable := v.amount("BR-15", "amount due for payment", totals.PayableAmount, true)
+	allowanceTotal := v.optionalAmount("BR-CO-11", "sum of allowances", totals.AllowanceTotalAmount)
+	chargeTotal := v.optionalAmount("BR-CO-12", "sum of charges", totals.ChargeTotalAmount)
+	prepaid := v.optionalAmount("BR-CO-16", "paid amount", totals.PrepaidAmount)
+	rounding := v.optionalAmount("BR-CO-16", "rounding amount", totals.PayableRoundingAmount)
+
+	if lines != nil && lines.Cmp(lineTotal) != 0 {
+		v.errorf("BR-CO-10", "sum of line net amounts %s does not equal the lines, which add up to %s", formatRat(lines), formatRat(lineTotal))
+	}
+	if allowanceTotal != nil && allowanceTotal.Cmp(allowances) != 0 {
+		v.errorf("BR-CO-11", "sum of allowances %s does not equal the document allowances, which add up to %s", formatRat(allowanceTotal), formatRat(allowances))
+	}
+	if chargeTotal != nil && chargeTotal.Cmp(charges) != 0 {
+		v.errorf("BR-CO-12", "sum of charges %s does not equal the document charges, which add up to %s", formatRat(chargeTotal), formatRat(charges))
+	}
+	if lines != nil && exclusive != nil && allowanceTotal != nil && chargeTotal != nil {
+		expected := new(big.Rat).Sub(lines, allowanceTotal)
+		expected.Add(expected, chargeTotal)
+		if exclusive.Cmp(expected) != 0 {
+			v.errorf("BR-CO-13", "total without VAT %s does not equal lines - allowances + charges (%s)", formatRat(exclusive), formatRat(expected))
+		}
+	}
+	if exclusive != nil && inclusive != nil {
+		if tax := v.documentTax(inv); tax != nil {
+			if expected := new(big.Rat).Add(exclusive, tax); inclusive.Cmp(expected) != 0 {
+				v.errorf("BR-CO-15", "total with VAT %s does not equal the total without VAT plus VAT (%s)", formatRat(inclusive), formatRat(expected))
+			}
+		}
+	}
+	if inclusive != nil && payable != nil && prepaid != nil && rounding != nil {
+		expected := new(big.Rat).Sub(inclusive, prepaid)
+		expected.Add(expected, rounding)
+		if payable.Cmp(expected) != 0 {
+			v.errorf("BR-CO-16", "amount due %s does not equal the total with VAT - paid amount + rounding (%s)", formatRat(payable), formatRat(expected))
+		}
+	}
+	if payable != nil && payable.Sign() > 0 && strings.TrimSpace(inv.DueDate) == "" &&
+		(inv.PaymentTerms == nil || strings.TrimSpace(inv.PaymentTerms.Note) == "") {
+		v.errorf("BR-CO-25", "an invoice with an amount due needs a due date or payment terms")
+	}
+}
+
+// documentTax returns the VAT total in the document currency without reporting issues
+func (v *validator) documentTax(inv *Invoice) *big.Rat {
+	for i := range inv.TaxTotals {
+		total := &inv.TaxTotals[i]
+		if total.TaxAmount != nil && strings.TrimSpace(total.TaxAmount.CurrencyID) == v.currency {
+			if amount, ok := ParseDecimal(total.TaxAmount.Value); ok {
+				return amount
+			}
+		}
+	}
+	return nil
+}
+
+func (v *validator) taxes(inv *Invoice, breakdown *breakdown, seller, buyer *Party) {
+	var total *TaxTotal
+	for i := range inv.TaxTotals {
+		if len(inv.TaxTotals[i].Subtotals) > 0 {
+			if total != nil {
+				v.errorf("PEPPOL-EN16931-R053", "only one VAT total may have a VAT breakdown")
+				break
+			}
+			total = &inv.TaxTotals[i]
+		}
+	}
+	if total == nil {
+		v.errorf("BR-CO-18", "the invoice has no VAT breakdown (TaxTotal/TaxSubtotal)")
+		return
+	}
+
+	taxTotal := v.amount("BR-CO-14", "VAT total", total.TaxAmount, true)
+	sum := new(big.Rat)
+	seen := make(map[string]bool)
+	codes := make(map[string]bool)
+	for i := range total.Subtotals {
+		subtotal := &total.Subtotals[i]
+		label := fmt.Sprintf("VAT breakdown %d", i+1)
+		taxable := v.amount("BR-45", label+" taxable amount", subtotal.TaxableAmount, true)
+		tax := v.amount("BR-46", label+" VAT amount", subtotal.TaxAmount, true)
+		if tax != nil {
+			sum.Add(sum, tax)
+		}
+		if subtotal.TaxCategory == nil || strings.TrimSpace(subtotal.TaxCategory.ID) == "" {
+			v.errorf("BR-47", "%s has no VAT category", label)
+			continue
+		}
+		category := subtotal.TaxCategory
+		code := strings.TrimSpace(category.ID)
+		v.category(label, category, "BR-47")
+		prefix := categoryRules[code]
+		if prefix == "" {
+			continue
+		}
+		codes[code] = true
+
+		key := categoryKey(category)
+		if seen[key] {
+			v.errorf("BR-CO-18", "%s repeats VAT category %s", label, describeCategory(category))
+		}
+		seen[key] = true
+
+		reason := strings.TrimSpace(category.ExemptionReason) != "" || strings.TrimSpace(category.ExemptionReasonCode) != ""
+		switch code {
+		case CategoryStandard, CategoryZero, CategoryCanaryIslands, CategoryCeutaMelilla:
+			if reason {
+				v.errorf(prefix+"-10", "%s VAT category %s must not have an exemption reason", label, code)
+			}
+		default:
+			if !reason {
+				v.errorf(prefix+"-10", "%s VAT category %s needs an exemption reason", label, code)
+			}
+		}
+
+		if taxable != nil {
+			if expected, ok := breakdown.amounts[key]; ok && expected.Cmp(taxable) != 0 {
+				v.errorf(prefix+"-08", "%s taxable amount %s does not equal the lines, allowances and charges of VAT category %s (%s)", label, formatRat(taxable), describeCategory(category), formatRat(expected))
+			} else if !ok {
+				v.warnf(prefix+"-08", "%s VAT category %s is not used by any line, allowance or charge", label, describeCategory(category))
+			}
+		}
+		if taxable != nil && tax != nil {
+			rate, _ := ParseDecimal(category.Percent)
+			if rate == nil {
+				rate = new(big.Rat)
+			}
+			expected := new(big.Rat).Quo(new(big.Rat).Mul(taxable, rate), big.NewRat(100, 1))
+			if difference := new(big.Rat).Sub(tax, expected); new(big.Rat).Abs(difference).Cmp(tolerance) > 0 {
+				v.errorf("BR-CO-17", "%s VAT amount %s does not equal the taxable amount times the rate (%s)", label, formatRat(tax), formatRat(roundCents(expected)))
+			}
+		}
+	}
+	if taxTotal != nil && taxTotal.Cmp(sum) != 0 {
+		v.errorf("BR-CO-14", "VAT total %s does not equal the VAT breakdown, which adds up to %s", formatRat(taxTotal), formatRat(sum))
+	}
+
+	for _, key := range breakdown.keys {
+		if !seen[key] {
+			category := breakdown.categories[key]
+			v.errorf(categoryRules[strings.TrimSpace(category.ID)]+"-01", "VAT category %s is used but has no VAT breakdown", describeCategory(category))
+		}
+		codes[strings.TrimSpace(breakdown.categories[key].ID)] = true
+	}
+
+	sellerVAT, buyerVAT := "", ""
+	if seller != nil {
+		sellerVAT = seller.VATID()
+	}
+	if buyer != nil {
+		buyerVAT = buyer.VATID()
+	}
+	for _, code := range sortedKeys(codes) {
+		prefix := categoryRules[code]
+		switch code {
+		case CategoryOutOfScope:
+			if len(codes) > 1 {
+				v.errorf("BR-O-11", "an invoice with VAT category O (not subject to VAT) must not use other VAT categories")
+			}
+			if sellerVAT != "" || buyerVAT != "" {
+				v.errorf("BR-O-02", "an invoice not subject to VAT must not contain seller or buyer VAT identifiers")
+			}
+		default:
+			if seller != nil && sellerVAT == "" {
+				v.errorf(prefix+"-02", "VAT category %s requires the seller VAT identifier", code)
+			}
+		}
+		if (code == CategoryReverseCharge || code == CategoryIntraCommunity) && buyer != nil && buyerVAT == "" {
+			v.errorf(prefix+"-02", "VAT category %s requires the buyer VAT identifier", code)
+		}
+	}
+}
+
+// amount checks a required amount: present, a decimal with at most two decimals, in the
+// document currency. It returns nil when the amount cannot be used.
+func (v *validator) amount(rule, label string, amount *Amount, required bool) *big.Rat {
+	if amount == nil || strings.TrimSpace(amount.Value) == "" {
+		if required {
+			v.errorf(rule, "%s is missing", label)
+		}
+		return nil
+	}
+	return v.decimal(rule, label, amount)
+}
+
+// optionalAmount checks an amount that counts as zero when it is left out
+func (v *validator) optionalAmount(rule, label string, amount *Amount) *big.Rat {
+	if amount == nil {
+		return new(big.Rat)
+	}
+	return v.amount(rule, label, amount, true)
+}
+
+func (v *validator) decimal(rule, label string, amount *Amount) *big.Rat {
+	value, ok := ParseDecimal(amount.Value)
+	if !ok {
+		v.errorf(rule, "%s %q is not a decimal number", label, amount.Value)
+		return nil
+	}
+	if currency := strings.TrimSpace(amount.CurrencyID); currency != v.currency {
+		v.errorf("PEPPOL-EN16931-R051", "%s is in %q instead of the document currency %s", label, currency, v.currency)
+	}
+	if !strings.HasSuffix(label, "price") && decimals(amount.Value) > 2 {
+		v.errorf("BR-DEC", "%s %s has more than two decimals", label, strings.TrimSpace(amount.Value))
+	}
+	return value
+}
+
+// breakdown accumulates the amounts of each VAT category and rate in the order they appear
+type breakdown struct {
+	keys       []string
+	amounts    map[string]*big.Rat
+	categories map[string]*TaxCategory
+}
+
+func newBreakdown() *breakdown {
+	return &breakdown{amounts: make(map[string]*big.Rat), categories: make(map[string]*TaxCategory)}
+}
+
+func (b *breakdown) add(category *TaxCategory, amount *big.Rat) {
+	if categoryRules[strings.TrimSpace(category.ID)] == "" {
+		return
+	}
+	key := categoryKey(category)
+	if _, ok := b.amounts[key]; !ok {
+		b.keys = append(b.keys, key)
+		b.amounts[key] = new(big.Rat)
+		b.categories[key] = category
+	}
+	b.amounts[key].Add(b.amounts[key], amount)
+}
+
+// categoryKey identifies a VAT category and rate, treating 20 and 20.00 alike
+func categoryKey(category *TaxCategory) string {
+	key := strings.TrimSpace(category.ID) + "|"
+	if percent, ok := ParseDecimal(category.Percent); ok {
+		key += percent.RatString()
+	}
+	return key
+}
+
+func describeCategory(category *TaxCategory) string {
+	if percent, ok := ParseDecimal(category.Percent); ok {
+		return fmt.Sprintf("%s (%s%%)", strings.TrimSpace(category.ID), FormatDecimal(percent))
+	}
+	return strings.TrimSpace(category.ID)
+}
+
+// ParseDecimal reads an XML Schema decimal such as 12, -3.50 or .5
+func ParseDecimal(s string) (*big.Rat, bool) {
+	s = strings.TrimSpace(s)
+	if !decimalValue.MatchString(s) {
+		return nil, false
+	}
+	r, ok := new(big.Rat).SetString(strings.TrimPrefix(s, "+"))
+	return r, ok
+}
+
+// FormatDecimal writes a decimal with as few decimals as it needs, at most four
+func FormatDecimal(r *big.Rat) string {
+	s := r.FloatString(4)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// ParseDate reads a date written as YYYY-MM-DD
+func ParseDate(s string) (time.Time, error) {
+	return time.Parse("2006-01-02", strings.TrimSpace(s))
+}
+
+func decimals(s string) int {
+	_, fraction, ok := strings.Cut(strings.TrimSpace(s), ".")
+	if !ok {
+		return 0
+	}
+	return len(fraction)
+}
+
+// roundCents rounds half away from zero to two decimals
+func roundCents(r *big.Rat) *big.Rat {
+	scaled := new(big.Rat).Mul(r, big.NewRat(100, 1))
+	num, den := scaled.Num(), scaled.Denom()
+	quotient, remainder := new(big.Int).QuoRem(num, den, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(den) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(num.Sign())))
+	}
+	return new(big.Rat).SetFrac(quotient, big.NewInt(100))
+}
+
+func formatRat(r *big.Rat) string {
+	return r.FloatString(2)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,92 @@
+package ubl
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// loadSample parses testdata/invoice.xml, a Peppol BIS Billing 3.0 invoice with a
+// discount, an untaxed charge and two VAT categories
+func loadSample(t *testing.T) *Invoice {
+	t.Helper()
+	file, err := os.Open("testdata/invoice.xml")
+	if err != nil {
+		t.Fatalf("open sample: %v", err)
+	}
+	defer file.Close()
+	inv, err := Parse(file)
+	if err != nil {
+		t.Fatalf("parse sample: %v", err)
+	}
+	return inv
+}
+
+func TestValidateSample(t *testing.T) {
+	inv := loadSample(t)
+	if issues := Validate(inv); len(issues) > 0 {
+		t.Fatalf("sample breaks rules: %v", issues)
+	}
+
+	data, err := inv.Marshal()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	again, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("parse marshalled sample: %v", err)
+	}
+	again.XMLNSCAC, again.XMLNSCBC = inv.XMLNSCAC, inv.XMLNSCBC
+	if !reflect.DeepEqual(again, inv) {
+		t.Error("the sample changed after marshalling and parsing it again")
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	cases := []struct {
+		rule   string
+		breaks func(inv *Invoice)
+	}{
+		{"BR-02", func(inv *Invoice) { inv.ID = "" }},
+		{"BR-05", func(inv *Invoice) { inv.DocumentCurrencyCode = "" }},
+		{"BR-09", func(inv *Invoice) { inv.Supplier.Party.PostalAddress.Country = nil }},
+		{"BR-CL-14", func(inv *Invoice) { inv.Customer.Party.PostalAddress.Country.IdentificationCode = "Germany" }},
+		{"PEPPOL-EN16931-R010", func(inv *Invoice) { inv.Customer.Party.EndpointID = nil }},
+		{"BR-CO-09", func(inv *Invoice) { inv.Supplier.Party.PartyTaxSchemes[0].CompanyID = "123456789B01" }},
+		{"BR-16", func(inv *Invoice) { inv.Lines = nil }},
+		{"BR-22", func(inv *Invoice) { inv.Lines[0].InvoicedQuantity.Value = "eight" }},
+		{"BR-CO-10", func(inv *Invoice) { inv.LegalMonetaryTotal.LineExtensionAmount.Value = "1000.00" }},
+		{"BR-CO-15", func(inv *Invoice) { inv.LegalMonetaryTotal.TaxInclusiveAmount.Value = "1112.39" }},
+		{"BR-CO-14", func(inv *Invoice) { inv.TaxTotals[0].TaxAmount.Value = "190.00" }},
+	}
+	for _, c := range cases {
+		inv := loadSample(t)
+		c.breaks(inv)
+		issues := Validate(inv)
+		if !HasErrors(issues) || !hasRule(issues, c.rule) {
+			t.Errorf("%s: got %v", c.rule, issues)
+		}
+	}
+}
+
+func hasRule(issues []Issue, rule string) bool {
+	for _, issue := range issues {
+		if issue.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func TestParseRejectsOtherDocuments(t *testing.T) {
+	creditNote := `<CreditNote xmlns="urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"></CreditNote>`
+	if _, err := Parse(strings.NewReader(creditNote)); !errors.Is(err, ErrUnsupportedDocument) {
+		t.Errorf("Parse of a credit note error = %v; want %v", err, ErrUnsupportedDocument)
+	}
+	if _, err := Parse(strings.NewReader("not xml")); err == nil {
+		t.Error("Parse of text that is not XML succeeded")
+	}
+}